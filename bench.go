@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha1" // nolint: gosec
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/cenkalti/rain/metainfo"
+	"github.com/cenkalti/rain/torrent"
+	"github.com/mitchellh/go-homedir"
+	"github.com/urfave/cli"
+)
+
+// benchChunkSize is the size of the buffer used when writing and reading the benchmark file.
+const benchChunkSize = 1 << 20
+
+var errBenchTimeout = errors.New("benchmark timed out")
+
+// benchResult is the measurement of a single benchmark step.
+type benchResult struct {
+	Name     string
+	Bytes    int64
+	Duration time.Duration
+}
+
+// Rate returns the throughput in bytes per second.
+func (r benchResult) Rate() int {
+	if r.Duration <= 0 {
+		return 0
+	}
+	return int(float64(r.Bytes) / r.Duration.Seconds())
+}
+
+func (r benchResult) String() string {
+	return fmt.Sprintf("%-10s %12s in %-12s %s", r.Name, strconv.FormatInt(r.Bytes, 10)+" B", r.Duration.Round(time.Millisecond), formatSpeed(r.Rate()))
+}
+
+// handleBench measures hashing rate, disk throughput and wire throughput between a seeder and a leecher
+// session running in the same process and connected over loopback.
+// Synthetic data is generated from a fixed seed so results of different runs are comparable.
+func handleBench(c *cli.Context) error {
+	cfg, err := prepareConfig(c)
+	if err != nil {
+		return err
+	}
+	size := c.Int64("size") << 20
+	if size <= 0 {
+		return errors.New("size must be positive")
+	}
+	pieceLength := c.Uint("piece-length") << 10
+	parent, err := homedir.Expand(c.String("dir"))
+	if err != nil {
+		return err
+	}
+	dir, err := os.MkdirTemp(parent, "rain-bench-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	hashBlock := int64(pieceLength)
+	if hashBlock == 0 {
+		hashBlock = 256 << 10
+	}
+	printBenchResult(benchHash(size, hashBlock))
+
+	// Seeder serves the file from its own directory, leecher downloads into another one.
+	seedDir := filepath.Join(dir, "seed")
+	err = os.Mkdir(seedDir, 0o750)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(seedDir, "bench.dat")
+	res, err := benchDiskWrite(path, size)
+	if err != nil {
+		return err
+	}
+	printBenchResult(res)
+	res, err = benchDiskRead(path)
+	if err != nil {
+		return err
+	}
+	printBenchResult(res)
+	res, err = benchWire(cfg, dir, path, uint32(pieceLength), c.Duration("timeout"))
+	if err != nil {
+		return err
+	}
+	printBenchResult(res)
+	return nil
+}
+
+func printBenchResult(r benchResult) {
+	fmt.Println(r.String())
+}
+
+// benchData returns a buffer filled with pseudo-random bytes from a fixed seed.
+func benchData(n int64) []byte {
+	b := make([]byte, n)
+	rand.New(rand.NewSource(1)).Read(b) // nolint: gosec
+	return b
+}
+
+// benchHash hashes size bytes in blocks of piece length, same as the verifier does.
+func benchHash(size, blockSize int64) benchResult {
+	b := benchData(blockSize)
+	h := sha1.New()
+	start := time.Now()
+	for n := int64(0); n < size; n += blockSize {
+		h.Reset()
+		if rem := size - n; rem < blockSize {
+			h.Write(b[:rem])
+		} else {
+			h.Write(b)
+		}
+		h.Sum(nil)
+	}
+	return benchResult{Name: "hash", Bytes: size, Duration: time.Since(start)}
+}
+
+// benchDiskWrite creates the file at path with size bytes. Data is synced to disk before the measurement ends.
+func benchDiskWrite(path string, size int64) (benchResult, error) {
+	res := benchResult{Name: "disk write", Bytes: size}
+	b := benchData(benchChunkSize)
+	start := time.Now()
+	f, err := os.Create(path)
+	if err != nil {
+		return res, err
+	}
+	defer f.Close()
+	for n := int64(0); n < size; n += benchChunkSize {
+		chunk := b
+		if rem := size - n; rem < benchChunkSize {
+			chunk = b[:rem]
+		}
+		_, err = f.Write(chunk)
+		if err != nil {
+			return res, err
+		}
+	}
+	err = f.Sync()
+	if err != nil {
+		return res, err
+	}
+	res.Duration = time.Since(start)
+	return res, f.Close()
+}
+
+// benchDiskRead reads the file at path. The result may be served from the page cache of the operating system.
+func benchDiskRead(path string) (benchResult, error) {
+	res := benchResult{Name: "disk read"}
+	start := time.Now()
+	f, err := os.Open(path)
+	if err != nil {
+		return res, err
+	}
+	defer f.Close()
+	res.Bytes, err = io.CopyBuffer(io.Discard, f, make([]byte, benchChunkSize))
+	if err != nil {
+		return res, err
+	}
+	res.Duration = time.Since(start)
+	return res, nil
+}
+
+// benchWire seeds the file at path from one session and downloads it with another session over loopback.
+// The measurement starts when the leecher connects to the seeder and ends when the download completes.
+func benchWire(cfg torrent.Config, dir, path string, pieceLength uint32, timeout time.Duration) (benchResult, error) {
+	res := benchResult{Name: "wire"}
+	info, err := metainfo.NewInfoBytes("", []string{path}, false, pieceLength, "", log)
+	if err != nil {
+		return res, err
+	}
+	mi, err := metainfo.NewBytes(info, nil, nil, "")
+	if err != nil {
+		return res, err
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return res, err
+	}
+	res.Bytes = fi.Size()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	seeder, err := newBenchSession(cfg, filepath.Dir(path), filepath.Join(dir, "seed.db"))
+	if err != nil {
+		return res, err
+	}
+	defer seeder.Close()
+	st, err := seeder.AddTorrent(bytes.NewReader(mi), &torrent.AddTorrentOptions{SeedOnly: true})
+	if err != nil {
+		return res, err
+	}
+	// Existing data is verified before seeding starts.
+	select {
+	case <-st.NotifyComplete():
+	case err = <-st.NotifyStop():
+		return res, err
+	case <-timer.C:
+		return res, errBenchTimeout
+	}
+
+	leecher, err := newBenchSession(cfg, filepath.Join(dir, "leech"), filepath.Join(dir, "leech.db"))
+	if err != nil {
+		return res, err
+	}
+	defer leecher.Close()
+	lt, err := leecher.AddTorrent(bytes.NewReader(mi), &torrent.AddTorrentOptions{StopAfterDownload: true})
+	if err != nil {
+		return res, err
+	}
+	start := time.Now()
+	err = lt.AddPeer("127.0.0.1:" + strconv.Itoa(st.Port()))
+	if err != nil {
+		return res, err
+	}
+	select {
+	case <-lt.NotifyComplete():
+	case err = <-lt.NotifyStop():
+		if err == nil {
+			err = errors.New("leecher stopped before completing download")
+		}
+		return res, err
+	case <-timer.C:
+		return res, errBenchTimeout
+	}
+	res.Duration = time.Since(start)
+	return res, nil
+}
+
+// newBenchSession returns a session that only connects to the peers added explicitly.
+// Settings that move data out of dataDir or run external commands are disabled.
+func newBenchSession(cfg torrent.Config, dataDir, database string) (*torrent.Session, error) {
+	cfg.Database = database
+	cfg.DataDir = dataDir
+	cfg.DataDirIncludesTorrentID = false
+	cfg.IncompleteDir = ""
+	cfg.S3Bucket = ""
+	cfg.WebDAVURL = ""
+	cfg.StorageEncryptionPassphrase = ""
+	cfg.SaveTorrentDir = ""
+	cfg.OnCompleteCmd = nil
+	cfg.BlocklistURL = ""
+	cfg.Host = "127.0.0.1"
+	cfg.DHTEnabled = false
+	cfg.PEXEnabled = false
+	cfg.RPCEnabled = false
+	return torrent.NewSession(cfg)
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cenkalti/rain/torrent"
+	"github.com/mitchellh/go-homedir"
+	"github.com/urfave/cli"
+	"gopkg.in/yaml.v2"
+)
+
+// Source of the Config struct. Doc comments of the fields are written into the default config file.
+//
+//go:embed torrent/config.go
+var configSource []byte
+
+func handleConfigInit(c *cli.Context) error {
+	configPath, err := homedir.Expand(c.String("config"))
+	if err != nil {
+		return err
+	}
+	if !c.Bool("force") {
+		_, err = os.Stat(configPath)
+		if err == nil {
+			return errors.New("config file already exists: " + configPath)
+		}
+		if !os.IsNotExist(err) {
+			return err
+		}
+	}
+	b, err := defaultConfigFile()
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(configPath), 0750)
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(configPath, b, 0640)
+	if err != nil {
+		return err
+	}
+	log.Noticeln("config written to:", configPath)
+	return nil
+}
+
+func handleConfigShow(c *cli.Context) error {
+	cfg, err := prepareConfig(c)
+	if err != nil {
+		return err
+	}
+	b, err := yaml.Marshal(&cfg)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(b)
+	return err
+}
+
+// defaultConfigFile returns the contents of a config file containing all keys with their default values.
+// Each key is preceded by the doc comment of the field in Config.
+func defaultConfigFile() ([]byte, error) {
+	docs, err := configDocs()
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.WriteString("# Configuration file of rain.\n")
+	buf.WriteString("# Values below are the defaults. Keys that are removed keep their default values.\n\n")
+	buf.WriteString("# Enable debug log.\n")
+	buf.WriteString("debug: false\n")
+	v := reflect.ValueOf(torrent.DefaultConfig)
+	for i := 0; i < v.NumField(); i++ {
+		name := v.Type().Field(i).Name
+		buf.WriteString("\n")
+		for _, line := range docs[name] {
+			buf.WriteString("# " + line + "\n")
+		}
+		// Keys are lowercase field names, same as the default field mapping of yaml package.
+		b, err := yaml.Marshal(yaml.MapSlice{yaml.MapItem{Key: strings.ToLower(name), Value: v.Field(i).Interface()}})
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(b)
+	}
+	return buf.Bytes(), nil
+}
+
+// configDocs returns the lines of doc comments of Config fields, keyed by field name.
+func configDocs() (map[string][]string, error) {
+	f, err := parser.ParseFile(token.NewFileSet(), "config.go", configSource, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	docs := make(map[string][]string)
+	ast.Inspect(f, func(n ast.Node) bool {
+		ts, ok := n.(*ast.TypeSpec)
+		if !ok || ts.Name.Name != "Config" {
+			return true
+		}
+		st, ok := ts.Type.(*ast.StructType)
+		if !ok {
+			return false
+		}
+		for _, field := range st.Fields.List {
+			if field.Doc == nil {
+				continue
+			}
+			lines := strings.Split(strings.TrimSpace(field.Doc.Text()), "\n")
+			for _, name := range field.Names {
+				docs[name.Name] = lines
+			}
+		}
+		return false
+	})
+	return docs, nil
+}
+
+// Prefix of environment variables that override the keys in config file.
+const configEnvPrefix = "RAIN_"
+
+// applyConfigEnv overrides the keys in config with the environment variables named as RAIN_<KEY>.
+// Key names are matched case-insensitively and underscores are ignored,
+// so both RAIN_SPEEDLIMITUPLOAD and RAIN_SPEED_LIMIT_UPLOAD set the speedlimitupload key.
+// RAIN_PORT sets portbegin and portend for listening on a single port.
+// Values other than strings are parsed as YAML, e.g. RAIN_DHTBOOTSTRAPNODES="[host1:6881, host2:6881]".
+// Returns the keys that are set from the environment.
+func applyConfigEnv(cfg *torrent.Config, lc *logConfig, environ []string) ([]string, error) {
+	kinds := make(map[string]reflect.Kind)
+	for _, t := range []reflect.Type{reflect.TypeOf(torrent.Config{}), reflect.TypeOf(logConfig{})} {
+		for i := 0; i < t.NumField(); i++ {
+			kinds[strings.ToLower(t.Field(i).Name)] = t.Field(i).Type.Kind()
+		}
+	}
+	fc := fileConfig{Config: *cfg, logConfig: *lc}
+	var keys []string
+	for _, kv := range environ {
+		name, value, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(name, configEnvPrefix) {
+			continue
+		}
+		key := strings.ToLower(strings.ReplaceAll(strings.TrimPrefix(name, configEnvPrefix), "_", ""))
+		if key == "port" {
+			port, err := strconv.ParseUint(value, 10, 16)
+			if err != nil || port == 0 || port == math.MaxUint16 {
+				return nil, fmt.Errorf("invalid port in %s: %q", name, value)
+			}
+			fc.PortBegin = uint16(port)
+			fc.PortEnd = uint16(port + 1)
+			keys = append(keys, "portbegin", "portend")
+			continue
+		}
+		kind, ok := kinds[key]
+		if !ok {
+			// Not a config key, like RAIN_TORRENT_ID passed to OnCompleteCmd.
+			continue
+		}
+		var v interface{} = value
+		if kind != reflect.String {
+			err := yaml.Unmarshal([]byte(value), &v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value in %s: %s", name, err)
+			}
+		}
+		b, err := yaml.Marshal(map[string]interface{}{key: v})
+		if err != nil {
+			return nil, err
+		}
+		err = yaml.UnmarshalStrict(b, &fc)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value in %s: %s", name, err)
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	*cfg = fc.Config
+	*lc = fc.logConfig
+	return keys, nil
+}
@@ -2,6 +2,7 @@ package acceptor
 
 import (
 	"net"
+	"time"
 
 	"github.com/cenkalti/rain/internal/logger"
 )
@@ -10,16 +11,19 @@ import (
 type Acceptor struct {
 	listener net.Listener
 	newConns chan net.Conn
+	throttle *Throttle
 	closeC   chan struct{}
 	doneC    chan struct{}
 	log      logger.Logger
 }
 
-// New returns a new Acceptor.
-func New(lis net.Listener, newConns chan net.Conn, l logger.Logger) *Acceptor {
+// New returns a new Acceptor. Connections that are not allowed by the throttle are closed immediately.
+// throttle may be nil.
+func New(lis net.Listener, newConns chan net.Conn, throttle *Throttle, l logger.Logger) *Acceptor {
 	return &Acceptor{
 		listener: lis,
 		newConns: newConns,
+		throttle: throttle,
 		closeC:   make(chan struct{}),
 		doneC:    make(chan struct{}),
 		log:      l,
@@ -57,6 +61,13 @@ func (a *Acceptor) Run() {
 			}
 			return
 		}
+		if a.throttle != nil {
+			if addr, ok := conn.RemoteAddr().(*net.TCPAddr); ok && !a.throttle.Allow(addr.IP, time.Now()) {
+				a.log.Debugln("throttling incoming connection from", addr.String())
+				conn.Close()
+				continue
+			}
+		}
 		select {
 		case a.newConns <- conn:
 		case <-a.closeC:
@@ -0,0 +1,57 @@
+package acceptor
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/juju/ratelimit"
+)
+
+// Counts of connections from each IP are reset after this duration.
+const ipWindow = time.Minute
+
+// Throttle limits the rate of accepted connections in total and per source IP.
+// A single Throttle can be shared by multiple Acceptors.
+type Throttle struct {
+	m           sync.Mutex
+	rate        *ratelimit.Bucket
+	maxPerIP    int
+	windowStart time.Time
+	countByIP   map[string]int
+}
+
+// NewThrottle returns a new Throttle that allows maxPerSecond connections in a second
+// and maxPerIP connections from an IP in a minute. Zero values mean no limit.
+func NewThrottle(maxPerSecond, maxPerIP int) *Throttle {
+	t := &Throttle{
+		maxPerIP:  maxPerIP,
+		countByIP: make(map[string]int),
+	}
+	if maxPerSecond > 0 {
+		t.rate = ratelimit.NewBucketWithRate(float64(maxPerSecond), int64(maxPerSecond))
+	}
+	return t
+}
+
+// Allow returns true if a new connection from ip can be accepted at time now.
+func (t *Throttle) Allow(ip net.IP, now time.Time) bool {
+	t.m.Lock()
+	defer t.m.Unlock()
+	if t.maxPerIP > 0 {
+		if now.Sub(t.windowStart) >= ipWindow {
+			t.windowStart = now
+			t.countByIP = make(map[string]int)
+		}
+		key := ip.String()
+		if t.countByIP[key] >= t.maxPerIP {
+			return false
+		}
+		if t.rate != nil && t.rate.TakeAvailable(1) == 0 {
+			return false
+		}
+		t.countByIP[key]++
+		return true
+	}
+	return t.rate == nil || t.rate.TakeAvailable(1) > 0
+}
@@ -0,0 +1,37 @@
+package acceptor
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThrottlePerIP(t *testing.T) {
+	th := NewThrottle(0, 2)
+	ip1 := net.IPv4(1, 2, 3, 4)
+	ip2 := net.IPv4(5, 6, 7, 8)
+	now := time.Now()
+	assert.True(t, th.Allow(ip1, now))
+	assert.True(t, th.Allow(ip1, now))
+	assert.False(t, th.Allow(ip1, now))
+	assert.True(t, th.Allow(ip2, now))
+	assert.True(t, th.Allow(ip1, now.Add(ipWindow)))
+}
+
+func TestThrottleRate(t *testing.T) {
+	th := NewThrottle(2, 0)
+	ip := net.IPv4(1, 2, 3, 4)
+	now := time.Now()
+	assert.True(t, th.Allow(ip, now))
+	assert.True(t, th.Allow(ip, now))
+	assert.False(t, th.Allow(ip, now))
+}
+
+func TestThrottleNoLimit(t *testing.T) {
+	th := NewThrottle(0, 0)
+	for i := 0; i < 100; i++ {
+		assert.True(t, th.Allow(net.IPv4(1, 2, 3, 4), time.Now()))
+	}
+}
@@ -56,19 +56,29 @@ func (d *AddrList) LenSource(s peersource.Source) int {
 }
 
 // Pop returns the next address. The returned address is removed from the list.
-func (d *AddrList) Pop() (*net.TCPAddr, peersource.Source) {
+// If the address is received from a tracker, URL of the tracker is also returned.
+func (d *AddrList) Pop() (addr *net.TCPAddr, source peersource.Source, tracker string) {
 	item := d.peerByPriority.DeleteMax()
 	if item == nil {
-		return nil, 0
+		return nil, 0, ""
 	}
 	p := item.(*peerAddr)
 	d.peerByTime[p.index] = nil
 	d.countBySource[p.source]--
-	return p.addr, p.source
+	return p.addr, p.source, p.tracker
 }
 
 // Push adds a new address to the list. Does nothing if the address is already in the list.
 func (d *AddrList) Push(addrs []*net.TCPAddr, source peersource.Source) {
+	d.push(addrs, source, "")
+}
+
+// PushFromTracker adds the addresses received from the tracker with the URL.
+func (d *AddrList) PushFromTracker(addrs []*net.TCPAddr, trackerURL string) {
+	d.push(addrs, peersource.Tracker, trackerURL)
+}
+
+func (d *AddrList) push(addrs []*net.TCPAddr, source peersource.Source, trackerURL string) {
 	now := time.Now()
 	var added int
 	for _, ad := range addrs {
@@ -93,6 +103,7 @@ func (d *AddrList) Push(addrs []*net.TCPAddr, source peersource.Source) {
 			timestamp: now,
 			source:    source,
 			priority:  peerpriority.Calculate(ad, d.clientAddr()),
+			tracker:   trackerURL,
 		}
 		item := d.peerByPriority.ReplaceOrInsert(p)
 		if item != nil {
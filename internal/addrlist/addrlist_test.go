@@ -56,3 +56,20 @@ func TestAddrList(t *testing.T) {
 func newAddr(ip string) *net.TCPAddr {
 	return &net.TCPAddr{IP: net.ParseIP(ip), Port: 1}
 }
+
+func TestAddrListTracker(t *testing.T) {
+	clientIP := net.IPv4(1, 2, 3, 4)
+	al := New(2, nil, 5000, &clientIP)
+
+	al.PushFromTracker([]*net.TCPAddr{newAddr("1.1.1.1")}, "http://tracker/announce")
+	assert.Equal(t, 1, al.LenSource(peersource.Tracker))
+	addr, src, tracker := al.Pop()
+	assert.Equal(t, "1.1.1.1", addr.IP.String())
+	assert.Equal(t, peersource.Tracker, src)
+	assert.Equal(t, "http://tracker/announce", tracker)
+
+	al.Push([]*net.TCPAddr{newAddr("2.2.2.2")}, peersource.DHT)
+	_, src, tracker = al.Pop()
+	assert.Equal(t, peersource.DHT, src)
+	assert.Equal(t, "", tracker)
+}
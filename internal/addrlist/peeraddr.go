@@ -16,6 +16,9 @@ type peerAddr struct {
 	source    peersource.Source
 	priority  peerpriority.Priority
 
+	// URL of the tracker if the address is received from a tracker.
+	tracker string
+
 	// index in AddrList.peerByTime slice
 	index int
 }
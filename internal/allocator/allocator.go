@@ -1,8 +1,8 @@
 package allocator
 
 import (
-	"github.com/cenkalti/rain/internal/metainfo"
 	"github.com/cenkalti/rain/internal/storage"
+	"github.com/cenkalti/rain/metainfo"
 )
 
 // Allocator allocates files on the disk.
@@ -65,9 +65,19 @@ func (a *Allocator) Run(info *metainfo.Info, sto storage.Storage, progressC chan
 	for i, f := range info.Files {
 		var sf storage.File
 		var exists bool
-		if f.Padding {
+		switch {
+		case f.Padding:
 			sf = storage.NewPaddingFile(f.Length)
-		} else {
+		case f.Symlink != "":
+			// Symlinks have no data. Link is created on storages that support it.
+			sf = storage.NewPaddingFile(0)
+			if at, ok := sto.(storage.Attributer); ok {
+				a.Error = at.Symlink(f.Path, f.Symlink)
+				if a.Error != nil {
+					return
+				}
+			}
+		default:
 			sf, exists, a.Error = sto.Open(f.Path, f.Length)
 			if a.Error != nil {
 				return
@@ -77,6 +87,13 @@ func (a *Allocator) Run(info *metainfo.Info, sto storage.Storage, progressC chan
 			} else {
 				a.HasMissing = true
 			}
+			if at, ok := sto.(storage.Attributer); ok && f.Executable {
+				a.Error = at.SetExecutable(f.Path)
+				if a.Error != nil {
+					sf.Close()
+					return
+				}
+			}
 		}
 		a.Files[i] = File{Storage: sf, Name: f.Path, Padding: f.Padding}
 		allocatedSize += f.Length
@@ -14,8 +14,14 @@ func announce(
 	numWant int,
 	torrent tracker.Torrent,
 	responseC chan *tracker.AnnounceResponse,
-	errC chan error,
+	errC chan announceFailure,
 ) {
+	// Tier switches to the next tracker on error, so the URL must be read before announcing.
+	u := trk.URL()
+	// BEP 21: Partial seeds announce with "paused" event, so trackers do not count them as leechers.
+	if e == tracker.EventNone && torrent.PartialSeed && torrent.BytesLeft > 0 {
+		e = tracker.EventPaused
+	}
 	annReq := tracker.AnnounceRequest{
 		Torrent: torrent,
 		Event:   e,
@@ -27,7 +33,7 @@ func announce(
 	}
 	if err != nil {
 		select {
-		case errC <- err:
+		case errC <- announceFailure{URL: u, Err: err}:
 		case <-ctx.Done():
 		}
 		return
@@ -37,3 +43,9 @@ func announce(
 	case <-ctx.Done():
 	}
 }
+
+// announceFailure is the error returned from the tracker with the URL of the tracker.
+type announceFailure struct {
+	URL string
+	Err error
+}
@@ -44,50 +44,102 @@ type PeriodicalAnnouncer struct {
 	leechers      int
 	warningMsg    string
 	lastError     *AnnounceError
+	failures      int
+	trackers      map[string]*trackerState
 	log           logger.Logger
 	completedC    chan struct{}
-	newPeers      chan []*net.TCPAddr
-	backoff       backoff.BackOff
+	newPeers      chan TrackerPeers
+	errors        chan *AnnounceError
 	getTorrent    func() tracker.Torrent
 	lastAnnounce  time.Time
 	nextAnnounce  time.Time
 	HasAnnounced  bool
-	responseC     chan *tracker.AnnounceResponse
-	errC          chan error
-	closeC        chan struct{}
-	doneC         chan struct{}
+	// Event of the announce request in progress.
+	event tracker.Event
+	// Set after the tracker has received "started" event.
+	startedSent bool
+	// Set when the download is finished until the tracker receives "completed" event.
+	completedPending bool
+	responseC        chan *tracker.AnnounceResponse
+	errC             chan announceFailure
+	closeC           chan struct{}
+	doneC            chan struct{}
 
 	needMorePeers  bool
 	mNeedMorePeers sync.RWMutex
 	needMorePeersC chan struct{}
+
+	forceAnnounceC chan struct{}
+
+	// Interval limits from config
+	configMinInterval time.Duration
+	maxInterval       time.Duration
+	intervalOverride  time.Duration
+	retryMinInterval  time.Duration
+	retryMaxInterval  time.Duration
+}
+
+// TrackerPeers contains the peer addresses received from a tracker.
+type TrackerPeers struct {
+	// URL of the tracker that has returned the addresses.
+	URL   string
+	Addrs []*net.TCPAddr
+}
+
+// trackerState holds the retry state of a single tracker.
+// Trackers in a tier are retried independently from each other.
+type trackerState struct {
+	failures  int
+	backoff   backoff.BackOff
+	nextRetry time.Time
 }
 
 // NewPeriodicalAnnouncer returns a new PeriodicalAnnouncer.
-func NewPeriodicalAnnouncer(trk tracker.Tracker, numWant int, minInterval time.Duration, getTorrent func() tracker.Torrent, completedC chan struct{}, newPeers chan []*net.TCPAddr, l logger.Logger) *PeriodicalAnnouncer {
+func NewPeriodicalAnnouncer(trk tracker.Tracker, numWant int, minInterval, maxInterval, intervalOverride, retryMinInterval, retryMaxInterval time.Duration, getTorrent func() tracker.Torrent, completedC chan struct{}, newPeers chan TrackerPeers, errors chan *AnnounceError, l logger.Logger) *PeriodicalAnnouncer {
 	return &PeriodicalAnnouncer{
-		Tracker:        trk,
-		status:         NotContactedYet,
-		statsCommandC:  make(chan statsRequest),
-		numWant:        numWant,
-		minInterval:    minInterval,
-		log:            l,
-		completedC:     completedC,
-		newPeers:       newPeers,
-		getTorrent:     getTorrent,
-		needMorePeersC: make(chan struct{}, 1),
-		responseC:      make(chan *tracker.AnnounceResponse),
-		errC:           make(chan error),
-		closeC:         make(chan struct{}),
-		doneC:          make(chan struct{}),
-		backoff: &backoff.ExponentialBackOff{
-			InitialInterval:     5 * time.Second,
-			RandomizationFactor: 0.5,
-			Multiplier:          2,
-			MaxInterval:         30 * time.Minute,
-			MaxElapsedTime:      0, // never stop
-			Clock:               backoff.SystemClock,
-		},
+		Tracker:           trk,
+		status:            NotContactedYet,
+		statsCommandC:     make(chan statsRequest),
+		numWant:           numWant,
+		minInterval:       minInterval,
+		configMinInterval: minInterval,
+		maxInterval:       maxInterval,
+		intervalOverride:  intervalOverride,
+		retryMinInterval:  retryMinInterval,
+		retryMaxInterval:  retryMaxInterval,
+		trackers:          make(map[string]*trackerState),
+		log:               l,
+		completedC:        completedC,
+		newPeers:          newPeers,
+		errors:            errors,
+		getTorrent:        getTorrent,
+		needMorePeersC:    make(chan struct{}, 1),
+		forceAnnounceC:    make(chan struct{}, 1),
+		responseC:         make(chan *tracker.AnnounceResponse),
+		errC:              make(chan announceFailure),
+		closeC:            make(chan struct{}),
+		doneC:             make(chan struct{}),
+	}
+}
+
+// trackerState returns the retry state of the tracker with URL, creating it if necessary.
+func (a *PeriodicalAnnouncer) trackerState(u string) *trackerState {
+	ts, ok := a.trackers[u]
+	if !ok {
+		ts = &trackerState{
+			backoff: &backoff.ExponentialBackOff{
+				InitialInterval:     a.retryMinInterval,
+				RandomizationFactor: 0.5,
+				Multiplier:          2,
+				MaxInterval:         a.retryMaxInterval,
+				MaxElapsedTime:      0, // never stop
+				Clock:               backoff.SystemClock,
+			},
+		}
+		ts.backoff.Reset()
+		a.trackers[u] = ts
 	}
+	return ts
 }
 
 // Close the announcer.
@@ -127,10 +179,18 @@ func (a *PeriodicalAnnouncer) NeedMorePeers(val bool) {
 	}
 }
 
+// ForceAnnounce makes the announcer contact the tracker immediately, ignoring the min interval.
+func (a *PeriodicalAnnouncer) ForceAnnounce() {
+	select {
+	case a.forceAnnounceC <- struct{}{}:
+	case <-a.doneC:
+	default:
+	}
+}
+
 // Run the announcer goroutine. Invoke with go statement.
 func (a *PeriodicalAnnouncer) Run() {
 	defer close(a.doneC)
-	a.backoff.Reset()
 
 	timer := time.NewTimer(math.MaxInt64)
 	defer timer.Stop()
@@ -153,61 +213,89 @@ func (a *PeriodicalAnnouncer) Run() {
 	default:
 	}
 
-	a.doAnnounce(ctx, tracker.EventStarted, a.numWant)
+	a.doAnnounce(ctx)
 	for {
 		select {
 		case <-timer.C:
 			if a.status == Contacting {
 				break
 			}
-			a.doAnnounce(ctx, tracker.EventNone, a.numWant)
+			a.doAnnounce(ctx)
 		case resp := <-a.responseC:
 			a.status = Working
+			a.eventAnnounced(a.event)
 			a.seeders = int(resp.Seeders)
 			a.leechers = int(resp.Leechers)
 			a.warningMsg = resp.WarningMessage
 			if a.warningMsg != "" {
 				a.log.Debugln("announce warning:", a.warningMsg)
 			}
-			a.interval = resp.Interval
-			if resp.MinInterval > 0 {
+			a.minInterval = a.configMinInterval
+			if resp.MinInterval > a.minInterval {
 				a.minInterval = resp.MinInterval
 			}
+			a.interval = a.clampInterval(resp.Interval)
 			a.HasAnnounced = true
 			a.lastError = nil
-			a.backoff.Reset()
+			a.failures = 0
+			// Tier does not switch to another tracker after a successful announce.
+			delete(a.trackers, a.Tracker.URL())
 			interval := a.getNextInterval()
+			if a.completedPending {
+				// Download has finished while "started" event was being announced.
+				interval = 0
+			}
 			resetTimer(interval)
+			peers := TrackerPeers{URL: a.Tracker.URL(), Addrs: resp.Peers}
 			go func() {
 				select {
-				case a.newPeers <- resp.Peers:
+				case a.newPeers <- peers:
 				case <-a.closeC:
 				}
 			}()
-		case err := <-a.errC:
+		case f := <-a.errC:
 			a.status = NotWorking
 			// Give more friendly error to the user
-			a.lastError = a.newAnnounceError(err)
+			a.lastError = a.newAnnounceError(f.URL, f.Err)
 			if a.lastError.Unknown {
 				a.log.Errorln("announce error:", a.lastError.ErrorWithType())
 			} else {
 				a.log.Debugln("announce error:", a.lastError.Err.Error())
 			}
-			interval := a.getNextIntervalFromError(a.lastError)
+			a.failures = a.handleFailure(a.lastError, time.Now())
+			interval := a.getNextIntervalFromError(time.Now())
 			resetTimer(interval)
+			if a.errors != nil {
+				go func(e *AnnounceError) {
+					select {
+					case a.errors <- e:
+					case <-a.closeC:
+					}
+				}(a.lastError)
+			}
 		case <-a.needMorePeersC:
 			if a.status == Contacting || a.status == NotWorking {
 				break
 			}
 			interval := time.Until(a.lastAnnounce.Add(a.getNextInterval()))
 			resetTimer(interval)
+		case <-a.forceAnnounceC:
+			if a.status == Contacting {
+				break
+			}
+			a.doAnnounce(ctx)
 		case <-a.completedC:
+			a.completedC = nil // do not send more than one "completed" event
+			a.completedPending = true
 			if a.status == Contacting {
+				if a.event == tracker.EventStarted {
+					// "completed" is sent after the tracker receives "started".
+					break
+				}
 				cancel()
 				ctx, cancel = context.WithCancel(context.Background())
 			}
-			a.doAnnounce(ctx, tracker.EventCompleted, 0)
-			a.completedC = nil // do not send more than one "completed" event
+			a.doAnnounce(ctx)
 		case req := <-a.statsCommandC:
 			req.Response <- a.stats()
 		case <-a.closeC:
@@ -217,6 +305,21 @@ func (a *PeriodicalAnnouncer) Run() {
 	}
 }
 
+// clampInterval applies the interval limits in config to the interval sent by the tracker.
+// Min interval of the tracker has precedence over other limits.
+func (a *PeriodicalAnnouncer) clampInterval(interval time.Duration) time.Duration {
+	if a.intervalOverride > 0 {
+		interval = a.intervalOverride
+	}
+	if a.maxInterval > 0 && interval > a.maxInterval {
+		interval = a.maxInterval
+	}
+	if interval < a.minInterval {
+		interval = a.minInterval
+	}
+	return interval
+}
+
 func (a *PeriodicalAnnouncer) getNextInterval() time.Duration {
 	a.mNeedMorePeers.RLock()
 	need := a.needMorePeers
@@ -227,15 +330,62 @@ func (a *PeriodicalAnnouncer) getNextInterval() time.Duration {
 	return a.interval
 }
 
-func (a *PeriodicalAnnouncer) getNextIntervalFromError(err *AnnounceError) time.Duration {
+// handleFailure records the failure for the tracker that has returned the error.
+// Returns the number of consecutive failures of that tracker.
+func (a *PeriodicalAnnouncer) handleFailure(err *AnnounceError, now time.Time) int {
+	ts := a.trackerState(err.URL)
+	ts.failures++
+	var wait time.Duration
 	if terr, ok := err.Err.(*tracker.Error); ok && terr.RetryIn > 0 {
-		return terr.RetryIn
+		wait = terr.RetryIn
+	} else {
+		wait = ts.backoff.NextBackOff()
+	}
+	ts.nextRetry = now.Add(wait)
+	return ts.failures
+}
+
+// getNextIntervalFromError returns the time to wait before retrying after a failed announce.
+// Tier switches to the next tracker after an error, so the wait depends on the backoff of the next tracker.
+func (a *PeriodicalAnnouncer) getNextIntervalFromError(now time.Time) time.Duration {
+	var interval time.Duration
+	if ts, ok := a.trackers[a.Tracker.URL()]; ok {
+		interval = ts.nextRetry.Sub(now)
+	}
+	// Do not retry more frequently than the limit.
+	if interval < a.retryMinInterval {
+		interval = a.retryMinInterval
+	}
+	return interval
+}
+
+// pendingEvent returns the event to be sent in the next announce request.
+// Events are retried until the tracker receives them.
+func (a *PeriodicalAnnouncer) pendingEvent() (tracker.Event, int) {
+	switch {
+	case !a.startedSent:
+		return tracker.EventStarted, a.numWant
+	case a.completedPending:
+		return tracker.EventCompleted, 0
+	default:
+		return tracker.EventNone, a.numWant
+	}
+}
+
+// eventAnnounced must be called after a successful announce with the event sent in the request.
+func (a *PeriodicalAnnouncer) eventAnnounced(e tracker.Event) {
+	switch e {
+	case tracker.EventStarted:
+		a.startedSent = true
+	case tracker.EventCompleted:
+		a.completedPending = false
 	}
-	return a.backoff.NextBackOff()
 }
 
-func (a *PeriodicalAnnouncer) doAnnounce(ctx context.Context, event tracker.Event, numWant int) {
+func (a *PeriodicalAnnouncer) doAnnounce(ctx context.Context) {
+	event, numWant := a.pendingEvent()
 	go a.announce(ctx, event, numWant)
+	a.event = event
 	a.status = Contacting
 	a.lastAnnounce = time.Now()
 }
@@ -253,6 +403,7 @@ type Stats struct {
 	Leechers     int
 	LastAnnounce time.Time
 	NextAnnounce time.Time
+	Failures     int
 }
 
 func (a *PeriodicalAnnouncer) stats() Stats {
@@ -264,28 +415,30 @@ func (a *PeriodicalAnnouncer) stats() Stats {
 		Leechers:     a.leechers,
 		LastAnnounce: a.lastAnnounce,
 		NextAnnounce: a.nextAnnounce,
+		Failures:     a.failures,
 	}
 }
 
 // AnnounceError the error that comes from the Tracker itself.
 type AnnounceError struct {
+	URL     string
 	Err     error
 	Message string
 	Unknown bool
 }
 
-func (a *PeriodicalAnnouncer) newAnnounceError(err error) (e *AnnounceError) {
-	e = &AnnounceError{Err: err}
+func (a *PeriodicalAnnouncer) newAnnounceError(u string, err error) (e *AnnounceError) {
+	e = &AnnounceError{URL: u, Err: err}
 	switch err {
 	case resolver.ErrNotIPv4Address:
-		parsed, _ := url.Parse(a.Tracker.URL())
+		parsed, _ := url.Parse(u)
 		e.Message = "tracker has no IPv4 address: " + parsed.Hostname()
 		return
 	case resolver.ErrBlocked:
 		e.Message = "tracker IP is blocked"
 		return
 	case resolver.ErrInvalidPort:
-		parsed, _ := url.Parse(a.Tracker.URL())
+		parsed, _ := url.Parse(u)
 		e.Message = "invalid port number in tracker address: " + parsed.Host
 		return
 	case tracker.ErrDecode:
@@ -329,12 +482,12 @@ func (a *PeriodicalAnnouncer) newAnnounceError(err error) (e *AnnounceError) {
 			return
 		}
 		if strings.HasSuffix(s, "no such host") {
-			parsed, _ := url.Parse(a.Tracker.URL())
+			parsed, _ := url.Parse(u)
 			e.Message = "no such host: " + parsed.Hostname()
 			return
 		}
 		if strings.HasSuffix(s, "server misbehaving") {
-			parsed, _ := url.Parse(a.Tracker.URL())
+			parsed, _ := url.Parse(u)
 			e.Message = "server misbehaving: " + parsed.Hostname()
 			return
 		}
@@ -343,17 +496,17 @@ func (a *PeriodicalAnnouncer) newAnnounceError(err error) (e *AnnounceError) {
 			return
 		}
 		if strings.HasSuffix(s, "no route to host") {
-			parsed, _ := url.Parse(a.Tracker.URL())
+			parsed, _ := url.Parse(u)
 			e.Message = "no route to host: " + parsed.Hostname()
 			return
 		}
 		if strings.HasSuffix(s, "No address associated with hostname") {
-			parsed, _ := url.Parse(a.Tracker.URL())
+			parsed, _ := url.Parse(u)
 			e.Message = "no address associated with hostname: " + parsed.Hostname()
 			return
 		}
 		if strings.HasSuffix(s, resolver.ErrNotIPv4Address.Error()) {
-			parsed, _ := url.Parse(a.Tracker.URL())
+			parsed, _ := url.Parse(u)
 			e.Message = "tracker has no IPv4 address: " + parsed.Hostname()
 			return
 		}
@@ -374,7 +527,7 @@ func (a *PeriodicalAnnouncer) newAnnounceError(err error) (e *AnnounceError) {
 			return
 		}
 		if strings.Contains(s, "network is unreachable") {
-			parsed, _ := url.Parse(a.Tracker.URL())
+			parsed, _ := url.Parse(u)
 			e.Message = "network is unreachable: " + parsed.Hostname()
 			return
 		}
@@ -0,0 +1,129 @@
+package announcer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff/v3"
+	"github.com/cenkalti/rain/internal/tracker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClampInterval(t *testing.T) {
+	a := &PeriodicalAnnouncer{minInterval: time.Minute}
+	assert.Equal(t, 30*time.Minute, a.clampInterval(30*time.Minute))
+	assert.Equal(t, time.Minute, a.clampInterval(time.Second))
+
+	a.maxInterval = 10 * time.Minute
+	assert.Equal(t, 10*time.Minute, a.clampInterval(30*time.Minute))
+
+	a.intervalOverride = 5 * time.Minute
+	assert.Equal(t, 5*time.Minute, a.clampInterval(30*time.Minute))
+
+	a.minInterval = 20 * time.Minute
+	assert.Equal(t, 20*time.Minute, a.clampInterval(30*time.Minute))
+}
+
+type testTracker string
+
+func (t testTracker) Announce(ctx context.Context, req tracker.AnnounceRequest) (*tracker.AnnounceResponse, error) {
+	return nil, errors.New("error")
+}
+
+func (t testTracker) URL() string {
+	return string(t)
+}
+
+func TestNextIntervalFromError(t *testing.T) {
+	tier := &tracker.Tier{Trackers: []tracker.Tracker{testTracker("http://a"), testTracker("http://b")}}
+	a := &PeriodicalAnnouncer{
+		Tracker:          tier,
+		retryMinInterval: 5 * time.Second,
+		trackers:         make(map[string]*trackerState),
+	}
+	a.trackerState("http://a").backoff = &backoff.ConstantBackOff{Interval: time.Minute}
+	a.trackerState("http://b").backoff = &backoff.ConstantBackOff{Interval: 2 * time.Minute}
+	now := time.Now()
+
+	// First tracker fails, tier switches to the second one which has not failed yet.
+	_, _ = tier.Announce(context.Background(), tracker.AnnounceRequest{})
+	assert.Equal(t, 1, a.handleFailure(&AnnounceError{URL: "http://a", Err: errors.New("error")}, now))
+	assert.Equal(t, 5*time.Second, a.getNextIntervalFromError(now))
+
+	// Second tracker fails, tier switches back to the first one which has its own backoff.
+	_, _ = tier.Announce(context.Background(), tracker.AnnounceRequest{})
+	assert.Equal(t, 1, a.handleFailure(&AnnounceError{URL: "http://b", Err: errors.New("error")}, now))
+	assert.Equal(t, time.Minute, a.getNextIntervalFromError(now))
+
+	// Failures are counted per tracker.
+	_, _ = tier.Announce(context.Background(), tracker.AnnounceRequest{})
+	assert.Equal(t, 2, a.handleFailure(&AnnounceError{URL: "http://a", Err: errors.New("error")}, now))
+	assert.Equal(t, 2*time.Minute, a.getNextIntervalFromError(now))
+
+	// Retry time sent by the tracker is respected but not less than the limit.
+	_, _ = tier.Announce(context.Background(), tracker.AnnounceRequest{})
+	assert.Equal(t, 2, a.handleFailure(&AnnounceError{URL: "http://b", Err: &tracker.Error{RetryIn: time.Second}}, now))
+	_, _ = tier.Announce(context.Background(), tracker.AnnounceRequest{})
+	assert.Equal(t, 3, a.handleFailure(&AnnounceError{URL: "http://a", Err: &tracker.Error{RetryIn: time.Second}}, now))
+	assert.Equal(t, 5*time.Second, a.getNextIntervalFromError(now))
+}
+
+func TestAnnounceErrorURL(t *testing.T) {
+	tier := &tracker.Tier{Trackers: []tracker.Tracker{testTracker("http://a"), testTracker("http://b")}}
+	errC := make(chan announceFailure, 1)
+
+	// Error is reported for the tracker that has failed, not the one tier has switched to.
+	announce(context.Background(), tier, tracker.EventNone, 0, tracker.Torrent{}, nil, errC)
+	assert.Equal(t, "http://a", (<-errC).URL)
+	assert.Equal(t, "http://b", tier.URL())
+}
+
+type eventTracker struct {
+	events []tracker.Event
+}
+
+func (t *eventTracker) Announce(ctx context.Context, req tracker.AnnounceRequest) (*tracker.AnnounceResponse, error) {
+	t.events = append(t.events, req.Event)
+	return &tracker.AnnounceResponse{}, nil
+}
+
+func (t *eventTracker) URL() string {
+	return "http://a"
+}
+
+func TestAnnouncePartialSeed(t *testing.T) {
+	trk := &eventTracker{}
+	responseC := make(chan *tracker.AnnounceResponse, 4)
+	partial := tracker.Torrent{PartialSeed: true, BytesLeft: 1}
+	announce(context.Background(), trk, tracker.EventNone, 0, partial, responseC, nil)
+	announce(context.Background(), trk, tracker.EventStarted, 0, partial, responseC, nil)
+	announce(context.Background(), trk, tracker.EventNone, 0, tracker.Torrent{PartialSeed: true}, responseC, nil)
+	announce(context.Background(), trk, tracker.EventNone, 0, tracker.Torrent{BytesLeft: 1}, responseC, nil)
+	assert.Equal(t, []tracker.Event{tracker.EventPaused, tracker.EventStarted, tracker.EventNone, tracker.EventNone}, trk.events)
+}
+
+func TestPendingEvent(t *testing.T) {
+	a := &PeriodicalAnnouncer{numWant: 50}
+
+	// "started" is retried until it is received by the tracker.
+	e, numWant := a.pendingEvent()
+	assert.Equal(t, tracker.EventStarted, e)
+	assert.Equal(t, 50, numWant)
+	e, _ = a.pendingEvent()
+	assert.Equal(t, tracker.EventStarted, e)
+	a.eventAnnounced(e)
+	e, numWant = a.pendingEvent()
+	assert.Equal(t, tracker.EventNone, e)
+	assert.Equal(t, 50, numWant)
+
+	// "completed" is sent once after download is finished.
+	a.completedPending = true
+	e, numWant = a.pendingEvent()
+	assert.Equal(t, tracker.EventCompleted, e)
+	assert.Equal(t, 0, numWant)
+	a.eventAnnounced(e)
+	e, _ = a.pendingEvent()
+	assert.Equal(t, tracker.EventNone, e)
+}
@@ -0,0 +1,127 @@
+package announcer
+
+import (
+	"context"
+	"time"
+
+	"github.com/cenkalti/rain/internal/logger"
+	"github.com/cenkalti/rain/internal/tracker"
+)
+
+// ScrapeResult is the combined result of scrape requests sent to the trackers of a torrent.
+type ScrapeResult struct {
+	Seeders   int32
+	Leechers  int32
+	Completed int32
+	// Number of trackers that have responded successfully.
+	Trackers int
+}
+
+// Scraper periodically sends scrape requests to the trackers of a torrent.
+type Scraper struct {
+	log      logger.Logger
+	trackers []tracker.Tracker
+	infoHash [20]byte
+	interval time.Duration
+	timeout  time.Duration
+	resultC  chan ScrapeResult
+	closeC   chan struct{}
+	doneC    chan struct{}
+}
+
+// NewScraper returns a new Scraper.
+func NewScraper(trackers []tracker.Tracker, infoHash [20]byte, interval, timeout time.Duration, resultC chan ScrapeResult, l logger.Logger) *Scraper {
+	return &Scraper{
+		log:      l,
+		trackers: trackers,
+		infoHash: infoHash,
+		interval: interval,
+		timeout:  timeout,
+		resultC:  resultC,
+		closeC:   make(chan struct{}),
+		doneC:    make(chan struct{}),
+	}
+}
+
+// Close the scraper.
+func (s *Scraper) Close() {
+	close(s.closeC)
+	<-s.doneC
+}
+
+// Run the scraper. Trackers are scraped immediately and then at every interval.
+// The result is sent to resultC if at least one of the trackers has responded.
+func (s *Scraper) Run() {
+	defer close(s.doneC)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		result := s.scrape()
+		if result.Trackers > 0 {
+			select {
+			case s.resultC <- result:
+			case <-s.closeC:
+				return
+			}
+		}
+		select {
+		case <-ticker.C:
+		case <-s.closeC:
+			return
+		}
+	}
+}
+
+func (s *Scraper) scrape() ScrapeResult {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-s.closeC:
+			cancel()
+		}
+	}()
+
+	type response struct {
+		resp *tracker.ScrapeResponse
+		err  error
+	}
+	responseC := make(chan response, len(s.trackers))
+	var n int
+	for _, trk := range s.trackers {
+		sc, ok := trk.(tracker.Scraper)
+		if !ok {
+			continue
+		}
+		n++
+		go func(sc tracker.Scraper) {
+			resp, err := sc.Scrape(ctx, s.infoHash)
+			responseC <- response{resp: resp, err: err}
+		}(sc)
+	}
+	var result ScrapeResult
+	for i := 0; i < n; i++ {
+		r := <-responseC
+		if r.err != nil {
+			if r.err != tracker.ErrScrapeNotSupported {
+				s.log.Debugln("scrape error:", r.err)
+			}
+			continue
+		}
+		result.Trackers++
+		// Swarms of trackers overlap, so the largest values are taken instead of the sum.
+		if r.resp.Seeders > result.Seeders {
+			result.Seeders = r.resp.Seeders
+		}
+		if r.resp.Leechers > result.Leechers {
+			result.Leechers = r.resp.Leechers
+		}
+		if r.resp.Completed > result.Completed {
+			result.Completed = r.resp.Completed
+		}
+	}
+	return result
+}
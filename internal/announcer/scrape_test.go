@@ -0,0 +1,34 @@
+package announcer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/rain/internal/logger"
+	"github.com/cenkalti/rain/internal/tracker"
+	"github.com/stretchr/testify/assert"
+)
+
+type testScraper struct {
+	testTracker
+	resp *tracker.ScrapeResponse
+	err  error
+}
+
+func (t testScraper) Scrape(ctx context.Context, infoHash [20]byte) (*tracker.ScrapeResponse, error) {
+	return t.resp, t.err
+}
+
+func TestScrape(t *testing.T) {
+	trackers := []tracker.Tracker{
+		testTracker("http://a"),
+		testScraper{testTracker: "http://b", resp: &tracker.ScrapeResponse{Seeders: 5, Leechers: 1, Completed: 10}},
+		testScraper{testTracker: "http://c", resp: &tracker.ScrapeResponse{Seeders: 2, Leechers: 3, Completed: 4}},
+		testScraper{testTracker: "http://d", err: errors.New("error")},
+		testScraper{testTracker: "http://e", err: tracker.ErrScrapeNotSupported},
+	}
+	s := NewScraper(trackers, [20]byte{}, time.Minute, time.Minute, nil, logger.New("test"))
+	assert.Equal(t, ScrapeResult{Seeders: 5, Leechers: 3, Completed: 10, Trackers: 2}, s.scrape())
+}
@@ -8,19 +8,22 @@ import (
 
 	"github.com/cenkalti/rain/internal/logger"
 	"github.com/cenkalti/rain/internal/mse"
+	"github.com/cenkalti/rain/internal/semaphore"
 )
 
 // Dial new connection to the address. Does the BitTorrent protocol handshake.
 // Handles encryption. May try to connect again if encryption does not match with given setting.
 // Returns a net.Conn that is ready for sending/receiving BitTorrent peer protocol messages.
+// If halfOpen is not nil, it limits the number of TCP connections that are being opened at the same time.
 func Dial(
 	addr net.Addr,
-	dialTimeout, handshakeTimeout time.Duration,
+	dialTimeout, handshakeTimeout, keepAlive time.Duration,
 	enableEncryption,
 	forceEncryption bool,
 	ourExtensions [8]byte,
 	ih [20]byte,
 	ourID [20]byte,
+	halfOpen *semaphore.Semaphore,
 	stopC chan struct{}) (
 	conn net.Conn, cipher mse.CryptoMethod, peerExtensions [8]byte, peerID [20]byte, err error) {
 	log := logger.New("conn -> " + addr.String())
@@ -38,8 +41,17 @@ func Dial(
 
 	// First connection
 	log.Debug("Connecting to peer...")
-	dialer := net.Dialer{Timeout: dialTimeout}
-	conn, err = dialer.DialContext(ctx, addr.Network(), addr.String())
+	dialer := net.Dialer{Timeout: dialTimeout, KeepAlive: keepAlive}
+	dial := func() (net.Conn, error) {
+		if halfOpen != nil {
+			if !halfOpen.WaitStop(ctx.Done()) {
+				return nil, ctx.Err()
+			}
+			defer halfOpen.Signal()
+		}
+		return dialer.DialContext(ctx, addr.Network(), addr.String())
+	}
+	conn, err = dial()
 	if err != nil {
 		return
 	}
@@ -97,7 +109,7 @@ func Dial(
 			// Close current connection and try again without encryption
 			conn.Close()
 			log.Debug("Connecting again without encryption...")
-			conn, err = dialer.DialContext(ctx, addr.Network(), addr.String())
+			conn, err = dial()
 			if err != nil {
 				return
 			}
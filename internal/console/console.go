@@ -570,7 +570,7 @@ func (c *Console) drawDetails(g *gocui.Gui) error {
 					if t.ErrorUnknown {
 						errStr = errStr + " (" + t.ErrorInternal + ")"
 					}
-					fmt.Fprintf(v, "    Status: %s, Error: %s\n", t.Status, errStr)
+					fmt.Fprintf(v, "    Status: %s, Failures: %d, Error: %s\n", t.Status, t.Failures, errStr)
 				default:
 					if t.Warning != "" {
 						fmt.Fprintf(v, "    Status: %s, Seeders: %d, Leechers: %d Warning: %s\n", t.Status, t.Seeders, t.Leechers, t.Warning)
@@ -585,10 +585,11 @@ func (c *Console) drawDetails(g *gocui.Gui) error {
 					nextAnnounce = t.NextAnnounce.Time.Format(time.RFC3339)
 				}
 				fmt.Fprintf(v, "    Last announce: %s, Next announce: %s\n", t.LastAnnounce.Time.Format(time.RFC3339), nextAnnounce)
+				fmt.Fprintf(v, "    Peers received: %d, connected: %d, Downloaded: %d KiB, Uploaded: %d KiB\n", t.PeersReceived, t.PeersConnected, t.BytesDownloaded/1024, t.BytesUploaded/1024)
 			}
 		case peers:
-			format := "%2s %21s %7s %8s %6s %s\n"
-			fmt.Fprintf(v, format, "#", "Addr", "Flags", "Download", "Upload", "Client")
+			format := "%2s %21s %7s %8s %6s %4s %s\n"
+			fmt.Fprintf(v, format, "#", "Addr", "Flags", "Download", "Upload", "Have", "Client")
 			for i, p := range c.peers {
 				num := fmt.Sprintf("%d", i+1)
 				var dl string
@@ -599,7 +600,7 @@ func (c *Console) drawDetails(g *gocui.Gui) error {
 				if p.UploadSpeed > 0 {
 					ul = fmt.Sprintf("%d", p.UploadSpeed/1024)
 				}
-				fmt.Fprintf(v, format, num, p.Addr, flags(p), dl, ul, p.Client)
+				fmt.Fprintf(v, format, num, p.Addr, flags(p), dl, ul, fmt.Sprintf("%d%%", p.Progress), p.Client)
 			}
 		case webseeds:
 			format := "%2s %40s %8s %s\n"
@@ -1140,6 +1141,8 @@ func flags(p rpctypes.Peer) string {
 		sb.WriteString("I")
 	case "MANUAL":
 		sb.WriteString("M")
+	case "LSD":
+		sb.WriteString("L")
 	default:
 		sb.WriteString(" ")
 	}
@@ -1215,6 +1218,7 @@ func FormatStats(stats *rpctypes.Stats, v io.Writer) {
 		status = status + ": " + stats.Error
 	}
 	fmt.Fprintf(v, "Status: %s\n", status)
+	fmt.Fprintf(v, "Priority: %s\n", stats.Priority)
 	fmt.Fprintf(v, "Progress: %d%%\n", getProgress(stats))
 	fmt.Fprintf(v, "Ratio: %.2f\n", getRatio(stats))
 	fmt.Fprintf(v, "Size: %s\n", getSize(stats))
@@ -1222,6 +1226,13 @@ func FormatStats(stats *rpctypes.Stats, v io.Writer) {
 	fmt.Fprintf(v, "Download speed: %11s\n", getDownloadSpeed(stats))
 	fmt.Fprintf(v, "Upload speed:   %11s\n", getUploadSpeed(stats))
 	fmt.Fprintf(v, "ETA: %s\n", getETA(stats))
+	for _, t := range stats.Trackers {
+		if t.Error != "" {
+			fmt.Fprintf(v, "Tracker: %s, Status: %s, Error: %s\n", t.URL, t.Status, t.Error)
+		} else {
+			fmt.Fprintf(v, "Tracker: %s, Status: %s, Seeders: %d, Leechers: %d\n", t.URL, t.Status, t.Seeders, t.Leechers)
+		}
+	}
 }
 
 // FormatSessionStats returns the human readable representation of session stats object.
@@ -1235,4 +1246,5 @@ func FormatSessionStats(s *rpctypes.SessionStats, v io.Writer) {
 	fmt.Fprintf(v, "DownloadSpeed: %dKB/s, UploadSpeed: %dKB/s\n", s.SpeedDownload/1024, s.SpeedUpload/1024)
 	fmt.Fprintf(v, "BytesDownloaded: %dMB, BytesUploaded: %dMB\n", s.BytesDownloaded/1024/1024, s.BytesUploaded/1024/1024)
 	fmt.Fprintf(v, "BytesRead: %dMB, BytesWritten: %dMB\n", s.BytesRead/1024/1024, s.BytesWritten/1024/1024)
+	fmt.Fprintf(v, "Lifetime BytesDownloaded: %dMB, BytesUploaded: %dMB, Sessions: %d\n", s.LifetimeBytesDownloaded/1024/1024, s.LifetimeBytesUploaded/1024/1024, s.SessionCount)
 }
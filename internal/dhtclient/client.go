@@ -0,0 +1,283 @@
+// Package dhtclient implements a client for the BitTorrent DHT network (BEP 5).
+package dhtclient
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/zeebo/bencode"
+)
+
+// maxKnownNodes is the max number of nodes that are remembered to start the next lookup from.
+const maxKnownNodes = 64
+
+var (
+	errClosed  = errors.New("dht client is closed")
+	errNoNodes = errors.New("no dht node has responded")
+)
+
+// Client sends queries to the nodes of the DHT network.
+// It does not respond to queries of other nodes and marks its queries as read-only (BEP 43),
+// so it is not added to the routing tables of other nodes.
+// Only IPv4 nodes are supported.
+type Client struct {
+	conn         net.PacketConn
+	id           [20]byte
+	queryTimeout time.Duration
+
+	m         sync.Mutex
+	bootstrap []string
+	pending   map[string]*transaction
+	txID      uint16
+	known     map[string]node
+
+	closeC chan struct{}
+	doneC  chan struct{}
+}
+
+type transaction struct {
+	addr  string
+	respC chan *message
+}
+
+// Error is returned from queries when the node responds with an error message.
+type Error struct {
+	Code    int64
+	Message string
+}
+
+func (e *Error) Error() string {
+	return "dht error: " + e.Message
+}
+
+type message struct {
+	T string   `bencode:"t"`
+	Y string   `bencode:"y"`
+	R response `bencode:"r"`
+	E []any    `bencode:"e"`
+}
+
+type response struct {
+	ID     string   `bencode:"id"`
+	Nodes  string   `bencode:"nodes"`
+	Token  string   `bencode:"token"`
+	Values []string `bencode:"values"`
+
+	// Fields of BEP 44 get response
+	V   bencode.RawMessage `bencode:"v"`
+	K   string             `bencode:"k"`
+	Seq int64              `bencode:"seq"`
+	Sig string             `bencode:"sig"`
+
+	// Fields of BEP 51 sample_infohashes response
+	Interval int64  `bencode:"interval"`
+	Num      int64  `bencode:"num"`
+	Samples  string `bencode:"samples"`
+}
+
+// New returns a new Client that sends queries over conn.
+// Addresses in bootstrap are used for finding the first nodes.
+// Client must be run with Run method and closed with Close after use.
+func New(conn net.PacketConn, bootstrap []string, queryTimeout time.Duration) (*Client, error) {
+	c := &Client{
+		conn:         conn,
+		queryTimeout: queryTimeout,
+		bootstrap:    bootstrap,
+		pending:      make(map[string]*transaction),
+		known:        make(map[string]node),
+		closeC:       make(chan struct{}),
+		doneC:        make(chan struct{}),
+	}
+	_, err := rand.Read(c.id[:])
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// ID returns the node ID that is sent in queries.
+func (c *Client) ID() [20]byte {
+	return c.id
+}
+
+// AddNodes adds addresses of nodes to be used for starting lookups, such as the nodes saved in previous session.
+func (c *Client) AddNodes(addrs []string) {
+	c.m.Lock()
+	c.bootstrap = append(c.bootstrap, addrs...)
+	c.m.Unlock()
+}
+
+// Close the client and its connection.
+func (c *Client) Close() {
+	close(c.closeC)
+	c.conn.Close()
+	<-c.doneC
+}
+
+// Run reads responses from the connection until the client is closed.
+func (c *Client) Run() {
+	defer close(c.doneC)
+	buf := make([]byte, 65536)
+	for {
+		n, from, err := c.conn.ReadFrom(buf)
+		if err != nil {
+			select {
+			case <-c.closeC:
+				return
+			default:
+			}
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				continue
+			}
+			return
+		}
+		var msg message
+		err = bencode.DecodeBytes(append([]byte(nil), buf[:n]...), &msg)
+		if err != nil {
+			continue
+		}
+		// Queries of other nodes are not answered.
+		if msg.Y != "r" && msg.Y != "e" {
+			continue
+		}
+		c.m.Lock()
+		t, ok := c.pending[msg.T]
+		if ok && t.addr == from.String() {
+			delete(c.pending, msg.T)
+		} else {
+			ok = false
+		}
+		c.m.Unlock()
+		if ok {
+			t.respC <- &msg
+		}
+	}
+}
+
+func (c *Client) query(ctx context.Context, addr *net.UDPAddr, method string, args map[string]any) (*response, error) {
+	args["id"] = string(c.id[:])
+	t := &transaction{
+		addr:  addr.String(),
+		respC: make(chan *message, 1),
+	}
+	c.m.Lock()
+	c.txID++
+	tx := string([]byte{byte(c.txID >> 8), byte(c.txID)})
+	c.pending[tx] = t
+	c.m.Unlock()
+	defer func() {
+		c.m.Lock()
+		if c.pending[tx] == t {
+			delete(c.pending, tx)
+		}
+		c.m.Unlock()
+	}()
+
+	b, err := bencode.EncodeBytes(map[string]any{
+		"t":  tx,
+		"y":  "q",
+		"q":  method,
+		"a":  args,
+		"ro": 1,
+	})
+	if err != nil {
+		return nil, err
+	}
+	_, err = c.conn.WriteTo(b, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.queryTimeout)
+	defer cancel()
+	select {
+	case msg := <-t.respC:
+		if msg.Y == "e" {
+			return nil, newError(msg.E)
+		}
+		if len(msg.R.ID) != 20 {
+			return nil, errors.New("invalid node id in response")
+		}
+		return &msg.R, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-c.closeC:
+		return nil, errClosed
+	}
+}
+
+func newError(e []any) *Error {
+	var ret Error
+	if len(e) > 0 {
+		ret.Code, _ = e[0].(int64)
+	}
+	if len(e) > 1 {
+		ret.Message, _ = e[1].(string)
+	}
+	return &ret
+}
+
+// addKnownNode remembers a node that has responded, so the next lookup does not need to start from the bootstrap nodes.
+func (c *Client) addKnownNode(n node) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	n.bootstrap = false
+	c.known[n.addr.String()] = n
+	if len(c.known) <= maxKnownNodes {
+		return
+	}
+	for key := range c.known {
+		if key != n.addr.String() {
+			delete(c.known, key)
+			return
+		}
+	}
+}
+
+// startNodes returns the nodes to start a lookup.
+// Bootstrap nodes are used only if there are not enough known nodes.
+func (c *Client) startNodes() []node {
+	c.m.Lock()
+	nodes := make([]node, 0, len(c.known)+len(c.bootstrap))
+	for _, n := range c.known {
+		nodes = append(nodes, n)
+	}
+	var bootstrap []string
+	if len(nodes) < k {
+		bootstrap = append(bootstrap, c.bootstrap...)
+	}
+	c.m.Unlock()
+	for _, s := range bootstrap {
+		addr, err := net.ResolveUDPAddr("udp4", s)
+		if err != nil {
+			continue
+		}
+		nodes = append(nodes, node{addr: addr, bootstrap: true})
+	}
+	return nodes
+}
+
+// Nodes returns the addresses of the nodes that have responded to queries recently.
+func (c *Client) Nodes() []string {
+	c.m.Lock()
+	defer c.m.Unlock()
+	ret := make([]string, 0, len(c.known))
+	for addr := range c.known {
+		ret = append(ret, addr)
+	}
+	return ret
+}
+
+// Port returns the UDP port that the client is sending queries from.
+func (c *Client) Port() int {
+	addr, ok := c.conn.LocalAddr().(*net.UDPAddr)
+	if !ok {
+		return 0
+	}
+	return addr.Port
+}
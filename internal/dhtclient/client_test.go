@@ -0,0 +1,144 @@
+package dhtclient
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zeebo/bencode"
+)
+
+type fakeQuery struct {
+	T  string         `bencode:"t"`
+	Y  string         `bencode:"y"`
+	Q  string         `bencode:"q"`
+	A  map[string]any `bencode:"a"`
+	RO int            `bencode:"ro"`
+}
+
+// runFakeNode answers get_peers queries with a single peer, stores items with put queries and
+// sends received queries to queryC.
+func runFakeNode(t *testing.T, conn net.PacketConn, queryC chan<- fakeQuery) {
+	id := string(make([]byte, 20))
+	items := make(map[string]map[string]any)
+	buf := make([]byte, 65536)
+	for {
+		n, from, err := conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		var q fakeQuery
+		if err = bencode.DecodeBytes(buf[:n], &q); err != nil {
+			t.Error(err)
+			return
+		}
+		queryC <- q
+		r := map[string]any{"id": id}
+		switch q.Q {
+		case "get_peers":
+			r["token"] = "tok"
+			r["values"] = []string{"\x01\x02\x03\x04\x1a\xe1"}
+		case "sample_infohashes":
+			r["interval"] = 60
+			r["num"] = 2
+			r["samples"] = strings.Repeat("a", 20) + strings.Repeat("b", 20)
+		case "get":
+			r["token"] = "tok"
+			for key, val := range items[q.A["target"].(string)] {
+				r[key] = val
+			}
+		case "put":
+			var target [20]byte
+			if k, ok := q.A["k"].(string); ok {
+				salt, _ := q.A["salt"].(string)
+				target = sha1.Sum([]byte(k + salt))
+			} else {
+				v, _ := bencode.EncodeBytes(q.A["v"])
+				target = sha1.Sum(v)
+			}
+			delete(q.A, "id")
+			delete(q.A, "token")
+			delete(q.A, "salt")
+			items[string(target[:])] = q.A
+		}
+		b, _ := bencode.EncodeBytes(map[string]any{"t": q.T, "y": "r", "r": r})
+		_, _ = conn.WriteTo(b, from)
+	}
+}
+
+// newTestClient returns a client that is connected to a fake node.
+func newTestClient(t *testing.T, queryC chan<- fakeQuery) (*Client, func()) {
+	nodeConn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	go runFakeNode(t, nodeConn, queryC)
+
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	c, err := New(conn, []string{nodeConn.LocalAddr().String()}, time.Second)
+	require.NoError(t, err)
+	go c.Run()
+	return c, func() {
+		c.Close()
+		nodeConn.Close()
+	}
+}
+
+func TestAnnounce(t *testing.T) {
+	queryC := make(chan fakeQuery, 10)
+	c, closeClient := newTestClient(t, queryC)
+	defer closeClient()
+
+	var ih [20]byte
+	ih[0] = 1
+	peers, err := c.Announce(context.Background(), ih, 6881)
+	require.NoError(t, err)
+	require.Len(t, peers, 1)
+	assert.Equal(t, "1.2.3.4:6881", peers[0].String())
+
+	q := <-queryC
+	assert.Equal(t, "get_peers", q.Q)
+	assert.Equal(t, 1, q.RO)
+	assert.Equal(t, string(ih[:]), q.A["info_hash"])
+	q = <-queryC
+	assert.Equal(t, "announce_peer", q.Q)
+	assert.Equal(t, 1, q.RO)
+	assert.Equal(t, "tok", q.A["token"])
+	assert.Equal(t, int64(6881), q.A["port"])
+}
+
+func TestNoNodes(t *testing.T) {
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	c, err := New(conn, nil, time.Second)
+	require.NoError(t, err)
+	go c.Run()
+	defer c.Close()
+
+	_, err = c.GetPeers(context.Background(), [20]byte{})
+	assert.ErrorIs(t, err, errNoNodes)
+}
+
+func TestParseCompactNodes(t *testing.T) {
+	s := string(make([]byte, 20)) + "\x7f\x00\x00\x01\x1a\xe1" + "trailing"
+	nodes := parseCompactNodes(s)
+	require.Len(t, nodes, 1)
+	assert.Equal(t, "127.0.0.1:6881", nodes[0].addr.String())
+}
+
+func hexString(b [20]byte) string {
+	return hex.EncodeToString(b[:])
+}
+
+func mustDecodeHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
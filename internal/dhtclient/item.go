@@ -0,0 +1,241 @@
+package dhtclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha1" // nolint: gosec
+	"errors"
+	"strconv"
+	"sync"
+
+	"github.com/zeebo/bencode"
+)
+
+const (
+	// MaxValueLength is the max length of the bencoded value of an item (BEP 44).
+	MaxValueLength = 1000
+	// MaxSaltLength is the max length of the salt of a mutable item (BEP 44).
+	MaxSaltLength = 64
+)
+
+var (
+	// ErrNotFound is returned when no node returns the requested item.
+	ErrNotFound = errors.New("item not found on dht")
+
+	errValueTooLong = errors.New("value is too long")
+	errSaltTooLong  = errors.New("salt is too long")
+	errInvalidValue = errors.New("value is not bencoded")
+	errSeqTooLow    = errors.New("sequence number is less than the current item")
+	errInvalidSig   = errors.New("invalid signature")
+)
+
+// MutableItem is an item that is signed with an ed25519 key and can be updated by increasing the sequence number.
+type MutableItem struct {
+	Key   [32]byte
+	Salt  []byte
+	Seq   int64
+	Value []byte
+	Sig   [64]byte
+}
+
+// ImmutableTarget returns the target of an immutable item with the bencoded value.
+func ImmutableTarget(value []byte) [20]byte {
+	return sha1.Sum(value) // nolint: gosec
+}
+
+// MutableTarget returns the target of a mutable item with the public key and salt.
+func MutableTarget(key [32]byte, salt []byte) [20]byte {
+	return sha1.Sum(append(key[:], salt...)) // nolint: gosec
+}
+
+// NewMutableItem returns an item that is signed with the private key.
+func NewMutableItem(privateKey ed25519.PrivateKey, salt []byte, seq int64, value []byte) (*MutableItem, error) {
+	if err := checkItem(salt, value); err != nil {
+		return nil, err
+	}
+	item := &MutableItem{
+		Salt:  salt,
+		Seq:   seq,
+		Value: value,
+	}
+	copy(item.Key[:], privateKey.Public().(ed25519.PublicKey))
+	copy(item.Sig[:], ed25519.Sign(privateKey, item.signedBuffer()))
+	return item, nil
+}
+
+// Verify the signature of the item.
+func (i *MutableItem) Verify() bool {
+	return ed25519.Verify(i.Key[:], i.signedBuffer(), i.Sig[:])
+}
+
+// signedBuffer returns the data to be signed as described in BEP 44.
+func (i *MutableItem) signedBuffer() []byte {
+	var b bytes.Buffer
+	if len(i.Salt) > 0 {
+		b.WriteString("4:salt")
+		b.WriteString(strconv.Itoa(len(i.Salt)))
+		b.WriteString(":")
+		b.Write(i.Salt)
+	}
+	b.WriteString("3:seqi")
+	b.WriteString(strconv.FormatInt(i.Seq, 10))
+	b.WriteString("e1:v")
+	b.Write(i.Value)
+	return b.Bytes()
+}
+
+func checkItem(salt, value []byte) error {
+	if len(salt) > MaxSaltLength {
+		return errSaltTooLong
+	}
+	if len(value) > MaxValueLength {
+		return errValueTooLong
+	}
+	var v any
+	if bencode.DecodeBytes(value, &v) != nil {
+		return errInvalidValue
+	}
+	return nil
+}
+
+// GetImmutable returns the bencoded value of the immutable item with the target.
+func (c *Client) GetImmutable(ctx context.Context, target [20]byte) ([]byte, error) {
+	var value []byte
+	var m sync.Mutex
+	_, err := c.lookup(ctx, target, "get", getArgs(target), func(r *response) {
+		if len(r.V) == 0 || ImmutableTarget(r.V) != target {
+			return
+		}
+		m.Lock()
+		value = append([]byte(nil), r.V...)
+		m.Unlock()
+	})
+	if value != nil {
+		return value, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return nil, ErrNotFound
+}
+
+// PutImmutable stores the bencoded value on the nodes closest to its target and returns the target.
+func (c *Client) PutImmutable(ctx context.Context, value []byte) ([20]byte, error) {
+	target := ImmutableTarget(value)
+	if err := checkItem(nil, value); err != nil {
+		return target, err
+	}
+	closest, err := c.lookup(ctx, target, "get", getArgs(target), nil)
+	if err != nil {
+		return target, err
+	}
+	return target, c.put(ctx, closest, func() map[string]any {
+		return map[string]any{"v": bencode.RawMessage(value)}
+	})
+}
+
+// GetMutable returns the mutable item with the highest sequence number that is signed with the key.
+func (c *Client) GetMutable(ctx context.Context, key [32]byte, salt []byte) (*MutableItem, error) {
+	item, _, err := c.getMutable(ctx, key, salt)
+	if item != nil {
+		return item, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return nil, ErrNotFound
+}
+
+func (c *Client) getMutable(ctx context.Context, key [32]byte, salt []byte) (*MutableItem, []lookupResult, error) {
+	target := MutableTarget(key, salt)
+	var item *MutableItem
+	var m sync.Mutex
+	closest, err := c.lookup(ctx, target, "get", getArgs(target), func(r *response) {
+		if r.K != string(key[:]) || len(r.Sig) != 64 || len(r.V) == 0 {
+			return
+		}
+		i := &MutableItem{
+			Key:   key,
+			Salt:  salt,
+			Seq:   r.Seq,
+			Value: append([]byte(nil), r.V...),
+		}
+		copy(i.Sig[:], r.Sig)
+		if !i.Verify() {
+			return
+		}
+		m.Lock()
+		if item == nil || i.Seq > item.Seq {
+			item = i
+		}
+		m.Unlock()
+	})
+	return item, closest, err
+}
+
+// PutMutable stores the signed item on the nodes closest to its target.
+// An error is returned if there is an item with a higher sequence number on DHT.
+func (c *Client) PutMutable(ctx context.Context, item *MutableItem) error {
+	if err := checkItem(item.Salt, item.Value); err != nil {
+		return err
+	}
+	if !item.Verify() {
+		return errInvalidSig
+	}
+	current, closest, err := c.getMutable(ctx, item.Key, item.Salt)
+	if err != nil {
+		return err
+	}
+	if current != nil && current.Seq > item.Seq {
+		return errSeqTooLow
+	}
+	return c.put(ctx, closest, func() map[string]any {
+		args := map[string]any{
+			"k":   string(item.Key[:]),
+			"seq": item.Seq,
+			"sig": string(item.Sig[:]),
+			"v":   bencode.RawMessage(item.Value),
+		}
+		if len(item.Salt) > 0 {
+			args["salt"] = string(item.Salt)
+		}
+		return args
+	})
+}
+
+func getArgs(target [20]byte) func() map[string]any {
+	return func() map[string]any {
+		return map[string]any{"target": string(target[:])}
+	}
+}
+
+// put sends put queries to the nodes that have returned a token.
+// Returns nil if at least one of the nodes has stored the item.
+func (c *Client) put(ctx context.Context, closest []lookupResult, args func() map[string]any) error {
+	var wg sync.WaitGroup
+	errC := make(chan error, len(closest))
+	for _, r := range closest {
+		if r.token == "" {
+			continue
+		}
+		wg.Add(1)
+		go func(r lookupResult) {
+			defer wg.Done()
+			a := args()
+			a["token"] = r.token
+			_, err := c.query(ctx, r.node.addr, "put", a)
+			errC <- err
+		}(r)
+	}
+	wg.Wait()
+	close(errC)
+	err := errNoNodes
+	for e := range errC {
+		if e == nil {
+			return nil
+		}
+		err = e
+	}
+	return err
+}
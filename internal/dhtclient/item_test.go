@@ -0,0 +1,77 @@
+package dhtclient
+
+import (
+	"context"
+	"crypto/ed25519"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImmutableItem(t *testing.T) {
+	c, closeClient := newTestClient(t, make(chan fakeQuery, 100))
+	defer closeClient()
+
+	target, err := c.PutImmutable(context.Background(), []byte("12:Hello World!"))
+	require.NoError(t, err)
+	// Test vector from BEP 44
+	assert.Equal(t, "e5f96f6f38320f0f33959cb4d3d656452117aadb", hexString(target))
+
+	value, err := c.GetImmutable(context.Background(), target)
+	require.NoError(t, err)
+	assert.Equal(t, "12:Hello World!", string(value))
+
+	_, err = c.GetImmutable(context.Background(), [20]byte{})
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, err = c.PutImmutable(context.Background(), []byte("not bencoded"))
+	assert.ErrorIs(t, err, errInvalidValue)
+}
+
+func TestMutableItem(t *testing.T) {
+	c, closeClient := newTestClient(t, make(chan fakeQuery, 100))
+	defer closeClient()
+
+	_, key, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	var pub [32]byte
+	copy(pub[:], key.Public().(ed25519.PublicKey))
+
+	_, err = c.GetMutable(context.Background(), pub, []byte("salt"))
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	item, err := NewMutableItem(key, []byte("salt"), 2, []byte("5:hello"))
+	require.NoError(t, err)
+	require.NoError(t, c.PutMutable(context.Background(), item))
+
+	got, err := c.GetMutable(context.Background(), pub, []byte("salt"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), got.Seq)
+	assert.Equal(t, "5:hello", string(got.Value))
+
+	item, err = NewMutableItem(key, []byte("salt"), 1, []byte("5:older"))
+	require.NoError(t, err)
+	assert.ErrorIs(t, c.PutMutable(context.Background(), item), errSeqTooLow)
+}
+
+func TestMutableItemSignature(t *testing.T) {
+	// Test vector from BEP 44
+	item := &MutableItem{
+		Salt:  []byte("foobar"),
+		Seq:   1,
+		Value: []byte("12:Hello World!"),
+	}
+	copy(item.Key[:], mustDecodeHex("77ff84905a91936367c01360803104f92432fcd904a43511876df5cdf3e7e548"))
+	copy(item.Sig[:], mustDecodeHex("6834284b6b24c3204eb2fea824d82f88883a3d95e8b4a21b8c0ded553d17d17ddf9a8a7104b1258f30bed3787e6cb896fca78c58f8e03b5f18f14951a87d9a08"))
+	assert.Equal(t, "411eba73b6f087ca51a3795d9c8c938d365e32c1", hexString(MutableTarget(item.Key, item.Salt)))
+	assert.True(t, item.Verify())
+	item.Seq++
+	assert.False(t, item.Verify())
+
+	_, key, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	item, err = NewMutableItem(key, nil, 1, []byte("12:Hello World!"))
+	require.NoError(t, err)
+	assert.True(t, item.Verify())
+}
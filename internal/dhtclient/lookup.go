@@ -0,0 +1,152 @@
+package dhtclient
+
+import (
+	"context"
+	"net"
+	"sort"
+	"sync"
+)
+
+const (
+	// k is the number of closest nodes that are queried (bucket size in BEP 5).
+	k = 8
+	// alpha is the number of concurrent queries in a lookup.
+	alpha = 3
+)
+
+type lookupResult struct {
+	node  node
+	token string
+}
+
+// lookup iteratively queries the nodes closest to target with method and calls onResponse for every response.
+// Returns the closest nodes that have responded along with the tokens they have returned.
+func (c *Client) lookup(ctx context.Context, target [20]byte, method string, args func() map[string]any, onResponse func(*response)) ([]lookupResult, error) {
+	candidates := c.startNodes()
+	if len(candidates) == 0 {
+		return nil, errNoNodes
+	}
+	queried := make(map[string]struct{})
+	var responded []lookupResult
+
+	var m sync.Mutex
+	for {
+		// Pick the closest unqueried candidates.
+		sortNodes(target, candidates)
+		var batch []node
+		for _, n := range candidates {
+			if len(batch) == alpha {
+				break
+			}
+			if _, ok := queried[n.addr.String()]; ok {
+				continue
+			}
+			// Stop when k closer nodes have already responded.
+			if !n.bootstrap && len(responded) >= k && !closer(target, n.id, responded[k-1].node.id) {
+				continue
+			}
+			queried[n.addr.String()] = struct{}{}
+			batch = append(batch, n)
+		}
+		if len(batch) == 0 {
+			break
+		}
+		var wg sync.WaitGroup
+		for _, n := range batch {
+			wg.Add(1)
+			go func(n node) {
+				defer wg.Done()
+				r, err := c.query(ctx, n.addr, method, args())
+				if err != nil {
+					return
+				}
+				copy(n.id[:], r.ID)
+				c.addKnownNode(n)
+				m.Lock()
+				defer m.Unlock()
+				if onResponse != nil {
+					onResponse(r)
+				}
+				responded = append(responded, lookupResult{node: n, token: r.Token})
+				candidates = append(candidates, parseCompactNodes(r.Nodes)...)
+			}(n)
+		}
+		wg.Wait()
+		sort.Slice(responded, func(i, j int) bool { return closer(target, responded[i].node.id, responded[j].node.id) })
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+	if len(responded) == 0 {
+		return nil, errNoNodes
+	}
+	if len(responded) > k {
+		responded = responded[:k]
+	}
+	return responded, nil
+}
+
+func sortNodes(target [20]byte, nodes []node) {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		// Bootstrap nodes have unknown IDs so they come last.
+		if nodes[i].bootstrap != nodes[j].bootstrap {
+			return !nodes[i].bootstrap
+		}
+		return closer(target, nodes[i].id, nodes[j].id)
+	})
+}
+
+// GetPeers finds the peers of the torrent with the infohash by querying the closest nodes.
+func (c *Client) GetPeers(ctx context.Context, infoHash [20]byte) ([]*net.TCPAddr, error) {
+	peers, _, err := c.getPeers(ctx, infoHash)
+	return peers, err
+}
+
+func (c *Client) getPeers(ctx context.Context, infoHash [20]byte) ([]*net.TCPAddr, []lookupResult, error) {
+	seen := make(map[string]struct{})
+	var peers []*net.TCPAddr
+	args := func() map[string]any {
+		return map[string]any{"info_hash": string(infoHash[:])}
+	}
+	closest, err := c.lookup(ctx, infoHash, "get_peers", args, func(r *response) {
+		for _, v := range r.Values {
+			addr := parseCompactPeer(v)
+			if addr == nil || addr.Port == 0 {
+				continue
+			}
+			if _, ok := seen[addr.String()]; ok {
+				continue
+			}
+			seen[addr.String()] = struct{}{}
+			peers = append(peers, addr)
+		}
+	})
+	return peers, closest, err
+}
+
+// Announce finds the peers of the torrent with the infohash and announces the port to the closest nodes.
+// Peers found during the lookup are returned.
+func (c *Client) Announce(ctx context.Context, infoHash [20]byte, port int) ([]*net.TCPAddr, error) {
+	peers, closest, err := c.getPeers(ctx, infoHash)
+	if err != nil {
+		return peers, err
+	}
+	var wg sync.WaitGroup
+	for _, r := range closest {
+		if r.token == "" {
+			continue
+		}
+		wg.Add(1)
+		go func(r lookupResult) {
+			defer wg.Done()
+			args := map[string]any{
+				"info_hash": string(infoHash[:]),
+				"port":      port,
+				"token":     r.token,
+			}
+			_, _ = c.query(ctx, r.node.addr, "announce_peer", args)
+		}(r)
+	}
+	wg.Wait()
+	return peers, nil
+}
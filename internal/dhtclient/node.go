@@ -0,0 +1,54 @@
+package dhtclient
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+)
+
+// compactNodeLen is the length of compact node info of an IPv4 node (BEP 5).
+const compactNodeLen = 26
+
+type node struct {
+	id   [20]byte
+	addr *net.UDPAddr
+	// ID of the bootstrap nodes are not known until they respond.
+	bootstrap bool
+}
+
+func parseCompactNodes(s string) []node {
+	nodes := make([]node, 0, len(s)/compactNodeLen)
+	for ; len(s) >= compactNodeLen; s = s[compactNodeLen:] {
+		var n node
+		copy(n.id[:], s[:20])
+		n.addr = &net.UDPAddr{
+			IP:   net.IP([]byte(s[20:24])),
+			Port: int(binary.BigEndian.Uint16([]byte(s[24:26]))),
+		}
+		if n.addr.Port == 0 {
+			continue
+		}
+		nodes = append(nodes, n)
+	}
+	return nodes
+}
+
+func parseCompactPeer(s string) *net.TCPAddr {
+	if len(s) != 6 {
+		return nil
+	}
+	return &net.TCPAddr{
+		IP:   net.IP([]byte(s[:4])),
+		Port: int(binary.BigEndian.Uint16([]byte(s[4:6]))),
+	}
+}
+
+// closer returns true if a is closer than b to target by XOR metric.
+func closer(target, a, b [20]byte) bool {
+	var da, db [20]byte
+	for i := range target {
+		da[i] = a[i] ^ target[i]
+		db[i] = b[i] ^ target[i]
+	}
+	return bytes.Compare(da[:], db[:]) < 0
+}
@@ -0,0 +1,105 @@
+package dhtclient
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"sync"
+)
+
+const (
+	// crawlConcurrency is the number of concurrent queries while crawling.
+	crawlConcurrency = 8
+	// maxCrawlQueue is the max number of nodes waiting to be queried while crawling.
+	maxCrawlQueue = 10000
+)
+
+// Crawl walks the DHT by sending sample_infohashes queries (BEP 51) and calls fn for every info hash seen for the first time.
+// Nodes that do not support the query are asked with find_node to discover more nodes.
+// Crawl returns when ctx is done or there are no more nodes to query. fn is not called concurrently.
+func (c *Client) Crawl(ctx context.Context, fn func(infoHash [20]byte)) error {
+	queue := c.startNodes()
+	if len(queue) == 0 {
+		return errNoNodes
+	}
+	queried := make(map[string]struct{})
+	seen := make(map[[20]byte]struct{})
+	responded := false
+	for len(queue) > 0 && ctx.Err() == nil {
+		var batch []node
+		for len(queue) > 0 && len(batch) < crawlConcurrency {
+			n := queue[0]
+			queue = queue[1:]
+			if _, ok := queried[n.addr.String()]; ok {
+				continue
+			}
+			queried[n.addr.String()] = struct{}{}
+			batch = append(batch, n)
+		}
+		var m sync.Mutex
+		var samples [][20]byte
+		var wg sync.WaitGroup
+		for _, n := range batch {
+			wg.Add(1)
+			go func(n node) {
+				defer wg.Done()
+				r, err := c.sampleInfoHashes(ctx, n)
+				if err != nil {
+					return
+				}
+				m.Lock()
+				defer m.Unlock()
+				responded = true
+				samples = append(samples, parseSamples(r.Samples)...)
+				for _, nn := range parseCompactNodes(r.Nodes) {
+					if _, ok := queried[nn.addr.String()]; !ok && len(queue) < maxCrawlQueue {
+						queue = append(queue, nn)
+					}
+				}
+			}(n)
+		}
+		wg.Wait()
+		for _, ih := range samples {
+			if _, ok := seen[ih]; ok {
+				continue
+			}
+			seen[ih] = struct{}{}
+			fn(ih)
+		}
+	}
+	if !responded && ctx.Err() == nil {
+		return errNoNodes
+	}
+	return nil
+}
+
+// sampleInfoHashes sends a sample_infohashes query with a random target to the node.
+// If the node does not support the query, a find_node query is sent to get the nodes close to the target.
+func (c *Client) sampleInfoHashes(ctx context.Context, n node) (*response, error) {
+	var target [20]byte
+	_, err := rand.Read(target[:])
+	if err != nil {
+		return nil, err
+	}
+	r, err := c.query(ctx, n.addr, "sample_infohashes", map[string]any{"target": string(target[:])})
+	var e *Error
+	if errors.As(err, &e) {
+		r, err = c.query(ctx, n.addr, "find_node", map[string]any{"target": string(target[:])})
+	}
+	if err != nil {
+		return nil, err
+	}
+	copy(n.id[:], r.ID)
+	c.addKnownNode(n)
+	return r, nil
+}
+
+func parseSamples(s string) [][20]byte {
+	ret := make([][20]byte, 0, len(s)/20)
+	for ; len(s) >= 20; s = s[20:] {
+		var ih [20]byte
+		copy(ih[:], s[:20])
+		ret = append(ret, ih)
+	}
+	return ret
+}
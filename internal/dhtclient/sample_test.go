@@ -0,0 +1,25 @@
+package dhtclient
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCrawl(t *testing.T) {
+	queryC := make(chan fakeQuery, 10)
+	c, closeClient := newTestClient(t, queryC)
+	defer closeClient()
+
+	var infoHashes []string
+	err := c.Crawl(context.Background(), func(ih [20]byte) {
+		infoHashes = append(infoHashes, string(ih[:]))
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"aaaaaaaaaaaaaaaaaaaa", "bbbbbbbbbbbbbbbbbbbb"}, infoHashes)
+	q := <-queryC
+	assert.Equal(t, "sample_infohashes", q.Q)
+	assert.Len(t, q.A["target"], 20)
+}
@@ -0,0 +1,35 @@
+// Package diskspace returns the free space on filesystems.
+package diskspace
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// ErrNotSupported is returned on platforms that free space cannot be determined.
+var ErrNotSupported = errors.New("disk space check is not supported on this platform")
+
+// Free returns the number of bytes available to the user on the filesystem that contains path.
+// If path does not exist yet, the nearest existing parent directory is checked.
+func Free(path string) (int64, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return 0, err
+	}
+	for {
+		_, err = os.Stat(path)
+		if err == nil {
+			break
+		}
+		if !os.IsNotExist(err) {
+			return 0, err
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return 0, err
+		}
+		path = parent
+	}
+	return free(path)
+}
@@ -0,0 +1,7 @@
+//go:build !linux && !darwin && !freebsd && !windows
+
+package diskspace
+
+func free(path string) (int64, error) {
+	return 0, ErrNotSupported
+}
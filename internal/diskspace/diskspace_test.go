@@ -0,0 +1,19 @@
+package diskspace
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFree(t *testing.T) {
+	dir := t.TempDir()
+	n, err := Free(dir)
+	assert.NoError(t, err)
+	assert.Greater(t, n, int64(0))
+
+	n2, err := Free(filepath.Join(dir, "not", "exists"))
+	assert.NoError(t, err)
+	assert.Greater(t, n2, int64(0))
+}
@@ -0,0 +1,14 @@
+//go:build linux || darwin || freebsd
+
+package diskspace
+
+import "syscall"
+
+func free(path string) (int64, error) {
+	var st syscall.Statfs_t
+	err := syscall.Statfs(path, &st)
+	if err != nil {
+		return 0, err
+	}
+	return int64(st.Bavail) * int64(st.Bsize), nil
+}
@@ -0,0 +1,18 @@
+//go:build windows
+
+package diskspace
+
+import "golang.org/x/sys/windows"
+
+func free(path string) (int64, error) {
+	p, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+	var avail, total, totalFree uint64
+	err = windows.GetDiskFreeSpaceEx(p, &avail, &total, &totalFree)
+	if err != nil {
+		return 0, err
+	}
+	return int64(avail), nil
+}
@@ -23,6 +23,17 @@ type ReadWriterAt interface {
 // all files are concatenated and splitted into pieces in length specified in the torrent file.
 type Piece []FileSection
 
+// PaddingLength returns the number of bytes in padding sections of the piece.
+func (p Piece) PaddingLength() int64 {
+	var n int64
+	for _, sec := range p {
+		if sec.Padding {
+			n += sec.Length
+		}
+	}
+	return n
+}
+
 // ReadAt implements io.ReaderAt interface.
 // It reads bytes from s at given offset into p.
 // Used when uploading blocks of a piece.
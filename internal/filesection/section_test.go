@@ -80,3 +80,15 @@ func content(f *os.File) string {
 	_, _ = f.Read(b)
 	return string(b)
 }
+
+func TestPaddingLength(t *testing.T) {
+	p := Piece{
+		{Length: 3},
+		{Length: 5, Padding: true},
+		{Length: 7},
+		{Length: 11, Padding: true},
+	}
+	if n := p.PaddingLength(); n != 16 {
+		t.Errorf("n == %d", n)
+	}
+}
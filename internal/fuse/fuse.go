@@ -0,0 +1,37 @@
+// Package fuse implements a minimal read-only FUSE file system server.
+// It talks to the kernel directly without a C library. It is only supported on Linux.
+//
+// Files must not be accessed from the process serving the file system.
+// The Go runtime registers opened files to its poller, which waits for the server and deadlocks.
+package fuse
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrNotSupported is returned from Mount on platforms other than Linux.
+var ErrNotSupported = errors.New("fuse is not supported on this platform")
+
+// Node is a file or a directory in the file system.
+type Node struct {
+	Name string
+	// Size of the file. Not used for directories.
+	Size int64
+	// Children of the directory. Nil for files.
+	Children []*Node
+	// Open is called when the file is opened. Not used for directories.
+	Open func() (File, error)
+}
+
+// Dir returns true if the node is a directory.
+func (n *Node) Dir() bool {
+	return n.Children != nil || n.Open == nil
+}
+
+// File is an open file in the file system.
+// ReadAt may be called concurrently and it should return io.EOF only at the end of the file.
+type File interface {
+	io.ReaderAt
+	io.Closer
+}
@@ -0,0 +1,386 @@
+package fuse
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// Size of the buffer for reading requests from the kernel. It must be larger than max write size.
+const (
+	maxWrite   = 128 << 10
+	bufferSize = maxWrite + 4096
+)
+
+// Attributes and entries are cached by the kernel for this duration. The file system does not change after mount.
+const cacheTimeout = time.Hour
+
+// Structs are read and written in the native byte order of the machine.
+var byteOrder binary.ByteOrder = binary.LittleEndian
+
+func init() {
+	x := uint16(1)
+	if *(*byte)(unsafe.Pointer(&x)) == 0 {
+		byteOrder = binary.BigEndian
+	}
+}
+
+// Server serves a file system mounted at a directory.
+type Server struct {
+	dir string
+	dev *os.File
+	fd  int
+
+	// Nodes are indexed by their IDs. Index 0 is not used because 0 is not a valid node ID.
+	nodes   []*Node
+	parents []uint64
+	// Children of directories by name.
+	children map[uint64]map[string]uint64
+
+	uid, gid uint32
+	mtime    time.Time
+
+	mHandles   sync.Mutex
+	handles    map[uint64]File
+	nextHandle uint64
+}
+
+// Mount the file system with root node at mountpoint directory.
+// Serve must be called for handling the requests after mount.
+func Mount(mountpoint string, root *Node) (*Server, error) {
+	s := &Server{
+		dir:      mountpoint,
+		nodes:    []*Node{nil},
+		parents:  []uint64{0},
+		children: make(map[uint64]map[string]uint64),
+		uid:      uint32(os.Getuid()),
+		gid:      uint32(os.Getgid()),
+		mtime:    time.Now(),
+		handles:  make(map[uint64]File),
+	}
+	s.addNode(root, rootID)
+	dev, err := mount(mountpoint)
+	if err != nil {
+		return nil, err
+	}
+	s.dev = dev
+	s.fd = int(dev.Fd())
+	return s, nil
+}
+
+func (s *Server) addNode(n *Node, parent uint64) uint64 {
+	id := uint64(len(s.nodes))
+	s.nodes = append(s.nodes, n)
+	s.parents = append(s.parents, parent)
+	if n.Dir() {
+		names := make(map[string]uint64, len(n.Children))
+		s.children[id] = names
+		for _, c := range n.Children {
+			names[c.Name] = s.addNode(c, id)
+		}
+	}
+	return id
+}
+
+// Unmount the file system. Serve returns after the kernel closes the connection.
+// The mount is detached lazily, so it does not fail if the files are in use.
+func (s *Server) Unmount() error {
+	return unmount(s.dir)
+}
+
+// Serve handles the requests from the kernel until the file system is unmounted.
+func (s *Server) Serve() error {
+	defer s.dev.Close()
+	defer s.closeHandles()
+	for {
+		buf := make([]byte, bufferSize)
+		n, err := unix.Read(s.fd, buf)
+		switch err {
+		case nil:
+		case unix.EINTR, unix.EAGAIN, unix.ENOENT:
+			// ENOENT is returned if the request is interrupted before it is read.
+			continue
+		case unix.ENODEV:
+			// File system is unmounted.
+			return nil
+		default:
+			return err
+		}
+		var h inHeader
+		err = binary.Read(bytes.NewReader(buf[:n]), byteOrder, &h)
+		if err != nil {
+			return err
+		}
+		payload := buf[binary.Size(h):n]
+		switch h.Opcode {
+		case opOpen, opRead:
+			// These may block while waiting for data.
+			go s.handle(&h, payload)
+		case opDestroy:
+			s.reply(&h, 0, nil)
+			return nil
+		default:
+			s.handle(&h, payload)
+		}
+	}
+}
+
+func (s *Server) closeHandles() {
+	s.mHandles.Lock()
+	defer s.mHandles.Unlock()
+	for fh, f := range s.handles {
+		f.Close()
+		delete(s.handles, fh)
+	}
+}
+
+func (s *Server) handle(h *inHeader, payload []byte) {
+	switch h.Opcode {
+	case opInit:
+		s.handleInit(h, payload)
+	case opLookup:
+		s.handleLookup(h, payload)
+	case opGetattr:
+		if !s.validNode(h.NodeID) {
+			s.reply(h, unix.ENOENT, nil)
+			return
+		}
+		s.reply(h, 0, &attrOut{AttrValid: uint64(cacheTimeout / time.Second), Attr: s.attr(h.NodeID)})
+	case opOpen:
+		s.handleOpen(h, payload)
+	case opRead:
+		s.handleRead(h, payload)
+	case opRelease:
+		s.handleRelease(h, payload)
+	case opOpendir:
+		s.reply(h, 0, &openOut{})
+	case opReaddir:
+		s.handleReaddir(h, payload)
+	case opStatfs:
+		s.reply(h, 0, &kstatfs{Bsize: 4096, Frsize: 4096, Namelen: 255})
+	case opReleasedir, opFlush, opAccess:
+		s.reply(h, 0, nil)
+	case opForget, opBatchForget, opInterrupt:
+		// No reply is expected.
+	default:
+		s.reply(h, unix.ENOSYS, nil)
+	}
+}
+
+func (s *Server) handleInit(h *inHeader, payload []byte) {
+	var in initIn
+	err := binary.Read(bytes.NewReader(payload), byteOrder, &in)
+	if err != nil || in.Major != kernelVersion {
+		s.reply(h, unix.EPROTO, nil)
+		return
+	}
+	minor := in.Minor
+	if minor > kernelMinorVersion {
+		minor = kernelMinorVersion
+	}
+	out := initOut{
+		Major:               kernelVersion,
+		Minor:               minor,
+		MaxReadahead:        in.MaxReadahead,
+		Flags:               in.Flags & initAsyncRead,
+		MaxBackground:       16,
+		CongestionThreshold: 12,
+		MaxWrite:            maxWrite,
+		TimeGran:            1,
+	}
+	var b bytes.Buffer
+	_ = binary.Write(&b, byteOrder, &out)
+	if minor < 23 {
+		// Size of the struct in older protocol versions.
+		s.reply(h, 0, b.Bytes()[:24])
+		return
+	}
+	s.reply(h, 0, b.Bytes())
+}
+
+func (s *Server) handleLookup(h *inHeader, payload []byte) {
+	name := string(bytes.TrimRight(payload, "\x00"))
+	names, ok := s.children[h.NodeID]
+	if !ok {
+		s.reply(h, unix.ENOTDIR, nil)
+		return
+	}
+	id, ok := names[name]
+	if !ok {
+		s.reply(h, unix.ENOENT, nil)
+		return
+	}
+	valid := uint64(cacheTimeout / time.Second)
+	s.reply(h, 0, &entryOut{NodeID: id, EntryValid: valid, AttrValid: valid, Attr: s.attr(id)})
+}
+
+func (s *Server) handleOpen(h *inHeader, payload []byte) {
+	var in openIn
+	err := binary.Read(bytes.NewReader(payload), byteOrder, &in)
+	if err != nil {
+		s.reply(h, unix.EIO, nil)
+		return
+	}
+	if in.Flags&unix.O_ACCMODE != unix.O_RDONLY {
+		s.reply(h, unix.EROFS, nil)
+		return
+	}
+	if !s.validNode(h.NodeID) {
+		s.reply(h, unix.ENOENT, nil)
+		return
+	}
+	n := s.nodes[h.NodeID]
+	if n.Dir() {
+		s.reply(h, unix.EISDIR, nil)
+		return
+	}
+	f, err := n.Open()
+	if err != nil {
+		s.reply(h, unix.EIO, nil)
+		return
+	}
+	s.mHandles.Lock()
+	s.nextHandle++
+	fh := s.nextHandle
+	s.handles[fh] = f
+	s.mHandles.Unlock()
+	s.reply(h, 0, &openOut{Fh: fh, OpenFlags: openKeepCache})
+}
+
+func (s *Server) handleRead(h *inHeader, payload []byte) {
+	var in readIn
+	err := binary.Read(bytes.NewReader(payload), byteOrder, &in)
+	if err != nil {
+		s.reply(h, unix.EIO, nil)
+		return
+	}
+	s.mHandles.Lock()
+	f, ok := s.handles[in.Fh]
+	s.mHandles.Unlock()
+	if !ok {
+		s.reply(h, unix.EBADF, nil)
+		return
+	}
+	b := make([]byte, in.Size)
+	n, err := f.ReadAt(b, int64(in.Offset))
+	if err != nil && err != io.EOF {
+		s.reply(h, unix.EIO, nil)
+		return
+	}
+	s.reply(h, 0, b[:n])
+}
+
+func (s *Server) handleRelease(h *inHeader, payload []byte) {
+	var in releaseIn
+	err := binary.Read(bytes.NewReader(payload), byteOrder, &in)
+	if err != nil {
+		s.reply(h, unix.EIO, nil)
+		return
+	}
+	s.mHandles.Lock()
+	f, ok := s.handles[in.Fh]
+	delete(s.handles, in.Fh)
+	s.mHandles.Unlock()
+	if ok {
+		f.Close()
+	}
+	s.reply(h, 0, nil)
+}
+
+func (s *Server) handleReaddir(h *inHeader, payload []byte) {
+	var in readIn
+	err := binary.Read(bytes.NewReader(payload), byteOrder, &in)
+	if err != nil {
+		s.reply(h, unix.EIO, nil)
+		return
+	}
+	if !s.validNode(h.NodeID) {
+		s.reply(h, unix.ENOENT, nil)
+		return
+	}
+	n := s.nodes[h.NodeID]
+	type entry struct {
+		name string
+		id   uint64
+	}
+	entries := []entry{{".", h.NodeID}, {"..", s.parents[h.NodeID]}}
+	if h.NodeID == rootID {
+		entries[1].id = rootID
+	}
+	for _, c := range n.Children {
+		entries = append(entries, entry{c.Name, s.children[h.NodeID][c.Name]})
+	}
+	var b bytes.Buffer
+	for i := int(in.Offset); i < len(entries); i++ {
+		e := entries[i]
+		typ := uint32(unix.DT_REG)
+		if s.nodes[e.id].Dir() {
+			typ = unix.DT_DIR
+		}
+		size := binary.Size(direntHeader{}) + len(e.name)
+		padded := (size + 7) &^ 7
+		if b.Len()+padded > int(in.Size) {
+			break
+		}
+		_ = binary.Write(&b, byteOrder, &direntHeader{Ino: e.id, Off: uint64(i + 1), Namelen: uint32(len(e.name)), Type: typ})
+		b.WriteString(e.name)
+		b.Write(make([]byte, padded-size))
+	}
+	s.reply(h, 0, b.Bytes())
+}
+
+func (s *Server) validNode(id uint64) bool {
+	return id > 0 && id < uint64(len(s.nodes))
+}
+
+func (s *Server) attr(id uint64) attr {
+	n := s.nodes[id]
+	a := attr{
+		Ino:     id,
+		Mtime:   uint64(s.mtime.Unix()),
+		Atime:   uint64(s.mtime.Unix()),
+		Ctime:   uint64(s.mtime.Unix()),
+		UID:     s.uid,
+		GID:     s.gid,
+		Blksize: 4096,
+	}
+	if n.Dir() {
+		a.Mode = unix.S_IFDIR | 0o555
+		a.Nlink = 2
+	} else {
+		a.Mode = unix.S_IFREG | 0o444
+		a.Nlink = 1
+		a.Size = uint64(n.Size)
+		a.Blocks = (a.Size + 511) / 512
+	}
+	return a
+}
+
+// reply writes the response of the request. Out is either a struct or a byte slice.
+func (s *Server) reply(h *inHeader, errno syscall.Errno, out interface{}) {
+	var body []byte
+	switch v := out.(type) {
+	case nil:
+	case []byte:
+		body = v
+	default:
+		var b bytes.Buffer
+		_ = binary.Write(&b, byteOrder, v)
+		body = b.Bytes()
+	}
+	oh := outHeader{Error: -int32(errno), Unique: h.Unique}
+	oh.Len = uint32(binary.Size(oh) + len(body))
+	var b bytes.Buffer
+	_ = binary.Write(&b, byteOrder, &oh)
+	b.Write(body)
+	// Each reply is written with a single call, so replies from multiple goroutines do not interleave.
+	// Error is returned if the request is interrupted. There is nothing to do in that case.
+	_, _ = unix.Write(s.fd, b.Bytes())
+}
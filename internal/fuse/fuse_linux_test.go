@@ -0,0 +1,104 @@
+package fuse
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/sys/unix"
+)
+
+type bytesFile struct {
+	*bytes.Reader
+}
+
+func (f bytesFile) Close() error { return nil }
+
+func fileNode(name string, data []byte) *Node {
+	return &Node{
+		Name: name,
+		Size: int64(len(data)),
+		Open: func() (File, error) { return bytesFile{bytes.NewReader(data)}, nil },
+	}
+}
+
+func TestMount(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 100000)
+	root := &Node{Children: []*Node{
+		fileNode("a.txt", []byte("hello")),
+		{Name: "dir", Children: []*Node{fileNode("b.bin", data)}},
+	}}
+	dir := t.TempDir()
+	s, err := Mount(dir, root)
+	if err != nil {
+		t.Skipf("cannot mount: %s", err)
+	}
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- s.Serve() }()
+	defer func() {
+		assert.NoError(t, s.Unmount())
+		assert.NoError(t, <-serveErr)
+	}()
+
+	// Files are accessed by other processes because the Go runtime registers opened files
+	// to its poller, which deadlocks if the file system is served by the same process.
+	out, err := exec.Command("ls", dir).Output()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a.txt", "dir"}, strings.Fields(string(out)))
+
+	out, err = exec.Command("cat", filepath.Join(dir, "a.txt")).Output()
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(out))
+
+	out, err = exec.Command("stat", "-c", "%s", filepath.Join(dir, "dir", "b.bin")).Output()
+	assert.NoError(t, err)
+	assert.Equal(t, strconv.Itoa(len(data)), strings.TrimSpace(string(out)))
+	out, err = exec.Command("cat", filepath.Join(dir, "dir", "b.bin")).Output()
+	assert.NoError(t, err)
+	assert.Equal(t, data, out)
+
+	err = exec.Command("sh", "-c", "echo x > "+filepath.Join(dir, "a.txt")).Run()
+	assert.Error(t, err)
+	err = exec.Command("cat", filepath.Join(dir, "missing")).Run()
+	assert.Error(t, err)
+}
+
+func TestInvalidNode(t *testing.T) {
+	fds, err := unix.Socketpair(unix.AF_UNIX, unix.SOCK_SEQPACKET, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unix.Close(fds[0])
+	defer unix.Close(fds[1])
+	s := &Server{
+		fd:       fds[0],
+		nodes:    []*Node{nil},
+		parents:  []uint64{0},
+		children: make(map[uint64]map[string]uint64),
+		handles:  make(map[uint64]File),
+	}
+	s.addNode(&Node{Children: []*Node{fileNode("a.txt", []byte("hello"))}}, rootID)
+
+	var payload bytes.Buffer
+	_ = binary.Write(&payload, byteOrder, &readIn{Size: 4096})
+	for _, op := range []uint32{opGetattr, opOpen, opReaddir} {
+		s.handle(&inHeader{Opcode: op, Unique: uint64(op), NodeID: 100}, payload.Bytes())
+		b := make([]byte, 4096)
+		n, err := unix.Read(fds[1], b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var oh outHeader
+		err = binary.Read(bytes.NewReader(b[:n]), byteOrder, &oh)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, uint64(op), oh.Unique)
+		assert.Equal(t, -int32(unix.ENOENT), oh.Error, "opcode %d", op)
+	}
+}
@@ -0,0 +1,21 @@
+//go:build !linux
+
+package fuse
+
+// Server serves a file system mounted at a directory.
+type Server struct{}
+
+// Mount is not supported on this platform.
+func Mount(mountpoint string, root *Node) (*Server, error) {
+	return nil, ErrNotSupported
+}
+
+// Serve is not supported on this platform.
+func (s *Server) Serve() error {
+	return ErrNotSupported
+}
+
+// Unmount is not supported on this platform.
+func (s *Server) Unmount() error {
+	return ErrNotSupported
+}
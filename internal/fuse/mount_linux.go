@@ -0,0 +1,107 @@
+package fuse
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+
+	"golang.org/x/sys/unix"
+)
+
+// mount mounts a FUSE file system at dir and returns the file of /dev/fuse for serving it.
+// The mount syscall is used if the process has the privileges, otherwise fusermount helper is used.
+func mount(dir string) (*os.File, error) {
+	f, err := os.OpenFile("/dev/fuse", os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	opts := fmt.Sprintf("fd=%d,rootmode=40000,user_id=%d,group_id=%d,default_permissions", f.Fd(), os.Getuid(), os.Getgid())
+	err = unix.Mount("rain", dir, "fuse.rain", unix.MS_NOSUID|unix.MS_NODEV|unix.MS_RDONLY, opts)
+	if err == nil {
+		return f, nil
+	}
+	f.Close()
+	if err != unix.EPERM {
+		return nil, err
+	}
+	return mountFusermount(dir)
+}
+
+// mountFusermount runs fusermount for mounting dir and receives the file descriptor of /dev/fuse from it over a socket.
+func mountFusermount(dir string) (*os.File, error) {
+	fds, err := unix.Socketpair(unix.AF_UNIX, unix.SOCK_STREAM, 0)
+	if err != nil {
+		return nil, err
+	}
+	local := os.NewFile(uintptr(fds[0]), "fusermount-local")
+	remote := os.NewFile(uintptr(fds[1]), "fusermount-remote")
+	defer local.Close()
+	defer remote.Close()
+
+	bin, err := fusermountPath()
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(bin, "-o", "ro,nosuid,nodev,fsname=rain,subtype=rain,default_permissions", "--", dir)
+	cmd.ExtraFiles = []*os.File{remote}
+	cmd.Env = append(os.Environ(), "_FUSE_COMMFD="+strconv.Itoa(3))
+	cmd.Stderr = os.Stderr
+	err = cmd.Start()
+	if err != nil {
+		return nil, err
+	}
+	remote.Close()
+
+	buf := make([]byte, 4)
+	oob := make([]byte, unix.CmsgSpace(4))
+	_, oobn, _, _, err := unix.Recvmsg(int(local.Fd()), buf, oob, 0)
+	waitErr := cmd.Wait()
+	if err != nil {
+		return nil, err
+	}
+	if waitErr != nil {
+		return nil, fmt.Errorf("fusermount: %w", waitErr)
+	}
+	msgs, err := unix.ParseSocketControlMessage(oob[:oobn])
+	if err != nil {
+		return nil, err
+	}
+	if len(msgs) != 1 {
+		return nil, fmt.Errorf("fusermount: unexpected number of control messages: %d", len(msgs))
+	}
+	rights, err := unix.ParseUnixRights(&msgs[0])
+	if err != nil {
+		return nil, err
+	}
+	if len(rights) != 1 {
+		return nil, fmt.Errorf("fusermount: unexpected number of file descriptors: %d", len(rights))
+	}
+	return os.NewFile(uintptr(rights[0]), "/dev/fuse"), nil
+}
+
+func fusermountPath() (string, error) {
+	for _, name := range []string{"fusermount3", "fusermount"} {
+		p, err := exec.LookPath(name)
+		if err == nil {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("fusermount is not found in PATH")
+}
+
+func unmount(dir string) error {
+	err := unix.Unmount(dir, unix.MNT_DETACH)
+	if err != unix.EPERM {
+		return err
+	}
+	bin, err := fusermountPath()
+	if err != nil {
+		return err
+	}
+	out, err := exec.Command(bin, "-u", "-z", dir).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("fusermount: %s: %w", out, err)
+	}
+	return nil
+}
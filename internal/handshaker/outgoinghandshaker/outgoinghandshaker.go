@@ -9,12 +9,15 @@ import (
 	"github.com/cenkalti/rain/internal/logger"
 	"github.com/cenkalti/rain/internal/mse"
 	"github.com/cenkalti/rain/internal/peersource"
+	"github.com/cenkalti/rain/internal/semaphore"
 )
 
 // OutgoingHandshaker does the BitTorrent handshake on an outgoing connection.
 type OutgoingHandshaker struct {
-	Addr       *net.TCPAddr
-	Source     peersource.Source
+	Addr   *net.TCPAddr
+	Source peersource.Source
+	// URL of the tracker if the address is received from a tracker.
+	Tracker    string
 	Conn       net.Conn
 	PeerID     [20]byte
 	Extensions [8]byte
@@ -42,11 +45,11 @@ func (h *OutgoingHandshaker) Close() {
 }
 
 // Run the handshaker.
-func (h *OutgoingHandshaker) Run(dialTimeout, handshakeTimeout time.Duration, peerID, infoHash [20]byte, resultC chan *OutgoingHandshaker, ourExtensions [8]byte, disableOutgoingEncryption, forceOutgoingEncryption bool) {
+func (h *OutgoingHandshaker) Run(dialTimeout, handshakeTimeout, keepAlive time.Duration, peerID, infoHash [20]byte, resultC chan *OutgoingHandshaker, ourExtensions [8]byte, disableOutgoingEncryption, forceOutgoingEncryption bool, halfOpen *semaphore.Semaphore) {
 	defer close(h.doneC)
-	log := logger.New("peer -> " + h.Addr.String())
+	log := logger.New("peer -> " + h.Addr.String() + " [" + h.Source.String() + "]")
 
-	conn, cipher, peerExtensions, peerID, err := btconn.Dial(h.Addr, dialTimeout, handshakeTimeout, !disableOutgoingEncryption, forceOutgoingEncryption, ourExtensions, infoHash, peerID, h.closeC)
+	conn, cipher, peerExtensions, peerID, err := btconn.Dial(h.Addr, dialTimeout, handshakeTimeout, keepAlive, !disableOutgoingEncryption, forceOutgoingEncryption, ourExtensions, infoHash, peerID, halfOpen, h.closeC)
 	if err != nil {
 		if err == io.EOF {
 			log.Debug("peer has closed the connection: EOF")
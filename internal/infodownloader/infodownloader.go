@@ -1,22 +1,117 @@
 package infodownloader
 
-import "fmt"
+import (
+	"bytes"
+	"fmt"
+)
 
 const blockSize = 16 * 1024
 
-// InfoDownloader downloads all blocks of a piece from a peer.
-type InfoDownloader struct {
-	Peer  Peer
+// Metadata is the info dictionary of a torrent that is being downloaded from multiple peers at the same time.
+// Each block is requested from the peer that has the fewest requests for that block,
+// so peers share the work and blocks of a slow or disconnected peer are requested from other peers.
+type Metadata struct {
 	Bytes []byte
 
-	blocks         []block
-	pending        int // in-flight requests
-	nextBlockIndex uint32
+	blocks    []block
+	remaining int
 }
 
 type block struct {
-	size      uint32
-	requested bool
+	size uint32
+	done bool
+	// Number of peers that the block is requested from.
+	requested int
+	// Peer that the block is received from.
+	peer Peer
+}
+
+// NewMetadata returns a new Metadata for info dictionary of given size.
+func NewMetadata(size uint32) *Metadata {
+	m := &Metadata{
+		Bytes: make([]byte, size),
+	}
+	m.blocks = createBlocks(size)
+	m.remaining = len(m.blocks)
+	return m
+}
+
+// Size of the info dictionary.
+func (m *Metadata) Size() uint32 {
+	return uint32(len(m.Bytes))
+}
+
+// Done returns true if all blocks of the metadata are downloaded.
+func (m *Metadata) Done() bool {
+	return m.remaining == 0
+}
+
+// Peers returns the peers that the downloaded blocks are received from.
+func (m *Metadata) Peers() []Peer {
+	seen := make(map[Peer]struct{})
+	var peers []Peer
+	for _, b := range m.blocks {
+		if b.peer == nil {
+			continue
+		}
+		if _, ok := seen[b.peer]; ok {
+			continue
+		}
+		seen[b.peer] = struct{}{}
+		peers = append(peers, b.peer)
+	}
+	return peers
+}
+
+// Mismatching returns the peers that have sent blocks different from the correct info dictionary in b.
+func (m *Metadata) Mismatching(b []byte) []Peer {
+	if len(b) != len(m.Bytes) {
+		return m.Peers()
+	}
+	seen := make(map[Peer]struct{})
+	var peers []Peer
+	for i, bl := range m.blocks {
+		if bl.peer == nil {
+			continue
+		}
+		if _, ok := seen[bl.peer]; ok {
+			continue
+		}
+		begin := uint32(i) * blockSize
+		end := begin + bl.size
+		if !bytes.Equal(m.Bytes[begin:end], b[begin:end]) {
+			seen[bl.peer] = struct{}{}
+			peers = append(peers, bl.peer)
+		}
+	}
+	return peers
+}
+
+func createBlocks(size uint32) []block {
+	numBlocks := size / blockSize
+	mod := size % blockSize
+	if mod != 0 {
+		numBlocks++
+	}
+	blocks := make([]block, numBlocks)
+	for i := range blocks {
+		blocks[i] = block{
+			size: blockSize,
+		}
+	}
+	if mod != 0 && len(blocks) > 0 {
+		blocks[len(blocks)-1].size = mod
+	}
+	return blocks
+}
+
+// InfoDownloader downloads blocks of the metadata from a single peer.
+type InfoDownloader struct {
+	Peer     Peer
+	Metadata *Metadata
+
+	// Blocks requested from the peer but not received yet.
+	requested map[uint32]struct{}
 }
 
 // Peer of a torrent.
@@ -26,62 +121,87 @@ type Peer interface {
 }
 
 // New return new InfoDownloader for a single Peer.
-func New(pe Peer) *InfoDownloader {
-	d := &InfoDownloader{
-		Peer:  pe,
-		Bytes: make([]byte, pe.MetadataSize()),
+// Size of the metadata must be equal to the size advertised by the peer.
+func New(pe Peer, m *Metadata) *InfoDownloader {
+	return &InfoDownloader{
+		Peer:      pe,
+		Metadata:  m,
+		requested: make(map[uint32]struct{}),
 	}
-	d.blocks = d.createBlocks()
-	return d
 }
 
 // GotBlock must be called when a metadata block is received from the peer.
 func (d *InfoDownloader) GotBlock(index uint32, data []byte) error {
-	if index >= uint32(len(d.blocks)) {
+	if index >= uint32(len(d.Metadata.blocks)) {
 		return fmt.Errorf("peer sent invalid metadata piece index: %q", index)
 	}
-	b := &d.blocks[index]
-	if !b.requested {
+	if _, ok := d.requested[index]; !ok {
 		return fmt.Errorf("peer sent unrequested index for metadata message: %q", index)
 	}
+	b := &d.Metadata.blocks[index]
 	if uint32(len(data)) != b.size {
 		return fmt.Errorf("peer sent invalid size for metadata message: %q", len(data))
 	}
-	d.pending--
+	delete(d.requested, index)
+	b.requested--
+	if b.done {
+		// Same block has been received from another peer.
+		return nil
+	}
 	begin := index * blockSize
 	end := begin + b.size
-	copy(d.Bytes[begin:end], data)
+	copy(d.Metadata.Bytes[begin:end], data)
+	b.done = true
+	b.peer = d.Peer
+	d.Metadata.remaining--
 	return nil
 }
 
-func (d *InfoDownloader) createBlocks() []block {
-	numBlocks := d.Peer.MetadataSize() / blockSize
-	mod := d.Peer.MetadataSize() % blockSize
-	if mod != 0 {
-		numBlocks++
-	}
-	blocks := make([]block, numBlocks)
-	for i := range blocks {
-		blocks[i] = block{
-			size: blockSize,
+// RequestBlocks is called to request remaining blocks of metadata from the peer.
+// Blocks that are not requested from any peer are requested first.
+// After that, blocks that are pending from other peers are requested again.
+func (d *InfoDownloader) RequestBlocks(queueLength int) {
+	for len(d.requested) < queueLength {
+		index, ok := d.nextBlock()
+		if !ok {
+			return
 		}
+		d.Peer.RequestMetadataPiece(index)
+		d.requested[index] = struct{}{}
+		d.Metadata.blocks[index].requested++
 	}
-	if mod != 0 && len(blocks) > 0 {
-		blocks[len(blocks)-1].size = mod
-	}
-	return blocks
 }
 
-// RequestBlocks is called to request remaining blocks of metadata from the peer.
-func (d *InfoDownloader) RequestBlocks(queueLength int) {
-	for ; d.nextBlockIndex < uint32(len(d.blocks)) && d.pending < queueLength; d.nextBlockIndex++ {
-		d.Peer.RequestMetadataPiece(d.nextBlockIndex)
-		d.blocks[d.nextBlockIndex].requested = true
-		d.pending++
+func (d *InfoDownloader) nextBlock() (index uint32, ok bool) {
+	for i, b := range d.Metadata.blocks {
+		if b.done {
+			continue
+		}
+		if _, ok := d.requested[uint32(i)]; ok {
+			continue
+		}
+		if !ok || b.requested < d.Metadata.blocks[index].requested {
+			index, ok = uint32(i), true
+		}
 	}
+	return
+}
+
+// Pending returns the number of requested blocks that are not received yet.
+func (d *InfoDownloader) Pending() int {
+	return len(d.requested)
 }
 
-// Done returns true if all pieces of the metadata is downloaded.
+// Done returns true if all blocks of the metadata are downloaded.
 func (d *InfoDownloader) Done() bool {
-	return d.nextBlockIndex == uint32(len(d.blocks)) && d.pending == 0
+	return d.Metadata.Done()
+}
+
+// Close must be called when the peer is disconnected or when it is not used for downloading anymore.
+// Blocks pending from the peer can be requested from other peers after close.
+func (d *InfoDownloader) Close() {
+	for index := range d.requested {
+		d.Metadata.blocks[index].requested--
+	}
+	d.requested = nil
 }
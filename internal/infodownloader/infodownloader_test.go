@@ -17,45 +17,113 @@ func (p *TestPeer) RequestMetadataPiece(index uint32) {
 
 func TestInfoDownloader(t *testing.T) {
 	p := &TestPeer{}
-	d := New(p)
-	assert.Equal(t, 11, len(d.blocks))
+	m := NewMetadata(p.MetadataSize())
+	d := New(p, m)
+	assert.Equal(t, 11, len(m.blocks))
 	assert.False(t, d.Done())
 
 	d.RequestBlocks(4)
-	assert.Equal(t, 4, d.pending)
+	assert.Equal(t, 4, d.Pending())
 	assert.False(t, d.Done())
 	assert.Equal(t, []uint32{0, 1, 2, 3}, p.requested)
 
 	d.RequestBlocks(4)
-	assert.Equal(t, 4, d.pending)
+	assert.Equal(t, 4, d.Pending())
 	assert.Equal(t, []uint32{0, 1, 2, 3}, p.requested)
 
 	assert.Nil(t, d.GotBlock(0, make([]byte, blockSize)))
-	assert.Equal(t, 3, d.pending)
+	assert.Equal(t, 3, d.Pending())
 	d.RequestBlocks(4)
-	assert.Equal(t, 4, d.pending)
+	assert.Equal(t, 4, d.Pending())
 	assert.Equal(t, []uint32{0, 1, 2, 3, 4}, p.requested)
 
 	d.GotBlock(1, make([]byte, blockSize))
 	d.GotBlock(2, make([]byte, blockSize))
 	d.GotBlock(3, make([]byte, blockSize))
 	d.GotBlock(4, make([]byte, blockSize))
-	assert.Equal(t, 0, d.pending)
+	assert.Equal(t, 0, d.Pending())
 	d.RequestBlocks(4)
-	assert.Equal(t, 4, d.pending)
+	assert.Equal(t, 4, d.Pending())
 	assert.Equal(t, []uint32{0, 1, 2, 3, 4, 5, 6, 7, 8}, p.requested)
 
-	println("---")
 	d.GotBlock(5, make([]byte, blockSize))
 	d.GotBlock(6, make([]byte, blockSize))
 	d.GotBlock(7, make([]byte, blockSize))
 	d.GotBlock(8, make([]byte, blockSize))
-	assert.Equal(t, 0, d.pending)
+	assert.Equal(t, 0, d.Pending())
 	d.RequestBlocks(4)
-	assert.Equal(t, 2, d.pending)
+	assert.Equal(t, 2, d.Pending())
 	assert.False(t, d.Done())
 
 	d.GotBlock(9, make([]byte, blockSize))
 	d.GotBlock(10, make([]byte, 42))
 	assert.True(t, d.Done())
+	assert.Equal(t, []Peer{p}, m.Peers())
+}
+
+func TestInfoDownloaderMultiplePeers(t *testing.T) {
+	p1 := &TestPeer{}
+	p2 := &TestPeer{}
+	m := NewMetadata(p1.MetadataSize())
+	d1 := New(p1, m)
+	d2 := New(p2, m)
+
+	// Peers share the blocks.
+	d1.RequestBlocks(4)
+	d2.RequestBlocks(4)
+	assert.Equal(t, []uint32{0, 1, 2, 3}, p1.requested)
+	assert.Equal(t, []uint32{4, 5, 6, 7}, p2.requested)
+
+	// Unrequested blocks are preferred, then blocks pending from other peers.
+	d1.RequestBlocks(8)
+	assert.Equal(t, []uint32{0, 1, 2, 3, 8, 9, 10, 4}, p1.requested)
+
+	// Blocks of a closed peer are requested from other peers.
+	d2.GotBlock(4, make([]byte, blockSize))
+	d2.Close()
+	p3 := &TestPeer{}
+	d3 := New(p3, m)
+	d3.RequestBlocks(3)
+	assert.Equal(t, []uint32{5, 6, 7}, p3.requested)
+
+	// Same block received from multiple peers is accepted once.
+	assert.Nil(t, d1.GotBlock(4, make([]byte, blockSize)))
+	assert.Equal(t, []Peer{p2}, m.Peers())
+	assert.NotNil(t, d1.GotBlock(4, make([]byte, blockSize)))
+
+	for _, i := range []uint32{0, 1, 2, 3, 8, 9} {
+		assert.Nil(t, d1.GotBlock(i, make([]byte, blockSize)))
+	}
+	assert.Nil(t, d1.GotBlock(10, make([]byte, 42)))
+	for _, i := range []uint32{5, 6, 7} {
+		assert.False(t, d3.Done())
+		assert.Nil(t, d3.GotBlock(i, make([]byte, blockSize)))
+	}
+	assert.True(t, d1.Done())
+	assert.True(t, d3.Done())
+	assert.Len(t, m.Peers(), 3)
+}
+
+func TestMetadataMismatching(t *testing.T) {
+	p1 := &TestPeer{}
+	p2 := &TestPeer{}
+	m := NewMetadata(p1.MetadataSize())
+	d1 := New(p1, m)
+	d2 := New(p2, m)
+	d1.RequestBlocks(6)
+	d2.RequestBlocks(5)
+
+	good := make([]byte, p1.MetadataSize())
+	for i := uint32(0); i < 6; i++ {
+		assert.Nil(t, d1.GotBlock(i, good[i*blockSize:(i+1)*blockSize]))
+	}
+	bad := make([]byte, blockSize)
+	bad[0] = 1
+	assert.Nil(t, d2.GotBlock(6, bad))
+	for i := uint32(7); i < 10; i++ {
+		assert.Nil(t, d2.GotBlock(i, good[i*blockSize:(i+1)*blockSize]))
+	}
+	assert.Nil(t, d2.GotBlock(10, good[10*blockSize:]))
+	assert.True(t, m.Done())
+	assert.Equal(t, []Peer{p2}, m.Mismatching(good))
 }
@@ -0,0 +1,67 @@
+// Package lockfile provides advisory locks for detecting other processes that use the same files.
+package lockfile
+
+import (
+	"errors"
+	"os"
+)
+
+// ErrLocked is returned from Lock when the file is locked by another process.
+var ErrLocked = errors.New("file is locked by another process")
+
+// Lockfile is an exclusive advisory lock held on a file.
+type Lockfile struct {
+	f *os.File
+}
+
+// Lock creates the file at path if it does not exist and locks it.
+// If the file is already locked, ErrLocked is returned immediately without waiting.
+func Lock(path string, perm os.FileMode) (*Lockfile, error) {
+	for {
+		f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, perm)
+		if err != nil {
+			return nil, err
+		}
+		err = lock(f)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		// The file may have been removed by the previous owner after we have opened it.
+		// In that case, lock the new file at path.
+		ok, err := samePath(f, path)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		if ok {
+			return &Lockfile{f: f}, nil
+		}
+		f.Close()
+	}
+}
+
+func samePath(f *os.File, path string) (bool, error) {
+	fi1, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+	fi2, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return os.SameFile(fi1, fi2), nil
+}
+
+// Unlock removes the file and releases the lock.
+func (l *Lockfile) Unlock() error {
+	err := os.Remove(l.f.Name())
+	err2 := l.f.Close()
+	if err != nil {
+		return err
+	}
+	return err2
+}
@@ -0,0 +1,27 @@
+package lockfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lock")
+	l, err := Lock(path, 0o640)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = Lock(path, 0o640)
+	assert.Equal(t, ErrLocked, err)
+
+	assert.Nil(t, l.Unlock())
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+
+	l, err = Lock(path, 0o640)
+	assert.Nil(t, err)
+	assert.Nil(t, l.Unlock())
+}
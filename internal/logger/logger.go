@@ -27,6 +27,11 @@ func SetDebug() {
 	handler.SetLevel(log.DEBUG)
 }
 
+// SetInfo sets the logging level to INFO on the global handler.
+func SetInfo() {
+	handler.SetLevel(log.INFO)
+}
+
 // Disable all logging by setting a handler that discards all messages.
 func Disable() {
 	SetHandler(log.NewWriterHandler(io.Discard))
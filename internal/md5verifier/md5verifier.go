@@ -0,0 +1,73 @@
+// Package md5verifier checks MD5 sums of completed files in a torrent.
+package md5verifier
+
+import (
+	"bytes"
+	"crypto/md5"
+	"io"
+
+	"github.com/cenkalti/rain/internal/storage"
+)
+
+// MD5Verifier compares the MD5 sum of a file on the disk with the sum in torrent metadata.
+type MD5Verifier struct {
+	// Index of the file in torrent.
+	FileIndex int
+	// Name of the file.
+	Name string
+	// True if sum of the file matches.
+	OK    bool
+	Error error
+
+	closeC chan struct{}
+	doneC  chan struct{}
+}
+
+// New returns a new MD5Verifier for the file at index.
+func New(fileIndex int, name string) *MD5Verifier {
+	return &MD5Verifier{
+		FileIndex: fileIndex,
+		Name:      name,
+		closeC:    make(chan struct{}),
+		doneC:     make(chan struct{}),
+	}
+}
+
+// Close the MD5Verifier.
+func (v *MD5Verifier) Close() {
+	close(v.closeC)
+	<-v.doneC
+}
+
+// Run the MD5Verifier and compare the sum of first length bytes in f with sum.
+func (v *MD5Verifier) Run(f storage.File, length int64, sum []byte, resultC chan *MD5Verifier) {
+	defer close(v.doneC)
+
+	defer func() {
+		select {
+		case resultC <- v:
+		case <-v.closeC:
+		}
+	}()
+
+	h := md5.New()
+	r := io.NewSectionReader(f, 0, length)
+	buf := make([]byte, 32*1024)
+	for {
+		select {
+		case <-v.closeC:
+			return
+		default:
+		}
+		n, err := r.Read(buf)
+		_, _ = h.Write(buf[:n])
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			v.Error = err
+			return
+		}
+	}
+	v.OK = bytes.Equal(h.Sum(nil), sum)
+}
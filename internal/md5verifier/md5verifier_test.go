@@ -0,0 +1,36 @@
+package md5verifier
+
+import (
+	"crypto/md5"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMD5Verifier(t *testing.T) {
+	data := []byte("hello world")
+	f, err := os.Create(filepath.Join(t.TempDir(), "file"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err = f.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	sum := md5.Sum(data)
+	resultC := make(chan *MD5Verifier, 1)
+
+	v := New(0, "file")
+	v.Run(f, int64(len(data)), sum[:], resultC)
+	<-resultC
+	assert.Nil(t, v.Error)
+	assert.True(t, v.OK)
+
+	v = New(0, "file")
+	v.Run(f, int64(len(data))-1, sum[:], resultC)
+	<-resultC
+	assert.Nil(t, v.Error)
+	assert.False(t, v.OK)
+}
@@ -1,22 +1,213 @@
 package peer
 
 import (
+	"strconv"
 	"strings"
 )
 
+// Client codes used in Azureus-style peer IDs.
+var azureusClients = map[string]string{
+	"7T": "aTorrent",
+	"AG": "Ares",
+	"AR": "Arctic",
+	"AT": "Artemis",
+	"AX": "BitPump",
+	"AZ": "Vuze",
+	"BB": "BitBuddy",
+	"BC": "BitComet",
+	"BE": "BitTorrent SDK",
+	"BF": "Bitflu",
+	"BG": "BTG",
+	"BI": "BiglyBT",
+	"BL": "BitCometLite",
+	"BP": "BitTorrent Pro",
+	"BR": "BitRocket",
+	"BT": "BBtor",
+	"BW": "BitWombat",
+	"BX": "BittorrentX",
+	"CD": "Enhanced CTorrent",
+	"CT": "CTorrent",
+	"DE": "Deluge",
+	"DP": "Propagate Data Client",
+	"EB": "EBit",
+	"ES": "Electric Sheep",
+	"FC": "FileCroc",
+	"FD": "Free Download Manager",
+	"FT": "FoxTorrent",
+	"FX": "Freebox BitTorrent",
+	"GS": "GSTorrent",
+	"HK": "Hekate",
+	"HL": "Halite",
+	"HM": "hMule",
+	"HN": "Hydranode",
+	"IL": "iLivid",
+	"JS": "Justseed.it",
+	"JT": "JavaTorrent",
+	"KG": "KGet",
+	"KT": "KTorrent",
+	"LC": "LeechCraft",
+	"LH": "LH-ABC",
+	"LP": "Lphant",
+	"LT": "libtorrent",
+	"LW": "LimeWire",
+	"MK": "Meerkat",
+	"MO": "MonoTorrent",
+	"MP": "MooPolice",
+	"MR": "Miro",
+	"MT": "MoonlightTorrent",
+	"NX": "Net Transport",
+	"OS": "OneSwarm",
+	"OT": "OmegaTorrent",
+	"PB": "Protocol::BitTorrent",
+	"PD": "Pando",
+	"PI": "PicoTorrent",
+	"PT": "PHPTracker",
+	"QD": "QQDownload",
+	"QT": "Qt 4 Torrent",
+	"RT": "Retriever",
+	"RZ": "RezTorrent",
+	"SB": "Swiftbit",
+	"SD": "Thunder",
+	"SM": "SoMud",
+	"SP": "BitSpirit",
+	"SS": "SwarmScope",
+	"ST": "SymTorrent",
+	"SZ": "Shareaza",
+	"TB": "Torch",
+	"TE": "terasaur Seed Bank",
+	"TL": "Tribler",
+	"TN": "TorrentDotNET",
+	"TR": "Transmission",
+	"TS": "Torrentstorm",
+	"TT": "TuoTu",
+	"UL": "uLeecher!",
+	"UM": "uTorrent Mac",
+	"UT": "uTorrent",
+	"UW": "uTorrent Web",
+	"VG": "Vagaa",
+	"WD": "WebTorrent Desktop",
+	"WT": "BitLet",
+	"WW": "WebTorrent",
+	"WY": "FireTorrent",
+	"XF": "Xfplay",
+	"XL": "Xunlei",
+	"XS": "XSwifter",
+	"XT": "XanTorrent",
+	"XX": "Xtorrent",
+	"ZT": "ZipTorrent",
+	"lt": "libTorrent (rakshasa)",
+	"pX": "pHoeniX",
+	"qB": "qBittorrent",
+	"st": "SharkTorrent",
+}
+
+// Client codes used in Shadow-style peer IDs.
+var shadowClients = map[byte]string{
+	'A': "ABC",
+	'O': "Osprey Permaseed",
+	'Q': "BTQueue",
+	'R': "Tribler",
+	'S': "Shadow",
+	'T': "BitTornado",
+	'U': "UPnP NAT Bit Torrent",
+}
+
+const shadowVersionChars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz.-"
+
+// clientID returns the human readable client name and version decoded from peer ID.
+// If the ID cannot be decoded, raw ID is returned.
 func clientID(id string) string {
-	// ID follows BEP 20 convention
-	if id[7] == '-' {
-		return id[:8]
+	if len(id) < 8 {
+		return id
 	}
 
 	// Rain convention
 	if strings.HasPrefix(id, "-RN") {
 		i := strings.IndexRune(id[1:], '-')
 		if i != -1 {
-			return id[:i+2]
+			return "Rain " + id[3:i+1]
+		}
+	}
+
+	// Azureus-style: '-', two characters for client id, four characters for version number, '-'.
+	if id[0] == '-' && id[7] == '-' {
+		if name, ok := azureusClients[id[1:3]]; ok {
+			return name + " " + azureusVersion(id[3:7])
+		}
+		return id[:8]
+	}
+
+	// Mainline-style: 'M', version number separated with dashes, padded with dashes. e.g. "M4-3-6--", "M10-1-0-"
+	if id[0] == 'M' && id[7] == '-' {
+		if ver, ok := mainlineVersion(id[1:8]); ok {
+			return "Mainline " + ver
+		}
+	}
+
+	// Shadow-style: one character for client id, three characters for version number, padded with dashes.
+	if name, ok := shadowClients[id[0]]; ok && id[4] == '-' && id[5] == '-' {
+		if ver, ok := shadowVersion(id[1:4]); ok {
+			return name + " " + ver
 		}
 	}
 
 	return id
 }
+
+func azureusVersion(s string) string {
+	// Some clients (e.g. uTorrent) use the last character for the build type (e.g. 'B' for beta) instead of a digit.
+	if c := s[len(s)-1]; (c < '0' || c > '9') && isDigits(s[:len(s)-1]) {
+		s = s[:len(s)-1]
+	}
+	parts := make([]string, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		n := strings.IndexByte(shadowVersionChars, s[i])
+		if n < 0 || n > 61 {
+			return s
+		}
+		parts = append(parts, strconv.Itoa(n))
+	}
+	// Trailing zeros are not significant. e.g. "4250" -> "4.2.5"
+	for len(parts) > 2 && parts[len(parts)-1] == "0" {
+		parts = parts[:len(parts)-1]
+	}
+	return strings.Join(parts, ".")
+}
+
+func mainlineVersion(s string) (string, bool) {
+	parts := strings.Split(strings.TrimRight(s, "-"), "-")
+	if len(parts) != 3 {
+		return "", false
+	}
+	for _, p := range parts {
+		if p == "" || !isDigits(p) {
+			return "", false
+		}
+	}
+	return strings.Join(parts, "."), true
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func shadowVersion(s string) (string, bool) {
+	s = strings.TrimRight(s, "-")
+	if s == "" {
+		return "", false
+	}
+	parts := make([]string, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		n := strings.IndexByte(shadowVersionChars, s[i])
+		if n < 0 {
+			return "", false
+		}
+		parts = append(parts, strconv.Itoa(n))
+	}
+	return strings.Join(parts, "."), true
+}
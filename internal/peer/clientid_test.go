@@ -0,0 +1,31 @@
+package peer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientID(t *testing.T) {
+	cases := []struct {
+		id     string
+		client string
+	}{
+		{"-qB4250-abcdefghijkl", "qBittorrent 4.2.5"},
+		{"-TR2940-abcdefghijkl", "Transmission 2.9.4"},
+		{"-UT355W-abcdefghijkl", "uTorrent 3.5.5"},
+		{"-UT2210-abcdefghijkl", "uTorrent 2.2.1"},
+		{"-ZZ1000-abcdefghijkl", "-ZZ1000-"},
+		{"-RN1.13.0-abcdefghij", "Rain 1.13.0"},
+		{"M4-3-6--abcdefghijkl", "Mainline 4.3.6"},
+		{"M4-20-8-abcdefghijkl", "Mainline 4.20.8"},
+		{"M10-1-0-abcdefghijkl", "Mainline 10.1.0"},
+		{"M4-3----abcdefghijkl", "M4-3----abcdefghijkl"},
+		{"S58B-----abcdefghijk", "Shadow 5.8.11"},
+		{"T03I--00000000000000", "BitTornado 0.3.18"},
+		{"abcdefghijklmnopqrst", "abcdefghijklmnopqrst"},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.client, clientID(c.id), c.id)
+	}
+}
@@ -18,8 +18,8 @@ import (
 	"github.com/cenkalti/rain/internal/pexlist"
 	"github.com/cenkalti/rain/internal/piece"
 	"github.com/cenkalti/rain/internal/sliceset"
+	"github.com/cenkalti/rain/internal/speedlimit"
 	"github.com/cenkalti/rain/internal/stringutil"
-	"github.com/juju/ratelimit"
 	"github.com/rcrowley/go-metrics"
 )
 
@@ -87,7 +87,7 @@ type PieceMessage struct {
 }
 
 // New wraps the net.Conn and returns a new Peer.
-func New(conn net.Conn, source peersource.Source, id [20]byte, extensions [8]byte, cipher mse.CryptoMethod, pieceReadTimeout, snubTimeout time.Duration, maxRequestsIn int, br, bw *ratelimit.Bucket) *Peer {
+func New(conn net.Conn, source peersource.Source, id [20]byte, extensions [8]byte, cipher mse.CryptoMethod, pieceReadTimeout, readTimeout, keepAliveInterval, snubTimeout time.Duration, maxRequestsIn int, br, bw *speedlimit.Limiter) *Peer {
 	bf, _ := bitfield.NewBytes(extensions[:], 64)
 	fastEnabled := bf.Test(61)
 	extensionsEnabled := bf.Test(43)
@@ -96,7 +96,7 @@ func New(conn net.Conn, source peersource.Source, id [20]byte, extensions [8]byt
 	t := time.NewTimer(math.MaxInt64)
 	t.Stop()
 	return &Peer{
-		Conn:              peerconn.New(conn, newPeerLogger(source, conn), pieceReadTimeout, maxRequestsIn, fastEnabled, br, bw),
+		Conn:              peerconn.New(conn, newPeerLogger(source, conn), pieceReadTimeout, readTimeout, keepAliveInterval, maxRequestsIn, fastEnabled, br, bw),
 		Source:            source,
 		ConnectedAt:       time.Now(),
 		ID:                id,
@@ -119,7 +119,7 @@ func newPeerLogger(src peersource.Source, conn net.Conn) logger.Logger {
 	if src == peersource.Incoming {
 		return logger.New("peer <- " + conn.RemoteAddr().String())
 	}
-	return logger.New("peer -> " + conn.RemoteAddr().String())
+	return logger.New("peer -> " + conn.RemoteAddr().String() + " [" + src.String() + "]")
 }
 
 // Close the peer connection.
@@ -219,6 +219,16 @@ func (p *Peer) UploadSpeed() int {
 	return int(p.uploadSpeed.Rate1())
 }
 
+// BytesDownloaded returns the number of piece bytes received from the Peer since the connection is established.
+func (p *Peer) BytesDownloaded() int64 {
+	return p.downloadSpeed.Count()
+}
+
+// BytesUploaded returns the number of piece bytes sent to the Peer since the connection is established.
+func (p *Peer) BytesUploaded() int64 {
+	return p.uploadSpeed.Count()
+}
+
 // Choke the connected Peer by sending a "choke" protocol message.
 func (p *Peer) Choke() {
 	p.ClientChoking = true
@@ -251,6 +261,15 @@ func (p *Peer) SetOptimistic(value bool) {
 	p.OptimisticUnchoked = value
 }
 
+// UploadOnly returns true if the remote Peer is not going to download any pieces from us.
+// This is the case when the Peer has all pieces or it has announced upload_only in the extension handshake.
+func (p *Peer) UploadOnly() bool {
+	if p.ExtensionHandshake != nil && p.ExtensionHandshake.UploadOnly != 0 {
+		return true
+	}
+	return p.Bitfield != nil && p.Bitfield.All()
+}
+
 // MetadataSize returns the torrent metadata size that is received from the Peer with an extension handshake message.
 func (p *Peer) MetadataSize() uint32 {
 	return uint32(p.ExtensionHandshake.MetadataSize)
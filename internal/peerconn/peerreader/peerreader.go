@@ -13,14 +13,12 @@ import (
 	"github.com/cenkalti/rain/internal/logger"
 	"github.com/cenkalti/rain/internal/peerprotocol"
 	"github.com/cenkalti/rain/internal/piece"
-	"github.com/juju/ratelimit"
+	"github.com/cenkalti/rain/internal/speedlimit"
 )
 
 const (
 	// MaxBlockSize allowed in "request" messages.
 	MaxBlockSize = 16 * 1024
-	// time to wait for a message. peer must send keep-alive messages to keep connection alive.
-	readTimeout = 2 * time.Minute
 	// length + msgid + requestmsg
 	readBufferSize = 4 + 1 + 12
 )
@@ -33,19 +31,22 @@ type PeerReader struct {
 	r            io.Reader
 	log          logger.Logger
 	pieceTimeout time.Duration
-	bucket       *ratelimit.Bucket
+	readTimeout  time.Duration
+	bucket       *speedlimit.Limiter
 	messages     chan any
 	stopC        chan struct{}
 	doneC        chan struct{}
 }
 
 // New returns a new PeerReader by wrapping a net.Conn.
-func New(conn net.Conn, l logger.Logger, pieceTimeout time.Duration, b *ratelimit.Bucket) *PeerReader {
+// The connection is closed if no message is received in readTimeout. Peer must send keep-alive messages to keep connection alive.
+func New(conn net.Conn, l logger.Logger, pieceTimeout, readTimeout time.Duration, b *speedlimit.Limiter) *PeerReader {
 	return &PeerReader{
 		conn:         conn,
 		r:            bufio.NewReaderSize(conn, readBufferSize),
 		log:          l,
 		pieceTimeout: pieceTimeout,
+		readTimeout:  readTimeout,
 		bucket:       b,
 		messages:     make(chan any),
 		stopC:        make(chan struct{}),
@@ -82,6 +83,9 @@ func (p *PeerReader) Run() {
 			return
 		} else if err == errStoppedWhileWaitingBucket {
 			return
+		} else if ne, ok := err.(net.Error); ok && ne.Timeout() {
+			p.log.Debugln("peer has been silent for too long, closing connection:", err)
+			return
 		} else if _, ok := err.(*net.OpError); ok {
 			return
 		}
@@ -97,7 +101,7 @@ func (p *PeerReader) Run() {
 	}()
 
 	for {
-		err = p.conn.SetReadDeadline(time.Now().Add(readTimeout))
+		err = p.conn.SetReadDeadline(time.Now().Add(p.readTimeout))
 		if err != nil {
 			return
 		}
@@ -267,8 +271,7 @@ func (p *PeerReader) readPiece(length uint32) (buf bufferpool.Buffer, err error)
 
 	var n, m int
 	for {
-		if p.bucket != nil {
-			d := p.bucket.Take(int64(length))
+		if d := p.bucket.Take(int64(length)); d > 0 {
 			select {
 			case <-time.After(d):
 			case <-p.stopC:
@@ -5,3 +5,9 @@ package peerwriter
 type BlockUploaded struct {
 	Length uint32
 }
+
+// PieceReadError is used to signal the Torrent when the data of a piece cannot be read for sending to remote peer.
+type PieceReadError struct {
+	Index uint32
+	Error error
+}
@@ -11,11 +11,9 @@ import (
 	"github.com/cenkalti/rain/internal/logger"
 	"github.com/cenkalti/rain/internal/peerconn/peerreader"
 	"github.com/cenkalti/rain/internal/peerprotocol"
-	"github.com/juju/ratelimit"
+	"github.com/cenkalti/rain/internal/speedlimit"
 )
 
-const keepAlivePeriod = 2 * time.Minute
-
 // PeerWriter is responsible for writing BitTorrent protocol messages to the peer connection.
 type PeerWriter struct {
 	conn                  net.Conn
@@ -23,25 +21,28 @@ type PeerWriter struct {
 	cancelC               chan peerprotocol.CancelMessage
 	writeQueue            *list.List
 	maxQueuedRequests     int
+	keepAliveInterval     time.Duration
 	fastEnabled           bool
 	currentQueuedRequests int
 	writeC                chan peerprotocol.Message
 	messages              chan any
 	servedRequests        map[peerprotocol.RequestMessage]struct{}
-	bucket                *ratelimit.Bucket
+	bucket                *speedlimit.Limiter
 	log                   logger.Logger
 	stopC                 chan struct{}
 	doneC                 chan struct{}
 }
 
 // New returns a new PeerWriter by wrapping a net.Conn.
-func New(conn net.Conn, l logger.Logger, maxQueuedRequests int, fastEnabled bool, b *ratelimit.Bucket) *PeerWriter {
+// A keep-alive message is sent if no other message is written in keepAliveInterval.
+func New(conn net.Conn, l logger.Logger, maxQueuedRequests int, keepAliveInterval time.Duration, fastEnabled bool, b *speedlimit.Limiter) *PeerWriter {
 	return &PeerWriter{
 		conn:              conn,
 		queueC:            make(chan peerprotocol.Message),
 		cancelC:           make(chan peerprotocol.CancelMessage),
 		writeQueue:        list.New(),
 		maxQueuedRequests: maxQueuedRequests,
+		keepAliveInterval: keepAliveInterval,
 		fastEnabled:       fastEnabled,
 		writeC:            make(chan peerprotocol.Message),
 		messages:          make(chan any),
@@ -183,8 +184,24 @@ func (p *PeerWriter) messageWriter() {
 		return
 	}
 
-	keepAliveTicker := time.NewTicker(keepAlivePeriod / 2)
-	defer keepAliveTicker.Stop()
+	// Keep-alive messages are disabled if the interval is not positive.
+	keepAliveTimer := time.NewTimer(p.keepAliveInterval)
+	defer keepAliveTimer.Stop()
+	if p.keepAliveInterval <= 0 {
+		keepAliveTimer.Stop()
+	}
+	resetKeepAliveTimer := func() {
+		if p.keepAliveInterval <= 0 {
+			return
+		}
+		if !keepAliveTimer.Stop() {
+			select {
+			case <-keepAliveTimer.C:
+			default:
+			}
+		}
+		keepAliveTimer.Reset(p.keepAliveInterval)
+	}
 
 	// Use a fixed-size array for slice storage.
 	// Length is calculated for a piece message at max block size.
@@ -225,6 +242,12 @@ func (p *PeerWriter) messageWriter() {
 				default:
 				}
 				p.log.Errorf("cannot serialize message [%v]: %s", msg.ID(), err.Error())
+				if pi, ok := msg.(Piece); ok {
+					select {
+					case p.messages <- PieceReadError{Index: pi.Index, Error: err}:
+					case <-p.stopC:
+					}
+				}
 				return
 			}
 
@@ -233,12 +256,13 @@ func (p *PeerWriter) messageWriter() {
 			// Put message ID
 			buf.Bytes()[4] = uint8(msg.ID())
 
-			if _, ok := msg.(Piece); ok && p.bucket != nil {
-				d := p.bucket.Take(int64(buf.Len()))
-				select {
-				case <-time.After(d):
-				case <-p.stopC:
-					return
+			if _, ok := msg.(Piece); ok {
+				if d := p.bucket.Take(int64(buf.Len())); d > 0 {
+					select {
+					case <-time.After(d):
+					case <-p.stopC:
+						return
+					}
 				}
 			}
 
@@ -254,7 +278,8 @@ func (p *PeerWriter) messageWriter() {
 				p.log.Errorf("cannot write message [%v]: %s", msg.ID(), err.Error())
 				return
 			}
-		case <-keepAliveTicker.C:
+			resetKeepAliveTimer()
+		case <-keepAliveTimer.C:
 			_, err := p.conn.Write([]byte{0, 0, 0, 0})
 			if _, ok := err.(*net.OpError); ok {
 				p.log.Debugf("cannot write keepalive message: %s", err.Error())
@@ -264,6 +289,7 @@ func (p *PeerWriter) messageWriter() {
 				p.log.Errorf("cannot write keepalive message: %s", err.Error())
 				return
 			}
+			keepAliveTimer.Reset(p.keepAliveInterval)
 		case <-p.stopC:
 			return
 		}
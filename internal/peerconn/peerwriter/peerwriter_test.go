@@ -0,0 +1,26 @@
+package peerwriter
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/rain/internal/logger"
+	"github.com/cenkalti/rain/internal/speedlimit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeepAlive(t *testing.T) {
+	c1, c2 := net.Pipe()
+	defer c2.Close()
+	w := New(c1, logger.New("test"), 1, 10*time.Millisecond, false, speedlimit.New(0))
+	go w.Run()
+	defer w.Stop()
+
+	buf := make([]byte, 4)
+	assert.NoError(t, c2.SetReadDeadline(time.Now().Add(time.Second)))
+	_, err := io.ReadFull(c2, buf)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0, 0, 0, 0}, buf)
+}
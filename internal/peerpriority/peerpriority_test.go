@@ -25,3 +25,15 @@ func TestPeerPriority(t *testing.T) {
 func newAddr(ip string) *net.TCPAddr {
 	return &net.TCPAddr{IP: net.ParseIP(ip)}
 }
+
+func TestPeerPriorityIPv6(t *testing.T) {
+	a := newAddr("2001:db8:1::1")
+	b := newAddr("2001:db9::2")
+	assert.Equal(t, Calculate(a, b), Calculate(b, a))
+	// Addresses in different /32 networks are masked beyond the first 32 bits.
+	assert.Equal(t, Calculate(a, b), Calculate(newAddr("2001:db8:3::1"), b))
+	// Same /40 network must not panic and must give a different result than the masked case.
+	assert.NotEqual(t, Calculate(a, b), Calculate(a, newAddr("2001:db8:1::2")))
+	// Mixed address families.
+	assert.NotPanics(t, func() { Calculate(a, newAddr("1.2.3.4")) })
+}
@@ -3,7 +3,7 @@ package peerprotocol
 import (
 	"bytes"
 	"encoding/binary"
-	"fmt"
+	"errors"
 	"io"
 	"net"
 
@@ -17,13 +17,27 @@ const (
 	ExtensionIDMetadata
 	// ExtensionIDPEX is ID for PEX extension messages.
 	ExtensionIDPEX
+	// ExtensionIDDontHave is ID for lt_donthave extension messages.
+	ExtensionIDDontHave
+	// ExtensionIDTEX is ID for lt_tex extension messages.
+	ExtensionIDTEX
 )
 
+var errInvalidDontHave = errors.New("invalid lt_donthave message")
+
+// ExtensionIDCustomFirst is the first ID that can be assigned to extensions that are not parsed by this package.
+// Messages with IDs that are not known by this package are returned as ExtensionRawMessage.
+const ExtensionIDCustomFirst = 128
+
 const (
 	// ExtensionKeyMetadata is the key for the metadata extension.
 	ExtensionKeyMetadata = "ut_metadata"
 	// ExtensionKeyPEX is the key for the PEX extension.
 	ExtensionKeyPEX = "ut_pex"
+	// ExtensionKeyDontHave is the key for the lt_donthave extension.
+	ExtensionKeyDontHave = "lt_donthave"
+	// ExtensionKeyTEX is the key for the tracker exchange extension.
+	ExtensionKeyTEX = "lt_tex"
 )
 
 const (
@@ -56,6 +70,18 @@ func (m ExtensionMessage) WriteTo(w io.Writer) (n int64, err error) {
 	if err != nil {
 		return
 	}
+	if dh, ok := m.Payload.(ExtensionDontHaveMessage); ok {
+		err = binary.Write(w, binary.BigEndian, dh.Index)
+		if err == nil {
+			n += 4
+		}
+		return
+	}
+	if raw, ok := m.Payload.(ExtensionRawMessage); ok {
+		nn, err = w.Write(raw.Data)
+		n += int64(nn)
+		return
+	}
 	wc := newWriterCounter(w)
 	err = bencode.NewEncoder(wc).Encode(m.Payload)
 	n += wc.Count()
@@ -100,8 +126,19 @@ func (m *ExtensionMessage) UnmarshalBinary(data []byte) error {
 		var extMsg ExtensionPEXMessage
 		err = dec.Decode(&extMsg)
 		m.Payload = extMsg
+	case ExtensionIDDontHave:
+		var extMsg ExtensionDontHaveMessage
+		if len(payload) != 4 {
+			return errInvalidDontHave
+		}
+		extMsg.Index = binary.BigEndian.Uint32(payload)
+		m.Payload = extMsg
+	case ExtensionIDTEX:
+		var extMsg ExtensionTEXMessage
+		err = dec.Decode(&extMsg)
+		m.Payload = extMsg
 	default:
-		return fmt.Errorf("peer sent invalid extension message id: %d", m.ExtendedMessageID)
+		m.Payload = ExtensionRawMessage{ExtendedMessageID: m.ExtendedMessageID, Data: payload}
 	}
 	return err
 }
@@ -113,6 +150,7 @@ type ExtensionHandshakeMessage struct {
 	YourIP       string           `bencode:"yourip,omitempty"`
 	MetadataSize int              `bencode:"metadata_size,omitempty"`
 	RequestQueue int              `bencode:"reqq"`
+	UploadOnly   int              `bencode:"upload_only,omitempty"`
 }
 
 // NewExtensionHandshake returns a new ExtensionHandshakeMessage by filling the struct with given values.
@@ -121,6 +159,8 @@ func NewExtensionHandshake(metadataSize uint32, version string, yourip net.IP, r
 		M: map[string]uint8{
 			ExtensionKeyMetadata: ExtensionIDMetadata,
 			ExtensionKeyPEX:      ExtensionIDPEX,
+			ExtensionKeyDontHave: ExtensionIDDontHave,
+			ExtensionKeyTEX:      ExtensionIDTEX,
 		},
 		V:            version,
 		YourIP:       string(truncateIP(yourip)),
@@ -137,12 +177,30 @@ type ExtensionMetadataMessage struct {
 	Data      []byte `bencode:"-"`
 }
 
+// ExtensionDontHaveMessage is the message for the lt_donthave extension.
+// It is sent when a piece that is announced before is no longer available.
+type ExtensionDontHaveMessage struct {
+	Index uint32
+}
+
+// ExtensionRawMessage is a message of an extension that is not parsed by this package.
+// When sending, Data is written after the extended message ID without encoding.
+type ExtensionRawMessage struct {
+	ExtendedMessageID uint8
+	Data              []byte
+}
+
 // ExtensionPEXMessage is the message for the PEX extension.
 type ExtensionPEXMessage struct {
 	Added   string `bencode:"added"`
 	Dropped string `bencode:"dropped"`
 }
 
+// ExtensionTEXMessage is the message for the tracker exchange extension (BEP 28).
+type ExtensionTEXMessage struct {
+	Added []string `bencode:"added"`
+}
+
 func truncateIP(ip net.IP) net.IP {
 	ip4 := ip.To4()
 	if ip4 != nil {
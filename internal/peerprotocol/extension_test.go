@@ -0,0 +1,40 @@
+package peerprotocol
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtensionDontHave(t *testing.T) {
+	msg := ExtensionMessage{
+		ExtendedMessageID: ExtensionIDDontHave,
+		Payload:           ExtensionDontHaveMessage{Index: 258},
+	}
+	var buf bytes.Buffer
+	n, err := msg.WriteTo(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), n)
+	assert.Equal(t, []byte{ExtensionIDDontHave, 0, 0, 1, 2}, buf.Bytes())
+
+	var msg2 ExtensionMessage
+	assert.NoError(t, msg2.UnmarshalBinary(buf.Bytes()))
+	assert.Equal(t, msg.Payload, msg2.Payload)
+
+	assert.Error(t, msg2.UnmarshalBinary([]byte{ExtensionIDDontHave, 1}))
+}
+
+func TestExtensionTEX(t *testing.T) {
+	msg := ExtensionMessage{
+		ExtendedMessageID: ExtensionIDTEX,
+		Payload:           ExtensionTEXMessage{Added: []string{"http://tracker.example.com/announce"}},
+	}
+	var buf bytes.Buffer
+	_, err := msg.WriteTo(&buf)
+	assert.NoError(t, err)
+
+	var msg2 ExtensionMessage
+	assert.NoError(t, msg2.UnmarshalBinary(buf.Bytes()))
+	assert.Equal(t, msg.Payload, msg2.Payload)
+}
@@ -0,0 +1,15 @@
+package peersource
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestString(t *testing.T) {
+	names := make(map[string]struct{})
+	for s := Tracker; s <= LSD; s++ {
+		assert.NotPanics(t, func() { names[s.String()] = struct{}{} })
+	}
+	assert.Len(t, names, int(LSD)+1)
+}
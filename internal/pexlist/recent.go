@@ -18,6 +18,10 @@ type RecentlySeen struct {
 
 // Add a new address to the list.
 func (l *RecentlySeen) Add(addr *net.TCPAddr) {
+	if addr.IP.To4() == nil {
+		// PEX messages only contain IPv4 addresses.
+		return
+	}
 	cp := tracker.NewCompactPeer(addr)
 	if l.has(cp) {
 		return
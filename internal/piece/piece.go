@@ -6,7 +6,7 @@ import (
 
 	"github.com/cenkalti/rain/internal/allocator"
 	"github.com/cenkalti/rain/internal/filesection"
-	"github.com/cenkalti/rain/internal/metainfo"
+	"github.com/cenkalti/rain/metainfo"
 	"golang.org/x/exp/constraints"
 )
 
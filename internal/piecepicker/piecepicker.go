@@ -24,6 +24,7 @@ These are the things to consider when selecting a piece for downloading:
   * Piece is reserved for downloading by a webseed source
   * Is endgame mode activated (all pieces are requested)
   * Are there stalled peers (snubbed or choked in the middle of download)
+  * Piece is in the priority list (needed by a reader of the torrent data)
 
 Do not forget to re-check these when making changes.
 
@@ -39,6 +40,8 @@ type PiecePicker struct {
 	maxDuplicateDownload int
 	available            uint32
 	endgame              bool
+	// Indexes of pieces that are picked before others, in order.
+	priority []uint32
 }
 
 type myPiece struct {
@@ -136,6 +139,21 @@ func (p *PiecePicker) Available() uint32 {
 	return p.available
 }
 
+// AvailabilityHistogram returns the distribution of piece availability among peers.
+// The value at index i is the number of pieces that are available from exactly i peers.
+// Length of the slice is one more than the highest availability, so the last value is never zero.
+func (p *PiecePicker) AvailabilityHistogram() []int {
+	var h []int
+	for i := range p.pieces {
+		n := p.pieces[i].Having.Len()
+		for len(h) <= n {
+			h = append(h, 0)
+		}
+		h[n]++
+	}
+	return h
+}
+
 // RequestedPeers returns the number of peers that the piece with the index is requested from.
 func (p *PiecePicker) RequestedPeers(i uint32) []*peer.Peer {
 	return p.pieces[i].Requested.Items
@@ -152,6 +170,12 @@ func (p *PiecePicker) HandleHave(pe *peer.Peer, i uint32) {
 	p.addHavingPeer(i, pe)
 }
 
+// HandleDontHave must be called when the peer no longer has the piece.
+func (p *PiecePicker) HandleDontHave(pe *peer.Peer, i uint32) {
+	pe.Bitfield.Clear(i)
+	p.removeHavingPeer(int(i), pe)
+}
+
 // HandleAllowedFast must be called to set the allowed-fast status of the piece at peer.
 func (p *PiecePicker) HandleAllowedFast(pe *peer.Peer, i uint32) {
 	pe.ReceivedAllowedFast.Add(p.pieces[i].Piece)
@@ -243,6 +267,11 @@ func (p *PiecePicker) findPiece(pe *peer.Peer) (mp *myPiece, allowedFast bool) {
 	if pe.PeerChoking {
 		return nil, false
 	}
+	// Pick pieces that are needed first
+	pi = p.pickPriority(pe)
+	if pi != nil {
+		return pi, false
+	}
 	// Short path for endgame mode.
 	if p.endgame {
 		return p.pickEndgame(pe), false
@@ -273,6 +302,25 @@ func (p *PiecePicker) pickAllowedFast(pe *peer.Peer) *myPiece {
 	return nil
 }
 
+// SetPriority sets the pieces that are picked before other pieces.
+// Pieces are picked in the given order. Previous priority list is replaced.
+func (p *PiecePicker) SetPriority(indexes []uint32) {
+	p.priority = indexes
+}
+
+func (p *PiecePicker) pickPriority(pe *peer.Peer) *myPiece {
+	for _, i := range p.priority {
+		mp := &p.pieces[i]
+		if mp.Done || mp.Writing {
+			continue
+		}
+		if mp.Requested.Len() == 0 && mp.Having.Has(pe) {
+			return mp
+		}
+	}
+	return nil
+}
+
 func (p *PiecePicker) pickRarest(pe *peer.Peer) *myPiece {
 	// Sort by rarity
 	sort.Slice(p.piecesByAvailability, func(i, j int) bool {
@@ -69,6 +69,26 @@ func TestPiecePicker(t *testing.T) {
 	assert.True(t, pp.endgame)
 }
 
+func TestPiecePickerPriority(t *testing.T) {
+	pieces := make([]piece.Piece, numPieces)
+	for i := range pieces {
+		pieces[i] = newPiece(i)
+	}
+	pe := newPeer(0)
+	pe2 := newPeer(1)
+	pp := New(pieces, 2, nil)
+	for i := uint32(0); i < numPieces; i++ {
+		pp.HandleHave(pe, i)
+		pp.HandleHave(pe2, i)
+	}
+	pp.HandleHave(newPeer(2), 0)
+	pieces[5].Done = true
+
+	pp.SetPriority([]uint32{5, 6, 3})
+	assert.Equal(t, &pieces[6], pp.pickFor(pe))
+	assert.Equal(t, &pieces[3], pp.pickFor(pe2))
+}
+
 func newPiece(i int) piece.Piece {
 	return piece.Piece{Index: uint32(i)}
 }
@@ -84,3 +104,42 @@ func (p *PiecePicker) pickFor(pe *peer.Peer) *piece.Piece {
 	pi, _ := p.PickFor(pe)
 	return pi
 }
+
+func TestAvailabilityHistogram(t *testing.T) {
+	pieces := make([]piece.Piece, 4)
+	for i := range pieces {
+		pieces[i] = newPiece(i)
+	}
+	peers := make([]*peer.Peer, 2)
+	for i := range peers {
+		peers[i] = newPeer(i)
+	}
+	pp := New(pieces, 2, nil)
+	assert.Equal(t, []int{4}, pp.AvailabilityHistogram())
+
+	pp.HandleHave(peers[0], 0)
+	pp.HandleHave(peers[0], 1)
+	pp.HandleHave(peers[1], 1)
+	pp.HandleHave(peers[1], 2)
+	assert.Equal(t, []int{1, 2, 1}, pp.AvailabilityHistogram())
+
+	pp.HandleDisconnect(peers[1])
+	assert.Equal(t, []int{2, 2}, pp.AvailabilityHistogram())
+}
+
+func TestHandleDontHave(t *testing.T) {
+	pieces := make([]piece.Piece, 2)
+	for i := range pieces {
+		pieces[i] = newPiece(i)
+	}
+	pe := newPeer(0)
+	pp := New(pieces, 2, nil)
+	pp.HandleHave(pe, 1)
+	assert.Equal(t, &pieces[1], pp.pickFor(pe))
+	pp.HandleCancelDownload(pe, 1)
+
+	pp.HandleDontHave(pe, 1)
+	assert.False(t, pe.Bitfield.Test(1))
+	assert.Nil(t, pp.pickFor(pe))
+	assert.Equal(t, []int{2}, pp.AvailabilityHistogram())
+}
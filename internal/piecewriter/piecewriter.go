@@ -6,6 +6,7 @@ import (
 	"github.com/cenkalti/rain/internal/bufferpool"
 	"github.com/cenkalti/rain/internal/piece"
 	"github.com/cenkalti/rain/internal/semaphore"
+	"github.com/cenkalti/rain/internal/smartban"
 	"github.com/rcrowley/go-metrics"
 )
 
@@ -15,8 +16,12 @@ type PieceWriter struct {
 	Source any
 	Buffer bufferpool.Buffer
 
-	HashOK bool
-	Error  error
+	// If true, block hashes are calculated even if the piece passes the hash check.
+	HashBlocks bool
+
+	HashOK      bool
+	BlockHashes smartban.Blocks
+	Error       error
 }
 
 // New returns new PieceWriter for a given piece.
@@ -31,6 +36,9 @@ func New(p *piece.Piece, source any, buf bufferpool.Buffer) *PieceWriter {
 // Run checks the hash, then writes the data in the buffer to the disk.
 func (w *PieceWriter) Run(resultC chan *PieceWriter, closeC chan struct{}, writesPerSecond, writeBytesPerSecond metrics.Meter, sem *semaphore.Semaphore) {
 	w.HashOK = w.Piece.VerifyHash(w.Buffer.Data, sha1.New())
+	if !w.HashOK || w.HashBlocks {
+		w.BlockHashes = smartban.HashBlocks(w.Buffer.Data, w.Piece.CalculateBlocks())
+	}
 	if w.HashOK {
 		writesPerSecond.Mark(1)
 		writeBytesPerSecond.Mark(int64(len(w.Buffer.Data)))
@@ -33,8 +33,13 @@ var Keys = struct {
 	Started           []byte
 	StopAfterDownload []byte
 	StopAfterMetadata []byte
+	SeedOnly          []byte
+	Paused            []byte
+	DataDir           []byte
 	CompleteCmdRun    []byte
+	Priority          []byte
 	Version           []byte
+	RenamedFiles      []byte
 }{
 	InfoHash:          []byte("info_hash"),
 	Port:              []byte("port"),
@@ -53,8 +58,13 @@ var Keys = struct {
 	Started:           []byte("started"),
 	StopAfterDownload: []byte("stop_after_download"),
 	StopAfterMetadata: []byte("stop_after_metadata"),
+	SeedOnly:          []byte("seed_only"),
+	Paused:            []byte("paused"),
+	DataDir:           []byte("data_dir"),
 	CompleteCmdRun:    []byte("complete_cmd_run"),
+	Priority:          []byte("priority"),
 	Version:           []byte("version"),
+	RenamedFiles:      []byte("renamed_files"),
 }
 
 // Resumer contains methods for saving/loading resume information of a torrent to a BoltDB database.
@@ -93,6 +103,13 @@ func (r *Resumer) Write(torrentID string, spec *Spec) error {
 	if err != nil {
 		return err
 	}
+	var renamedFiles []byte
+	if spec.RenamedFiles != nil {
+		renamedFiles, err = json.Marshal(spec.RenamedFiles)
+		if err != nil {
+			return err
+		}
+	}
 	version := LatestVersion
 	if spec.Version != 0 {
 		version = spec.Version
@@ -118,8 +135,17 @@ func (r *Resumer) Write(torrentID string, spec *Spec) error {
 		_ = b.Put(Keys.Started, []byte(strconv.FormatBool(spec.Started)))
 		_ = b.Put(Keys.StopAfterDownload, []byte(strconv.FormatBool(spec.StopAfterDownload)))
 		_ = b.Put(Keys.StopAfterMetadata, []byte(strconv.FormatBool(spec.StopAfterMetadata)))
+		_ = b.Put(Keys.SeedOnly, []byte(strconv.FormatBool(spec.SeedOnly)))
+		_ = b.Put(Keys.Paused, []byte(strconv.FormatBool(spec.Paused)))
+		if spec.DataDir != "" {
+			_ = b.Put(Keys.DataDir, []byte(spec.DataDir))
+		}
 		_ = b.Put(Keys.CompleteCmdRun, []byte(strconv.FormatBool(spec.CompleteCmdRun)))
+		_ = b.Put(Keys.Priority, []byte(strconv.Itoa(spec.Priority)))
 		_ = b.Put(Keys.Version, []byte(strconv.Itoa(version)))
+		if renamedFiles != nil {
+			_ = b.Put(Keys.RenamedFiles, renamedFiles)
+		}
 		return nil
 	})
 }
@@ -135,6 +161,32 @@ func (r *Resumer) WriteInfo(torrentID string, value []byte) error {
 	})
 }
 
+// WriteRenamedFiles writes the paths of files on disk that are different from the paths in torrent.
+func (r *Resumer) WriteRenamedFiles(torrentID string, value map[string]string) error {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		bu := tx.Bucket(r.bucket).Bucket([]byte(torrentID))
+		if bu == nil {
+			return nil
+		}
+		return bu.Put(Keys.RenamedFiles, b)
+	})
+}
+
+// WriteDataDir writes the directory that the files of a torrent are saved in.
+func (r *Resumer) WriteDataDir(torrentID string, value string) error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(r.bucket).Bucket([]byte(torrentID))
+		if b == nil {
+			return nil
+		}
+		return b.Put(Keys.DataDir, []byte(value))
+	})
+}
+
 // WriteBitfield writes only bitfield of a torrent.
 func (r *Resumer) WriteBitfield(torrentID string, value []byte) error {
 	return r.db.Update(func(tx *bbolt.Tx) error {
@@ -157,6 +209,28 @@ func (r *Resumer) WriteStarted(torrentID string, value bool) error {
 	})
 }
 
+// WritePaused writes the pause status of a torrent.
+func (r *Resumer) WritePaused(torrentID string, value bool) error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(r.bucket).Bucket([]byte(torrentID))
+		if b == nil {
+			return nil
+		}
+		return b.Put(Keys.Paused, []byte(strconv.FormatBool(value)))
+	})
+}
+
+// WritePriority writes the priority of a torrent.
+func (r *Resumer) WritePriority(torrentID string, value int) error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(r.bucket).Bucket([]byte(torrentID))
+		if b == nil {
+			return nil
+		}
+		return b.Put(Keys.Priority, []byte(strconv.Itoa(value)))
+	})
+}
+
 // HandleStopAfterDownload clears the start status and stop_after_download fields.
 func (r *Resumer) HandleStopAfterDownload(torrentID string) error {
 	return r.db.Update(func(tx *bbolt.Tx) error {
@@ -351,6 +425,27 @@ func (r *Resumer) Read(torrentID string) (spec *Spec, err error) {
 			}
 		}
 
+		value = b.Get(Keys.SeedOnly)
+		if value != nil {
+			spec.SeedOnly, err = strconv.ParseBool(string(value))
+			if err != nil {
+				return err
+			}
+		}
+
+		value = b.Get(Keys.Paused)
+		if value != nil {
+			spec.Paused, err = strconv.ParseBool(string(value))
+			if err != nil {
+				return err
+			}
+		}
+
+		value = b.Get(Keys.DataDir)
+		if value != nil {
+			spec.DataDir = string(value)
+		}
+
 		value = b.Get(Keys.CompleteCmdRun)
 		if value != nil {
 			spec.CompleteCmdRun, err = strconv.ParseBool(string(value))
@@ -359,6 +454,14 @@ func (r *Resumer) Read(torrentID string) (spec *Spec, err error) {
 			}
 		}
 
+		value = b.Get(Keys.Priority)
+		if value != nil {
+			spec.Priority, err = strconv.Atoi(string(value))
+			if err != nil {
+				return err
+			}
+		}
+
 		value = b.Get(Keys.Version)
 		if value != nil {
 			spec.Version, err = strconv.Atoi(string(value))
@@ -371,6 +474,14 @@ func (r *Resumer) Read(torrentID string) (spec *Spec, err error) {
 			spec.Version = 1
 		}
 
+		value = b.Get(Keys.RenamedFiles)
+		if value != nil {
+			err = json.Unmarshal(value, &spec.RenamedFiles)
+			if err != nil {
+				return err
+			}
+		}
+
 		return nil
 	})
 	return
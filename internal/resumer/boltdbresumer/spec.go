@@ -24,8 +24,16 @@ type Spec struct {
 	Started           bool
 	StopAfterDownload bool
 	StopAfterMetadata bool
+	SeedOnly          bool
+	Paused            bool
 	CompleteCmdRun    bool
+	Priority          int
 	Version           int
+	// Directory that the files are saved in. Empty for torrents added before it is saved.
+	DataDir string
+	// Maps paths of files in torrent to the paths on disk if they are different.
+	// Nil if files are never renamed.
+	RenamedFiles map[string]string
 }
 
 type jsonSpec struct {
@@ -41,8 +49,13 @@ type jsonSpec struct {
 	Started           bool
 	StopAfterDownload bool
 	StopAfterMetadata bool
+	SeedOnly          bool
+	Paused            bool
 	CompleteCmdRun    bool
+	Priority          int
 	Version           int
+	DataDir           string
+	RenamedFiles      map[string]string
 
 	// JSON unsafe types
 	InfoHash  string
@@ -66,8 +79,13 @@ func (s Spec) MarshalJSON() ([]byte, error) {
 		Started:           s.Started,
 		StopAfterDownload: s.StopAfterDownload,
 		StopAfterMetadata: s.StopAfterMetadata,
+		SeedOnly:          s.SeedOnly,
+		Paused:            s.Paused,
 		CompleteCmdRun:    s.CompleteCmdRun,
+		Priority:          s.Priority,
 		Version:           s.Version,
+		DataDir:           s.DataDir,
+		RenamedFiles:      s.RenamedFiles,
 
 		InfoHash:  base64.StdEncoding.EncodeToString(s.InfoHash),
 		Info:      base64.StdEncoding.EncodeToString(s.Info),
@@ -109,7 +127,12 @@ func (s *Spec) UnmarshalJSON(b []byte) error {
 	s.Started = j.Started
 	s.StopAfterDownload = j.StopAfterDownload
 	s.StopAfterMetadata = j.StopAfterMetadata
+	s.SeedOnly = j.SeedOnly
+	s.Paused = j.Paused
 	s.CompleteCmdRun = j.CompleteCmdRun
+	s.Priority = j.Priority
 	s.Version = j.Version
+	s.DataDir = j.DataDir
+	s.RenamedFiles = j.RenamedFiles
 	return nil
 }
@@ -7,8 +7,9 @@ import (
 
 func TestMarshalUnmarshalSpec(t *testing.T) {
 	s := Spec{
-		Info: []byte{1, 2, 3},
-		Name: "foo",
+		Info:         []byte{1, 2, 3},
+		Name:         "foo",
+		RenamedFiles: map[string]string{"a:b": "a_b"},
 	}
 	b, err := s.MarshalJSON()
 	if err != nil {
@@ -25,4 +26,7 @@ func TestMarshalUnmarshalSpec(t *testing.T) {
 	if s.Name != s2.Name {
 		t.FailNow()
 	}
+	if s2.RenamedFiles["a:b"] != "a_b" {
+		t.FailNow()
+	}
 }
@@ -15,6 +15,7 @@ type Peer struct {
 	Client             string
 	Addr               string
 	Source             string
+	Tracker            string
 	ConnectedAt        Time
 	Downloading        bool
 	ClientInterested   bool
@@ -27,6 +28,10 @@ type Peer struct {
 	EncryptedStream    bool
 	DownloadSpeed      int
 	UploadSpeed        int
+	BytesDownloaded    int64
+	BytesUploaded      int64
+	Incoming           bool
+	Progress           int
 }
 
 // Webseed source of a Torrent.
@@ -48,6 +53,12 @@ type Tracker struct {
 	ErrorInternal string
 	LastAnnounce  Time
 	NextAnnounce  Time
+	Failures      int
+
+	PeersReceived   int
+	PeersConnected  int
+	BytesDownloaded int64
+	BytesUploaded   int64
 }
 
 // SessionStats contains statistics about a Session.
@@ -85,6 +96,20 @@ type SessionStats struct {
 	BytesUploaded   int64
 	BytesRead       int64
 	BytesWritten    int64
+
+	LifetimeBytesDownloaded int64
+	LifetimeBytesUploaded   int64
+	SessionCount            int64
+}
+
+// Settings contains the settings of a Session that can be changed while it is running.
+type Settings struct {
+	SpeedLimitDownload      int64
+	SpeedLimitUpload        int64
+	MaxPeerDial             int
+	MaxPeerAccept           int
+	QueueMaxActiveDownloads int
+	QueueMaxActiveSeeds     int
 }
 
 // Stats contains statistics about a Torrent.
@@ -94,11 +119,13 @@ type Stats struct {
 	Status   string
 	Error    string
 	Pieces   struct {
-		Checked   uint32
-		Have      uint32
-		Missing   uint32
-		Available uint32
-		Total     uint32
+		Checked           uint32
+		Have              uint32
+		Missing           uint32
+		Available         uint32
+		Total             uint32
+		Availability      []int
+		DistributedCopies float64
 	}
 	Bytes struct {
 		Total      int64
@@ -114,6 +141,12 @@ type Stats struct {
 		Incoming int
 		Outgoing int
 	}
+	Swarm struct {
+		Seeders    int
+		Leechers   int
+		Snatches   int
+		LastScrape Time
+	}
 	Handshakes struct {
 		Total    int
 		Incoming int
@@ -138,14 +171,27 @@ type Stats struct {
 	}
 	Name        string
 	Private     bool
+	DHTEnabled  bool
+	PEXEnabled  bool
 	FileCount   int
 	PieceLength uint32
 	SeededFor   uint
 	Speed       struct {
-		Download int
-		Upload   int
+		Download        int
+		Upload          int
+		DownloadCurrent int
+		UploadCurrent   int
 	}
-	ETA int
+	ETA          int
+	Trackers     []Tracker
+	Priority     string
+	RecentErrors []RecentError
+}
+
+// RecentError is a non-fatal error that has occurred while the torrent is running.
+type RecentError struct {
+	Time  Time
+	Error string
 }
 
 // GetMagnetRequest contains request arguments for Session.GetMagnet method.
@@ -183,6 +229,10 @@ type AddTorrentOptions struct {
 	Stopped           bool
 	StopAfterDownload bool
 	StopAfterMetadata bool
+	SeedOnly          bool
+	Priority          string
+	Trackers          []string
+	CrossSeed         string
 }
 
 // AddTorrentRequest contains request arguments for Session.AddTorrent method.
@@ -233,6 +283,24 @@ type GetSessionStatsResponse struct {
 	Stats SessionStats
 }
 
+// GetSettingsRequest contains request arguments for Session.GetSettings method.
+type GetSettingsRequest struct {
+}
+
+// GetSettingsResponse contains response arguments for Session.GetSettings method.
+type GetSettingsResponse struct {
+	Settings Settings
+}
+
+// SetSettingsRequest contains request arguments for Session.SetSettings method.
+type SetSettingsRequest struct {
+	Settings Settings
+}
+
+// SetSettingsResponse contains response arguments for Session.SetSettings method.
+type SetSettingsResponse struct {
+}
+
 // GetTorrentStatsRequest contains request arguments for Session.GetTorrentStats method.
 type GetTorrentStatsRequest struct {
 	ID string
@@ -291,6 +359,34 @@ type StopTorrentRequest struct {
 type StopTorrentResponse struct {
 }
 
+// SetTorrentPriorityRequest contains request arguments for Session.SetTorrentPriority method.
+type SetTorrentPriorityRequest struct {
+	ID       string
+	Priority string
+}
+
+// SetTorrentPriorityResponse contains response arguments for Session.SetTorrentPriority method.
+type SetTorrentPriorityResponse struct {
+}
+
+// PauseTorrentRequest contains request arguments for Session.PauseTorrent method.
+type PauseTorrentRequest struct {
+	ID string
+}
+
+// PauseTorrentResponse contains response arguments for Session.PauseTorrent method.
+type PauseTorrentResponse struct {
+}
+
+// ResumeTorrentRequest contains request arguments for Session.ResumeTorrent method.
+type ResumeTorrentRequest struct {
+	ID string
+}
+
+// ResumeTorrentResponse contains response arguments for Session.ResumeTorrent method.
+type ResumeTorrentResponse struct {
+}
+
 // AnnounceTorrentRequest contains request arguments for Session.AnnounceTorrent method.
 type AnnounceTorrentRequest struct {
 	ID string
@@ -300,6 +396,15 @@ type AnnounceTorrentRequest struct {
 type AnnounceTorrentResponse struct {
 }
 
+// ForceAnnounceTorrentRequest contains request arguments for Session.ForceAnnounceTorrent method.
+type ForceAnnounceTorrentRequest struct {
+	ID string
+}
+
+// ForceAnnounceTorrentResponse contains response arguments for Session.ForceAnnounceTorrent method.
+type ForceAnnounceTorrentResponse struct {
+}
+
 // VerifyTorrentRequest contains request arguments for Session.VerifyTorrent method.
 type VerifyTorrentRequest struct {
 	ID string
@@ -354,3 +459,52 @@ type StopAllTorrentsRequest struct {
 // StopAllTorrentsResponse contains response arguments for Session.StopAllTorrents method.
 type StopAllTorrentsResponse struct {
 }
+
+// DHTStats contains statistics about the DHT node of the Session.
+type DHTStats struct {
+	NodeID         string
+	Port           int
+	Nodes          int64
+	ReachableNodes int64
+	SavedNodes     int
+	ReadOnly       bool
+
+	PacketsSent     int64
+	PacketsReceived int64
+	PacketsDropped  int64
+	BytesSent       int64
+	BytesReceived   int64
+
+	GetPeersSent         int64
+	GetPeersReceived     int64
+	GetPeersReplies      int64
+	FindNodeSent         int64
+	FindNodeReceived     int64
+	FindNodeReplies      int64
+	PingSent             int64
+	PingReplies          int64
+	PeersFound           int64
+	PacketsFromBlocklist int64
+}
+
+// GetDHTStatsRequest contains request arguments for Session.GetDHTStats method.
+type GetDHTStatsRequest struct {
+}
+
+// GetDHTStatsResponse contains response arguments for Session.GetDHTStats method.
+type GetDHTStatsResponse struct {
+	Stats DHTStats
+}
+
+// DHTGetPeersRequest contains request arguments for Session.DHTGetPeers method.
+type DHTGetPeersRequest struct {
+	// Info hash in hex.
+	InfoHash string
+	// Duration of the lookup in seconds.
+	Timeout int
+}
+
+// DHTGetPeersResponse contains response arguments for Session.DHTGetPeers method.
+type DHTGetPeersResponse struct {
+	Peers []string
+}
@@ -0,0 +1,22 @@
+package semaphore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitStop(t *testing.T) {
+	s := New(1)
+	stopC := make(chan struct{})
+	assert.True(t, s.WaitStop(stopC))
+	assert.Equal(t, 1, s.Len())
+
+	close(stopC)
+	assert.False(t, s.WaitStop(stopC))
+	assert.Equal(t, 1, s.Len())
+	assert.Equal(t, 0, s.Waiting())
+
+	s.Signal()
+	assert.Equal(t, 0, s.Len())
+}
@@ -0,0 +1,89 @@
+// Package smartban keeps the hashes of blocks in pieces that have failed hash check.
+// After the piece is downloaded successfully, the saved hashes are compared with the good data
+// to find out which peer has sent the corrupt block.
+// Peers that keep sending corrupt pieces are banned after a number of failures.
+package smartban
+
+import (
+	"crypto/sha1"
+
+	"github.com/cenkalti/rain/internal/piece"
+)
+
+// Blocks maps the offset of a block in piece to the hash of its data.
+type Blocks map[uint32][sha1.Size]byte
+
+// HashBlocks calculates the hashes of blocks in the piece data.
+func HashBlocks(buf []byte, blocks []piece.Block) Blocks {
+	ret := make(Blocks, len(blocks))
+	for _, blk := range blocks {
+		ret[blk.Begin] = sha1.Sum(buf[blk.Begin : blk.Begin+blk.Length])
+	}
+	return ret
+}
+
+// SmartBan keeps block hashes of corrupt pieces received from peers.
+type SmartBan struct {
+	pieces      map[uint32]map[string]Blocks // piece index -> peer IP -> blocks
+	failures    map[string]int               // peer IP -> number of corrupt pieces
+	maxFailures int
+}
+
+// New returns a new SmartBan.
+// Peers are banned after sending maxFailures corrupt pieces even if the corrupt blocks cannot be identified.
+func New(maxFailures int) *SmartBan {
+	return &SmartBan{
+		pieces:      make(map[uint32]map[string]Blocks),
+		failures:    make(map[string]int),
+		maxFailures: maxFailures,
+	}
+}
+
+// Add saves the block hashes of a corrupt piece sent by the peer with IP.
+// Returns true if the peer must be banned immediately,
+// that is the same peer has already sent a corrupt copy of the piece before
+// or the peer has sent too many corrupt pieces.
+func (s *SmartBan) Add(index uint32, ip string, blocks Blocks) bool {
+	peers, ok := s.pieces[index]
+	if !ok {
+		peers = make(map[string]Blocks)
+		s.pieces[index] = peers
+	}
+	_, ok = peers[ip]
+	peers[ip] = blocks
+	s.failures[ip]++
+	return ok || s.failures[ip] >= s.maxFailures
+}
+
+// Has returns true if there is a corrupt copy of the piece saved.
+func (s *SmartBan) Has(index uint32) bool {
+	_, ok := s.pieces[index]
+	return ok
+}
+
+// Check compares the saved block hashes with the hashes of a piece that has passed the hash check.
+// Returns the IPs of peers that have sent at least one block that does not match.
+// Saved hashes of the piece are removed after the check.
+func (s *SmartBan) Check(index uint32, good Blocks) []string {
+	peers, ok := s.pieces[index]
+	if !ok {
+		return nil
+	}
+	delete(s.pieces, index)
+	var ret []string
+	for ip, blocks := range peers {
+		for begin, sum := range blocks {
+			if good[begin] != sum {
+				ret = append(ret, ip)
+				break
+			}
+		}
+	}
+	return ret
+}
+
+// Reset removes all saved hashes.
+func (s *SmartBan) Reset() {
+	s.pieces = make(map[uint32]map[string]Blocks)
+	s.failures = make(map[string]int)
+}
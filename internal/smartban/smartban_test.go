@@ -0,0 +1,40 @@
+package smartban
+
+import (
+	"testing"
+
+	"github.com/cenkalti/rain/internal/piece"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSmartBan(t *testing.T) {
+	blocks := []piece.Block{{Begin: 0, Length: 4}, {Begin: 4, Length: 4}}
+	good := []byte("aaaabbbb")
+	bad := []byte("aaaacccc")
+
+	s := New(3)
+	assert.False(t, s.Has(1))
+	assert.False(t, s.Add(1, "1.1.1.1", HashBlocks(bad, blocks)))
+	assert.False(t, s.Add(1, "2.2.2.2", HashBlocks(good, blocks)))
+	assert.True(t, s.Add(1, "1.1.1.1", HashBlocks(bad, blocks)))
+	assert.True(t, s.Has(1))
+
+	banned := s.Check(1, HashBlocks(good, blocks))
+	assert.Equal(t, []string{"1.1.1.1"}, banned)
+	assert.False(t, s.Has(1))
+	assert.Nil(t, s.Check(1, HashBlocks(good, blocks)))
+}
+
+func TestSmartBanRepeatedFailures(t *testing.T) {
+	blocks := []piece.Block{{Begin: 0, Length: 4}}
+	bad := HashBlocks([]byte("cccc"), blocks)
+
+	s := New(3)
+	assert.False(t, s.Add(1, "1.1.1.1", bad))
+	assert.False(t, s.Add(2, "1.1.1.1", bad))
+	assert.False(t, s.Add(2, "2.2.2.2", bad))
+	assert.True(t, s.Add(3, "1.1.1.1", bad))
+
+	s.Reset()
+	assert.False(t, s.Add(4, "1.1.1.1", bad))
+}
@@ -0,0 +1,185 @@
+// Package speedlimit provides a rate limiter for limiting the transfer speed.
+// Unlike ratelimit.Bucket, the rate of the limiter can be changed while it is in use.
+package speedlimit
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/juju/ratelimit"
+)
+
+// Priorities of the consumers of a Limiter.
+const (
+	PriorityLow    = -1
+	PriorityNormal = 0
+	PriorityHigh   = 1
+)
+
+// Share of the limit of each priority, indexed by priority.
+// When consumers with different priorities are active, the limit is divided in proportion to these weights.
+var weights = [3]int64{1, 2, 4}
+
+// A priority is considered active if one of its consumers has taken from the Limiter in this duration.
+const activeWindow = time.Second
+
+// Limiter limits the number of bytes transferred per second.
+// The zero value is a Limiter without any limit.
+type Limiter struct {
+	m     sync.RWMutex
+	limit int64
+
+	// Each priority has its own bucket. Rates of the buckets are set according to the active priorities.
+	buckets [3]*ratelimit.Bucket
+	active  int // bitmask of priorities that the buckets are created for
+
+	// Time in Unix nanoseconds until a consumer of a priority is known to be active, indexed by priority.
+	activeUntil [3]int64
+
+	// Set only on limiters returned from WithPriority.
+	parent   *Limiter
+	priority *int32
+}
+
+// New returns a new Limiter with the limit in KiB/s. Zero value means no limit.
+func New(limit int64) *Limiter {
+	l := new(Limiter)
+	l.SetLimit(limit)
+	return l
+}
+
+// WithPriority returns a new Limiter that shares the limit of l.
+// When consumers with different priorities are active at the same time,
+// the limit is divided between them so consumers with higher priority get a larger share of the bandwidth.
+// The value pointed by priority is read atomically on each call to Take and must be one of the Priority constants.
+// The returned Limiter may have its own limit set with SetLimit, in which case both limits apply.
+func (l *Limiter) WithPriority(priority *int32) *Limiter {
+	return &Limiter{
+		parent:   l,
+		priority: priority,
+	}
+}
+
+// SetLimit changes the limit of the Limiter in KiB/s. Zero value means no limit.
+func (l *Limiter) SetLimit(limit int64) {
+	l.m.Lock()
+	defer l.m.Unlock()
+	if limit == l.limit {
+		return
+	}
+	l.limit = limit
+	// Buckets of the new limit start full.
+	l.buckets = [3]*ratelimit.Bucket{}
+	l.setBuckets(l.active)
+}
+
+// setBuckets creates new buckets that share the limit between the priorities in active bitmask.
+// Tokens left in the buckets of previously active priorities, or the debt of them, are divided
+// between the new buckets, so changing the active priorities does not let the consumers exceed the limit.
+// Must be called with the lock held.
+func (l *Limiter) setBuckets(active int) {
+	var avail int64
+	carry := false
+	for i, b := range l.buckets {
+		if b != nil && l.active&(1<<i) != 0 {
+			avail += b.Available()
+			carry = true
+		}
+	}
+	l.active = active
+	if l.limit <= 0 {
+		l.buckets = [3]*ratelimit.Bucket{}
+		return
+	}
+	var total int64
+	for i, w := range weights {
+		if active&(1<<i) != 0 {
+			total += w
+		}
+	}
+	rate := l.limit * 1024
+	for i, w := range weights {
+		r := rate
+		if active&(1<<i) != 0 {
+			r = rate * w / total
+		}
+		b := ratelimit.NewBucketWithRate(float64(r), r)
+		if carry && active&(1<<i) != 0 {
+			// New buckets are full. Take the difference to leave only the share of the carried tokens.
+			if share := avail * w / total; share < r {
+				b.Take(r - share)
+			}
+		}
+		l.buckets[i] = b
+	}
+}
+
+// Limit returns the current limit in KiB/s.
+func (l *Limiter) Limit() int64 {
+	l.m.RLock()
+	defer l.m.RUnlock()
+	return l.limit
+}
+
+// Take count bytes from the Limiter.
+// Returns the duration that the caller must wait until the bytes are available.
+// Calling Take on nil Limiter is allowed and always returns zero.
+func (l *Limiter) Take(count int64) time.Duration {
+	if l == nil {
+		return 0
+	}
+	if l.parent != nil {
+		d := l.parent.take(count, atomic.LoadInt32(l.priority))
+		if own := l.take(count, PriorityNormal); own > d {
+			d = own
+		}
+		return d
+	}
+	return l.take(count, PriorityNormal)
+}
+
+func (l *Limiter) take(count int64, priority int32) time.Duration {
+	i := int(priority - PriorityLow)
+	if i < 0 {
+		i = 0
+	} else if i >= len(weights) {
+		i = len(weights) - 1
+	}
+	now := time.Now().UnixNano()
+	if atomic.LoadInt64(&l.activeUntil[i]) < now+int64(activeWindow) {
+		atomic.StoreInt64(&l.activeUntil[i], now+int64(activeWindow))
+	}
+	var active int
+	for j := range l.activeUntil {
+		if atomic.LoadInt64(&l.activeUntil[j]) > now {
+			active |= 1 << j
+		}
+	}
+
+	l.m.RLock()
+	b := l.buckets[i]
+	changed := active != l.active
+	l.m.RUnlock()
+	if b == nil {
+		return 0
+	}
+	if changed {
+		l.m.Lock()
+		if active != l.active {
+			l.setBuckets(active)
+		}
+		b = l.buckets[i]
+		l.m.Unlock()
+	}
+
+	d := b.Take(count)
+	if d > 0 {
+		// The consumer stays active while waiting for the bucket.
+		until := now + int64(d) + int64(activeWindow)
+		if atomic.LoadInt64(&l.activeUntil[i]) < until {
+			atomic.StoreInt64(&l.activeUntil[i], until)
+		}
+	}
+	return d
+}
@@ -0,0 +1,81 @@
+package speedlimit
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiter(t *testing.T) {
+	l := New(0)
+	assert.Zero(t, l.Take(1<<30))
+
+	l.SetLimit(1)
+	assert.Equal(t, int64(1), l.Limit())
+	assert.Zero(t, l.Take(1024))
+	assert.NotZero(t, l.Take(1024))
+
+	l.SetLimit(0)
+	assert.Zero(t, l.Take(1<<30))
+}
+
+func TestLimiterPriority(t *testing.T) {
+	l := New(5)
+	high := int32(PriorityHigh)
+	low := int32(PriorityLow)
+	lh := l.WithPriority(&high)
+	ll := l.WithPriority(&low)
+
+	// A single active priority gets the whole limit.
+	assert.Zero(t, lh.Take(5*1024))
+	assert.NotZero(t, lh.Take(1))
+
+	// When both are active, the limit is divided as 4 KiB/s for high and 1 KiB/s for low priority.
+	dl := ll.Take(1024)
+	dh := lh.Take(4 * 1024)
+	assert.InDelta(t, time.Second, dh, float64(100*time.Millisecond))
+	assert.InDelta(t, time.Second, dl, float64(100*time.Millisecond))
+}
+
+func TestLimiterPriorityChange(t *testing.T) {
+	l := New(10)
+	p := int32(PriorityHigh)
+	c := l.WithPriority(&p)
+
+	// Buckets are replaced on each Take because the previous priority is made inactive.
+	// The debt must be carried to the new buckets, otherwise each change allows another full second of data.
+	var d time.Duration
+	for i := 0; i < 20; i++ {
+		if i%2 == 0 {
+			atomic.StoreInt32(&p, PriorityHigh)
+		} else {
+			atomic.StoreInt32(&p, PriorityLow)
+		}
+		for j := range l.activeUntil {
+			atomic.StoreInt64(&l.activeUntil[j], 0)
+		}
+		d = c.Take(10 * 1024)
+	}
+	// 200 KiB at 10 KiB/s, the first 10 KiB is available immediately.
+	assert.InDelta(t, 19*time.Second, d, float64(500*time.Millisecond))
+}
+
+func TestLimiterChildLimit(t *testing.T) {
+	l := New(0)
+	p := int32(PriorityNormal)
+	c := l.WithPriority(&p)
+	assert.Zero(t, c.Take(1<<30))
+
+	// The limit of the child applies when the parent has no limit.
+	c.SetLimit(1)
+	assert.Zero(t, c.Take(1024))
+	assert.NotZero(t, c.Take(1024))
+
+	// The lower limit of the parent applies to the child too.
+	c.SetLimit(100)
+	l.SetLimit(1)
+	assert.Zero(t, c.Take(1024))
+	assert.NotZero(t, c.Take(1024))
+}
@@ -0,0 +1,130 @@
+// Package encryptedstorage implements Storage interface that encrypts the files of another Storage.
+package encryptedstorage
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"path/filepath"
+
+	"github.com/cenkalti/rain/internal/storage"
+)
+
+// EncryptedStorage encrypts the contents of files with AES-256 in CTR mode before writing to the underlying Storage.
+// CTR mode allows reading and writing at any offset, so pieces can be read and written independently.
+// Data is not authenticated by the cipher. Modified data is detected by piece hashes when the torrent is verified.
+type EncryptedStorage struct {
+	storage.Storage
+	key []byte
+}
+
+// New returns a new EncryptedStorage that saves files in sto, encrypted with key.
+// Key must be 32 bytes long. Use TorrentKey to get a different key for each torrent.
+func New(sto storage.Storage, key []byte) *EncryptedStorage {
+	return &EncryptedStorage{
+		Storage: sto,
+		key:     key,
+	}
+}
+
+var (
+	_ storage.Storage    = (*EncryptedStorage)(nil)
+	_ storage.Attributer = (*EncryptedStorage)(nil)
+)
+
+// Open a file.
+func (s *EncryptedStorage) Open(name string, size int64) (f storage.File, exists bool, err error) {
+	block, err := aes.NewCipher(s.key)
+	if err != nil {
+		return
+	}
+	f, exists, err = s.Storage.Open(name, size)
+	if err != nil {
+		return
+	}
+	return &file{File: f, block: block, iv: fileIV(s.key, name)}, exists, nil
+}
+
+// Symlink creates the symbolic link in the underlying storage if it is supported. The target is not encrypted.
+func (s *EncryptedStorage) Symlink(name, target string) error {
+	if at, ok := s.Storage.(storage.Attributer); ok {
+		return at.Symlink(name, target)
+	}
+	return nil
+}
+
+// SetExecutable makes the file executable in the underlying storage if it is supported.
+func (s *EncryptedStorage) SetExecutable(name string) error {
+	if at, ok := s.Storage.(storage.Attributer); ok {
+		return at.SetExecutable(name)
+	}
+	return nil
+}
+
+// fileIV returns a different initial counter for each file, so files do not share the key stream.
+func fileIV(key []byte, name string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte("iv"))
+	h.Write([]byte(filepath.ToSlash(name)))
+	return h.Sum(nil)[:aes.BlockSize]
+}
+
+type file struct {
+	storage.File
+	block cipher.Block
+	iv    []byte
+}
+
+var (
+	_ storage.File      = (*file)(nil)
+	_ storage.Completer = (*file)(nil)
+)
+
+func (f *file) ReadAt(p []byte, off int64) (int, error) {
+	n, err := f.File.ReadAt(p, off)
+	f.xor(p[:n], p[:n], off)
+	return n, err
+}
+
+func (f *file) WriteAt(p []byte, off int64) (int, error) {
+	b := make([]byte, len(p))
+	f.xor(b, p, off)
+	return f.File.WriteAt(b, off)
+}
+
+// Complete notifies the underlying file if it needs to know about completion.
+func (f *file) Complete() error {
+	if c, ok := f.File.(storage.Completer); ok {
+		return c.Complete()
+	}
+	return nil
+}
+
+// xor XORs src with the key stream at offset off and writes the result into dst.
+func (f *file) xor(dst, src []byte, off int64) {
+	if len(src) == 0 {
+		return
+	}
+	stream := cipher.NewCTR(f.block, counter(f.iv, uint64(off/aes.BlockSize)))
+	if skip := off % aes.BlockSize; skip > 0 {
+		var discard [aes.BlockSize]byte
+		stream.XORKeyStream(discard[:skip], discard[:skip])
+	}
+	stream.XORKeyStream(dst, src)
+}
+
+// counter returns the 128-bit big-endian sum of iv and n.
+func counter(iv []byte, n uint64) []byte {
+	c := make([]byte, aes.BlockSize)
+	hi := binary.BigEndian.Uint64(iv[:8])
+	lo := binary.BigEndian.Uint64(iv[8:])
+	sum := lo + n
+	if sum < lo {
+		hi++
+	}
+	binary.BigEndian.PutUint64(c[:8], hi)
+	binary.BigEndian.PutUint64(c[8:], sum)
+	return c
+}
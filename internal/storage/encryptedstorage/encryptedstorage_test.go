@@ -0,0 +1,71 @@
+package encryptedstorage
+
+import (
+	"bytes"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cenkalti/rain/internal/storage/filestorage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPBKDF2(t *testing.T) {
+	// Test vector from RFC 7914.
+	dk := pbkdf2([]byte("passwd"), []byte("salt"), 1, 64)
+	assert.Equal(t, "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783", hex.EncodeToString(dk))
+}
+
+func TestEncryptedStorage(t *testing.T) {
+	dir := t.TempDir()
+	fs, err := filestorage.New(dir, 0o640, 0o750, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := TorrentKey(NewKey("secret", []byte("salt")), make([]byte, 20))
+	s := New(fs, key)
+
+	data := make([]byte, 100)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	f, exists, err := s.Open("file", int64(len(data)))
+	assert.NoError(t, err)
+	assert.False(t, exists)
+	// Write at offsets that are not aligned to the cipher block size.
+	_, err = f.WriteAt(data[37:], 37)
+	assert.NoError(t, err)
+	_, err = f.WriteAt(data[:37], 0)
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+
+	raw, err := os.ReadFile(filepath.Join(dir, "file"))
+	assert.NoError(t, err)
+	assert.False(t, bytes.Equal(data, raw))
+
+	f, exists, err = s.Open("file", int64(len(data)))
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	b := make([]byte, 50)
+	_, err = f.ReadAt(b, 21)
+	assert.NoError(t, err)
+	assert.Equal(t, data[21:71], b)
+	assert.NoError(t, f.Close())
+
+	// Files do not share the key stream.
+	f2, _, err := s.Open("file2", int64(len(data)))
+	assert.NoError(t, err)
+	_, err = f2.WriteAt(data, 0)
+	assert.NoError(t, err)
+	assert.NoError(t, f2.Close())
+	raw2, err := os.ReadFile(filepath.Join(dir, "file2"))
+	assert.NoError(t, err)
+	assert.NotEqual(t, raw, raw2)
+}
+
+func TestCounter(t *testing.T) {
+	iv, _ := hex.DecodeString("0000000000000000ffffffffffffffff")
+	assert.Equal(t, "00000000000000010000000000000000", hex.EncodeToString(counter(iv, 1)))
+	assert.Equal(t, "0000000000000001000000000000000f", hex.EncodeToString(counter(iv, 16)))
+}
@@ -0,0 +1,60 @@
+package encryptedstorage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+)
+
+// Number of PBKDF2 iterations for deriving the key from a passphrase.
+const iterations = 100000
+
+// KeySize is the length of the keys in bytes.
+const KeySize = 32
+
+// NewKey derives a key from passphrase with PBKDF2-HMAC-SHA256.
+// Salt must be random and saved for deriving the same key again.
+func NewKey(passphrase string, salt []byte) []byte {
+	return pbkdf2([]byte(passphrase), salt, iterations, KeySize)
+}
+
+// TorrentKey returns the key for encrypting the files of the torrent with infoHash.
+func TorrentKey(key, infoHash []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte("torrent"))
+	h.Write(infoHash)
+	return h.Sum(nil)
+}
+
+// CheckValue returns a value that can be saved to check if the same key is used later, without saving the key itself.
+func CheckValue(key []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte("check"))
+	return h.Sum(nil)
+}
+
+// pbkdf2 implements the key derivation function in RFC 8018.
+func pbkdf2(password, salt []byte, iter, keyLen int) []byte {
+	prf := hmac.New(sha256.New, password)
+	var dk []byte
+	var buf [4]byte
+	for block := uint32(1); len(dk) < keyLen; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		binary.BigEndian.PutUint32(buf[:], block)
+		prf.Write(buf[:])
+		u := prf.Sum(nil)
+		t := make([]byte, len(u))
+		copy(t, u)
+		for i := 1; i < iter; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		dk = append(dk, t...)
+	}
+	return dk[:keyLen]
+}
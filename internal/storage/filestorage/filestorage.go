@@ -2,40 +2,82 @@
 package filestorage
 
 import (
+	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 
 	"github.com/cenkalti/rain/internal/storage"
 )
 
 // FileStorage implements Storage interface for saving files on disk.
 type FileStorage struct {
-	dest string
-	perm fs.FileMode
+	dest    string
+	perm    fs.FileMode
+	dirPerm fs.FileMode
+	sync    bool
+	lazy    bool
+
+	// Files that must be created with execute bits.
+	mExecutables sync.Mutex
+	executables  map[string]struct{}
 }
 
 // New returns a new FileStorage at the destination.
-func New(dest string, perm fs.FileMode) (*FileStorage, error) {
+// Execute bits in perm are removed for files that are not executable.
+// If sync is true, files are opened with O_SYNC flag.
+// If lazy is true, missing files are not created until the first write.
+func New(dest string, perm, dirPerm fs.FileMode, sync, lazy bool) (*FileStorage, error) {
 	var err error
 	dest, err = filepath.Abs(dest)
 	if err != nil {
 		return nil, err
 	}
-	return &FileStorage{dest: dest, perm: perm}, nil
+	return &FileStorage{
+		dest:        dest,
+		perm:        perm,
+		dirPerm:     dirPerm,
+		sync:        sync,
+		lazy:        lazy,
+		executables: make(map[string]struct{}),
+	}, nil
 }
 
-var _ storage.Storage = (*FileStorage)(nil)
+var (
+	_ storage.Storage    = (*FileStorage)(nil)
+	_ storage.Attributer = (*FileStorage)(nil)
+)
 
 // Open a file.
+// If the storage is lazy and the file does not exist, it is created on first write.
 func (s *FileStorage) Open(name string, size int64) (f storage.File, exists bool, err error) {
-	name = filepath.Clean(name)
-
 	// All files are saved under dest.
-	name = filepath.Join(s.dest, name)
+	name, err = s.path(name)
+	if err != nil {
+		return
+	}
 
+	if s.lazy && size > 0 {
+		_, err = os.Stat(name)
+		if os.IsNotExist(err) {
+			return &lazyFile{storage: s, name: name, size: size}, false, nil
+		}
+		if err != nil {
+			return
+		}
+	}
+	of, exists, err := s.open(name, size)
+	if err != nil {
+		return nil, false, err
+	}
+	return of, exists, nil
+}
+
+func (s *FileStorage) open(name string, size int64) (f *os.File, exists bool, err error) {
 	// Create containing dir if not exists.
-	err = os.MkdirAll(filepath.Dir(name), os.ModeDir|s.perm)
+	err = os.MkdirAll(filepath.Dir(name), os.ModeDir|s.dirPerm)
 	if err != nil {
 		return
 	}
@@ -55,7 +97,15 @@ func (s *FileStorage) Open(name string, size int64) (f storage.File, exists bool
 
 	// Open OS file.
 	var mode = s.perm &^ 0111
-	openFlags := os.O_RDWR | os.O_SYNC
+	s.mExecutables.Lock()
+	if _, ok := s.executables[name]; ok {
+		mode = s.perm
+	}
+	s.mExecutables.Unlock()
+	openFlags := os.O_RDWR
+	if s.sync {
+		openFlags |= os.O_SYNC
+	}
 	openFlags = applyNoAtimeFlag(openFlags)
 	of, err = os.OpenFile(name, openFlags, mode)
 	if os.IsNotExist(err) {
@@ -81,6 +131,84 @@ func (s *FileStorage) Open(name string, size int64) (f storage.File, exists bool
 	return
 }
 
+// Symlink creates a relative symbolic link at name that points to target.
+// An existing empty regular file at name is replaced with the link.
+func (s *FileStorage) Symlink(name, target string) error {
+	name, err := s.path(name)
+	if err != nil {
+		return err
+	}
+	target, err = s.path(target)
+	if err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(filepath.Dir(name), target)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(name), os.ModeDir|s.dirPerm)
+	if err != nil {
+		return err
+	}
+	fi, err := os.Lstat(name)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return err
+	case fi.Mode()&fs.ModeSymlink != 0:
+		existing, err := os.Readlink(name)
+		if err != nil {
+			return err
+		}
+		if existing == rel {
+			return nil
+		}
+		return fmt.Errorf("symlink points to a different target: %s", name)
+	case fi.Mode().IsRegular() && fi.Size() == 0:
+		err = os.Remove(name)
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("cannot create symlink, file exists: %s", name)
+	}
+	return os.Symlink(rel, name)
+}
+
+// SetExecutable sets the execute bits of the file at name from the permissions of the storage.
+// If the file is not created yet, it is created with execute bits.
+func (s *FileStorage) SetExecutable(name string) error {
+	name, err := s.path(name)
+	if err != nil {
+		return err
+	}
+	s.mExecutables.Lock()
+	s.executables[name] = struct{}{}
+	s.mExecutables.Unlock()
+	err = os.Chmod(name, s.perm)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// path returns the absolute path of name in storage.
+// An error is returned if the path is absolute or it points to a location outside of the destination directory.
+func (s *FileStorage) path(name string) (string, error) {
+	if filepath.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return "", fmt.Errorf("absolute path is not allowed: %q", name)
+	}
+	p := filepath.Join(s.dest, name)
+	rel, err := filepath.Rel(s.dest, p)
+	if err != nil {
+		return "", err
+	}
+	if rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path is outside of destination: %q", name)
+	}
+	return p, nil
+}
+
 // RootDir is the root of opened storage file.
 func (s *FileStorage) RootDir() string {
 	return s.dest
@@ -0,0 +1,150 @@
+package filestorage
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSymlink(t *testing.T) {
+	dir := t.TempDir()
+	s, err := New(dir, 0o750, 0o750, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, _, err := s.Open(filepath.Join("torrent", "a", "file"), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	link := filepath.Join("torrent", "b", "link")
+	target := filepath.Join("torrent", "a", "file")
+	assert.Nil(t, s.Symlink(link, target))
+	dest, err := os.Readlink(filepath.Join(dir, link))
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join("..", "a", "file"), dest)
+
+	// Creating the same link again is allowed.
+	assert.Nil(t, s.Symlink(link, target))
+	// Link to a different target is not.
+	assert.NotNil(t, s.Symlink(link, filepath.Join("torrent", "other")))
+	// Non-empty files are not replaced.
+	assert.NotNil(t, s.Symlink(target, link))
+}
+
+func TestSetExecutable(t *testing.T) {
+	dir := t.TempDir()
+	s, err := New(dir, 0o750, 0o750, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, _, err := s.Open("file", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	fi, err := os.Stat(filepath.Join(dir, "file"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, os.FileMode(0o640), fi.Mode().Perm()&0o750)
+
+	assert.Nil(t, s.SetExecutable("file"))
+	fi, err = os.Stat(filepath.Join(dir, "file"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, os.FileMode(0o750), fi.Mode().Perm())
+}
+
+func TestPathTraversal(t *testing.T) {
+	dir := t.TempDir()
+	s, err := New(filepath.Join(dir, "dest"), 0o750, 0o750, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{
+		"",
+		".",
+		"..",
+		filepath.Join("..", "file"),
+		filepath.Join("a", "..", "..", "file"),
+		filepath.Join(dir, "file"),
+	} {
+		_, _, err = s.Open(name, 1)
+		assert.Error(t, err, name)
+	}
+	_, err = os.Stat(filepath.Join(dir, "file"))
+	assert.True(t, os.IsNotExist(err))
+
+	f, _, err := s.Open(filepath.Join("a", "..", "file"), 1)
+	if assert.NoError(t, err) {
+		f.Close()
+	}
+	assert.Error(t, s.Symlink("link", filepath.Join("..", "file")))
+}
+
+func TestDirPermissions(t *testing.T) {
+	dir := t.TempDir()
+	s, err := New(dir, 0o640, 0o700, true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, _, err := s.Open(filepath.Join("a", "file"), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	fi, err := os.Stat(filepath.Join(dir, "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, os.FileMode(0o700), fi.Mode().Perm())
+}
+
+func TestLazyFile(t *testing.T) {
+	dir := t.TempDir()
+	s, err := New(dir, 0o750, 0o750, false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := filepath.Join(dir, "a", "file")
+	f, exists, err := s.Open(filepath.Join("a", "file"), 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	assert.False(t, exists)
+	_, err = os.Stat(name)
+	assert.True(t, os.IsNotExist(err))
+
+	b := []byte{1, 2, 3, 4}
+	n, err := f.ReadAt(b, 1)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, []byte{0, 0, 0, 4}, b)
+
+	_, err = f.WriteAt([]byte{5}, 2)
+	assert.NoError(t, err)
+	fi, err := os.Stat(name)
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(4), fi.Size())
+	}
+	n, err = f.ReadAt(b, 0)
+	assert.Equal(t, 4, n)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0, 0, 5, 0}, b)
+
+	// Empty files are created immediately.
+	f2, _, err := s.Open("empty", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f2.Close()
+	_, err = os.Stat(filepath.Join(dir, "empty"))
+	assert.NoError(t, err)
+}
@@ -0,0 +1,81 @@
+package filestorage
+
+import (
+	"io"
+	"os"
+	"sync"
+)
+
+// lazyFile is a file that is not created on disk until the first write.
+// Reads before the first write return zeros.
+type lazyFile struct {
+	storage *FileStorage
+	name    string
+	size    int64
+
+	m      sync.Mutex
+	f      *os.File
+	closed bool
+}
+
+func (l *lazyFile) file(create bool) (*os.File, error) {
+	l.m.Lock()
+	defer l.m.Unlock()
+	if l.closed {
+		return nil, os.ErrClosed
+	}
+	if l.f != nil || !create {
+		return l.f, nil
+	}
+	f, _, err := l.storage.open(l.name, l.size)
+	if err != nil {
+		return nil, err
+	}
+	l.f = f
+	return f, nil
+}
+
+// ReadAt implements io.ReaderAt interface.
+func (l *lazyFile) ReadAt(p []byte, off int64) (int, error) {
+	f, err := l.file(false)
+	if err != nil {
+		return 0, err
+	}
+	if f != nil {
+		return f.ReadAt(p, off)
+	}
+	if off >= l.size {
+		return 0, io.EOF
+	}
+	n := len(p)
+	if int64(n) > l.size-off {
+		n = int(l.size - off)
+	}
+	for i := range p[:n] {
+		p[i] = 0
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// WriteAt implements io.WriterAt interface.
+func (l *lazyFile) WriteAt(p []byte, off int64) (int, error) {
+	f, err := l.file(true)
+	if err != nil {
+		return 0, err
+	}
+	return f.WriteAt(p, off)
+}
+
+// Close implements io.Closer interface.
+func (l *lazyFile) Close() error {
+	l.m.Lock()
+	defer l.m.Unlock()
+	l.closed = true
+	if l.f == nil {
+		return nil
+	}
+	return l.f.Close()
+}
@@ -0,0 +1,309 @@
+package s3storage
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// partSize is the size of the parts for multipart uploads.
+// Objects smaller than this are uploaded with a single request.
+var partSize int64 = 64 << 20
+
+// Client makes requests to an S3-compatible object storage service.
+// Objects are addressed with path-style URLs, so it works with services other than AWS that do not support virtual-hosted buckets.
+// Requests are signed with AWS Signature Version 4.
+type Client struct {
+	endpoint  *url.URL
+	region    string
+	bucket    string
+	accessKey string
+	secretKey string
+
+	httpClient *http.Client
+}
+
+// NewClient returns a new Client for the bucket at endpoint.
+// Endpoint is a URL like "https://s3.amazonaws.com".
+func NewClient(endpoint, region, bucket, accessKey, secretKey string) (*Client, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid s3 endpoint: %s", endpoint)
+	}
+	if bucket == "" {
+		return nil, errors.New("s3 bucket name is empty")
+	}
+	return &Client{
+		endpoint:   u,
+		region:     region,
+		bucket:     bucket,
+		accessKey:  accessKey,
+		secretKey:  secretKey,
+		httpClient: &http.Client{},
+	}, nil
+}
+
+// Head returns the size of the object at key.
+func (c *Client) Head(key string) (size int64, exists bool, err error) {
+	resp, err := c.do(http.MethodHead, key, nil, nil, nil, 0)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.ContentLength, true, nil
+	case http.StatusNotFound:
+		return 0, false, nil
+	default:
+		return 0, false, responseError(resp)
+	}
+}
+
+// ReadAt reads len(p) bytes of the object at key starting from off.
+func (c *Client) ReadAt(key string, p []byte, off int64) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	h := http.Header{}
+	h.Set("Range", "bytes="+strconv.FormatInt(off, 10)+"-"+strconv.FormatInt(off+int64(len(p))-1, 10))
+	resp, err := c.do(http.MethodGet, key, nil, h, nil, 0)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusPartialContent {
+		return 0, responseError(resp)
+	}
+	return io.ReadFull(resp.Body, p)
+}
+
+// Put uploads size bytes from r as the object at key.
+// Large objects are uploaded in parts.
+func (c *Client) Put(key string, r io.ReaderAt, size int64) error {
+	if size <= partSize {
+		return c.putObject(key, io.NewSectionReader(r, 0, size), size)
+	}
+	return c.putMultipart(key, r, size)
+}
+
+func (c *Client) putObject(key string, body io.Reader, size int64) error {
+	resp, err := c.do(http.MethodPut, key, nil, nil, body, size)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+	return nil
+}
+
+type initiateMultipartUploadResult struct {
+	UploadID string `xml:"UploadId"`
+}
+
+type completeMultipartUpload struct {
+	XMLName xml.Name       `xml:"CompleteMultipartUpload"`
+	Parts   []completePart `xml:"Part"`
+}
+
+type completePart struct {
+	PartNumber int
+	ETag       string
+}
+
+func (c *Client) putMultipart(key string, r io.ReaderAt, size int64) error {
+	resp, err := c.do(http.MethodPost, key, url.Values{"uploads": {""}}, nil, nil, 0)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+	var result initiateMultipartUploadResult
+	err = xml.NewDecoder(resp.Body).Decode(&result)
+	if err != nil {
+		return err
+	}
+	err = c.uploadParts(key, result.UploadID, r, size)
+	if err != nil {
+		c.abortMultipart(key, result.UploadID)
+	}
+	return err
+}
+
+func (c *Client) uploadParts(key, uploadID string, r io.ReaderAt, size int64) error {
+	var complete completeMultipartUpload
+	for off, num := int64(0), 1; off < size; off, num = off+partSize, num+1 {
+		n := size - off
+		if n > partSize {
+			n = partSize
+		}
+		q := url.Values{"partNumber": {strconv.Itoa(num)}, "uploadId": {uploadID}}
+		resp, err := c.do(http.MethodPut, key, q, nil, io.NewSectionReader(r, off, n), n)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return responseError(resp)
+		}
+		complete.Parts = append(complete.Parts, completePart{PartNumber: num, ETag: resp.Header.Get("ETag")})
+	}
+	b, err := xml.Marshal(complete)
+	if err != nil {
+		return err
+	}
+	resp, err := c.do(http.MethodPost, key, url.Values{"uploadId": {uploadID}}, nil, bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+	// Errors during completion may be reported with 200 status.
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if bytes.Contains(body, []byte("<Error>")) {
+		return fmt.Errorf("s3 error: %s", body)
+	}
+	return nil
+}
+
+func (c *Client) abortMultipart(key, uploadID string) {
+	resp, err := c.do(http.MethodDelete, key, url.Values{"uploadId": {uploadID}}, nil, nil, 0)
+	if err != nil {
+		return
+	}
+	resp.Body.Close()
+}
+
+func responseError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if len(body) == 0 {
+		return fmt.Errorf("s3 error: %s", resp.Status)
+	}
+	return fmt.Errorf("s3 error: %s: %s", resp.Status, body)
+}
+
+// do sends a signed request for the object at key.
+// The payload is not signed, so the body can be streamed from disk.
+func (c *Client) do(method, key string, query url.Values, header http.Header, body io.Reader, size int64) (*http.Response, error) {
+	u := *c.endpoint
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + c.bucket + "/" + key
+	u.RawPath = escapePath(u.Path)
+	u.RawQuery = canonicalQuery(query)
+	req, err := http.NewRequest(method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if body != nil {
+		req.ContentLength = size
+	}
+	c.sign(req, time.Now().UTC())
+	return c.httpClient.Do(req)
+}
+
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+func (c *Client) sign(req *http.Request, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + unsignedPayload,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		unsignedPayload,
+	}, "\n")
+	scope := date + "/" + c.region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hexSHA256([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+c.secretKey), date)
+	key = hmacSHA256(key, c.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+c.accessKey+"/"+scope+", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func hexSHA256(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// escapePath escapes each segment of the path as required by the signature.
+func escapePath(p string) string {
+	segments := strings.Split(p, "/")
+	for i, s := range segments {
+		segments[i] = escape(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+// canonicalQuery returns the query string with sorted keys as required by the signature.
+func canonicalQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		for _, v := range q[k] {
+			parts = append(parts, escape(k)+"="+escape(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// escape encodes all characters except the unreserved ones in RFC 3986.
+func escape(s string) string {
+	var sb strings.Builder
+	for _, b := range []byte(s) {
+		switch {
+		case 'A' <= b && b <= 'Z', 'a' <= b && b <= 'z', '0' <= b && b <= '9', b == '-', b == '.', b == '_', b == '~':
+			sb.WriteByte(b)
+		default:
+			fmt.Fprintf(&sb, "%%%02X", b)
+		}
+	}
+	return sb.String()
+}
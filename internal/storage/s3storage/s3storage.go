@@ -0,0 +1,173 @@
+// Package s3storage implements Storage interface that saves files in an S3-compatible object storage bucket.
+package s3storage
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+
+	"github.com/cenkalti/rain/internal/storage"
+	"github.com/cenkalti/rain/internal/storage/filestorage"
+)
+
+// S3Storage implements Storage interface for saving files in a bucket.
+// Objects cannot be written partially, so pieces are written to files in a local staging storage first.
+// A file is uploaded to the bucket when all of its pieces are downloaded and it is removed from the staging storage after that.
+// Files that already exist in the bucket are read directly from there.
+type S3Storage struct {
+	client  *Client
+	prefix  string
+	staging *filestorage.FileStorage
+}
+
+// New returns a new S3Storage that saves files under prefix in the bucket.
+// Pieces of incomplete files are kept in staging.
+func New(client *Client, prefix string, staging *filestorage.FileStorage) *S3Storage {
+	return &S3Storage{
+		client:  client,
+		prefix:  prefix,
+		staging: staging,
+	}
+}
+
+var _ storage.Storage = (*S3Storage)(nil)
+
+// Open a file.
+// The file exists if it is in the bucket or some of its pieces are in the staging storage.
+func (s *S3Storage) Open(name string, size int64) (f storage.File, exists bool, err error) {
+	key := path.Join(s.prefix, filepath.ToSlash(name))
+	stagingPath := filepath.Join(s.staging.RootDir(), name)
+
+	remoteSize, exists, err := s.client.Head(key)
+	if err != nil {
+		return
+	}
+	if exists && remoteSize == size {
+		// Staging file may be left if the program is closed after upload.
+		err = os.Remove(stagingPath)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, false, err
+		}
+		return &file{client: s.client, key: key}, true, nil
+	}
+	if size == 0 {
+		// There are no pieces to write for an empty file.
+		err = s.client.Put(key, bytes.NewReader(nil), 0)
+		if err != nil {
+			return
+		}
+		return &file{client: s.client, key: key}, false, nil
+	}
+
+	_, err = os.Stat(stagingPath)
+	exists = err == nil
+	if err != nil && !os.IsNotExist(err) {
+		return
+	}
+	sf, _, err := s.staging.Open(name, size)
+	if err != nil {
+		return
+	}
+	return &file{client: s.client, key: key, size: size, staging: sf, stagingPath: stagingPath}, exists, nil
+}
+
+// RootDir returns the directory of the staging storage.
+func (s *S3Storage) RootDir() string {
+	return s.staging.RootDir()
+}
+
+var errUploaded = errors.New("file is already uploaded")
+
+type file struct {
+	client *Client
+	key    string
+	size   int64
+
+	// Protects fields below.
+	// Read lock is held while reading from the staging file, so it is not closed during the read.
+	m           sync.RWMutex
+	staging     storage.File
+	stagingPath string
+	// Closed when the upload started by Complete finishes.
+	uploadDoneC chan struct{}
+	uploadErr   error
+}
+
+var (
+	_ storage.File      = (*file)(nil)
+	_ storage.Completer = (*file)(nil)
+)
+
+func (f *file) ReadAt(p []byte, off int64) (int, error) {
+	f.m.RLock()
+	if f.staging != nil {
+		defer f.m.RUnlock()
+		return f.staging.ReadAt(p, off)
+	}
+	f.m.RUnlock()
+	return f.client.ReadAt(f.key, p, off)
+}
+
+func (f *file) WriteAt(p []byte, off int64) (int, error) {
+	f.m.RLock()
+	defer f.m.RUnlock()
+	if f.staging == nil {
+		return 0, errUploaded
+	}
+	return f.staging.WriteAt(p, off)
+}
+
+// Complete starts uploading the file to the bucket in background.
+// The file is read from the staging storage until the upload finishes.
+// If the upload fails, error is returned from Close and the upload is retried on next call.
+func (f *file) Complete() error {
+	f.m.Lock()
+	defer f.m.Unlock()
+	if f.staging == nil || f.uploadDoneC != nil {
+		return nil
+	}
+	f.uploadDoneC = make(chan struct{})
+	f.uploadErr = nil
+	go f.upload(f.staging, f.uploadDoneC)
+	return nil
+}
+
+func (f *file) upload(sf storage.File, doneC chan struct{}) {
+	err := f.client.Put(f.key, sf, f.size)
+	f.m.Lock()
+	defer f.m.Unlock()
+	defer close(doneC)
+	f.uploadDoneC = nil
+	if err != nil {
+		f.uploadErr = err
+		return
+	}
+	_ = f.staging.Close()
+	f.staging = nil
+	err = os.Remove(f.stagingPath)
+	if err != nil && !os.IsNotExist(err) {
+		f.uploadErr = err
+	}
+}
+
+// Close waits for the upload in progress and closes the staging file.
+func (f *file) Close() error {
+	f.m.RLock()
+	doneC := f.uploadDoneC
+	f.m.RUnlock()
+	if doneC != nil {
+		<-doneC
+	}
+	f.m.Lock()
+	defer f.m.Unlock()
+	if f.staging != nil {
+		err := f.staging.Close()
+		if err != nil && f.uploadErr == nil {
+			return err
+		}
+	}
+	return f.uploadErr
+}
@@ -9,9 +9,23 @@ type Storage interface {
 	RootDir() string
 }
 
+// Attributer is an optional interface implemented by Storage types that support file attributes in BEP 47.
+type Attributer interface {
+	// Symlink creates a symbolic link at name that points to target. Both paths are relative to the storage root.
+	Symlink(name, target string) error
+	// SetExecutable makes the file at name executable.
+	SetExecutable(name string) error
+}
+
 // File interface for reading/writing torrent data.
 type File interface {
 	io.ReaderAt
 	io.WriterAt
 	io.Closer
 }
+
+// Completer is an optional interface implemented by File types that need to know when all pieces of the file are downloaded.
+type Completer interface {
+	// Complete is called when all pieces of the file are written. It may be called more than once.
+	Complete() error
+}
@@ -0,0 +1,205 @@
+package webdavstorage
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cenkalti/backoff/v3"
+	"github.com/cenkalti/rain/internal/semaphore"
+)
+
+// Client makes requests to a WebDAV server.
+// Failed requests are retried with exponential backoff if the error is temporary.
+type Client struct {
+	baseURL      *url.URL
+	username     string
+	password     string
+	retryTimeout time.Duration
+
+	// Limits the number of concurrent requests to the server.
+	sem *semaphore.Semaphore
+
+	httpClient *http.Client
+}
+
+// NewClient returns a new Client for the collection at baseURL.
+// At most maxConnections requests are made at the same time.
+// A request is retried until retryTimeout passes.
+func NewClient(baseURL, username, password string, maxConnections int, retryTimeout time.Duration) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid webdav url: %s", baseURL)
+	}
+	if maxConnections <= 0 {
+		return nil, fmt.Errorf("invalid webdav max connections: %d", maxConnections)
+	}
+	return &Client{
+		baseURL:      u,
+		username:     username,
+		password:     password,
+		retryTimeout: retryTimeout,
+		sem:          semaphore.New(maxConnections),
+		httpClient:   &http.Client{},
+	}, nil
+}
+
+// BaseURL returns the URL of the collection that paths are relative to.
+func (c *Client) BaseURL() string {
+	return c.baseURL.String()
+}
+
+type response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// StatusError is returned when the server responds with an unexpected status code.
+type StatusError struct {
+	Method     string
+	Path       string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("webdav %s %s: %d %s", e.Method, e.Path, e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// Stat returns the size of the file at p.
+func (c *Client) Stat(p string) (size int64, exists bool, err error) {
+	resp, err := c.do(http.MethodHead, p, nil, nil, http.StatusOK, http.StatusNotFound)
+	if err != nil {
+		return
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return 0, false, nil
+	}
+	size, err = strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64)
+	return size, true, err
+}
+
+// ReadAt reads len(b) bytes of the file at p starting from off.
+// Parts of the file that are not written yet are read as zeros.
+func (c *Client) ReadAt(p string, b []byte, off int64) (int, error) {
+	if len(b) == 0 {
+		return 0, nil
+	}
+	h := http.Header{}
+	h.Set("Range", "bytes="+strconv.FormatInt(off, 10)+"-"+strconv.FormatInt(off+int64(len(b))-1, 10))
+	resp, err := c.do(http.MethodGet, p, h, nil, http.StatusOK, http.StatusPartialContent, http.StatusNotFound, http.StatusRequestedRangeNotSatisfiable)
+	if err != nil {
+		return 0, err
+	}
+	var data []byte
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		data = resp.Body
+	case http.StatusOK:
+		// Server does not support ranges.
+		if off < int64(len(resp.Body)) {
+			data = resp.Body[off:]
+		}
+	}
+	n := copy(b, data)
+	for i := n; i < len(b); i++ {
+		b[i] = 0
+	}
+	return len(b), nil
+}
+
+// WriteAt writes b into the file at p starting from off.
+// The file is created if it does not exist.
+// It uses the Content-Range header in PUT requests for partial updates, which is supported by Apache mod_dav.
+func (c *Client) WriteAt(p string, b []byte, off int64) (int, error) {
+	h := http.Header{}
+	h.Set("Content-Range", "bytes "+strconv.FormatInt(off, 10)+"-"+strconv.FormatInt(off+int64(len(b))-1, 10)+"/*")
+	_, err := c.do(http.MethodPut, p, h, b, http.StatusOK, http.StatusCreated, http.StatusNoContent)
+	if err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// Create an empty file at p.
+func (c *Client) Create(p string) error {
+	_, err := c.do(http.MethodPut, p, nil, []byte{}, http.StatusOK, http.StatusCreated, http.StatusNoContent)
+	return err
+}
+
+// Mkcol creates the collection at p. It is not an error if the collection exists.
+func (c *Client) Mkcol(p string) error {
+	_, err := c.do("MKCOL", p, nil, nil, http.StatusCreated, http.StatusMethodNotAllowed)
+	return err
+}
+
+// do sends a request for the resource at p and returns an error if the response status is not one of the expected codes.
+// Requests failing with network errors or server errors are retried.
+func (c *Client) do(method, p string, header http.Header, body []byte, codes ...int) (*response, error) {
+	u := *c.baseURL
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + p
+	u.RawPath = ""
+	var resp *response
+	op := func() error {
+		var err error
+		resp, err = c.send(method, u.String(), header, body)
+		if err != nil {
+			return err
+		}
+		for _, code := range codes {
+			if resp.StatusCode == code {
+				return nil
+			}
+		}
+		err = &StatusError{Method: method, Path: p, StatusCode: resp.StatusCode}
+		if resp.StatusCode >= 500 {
+			return err
+		}
+		return backoff.Permanent(err)
+	}
+	bo := backoff.NewExponentialBackOff()
+	bo.MaxElapsedTime = c.retryTimeout
+	err := backoff.Retry(op, bo)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *Client) send(method, u string, header http.Header, body []byte) (*response, error) {
+	c.sem.Wait()
+	defer c.sem.Signal()
+
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, u, r)
+	if err != nil {
+		return nil, backoff.Permanent(err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if c.username != "" || c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &response{StatusCode: resp.StatusCode, Header: resp.Header, Body: b}, nil
+}
@@ -0,0 +1,118 @@
+// Package webdavstorage implements Storage interface that saves files on a WebDAV server.
+package webdavstorage
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/cenkalti/rain/internal/storage"
+)
+
+// WebDAVStorage implements Storage interface for saving files on a WebDAV server, like a NAS.
+// Pieces are written directly to the server without a local copy.
+// Missing files are created on the first write, so their parts that are not written yet are read as zeros.
+type WebDAVStorage struct {
+	client *Client
+	dest   string
+
+	// Collections that are known to exist on the server.
+	mCollections sync.Mutex
+	collections  map[string]struct{}
+}
+
+// New returns a new WebDAVStorage that saves files under dest collection.
+// Dest is relative to the base URL of the client.
+func New(client *Client, dest string) *WebDAVStorage {
+	return &WebDAVStorage{
+		client:      client,
+		dest:        strings.Trim(dest, "/"),
+		collections: make(map[string]struct{}),
+	}
+}
+
+var _ storage.Storage = (*WebDAVStorage)(nil)
+
+// Open a file.
+func (s *WebDAVStorage) Open(name string, size int64) (f storage.File, exists bool, err error) {
+	p := path.Join(s.dest, filepath.ToSlash(name))
+	_, exists, err = s.client.Stat(p)
+	if err != nil {
+		return
+	}
+	if !exists && size == 0 {
+		// There are no pieces to write for an empty file.
+		err = s.mkdirAll(path.Dir(p))
+		if err != nil {
+			return
+		}
+		err = s.client.Create(p)
+		if err != nil {
+			return
+		}
+	}
+	return &file{storage: s, path: p, dirCreated: exists}, exists, nil
+}
+
+// RootDir returns the URL of the destination collection.
+func (s *WebDAVStorage) RootDir() string {
+	return strings.TrimSuffix(s.client.BaseURL(), "/") + "/" + s.dest
+}
+
+// mkdirAll creates the collection at p with its parents.
+func (s *WebDAVStorage) mkdirAll(p string) error {
+	if p == "." || p == "" {
+		return nil
+	}
+	s.mCollections.Lock()
+	defer s.mCollections.Unlock()
+	var parts []string
+	for _, part := range strings.Split(p, "/") {
+		parts = append(parts, part)
+		dir := strings.Join(parts, "/")
+		if _, ok := s.collections[dir]; ok {
+			continue
+		}
+		err := s.client.Mkcol(dir)
+		if err != nil {
+			return err
+		}
+		s.collections[dir] = struct{}{}
+	}
+	return nil
+}
+
+type file struct {
+	storage *WebDAVStorage
+	path    string
+
+	// Protects dirCreated.
+	m sync.Mutex
+	// Parent collections of the file exist on the server.
+	dirCreated bool
+}
+
+var _ storage.File = (*file)(nil)
+
+func (f *file) ReadAt(p []byte, off int64) (int, error) {
+	return f.storage.client.ReadAt(f.path, p, off)
+}
+
+func (f *file) WriteAt(p []byte, off int64) (int, error) {
+	f.m.Lock()
+	if !f.dirCreated {
+		err := f.storage.mkdirAll(path.Dir(f.path))
+		if err != nil {
+			f.m.Unlock()
+			return 0, err
+		}
+		f.dirCreated = true
+	}
+	f.m.Unlock()
+	return f.storage.client.WriteAt(f.path, p, off)
+}
+
+func (f *file) Close() error {
+	return nil
+}
@@ -0,0 +1,183 @@
+package webdavstorage
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeServer implements the subset of WebDAV used by Client.
+type fakeServer struct {
+	m           sync.Mutex
+	files       map[string][]byte
+	collections map[string]bool
+	// Number of requests to fail with 503 before serving.
+	failures int
+}
+
+func newFakeServer() *fakeServer {
+	return &fakeServer{
+		files:       make(map[string][]byte),
+		collections: map[string]bool{"/dav": true},
+	}
+}
+
+func (s *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if s.failures > 0 {
+		s.failures--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	if user, pass, _ := r.BasicAuth(); user != "user" || pass != "pass" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	p := r.URL.Path
+	switch r.Method {
+	case http.MethodHead:
+		b, ok := s.files[p]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(b)))
+	case http.MethodGet:
+		b, ok := s.files[p]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var begin, end int
+		fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-%d", &begin, &end)
+		if begin >= len(b) {
+			w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+		if end >= len(b) {
+			end = len(b) - 1
+		}
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write(b[begin : end+1])
+	case http.MethodPut:
+		if !s.collections[path.Dir(p)] {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		data, _ := io.ReadAll(r.Body)
+		b := s.files[p]
+		var begin, end int
+		if cr := r.Header.Get("Content-Range"); cr != "" {
+			fmt.Sscanf(cr, "bytes %d-%d/*", &begin, &end)
+		}
+		if len(b) < begin+len(data) {
+			b = append(b, make([]byte, begin+len(data)-len(b))...)
+		}
+		copy(b[begin:], data)
+		s.files[p] = b
+		w.WriteHeader(http.StatusCreated)
+	case "MKCOL":
+		if s.collections[p] {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if !s.collections[path.Dir(p)] {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		s.collections[p] = true
+		w.WriteHeader(http.StatusCreated)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func newTestStorage(t *testing.T, fake *fakeServer) *WebDAVStorage {
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	client, err := NewClient(srv.URL+"/dav/", "user", "pass", 2, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return New(client, "torrents")
+}
+
+func TestWriteRead(t *testing.T) {
+	fake := newFakeServer()
+	s := newTestStorage(t, fake)
+
+	f, exists, err := s.Open(filepath.Join("dir", "file"), 10)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+	assert.Empty(t, fake.files)
+
+	b := make([]byte, 4)
+	_, err = f.ReadAt(b, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, make([]byte, 4), b)
+
+	_, err = f.WriteAt([]byte("567"), 5)
+	assert.NoError(t, err)
+	assert.True(t, fake.collections["/dav/torrents/dir"])
+	_, err = f.ReadAt(b, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("\x00567"), b)
+
+	_, err = f.ReadAt(b, 8)
+	assert.NoError(t, err)
+	assert.Equal(t, make([]byte, 4), b)
+	assert.NoError(t, f.Close())
+
+	_, exists, err = s.Open(filepath.Join("dir", "file"), 10)
+	assert.NoError(t, err)
+	assert.True(t, exists)
+}
+
+func TestEmptyFile(t *testing.T) {
+	fake := newFakeServer()
+	s := newTestStorage(t, fake)
+
+	_, exists, err := s.Open("empty", 0)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+	_, ok := fake.files["/dav/torrents/empty"]
+	assert.True(t, ok)
+}
+
+func TestRetry(t *testing.T) {
+	fake := newFakeServer()
+	fake.failures = 2
+	s := newTestStorage(t, fake)
+
+	f, _, err := s.Open("file", 3)
+	assert.NoError(t, err)
+	_, err = f.WriteAt([]byte("abc"), 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("abc"), fake.files["/dav/torrents/file"])
+}
+
+func TestPermanentError(t *testing.T) {
+	fake := newFakeServer()
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+	client, err := NewClient(srv.URL+"/dav/", "user", "wrong", 2, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = New(client, "torrents").Open("file", 3)
+	if assert.Error(t, err) {
+		serr, ok := err.(*StatusError)
+		assert.True(t, ok)
+		assert.Equal(t, http.StatusUnauthorized, serr.StatusCode)
+	}
+}
@@ -0,0 +1,427 @@
+// Package toml implements a decoder for the subset of TOML that is used in config files.
+// Date and time values and multi-line strings are not supported.
+package toml
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Unmarshal parses the TOML document in b.
+// Tables are returned as map[string]interface{} and arrays as []interface{}.
+// Integers are returned as int64 and floats as float64.
+func Unmarshal(b []byte) (map[string]interface{}, error) {
+	p := &parser{s: b}
+	root := make(map[string]interface{})
+	cur := root
+	for {
+		p.skipBlank()
+		if p.eof() {
+			return root, nil
+		}
+		var err error
+		if p.peek() == '[' {
+			array := p.consume("[[")
+			if !array {
+				p.pos++
+			}
+			p.skipSpace()
+			var keys []string
+			keys, err = p.parseKey()
+			if err != nil {
+				return nil, err
+			}
+			p.skipSpace()
+			if array {
+				if !p.consume("]]") {
+					return nil, p.errorf("expected ]]")
+				}
+				cur, err = p.arrayTable(root, keys)
+			} else {
+				if !p.consume("]") {
+					return nil, p.errorf("expected ]")
+				}
+				cur, err = p.table(root, keys)
+			}
+		} else {
+			err = p.parseKeyValue(cur)
+		}
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		p.skipComment()
+		if !p.eof() && !p.consume("\n") && !p.consume("\r\n") {
+			return nil, p.errorf("expected new line")
+		}
+	}
+}
+
+type parser struct {
+	s   []byte
+	pos int
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	line := bytes.Count(p.s[:p.pos], []byte("\n")) + 1
+	return fmt.Errorf("toml: line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.s)
+}
+
+func (p *parser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.s[p.pos]
+}
+
+// consume advances past prefix if the remaining input starts with it.
+func (p *parser) consume(prefix string) bool {
+	if !bytes.HasPrefix(p.s[p.pos:], []byte(prefix)) {
+		return false
+	}
+	p.pos += len(prefix)
+	return true
+}
+
+func (p *parser) skipSpace() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.pos++
+	}
+}
+
+func (p *parser) skipComment() {
+	if p.peek() != '#' {
+		return
+	}
+	for !p.eof() && p.peek() != '\n' {
+		p.pos++
+	}
+}
+
+// skipBlank skips whitespace, new lines and comments.
+func (p *parser) skipBlank() {
+	for {
+		p.skipSpace()
+		p.skipComment()
+		if !p.consume("\n") && !p.consume("\r\n") {
+			return
+		}
+	}
+}
+
+// parseKey parses a dotted key.
+func (p *parser) parseKey() ([]string, error) {
+	var keys []string
+	for {
+		key, err := p.parseSimpleKey()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+		p.skipSpace()
+		if !p.consume(".") {
+			return keys, nil
+		}
+		p.skipSpace()
+	}
+}
+
+func (p *parser) parseSimpleKey() (string, error) {
+	switch p.peek() {
+	case '"':
+		return p.parseBasicString()
+	case '\'':
+		return p.parseLiteralString()
+	}
+	begin := p.pos
+	for !p.eof() && isBareKeyChar(p.peek()) {
+		p.pos++
+	}
+	if p.pos == begin {
+		return "", p.errorf("expected key")
+	}
+	return string(p.s[begin:p.pos]), nil
+}
+
+func isBareKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+func (p *parser) parseKeyValue(m map[string]interface{}) error {
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+	p.skipSpace()
+	if !p.consume("=") {
+		return p.errorf("expected =")
+	}
+	p.skipSpace()
+	v, err := p.parseValue()
+	if err != nil {
+		return err
+	}
+	for _, key := range keys[:len(keys)-1] {
+		m, err = p.subTable(m, key)
+		if err != nil {
+			return err
+		}
+	}
+	key := keys[len(keys)-1]
+	if _, ok := m[key]; ok {
+		return p.errorf("duplicate key: %s", key)
+	}
+	m[key] = v
+	return nil
+}
+
+// subTable returns the table at key in m, creating it if it does not exist.
+// If the key is an array of tables, the last table in the array is returned.
+func (p *parser) subTable(m map[string]interface{}, key string) (map[string]interface{}, error) {
+	switch v := m[key].(type) {
+	case nil:
+		t := make(map[string]interface{})
+		m[key] = t
+		return t, nil
+	case map[string]interface{}:
+		return v, nil
+	case []interface{}:
+		if len(v) > 0 {
+			if t, ok := v[len(v)-1].(map[string]interface{}); ok {
+				return t, nil
+			}
+		}
+	}
+	return nil, p.errorf("key is not a table: %s", key)
+}
+
+func (p *parser) table(root map[string]interface{}, keys []string) (map[string]interface{}, error) {
+	m := root
+	var err error
+	for _, key := range keys {
+		m, err = p.subTable(m, key)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func (p *parser) arrayTable(root map[string]interface{}, keys []string) (map[string]interface{}, error) {
+	m, err := p.table(root, keys[:len(keys)-1])
+	if err != nil {
+		return nil, err
+	}
+	key := keys[len(keys)-1]
+	var a []interface{}
+	if v, ok := m[key]; ok {
+		a, ok = v.([]interface{})
+		if !ok {
+			return nil, p.errorf("key is not an array of tables: %s", key)
+		}
+	}
+	t := make(map[string]interface{})
+	m[key] = append(a, t)
+	return t, nil
+}
+
+func (p *parser) parseValue() (interface{}, error) {
+	switch c := p.peek(); {
+	case c == '"':
+		if p.consume(`"""`) {
+			return nil, p.errorf("multi-line strings are not supported")
+		}
+		return p.parseBasicString()
+	case c == '\'':
+		if p.consume("'''") {
+			return nil, p.errorf("multi-line strings are not supported")
+		}
+		return p.parseLiteralString()
+	case c == '[':
+		return p.parseArray()
+	case c == '{':
+		return p.parseInlineTable()
+	case p.consume("true"):
+		return true, nil
+	case p.consume("false"):
+		return false, nil
+	}
+	return p.parseNumber()
+}
+
+func (p *parser) parseBasicString() (string, error) {
+	p.pos++ // opening quote
+	var sb strings.Builder
+	for {
+		if p.eof() || p.peek() == '\n' {
+			return "", p.errorf("unterminated string")
+		}
+		c := p.s[p.pos]
+		p.pos++
+		switch c {
+		case '"':
+			return sb.String(), nil
+		case '\\':
+			if p.eof() {
+				return "", p.errorf("unterminated string")
+			}
+			e := p.s[p.pos]
+			p.pos++
+			switch e {
+			case 'b':
+				sb.WriteByte('\b')
+			case 't':
+				sb.WriteByte('\t')
+			case 'n':
+				sb.WriteByte('\n')
+			case 'f':
+				sb.WriteByte('\f')
+			case 'r':
+				sb.WriteByte('\r')
+			case '"', '\\':
+				sb.WriteByte(e)
+			case 'u', 'U':
+				n := 4
+				if e == 'U' {
+					n = 8
+				}
+				if p.pos+n > len(p.s) {
+					return "", p.errorf("invalid unicode escape")
+				}
+				r, err := strconv.ParseUint(string(p.s[p.pos:p.pos+n]), 16, 32)
+				if err != nil || !utf8.ValidRune(rune(r)) {
+					return "", p.errorf("invalid unicode escape")
+				}
+				p.pos += n
+				sb.WriteRune(rune(r))
+			default:
+				return "", p.errorf("invalid escape character: %q", e)
+			}
+		default:
+			sb.WriteByte(c)
+		}
+	}
+}
+
+func (p *parser) parseLiteralString() (string, error) {
+	p.pos++ // opening quote
+	begin := p.pos
+	for !p.eof() && p.peek() != '\'' {
+		if p.peek() == '\n' {
+			break
+		}
+		p.pos++
+	}
+	if p.peek() != '\'' {
+		return "", p.errorf("unterminated string")
+	}
+	s := string(p.s[begin:p.pos])
+	p.pos++
+	return s, nil
+}
+
+func (p *parser) parseArray() ([]interface{}, error) {
+	p.pos++ // [
+	a := make([]interface{}, 0)
+	for {
+		p.skipBlank()
+		if p.consume("]") {
+			return a, nil
+		}
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		a = append(a, v)
+		p.skipBlank()
+		if p.consume("]") {
+			return a, nil
+		}
+		if !p.consume(",") {
+			return nil, p.errorf("expected , or ]")
+		}
+	}
+}
+
+func (p *parser) parseInlineTable() (map[string]interface{}, error) {
+	p.pos++ // {
+	m := make(map[string]interface{})
+	p.skipSpace()
+	if p.consume("}") {
+		return m, nil
+	}
+	for {
+		p.skipSpace()
+		err := p.parseKeyValue(m)
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if p.consume("}") {
+			return m, nil
+		}
+		if !p.consume(",") {
+			return nil, p.errorf("expected , or }")
+		}
+	}
+}
+
+// isDate returns true if s starts like a date in "2006-01-02" format.
+func isDate(s string) bool {
+	if len(s) < 5 || s[4] != '-' {
+		return false
+	}
+	for i := 0; i < 4; i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *parser) parseNumber() (interface{}, error) {
+	begin := p.pos
+	for !p.eof() && strings.IndexByte("0123456789abcdefABCDEFxoinINX_+-.:", p.peek()) >= 0 {
+		p.pos++
+	}
+	s := string(p.s[begin:p.pos])
+	if s == "" {
+		return nil, p.errorf("invalid value")
+	}
+	if strings.Contains(s, ":") || isDate(s) {
+		return nil, p.errorf("date and time values are not supported: %s", s)
+	}
+	s = strings.ReplaceAll(s, "_", "")
+	switch strings.TrimLeft(s, "+-") {
+	case "inf", "nan":
+		f, _ := strconv.ParseFloat(s, 64)
+		return f, nil
+	}
+	unsigned := strings.TrimLeft(s, "+-")
+	if len(unsigned) > 2 && unsigned[0] == '0' && strings.IndexByte("xob", unsigned[1]) >= 0 {
+		i, err := strconv.ParseInt(s, 0, 64)
+		if err != nil {
+			return nil, p.errorf("invalid integer: %s", s)
+		}
+		return i, nil
+	}
+	if strings.ContainsAny(s, ".eE") {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, p.errorf("invalid float: %s", s)
+		}
+		return f, nil
+	}
+	i, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return nil, p.errorf("invalid integer: %s", s)
+	}
+	return i, nil
+}
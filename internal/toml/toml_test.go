@@ -0,0 +1,82 @@
+package toml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnmarshal(t *testing.T) {
+	doc := `
+# comment
+datadir = "~/rain/data" # trailing comment
+"quoted.key" = 'C:\path'
+portbegin = 20_000
+seedratio = 1.5
+negative = -1e-3
+hex = 0xff
+enabled = true
+cmd = [
+  "sh", "-c",
+  "echo \"done\"\t\u00e7", # comment in array
+]
+nested.key = {a = 1, b = [false]}
+
+[torrentoverrides.0123456789abcdef0123456789abcdef01234567]
+datadir = "/tmp"
+
+[[speedlimitschedules]]
+days = ["mon"]
+
+[[speedlimitschedules]]
+days = []
+`
+	m, err := Unmarshal([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"datadir":    "~/rain/data",
+		"quoted.key": `C:\path`,
+		"portbegin":  int64(20000),
+		"seedratio":  1.5,
+		"negative":   -1e-3,
+		"hex":        int64(255),
+		"enabled":    true,
+		"cmd":        []interface{}{"sh", "-c", "echo \"done\"\tç"},
+		"nested": map[string]interface{}{
+			"key": map[string]interface{}{
+				"a": int64(1),
+				"b": []interface{}{false},
+			},
+		},
+		"torrentoverrides": map[string]interface{}{
+			"0123456789abcdef0123456789abcdef01234567": map[string]interface{}{
+				"datadir": "/tmp",
+			},
+		},
+		"speedlimitschedules": []interface{}{
+			map[string]interface{}{"days": []interface{}{"mon"}},
+			map[string]interface{}{"days": []interface{}{}},
+		},
+	}
+	assert.Equal(t, expected, m)
+}
+
+func TestUnmarshalError(t *testing.T) {
+	cases := []string{
+		"key",
+		"key = ",
+		"key = 1\nkey = 2",
+		"key = \"unterminated",
+		"key = 1979-05-27T07:32:00Z",
+		"key = \"\"\"multi\"\"\"",
+		"[table",
+		"key = 1 2",
+		"key = 1\n[key]",
+	}
+	for _, c := range cases {
+		_, err := Unmarshal([]byte(c))
+		assert.Error(t, err, c)
+	}
+}
@@ -41,6 +41,23 @@ func (p *CompactPeer) UnmarshalBinary(data []byte) error {
 	return binary.Read(bytes.NewReader(data), binary.BigEndian, p)
 }
 
+// DecodePeersCompact6 parses and returns addresses for list of IPv6 peers (BEP 7).
+// Each peer is a 16-bytes IP address followed by a 2-bytes port value.
+func DecodePeersCompact6(b []byte) ([]*net.TCPAddr, error) {
+	const size = net.IPv6len + 2
+	if len(b)%size != 0 {
+		return nil, errors.New("invalid peer6 list length")
+	}
+	addrs := make([]*net.TCPAddr, 0, len(b)/size)
+	for i := 0; i < len(b); i += size {
+		ip := make(net.IP, net.IPv6len)
+		copy(ip, b[i:i+net.IPv6len])
+		port := binary.BigEndian.Uint16(b[i+net.IPv6len : i+size])
+		addrs = append(addrs, &net.TCPAddr{IP: ip, Port: int(port)})
+	}
+	return addrs, nil
+}
+
 // DecodePeersCompact parses and returns addresses for list of CompactPeers.
 func DecodePeersCompact(b []byte) ([]*net.TCPAddr, error) {
 	if len(b)%6 != 0 {
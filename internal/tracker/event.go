@@ -9,6 +9,8 @@ const (
 	EventCompleted
 	EventStarted
 	EventStopped
+	// EventPaused is sent by partial seeds instead of EventNone (BEP 21).
+	EventPaused
 )
 
 var eventNames = [...]string{
@@ -16,6 +18,7 @@ var eventNames = [...]string{
 	"completed",
 	"started",
 	"stopped",
+	"paused",
 }
 
 // String returns the name of event as represented in HTTP tracker protocol.
@@ -6,7 +6,7 @@ import (
 
 type announceResponse struct {
 	FailureReason  string             `bencode:"failure reason"`
-	RetryIn        string             `bencode:"retry in"`
+	RetryIn        bencode.RawMessage `bencode:"retry in"`
 	WarningMessage string             `bencode:"warning message"`
 	Interval       int32              `bencode:"interval"`
 	MinInterval    int32              `bencode:"min interval"`
@@ -14,5 +14,6 @@ type announceResponse struct {
 	Complete       int32              `bencode:"complete"`
 	Incomplete     int32              `bencode:"incomplete"`
 	Peers          bencode.RawMessage `bencode:"peers"`
+	Peers6         bencode.RawMessage `bencode:"peers6"`
 	ExternalIP     []byte             `bencode:"external ip"`
 }
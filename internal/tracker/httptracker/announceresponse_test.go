@@ -0,0 +1,68 @@
+package httptracker
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/rain/internal/tracker"
+	"github.com/stretchr/testify/assert"
+	"github.com/zeebo/bencode"
+)
+
+func TestParsePeers(t *testing.T) {
+	compact := string([]byte{1, 2, 3, 4, 0x1a, 0xe1})
+	b, _ := bencode.EncodeBytes(compact)
+	peers, err := parsePeers(b, tracker.DecodePeersCompact)
+	assert.NoError(t, err)
+	assert.Equal(t, []*net.TCPAddr{{IP: net.IP{1, 2, 3, 4}, Port: 6881}}, peers)
+
+	dict := []map[string]any{
+		{"ip": "1.2.3.4", "port": 6881, "peer id": "abc"},
+		{"ip": "::1", "port": 6882},
+		{"ip": "host.invalid", "port": 6883},
+	}
+	b, _ = bencode.EncodeBytes(dict)
+	peers, err = parsePeers(b, tracker.DecodePeersCompact)
+	assert.NoError(t, err)
+	assert.Len(t, peers, 2)
+	assert.Equal(t, 6882, peers[1].Port)
+
+	compact6 := string(append(net.ParseIP("2001:db8::1"), 0x1a, 0xe1))
+	b, _ = bencode.EncodeBytes(compact6)
+	peers, err = parsePeers(b, tracker.DecodePeersCompact6)
+	assert.NoError(t, err)
+	assert.Equal(t, []*net.TCPAddr{{IP: net.ParseIP("2001:db8::1"), Port: 6881}}, peers)
+
+	peers, err = parsePeers(nil, tracker.DecodePeersCompact)
+	assert.NoError(t, err)
+	assert.Empty(t, peers)
+}
+
+func TestParseRetryIn(t *testing.T) {
+	assert.Equal(t, 5*time.Minute, parseRetryIn(bencode.RawMessage("i5e")))
+	assert.Equal(t, 5*time.Minute, parseRetryIn(bencode.RawMessage("1:5")))
+	assert.Equal(t, time.Duration(0), parseRetryIn(bencode.RawMessage("5:never")))
+	assert.Equal(t, time.Duration(0), parseRetryIn(nil))
+}
+
+func TestScrapeURL(t *testing.T) {
+	cases := []struct {
+		announce, scrape string
+		ok               bool
+	}{
+		{"http://example.com/announce", "http://example.com/scrape", true},
+		{"http://example.com/x/announce", "http://example.com/x/scrape", true},
+		{"http://example.com/announce.php", "http://example.com/scrape.php", true},
+		{"http://example.com/announce?x2%0644", "http://example.com/scrape?x2%0644", true},
+		{"http://example.com/x%064announce", "", false},
+		{"http://example.com/a", "", false},
+		{"http://example.com/announce?x=2/4", "http://example.com/scrape?x=2/4", true},
+		{"http://example.com/x/announce/y", "", false},
+	}
+	for _, c := range cases {
+		s, ok := scrapeURL(c.announce)
+		assert.Equal(t, c.ok, ok, c.announce)
+		assert.Equal(t, c.scrape, s, c.announce)
+	}
+}
@@ -1,7 +1,6 @@
 package httptracker
 
 import (
-	"bytes"
 	"context"
 	"encoding/hex"
 	"fmt"
@@ -27,18 +26,26 @@ type HTTPTracker struct {
 	trackerID         string
 	userAgent         string
 	maxResponseLength int64
+	compact           bool
+	noPeerID          bool
 }
 
-var _ tracker.Tracker = (*HTTPTracker)(nil)
+var (
+	_ tracker.Tracker = (*HTTPTracker)(nil)
+	_ tracker.Scraper = (*HTTPTracker)(nil)
+)
 
 // New returns a new HTTPTracker.
-func New(rawURL string, u *url.URL, timeout time.Duration, t *http.Transport, userAgent string, maxResponseLength int64) *HTTPTracker {
+// compact and noPeerID are sent to the tracker as preferences for the format of the peer list in the response.
+func New(rawURL string, u *url.URL, timeout time.Duration, t *http.Transport, userAgent string, maxResponseLength int64, compact, noPeerID bool) *HTTPTracker {
 	return &HTTPTracker{
 		rawURL:            rawURL,
 		log:               logger.New("tracker " + u.Host),
 		transport:         t,
 		userAgent:         userAgent,
 		maxResponseLength: maxResponseLength,
+		compact:           compact,
+		noPeerID:          noPeerID,
 		http: &http.Client{
 			Timeout:   timeout,
 			Transport: t,
@@ -67,16 +74,29 @@ func (t *HTTPTracker) Announce(ctx context.Context, req tracker.AnnounceRequest)
 	sb.WriteString(percentEscape(req.Torrent.PeerID))
 	sb.WriteString("&port=")
 	sb.WriteString(strconv.Itoa(req.Torrent.Port))
+	if req.Torrent.IP != nil {
+		sb.WriteString("&ip=")
+		sb.WriteString(req.Torrent.IP.String())
+	}
 	sb.WriteString("&uploaded=")
 	sb.WriteString(strconv.FormatInt(req.Torrent.BytesUploaded, 10))
 	sb.WriteString("&downloaded=")
 	sb.WriteString(strconv.FormatInt(req.Torrent.BytesDownloaded, 10))
 	sb.WriteString("&left=")
 	sb.WriteString(strconv.FormatInt(req.Torrent.BytesLeft, 10))
-	sb.WriteString("&compact=1")
-	sb.WriteString("&no_peer_id=1")
-	sb.WriteString("&numwant=")
-	sb.WriteString(strconv.Itoa(req.NumWant))
+	if t.compact {
+		sb.WriteString("&compact=1")
+	} else {
+		sb.WriteString("&compact=0")
+	}
+	if t.noPeerID {
+		sb.WriteString("&no_peer_id=1")
+	}
+	// Negative value lets the tracker decide.
+	if req.NumWant >= 0 {
+		sb.WriteString("&numwant=")
+		sb.WriteString(strconv.Itoa(req.NumWant))
+	}
 
 	if req.Event != tracker.EventNone {
 		sb.WriteString("&event=")
@@ -87,7 +107,7 @@ func (t *HTTPTracker) Announce(ctx context.Context, req tracker.AnnounceRequest)
 		sb.WriteString(t.trackerID)
 	}
 	sb.WriteString("&key=")
-	sb.WriteString(hex.EncodeToString(req.Torrent.PeerID[16:20]))
+	sb.WriteString(fmt.Sprintf("%08x", req.Torrent.Key))
 
 	t.log.Debugf("making request to: %q", sb.String())
 
@@ -97,31 +117,10 @@ func (t *HTTPTracker) Announce(ctx context.Context, req tracker.AnnounceRequest)
 	}
 	httpReq = httpReq.WithContext(ctx)
 
-	httpReq.Header.Set("User-Agent", t.userAgent)
-
-	doReq := func() (int, http.Header, []byte, error) {
-		resp, err := t.http.Do(httpReq)
-		if err != nil {
-			return 0, nil, nil, err
-		}
-		t.log.Debugf("tracker responded %d with %d bytes body", resp.StatusCode, resp.ContentLength)
-		defer resp.Body.Close()
-		if resp.ContentLength > t.maxResponseLength {
-			return 0, resp.Header, nil, fmt.Errorf("tracker respsonse too large: %d", resp.ContentLength)
-		}
-		r := io.LimitReader(resp.Body, t.maxResponseLength)
-		data, err := io.ReadAll(r)
-		return resp.StatusCode, resp.Header, data, err
-	}
-
-	code, header, body, err := doReq()
-	if uerr, ok := err.(*url.Error); ok && uerr.Err == context.Canceled {
-		return nil, context.Canceled
-	}
+	code, header, body, err := t.doRequest(httpReq)
 	if err != nil {
 		return nil, err
 	}
-	t.log.Debugf("read %d bytes from body", len(body))
 
 	var response announceResponse
 	err = bencode.DecodeBytes(body, &response)
@@ -137,10 +136,9 @@ func (t *HTTPTracker) Announce(ctx context.Context, req tracker.AnnounceRequest)
 	}
 
 	if response.FailureReason != "" {
-		retryIn, _ := strconv.Atoi(response.RetryIn)
 		return nil, &tracker.Error{
 			FailureReason: response.FailureReason,
-			RetryIn:       time.Duration(retryIn) * time.Minute,
+			RetryIn:       parseRetryIn(response.RetryIn),
 		}
 	}
 
@@ -148,31 +146,24 @@ func (t *HTTPTracker) Announce(ctx context.Context, req tracker.AnnounceRequest)
 		t.trackerID = response.TrackerID
 	}
 
-	// Peers may be in binary or dictionary model.
-	var peers []*net.TCPAddr
-	if len(response.Peers) > 0 {
-		if response.Peers[0] == 'l' {
-			peers, err = parsePeersDictionary(response.Peers)
-		} else {
-			var b []byte
-			err = bencode.DecodeBytes(response.Peers, &b)
-			if err != nil {
-				return nil, tracker.ErrDecode
-			}
-			peers, err = tracker.DecodePeersCompact(b)
-		}
+	peers, err := parsePeers(response.Peers, tracker.DecodePeersCompact)
+	if err != nil {
+		return nil, err
 	}
+	peers6, err := parsePeers(response.Peers6, tracker.DecodePeersCompact6)
 	if err != nil {
 		return nil, err
 	}
+	peers = append(peers, peers6...)
 	t.log.Debugf("got %d peers", len(peers))
 
 	// Filter external IP
 	if len(response.ExternalIP) != 0 {
+		externalIP := net.IP(response.ExternalIP)
 		var filtered int
-		for i, p := range peers {
-			if !bytes.Equal(p.IP[:], response.ExternalIP) {
-				peers[i] = p
+		for _, p := range peers {
+			if !p.IP.Equal(externalIP) {
+				peers[filtered] = p
 				filtered++
 			}
 		}
@@ -189,6 +180,94 @@ func (t *HTTPTracker) Announce(ctx context.Context, req tracker.AnnounceRequest)
 	}, nil
 }
 
+// Scrape the torrent by doing a GET request to the scrape URL of the tracker.
+// The scrape URL is found by replacing "announce" with "scrape" in the last part of the announce URL.
+func (t *HTTPTracker) Scrape(ctx context.Context, infoHash [20]byte) (*tracker.ScrapeResponse, error) {
+	scrapeURL, ok := scrapeURL(t.rawURL)
+	if !ok {
+		return nil, tracker.ErrScrapeNotSupported
+	}
+	if strings.ContainsRune(scrapeURL, '?') {
+		scrapeURL += "&info_hash="
+	} else {
+		scrapeURL += "?info_hash="
+	}
+	scrapeURL += percentEscape(infoHash)
+
+	t.log.Debugf("making request to: %q", scrapeURL)
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, scrapeURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	code, header, body, err := t.doRequest(httpReq)
+	if err != nil {
+		return nil, err
+	}
+
+	var response scrapeResponse
+	err = bencode.DecodeBytes(body, &response)
+	if err != nil {
+		if code != 200 {
+			return nil, &StatusError{
+				Code:   code,
+				Header: header,
+				Body:   string(body),
+			}
+		}
+		return nil, tracker.ErrDecode
+	}
+	if response.FailureReason != "" {
+		return nil, &tracker.Error{FailureReason: response.FailureReason}
+	}
+	// Tracker does not include the torrent in response if it does not know about it.
+	f := response.Files[string(infoHash[:])]
+	return &tracker.ScrapeResponse{
+		Seeders:   f.Complete,
+		Leechers:  f.Incomplete,
+		Completed: f.Downloaded,
+	}, nil
+}
+
+// doRequest does the HTTP request and reads the body of the response.
+func (t *HTTPTracker) doRequest(req *http.Request) (code int, header http.Header, body []byte, err error) {
+	req.Header.Set("User-Agent", t.userAgent)
+	resp, err := t.http.Do(req)
+	if uerr, ok := err.(*url.Error); ok && uerr.Err == context.Canceled {
+		return 0, nil, nil, context.Canceled
+	}
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	t.log.Debugf("tracker responded %d with %d bytes body", resp.StatusCode, resp.ContentLength)
+	defer resp.Body.Close()
+	if resp.ContentLength > t.maxResponseLength {
+		return 0, resp.Header, nil, fmt.Errorf("tracker respsonse too large: %d", resp.ContentLength)
+	}
+	r := io.LimitReader(resp.Body, t.maxResponseLength)
+	body, err = io.ReadAll(r)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	t.log.Debugf("read %d bytes from body", len(body))
+	return resp.StatusCode, resp.Header, body, nil
+}
+
+// scrapeURL returns the scrape URL of the tracker from announce URL.
+// Returns false if the last part of the path does not start with "announce", which means the tracker does not support scrape.
+func scrapeURL(announceURL string) (string, bool) {
+	path, query, hasQuery := strings.Cut(announceURL, "?")
+	i := strings.LastIndexByte(path, '/')
+	if i == -1 || !strings.HasPrefix(path[i+1:], "announce") {
+		return "", false
+	}
+	s := path[:i+1] + "scrape" + strings.TrimPrefix(path[i+1:], "announce")
+	if hasQuery {
+		s += "?" + query
+	}
+	return s, true
+}
+
 // percentEscape puts `%` before every byte.
 // Some trackers don't like the output of url.QueryEscape function because it may skip encoding safe characters.
 // This function escapes every byte explicitly.
@@ -204,6 +283,22 @@ func percentEscape(b [20]byte) string {
 	return sb.String()
 }
 
+// parsePeers parses the peer list which may be in compact or dictionary model.
+func parsePeers(b bencode.RawMessage, decodeCompact func([]byte) ([]*net.TCPAddr, error)) ([]*net.TCPAddr, error) {
+	if len(b) == 0 {
+		return nil, nil
+	}
+	if b[0] == 'l' {
+		return parsePeersDictionary(b)
+	}
+	var s []byte
+	err := bencode.DecodeBytes(b, &s)
+	if err != nil {
+		return nil, tracker.ErrDecode
+	}
+	return decodeCompact(s)
+}
+
 func parsePeersDictionary(b bencode.RawMessage) ([]*net.TCPAddr, error) {
 	var peers []struct {
 		IP   string `bencode:"ip"`
@@ -214,10 +309,35 @@ func parsePeersDictionary(b bencode.RawMessage) ([]*net.TCPAddr, error) {
 		return nil, tracker.ErrDecode
 	}
 
-	addrs := make([]*net.TCPAddr, len(peers))
-	for i, p := range peers {
-		pe := &net.TCPAddr{IP: net.ParseIP(p.IP), Port: int(p.Port)}
-		addrs[i] = pe
+	addrs := make([]*net.TCPAddr, 0, len(peers))
+	for _, p := range peers {
+		ip := net.ParseIP(p.IP)
+		if ip == nil || p.Port == 0 {
+			// Skip host names and invalid addresses instead of failing the whole announce.
+			continue
+		}
+		addrs = append(addrs, &net.TCPAddr{IP: ip, Port: int(p.Port)})
+	}
+	return addrs, nil
+}
+
+// parseRetryIn parses the "retry in" field sent with failure reason.
+// Trackers send it as a number of minutes, either as an integer or a string. "never" means no retry.
+func parseRetryIn(b bencode.RawMessage) time.Duration {
+	if len(b) == 0 {
+		return 0
+	}
+	var minutes int
+	if b[0] == 'i' {
+		if bencode.DecodeBytes(b, &minutes) != nil {
+			return 0
+		}
+	} else {
+		var s string
+		if bencode.DecodeBytes(b, &s) != nil {
+			return 0
+		}
+		minutes, _ = strconv.Atoi(s)
 	}
-	return addrs, err
+	return time.Duration(minutes) * time.Minute
 }
@@ -2,7 +2,9 @@ package httptracker_test
 
 import (
 	"context"
+	"net"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"testing"
 	"time"
@@ -56,7 +58,7 @@ func TestHTTPTracker(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	trk := httptracker.New(rawURL, u, timeout, new(http.Transport), "Mozilla/5.0", 2*1024*1024)
+	trk := httptracker.New(rawURL, u, timeout, new(http.Transport), "Mozilla/5.0", 2*1024*1024, true, true)
 
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
@@ -98,4 +100,97 @@ func TestHTTPTracker(t *testing.T) {
 		t.Log(addr.String())
 		t.FailNow()
 	}
+
+	sresp, err := trk.Scrape(ctx, [20]byte{6})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sresp.Seeders != 1 || sresp.Leechers != 1 {
+		t.Fatalf("%#v", sresp)
+	}
+}
+
+func TestAnnounceIP(t *testing.T) {
+	queryC := make(chan url.Values, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queryC <- r.URL.Query()
+		_, _ = w.Write([]byte("d8:intervali60e5:peers0:e"))
+	}))
+	defer srv.Close()
+
+	rawURL := srv.URL + "/announce"
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trk := httptracker.New(rawURL, u, timeout, new(http.Transport), "Mozilla/5.0", 2*1024*1024, true, true)
+
+	req := tracker.AnnounceRequest{
+		Torrent: tracker.Torrent{
+			InfoHash: [20]byte{6},
+			PeerID:   [20]byte{1},
+			Port:     3333,
+			IP:       net.ParseIP("1.2.3.4"),
+		},
+	}
+	_, err = trk.Announce(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := <-queryC
+	if q.Get("ip") != "1.2.3.4" {
+		t.Fatalf("invalid ip: %q", q.Get("ip"))
+	}
+	if q.Get("port") != "3333" {
+		t.Fatalf("invalid port: %q", q.Get("port"))
+	}
+}
+
+func TestAnnouncePreferences(t *testing.T) {
+	queryC := make(chan url.Values, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queryC <- r.URL.Query()
+		_, _ = w.Write([]byte("d8:intervali60e5:peersld2:ip7:1.2.3.47:peer id20:abcdefghijklmnopqrst4:porti5000eeee"))
+	}))
+	defer srv.Close()
+
+	rawURL := srv.URL + "/announce"
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trk := httptracker.New(rawURL, u, timeout, new(http.Transport), "Mozilla/5.0", 2*1024*1024, false, false)
+
+	req := tracker.AnnounceRequest{
+		Torrent: tracker.Torrent{InfoHash: [20]byte{6}, PeerID: [20]byte{1}},
+		NumWant: 500,
+	}
+	resp, err := trk.Announce(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := <-queryC
+	if q.Get("compact") != "0" {
+		t.Fatalf("invalid compact: %q", q.Get("compact"))
+	}
+	if q.Has("no_peer_id") {
+		t.Fatal("no_peer_id must not be sent")
+	}
+	if q.Get("numwant") != "500" {
+		t.Fatalf("invalid numwant: %q", q.Get("numwant"))
+	}
+	if len(resp.Peers) != 1 || resp.Peers[0].String() != "1.2.3.4:5000" {
+		t.Fatalf("invalid peers: %v", resp.Peers)
+	}
+
+	// Negative value lets the tracker decide, so the parameter is not sent.
+	req.NumWant = -1
+	_, err = trk.Announce(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q = <-queryC
+	if q.Has("numwant") {
+		t.Fatalf("numwant must not be sent: %q", q.Get("numwant"))
+	}
 }
@@ -2,17 +2,23 @@ package tracker
 
 import (
 	"context"
+	"errors"
 	"math/rand"
-	"sync/atomic"
+	"sync"
 )
 
 // Tier implements the Tracker interface and contains multiple Trackers which tries to announce to the working Tracker.
+// Trackers are shuffled once when the Tier is created and the tracker that responds is moved to the front of the tier as described in BEP 12.
 type Tier struct {
 	Trackers []Tracker
-	index    int32
+	m        sync.Mutex
+	index    int
 }
 
-var _ Tracker = (*Tier)(nil)
+var (
+	_ Tracker = (*Tier)(nil)
+	_ Scraper = (*Tier)(nil)
+)
 
 // NewTier returns a new Tier.
 func NewTier(trackers []Tracker) *Tier {
@@ -24,24 +30,60 @@ func NewTier(trackers []Tracker) *Tier {
 
 // Announce a torrent to the tracker.
 // If annouce fails, the next announce will be made to the next Tracker in the tier.
+// If announce succeeds, the Tracker is moved to the front of the tier.
 func (t *Tier) Announce(ctx context.Context, req AnnounceRequest) (*AnnounceResponse, error) {
+	t.m.Lock()
 	index := t.loadIndex()
-	resp, err := t.Trackers[index].Announce(ctx, req)
+	trk := t.Trackers[index]
+	t.m.Unlock()
+
+	resp, err := trk.Announce(ctx, req)
+
+	t.m.Lock()
+	defer t.m.Unlock()
+	if errors.Is(err, context.Canceled) || index != t.index || t.Trackers[index] != trk {
+		// Tier is changed by another announce in the meantime.
+		return resp, err
+	}
 	if err != nil {
-		atomic.CompareAndSwapInt32(&t.index, index, index+1)
+		t.index = index + 1
+		return resp, err
 	}
+	copy(t.Trackers[1:index+1], t.Trackers[:index])
+	t.Trackers[0] = trk
+	t.index = 0
 	return resp, err
 }
 
+// Scrape the torrent from the current Tracker in the tier.
+func (t *Tier) Scrape(ctx context.Context, infoHash [20]byte) (*ScrapeResponse, error) {
+	t.m.Lock()
+	trk := t.Trackers[t.loadIndex()]
+	t.m.Unlock()
+	s, ok := trk.(Scraper)
+	if !ok {
+		return nil, ErrScrapeNotSupported
+	}
+	return s.Scrape(ctx, infoHash)
+}
+
 // URL returns the current Tracker in the Tier.
 func (t *Tier) URL() string {
+	t.m.Lock()
+	defer t.m.Unlock()
 	return t.Trackers[t.loadIndex()].URL()
 }
 
-func (t *Tier) loadIndex() int32 {
-	index := atomic.LoadInt32(&t.index)
-	if index >= int32(len(t.Trackers)) {
-		index = 0
+// List returns the Trackers in the Tier in their current order.
+func (t *Tier) List() []Tracker {
+	t.m.Lock()
+	defer t.m.Unlock()
+	return append([]Tracker(nil), t.Trackers...)
+}
+
+func (t *Tier) loadIndex() int {
+	if t.index >= len(t.Trackers) {
+		t.index = 0
 	}
-	return index
+	return t.index
 }
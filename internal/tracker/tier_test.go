@@ -0,0 +1,68 @@
+package tracker
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type testTracker struct {
+	url  string
+	fail bool
+}
+
+func (t *testTracker) Announce(ctx context.Context, req AnnounceRequest) (*AnnounceResponse, error) {
+	if t.fail {
+		return nil, errors.New("error")
+	}
+	return &AnnounceResponse{}, nil
+}
+
+func (t *testTracker) URL() string {
+	return t.url
+}
+
+func TestTierPromote(t *testing.T) {
+	a := &testTracker{url: "a", fail: true}
+	b := &testTracker{url: "b", fail: true}
+	c := &testTracker{url: "c"}
+	tier := &Tier{Trackers: []Tracker{a, b, c}}
+
+	for _, u := range []string{"a", "b", "c"} {
+		if tier.URL() != u {
+			t.Fatalf("expected tracker %s, got %s", u, tier.URL())
+		}
+		_, _ = tier.Announce(context.Background(), AnnounceRequest{})
+	}
+	// Responding tracker is moved to the front of the tier.
+	list := tier.List()
+	if list[0] != c || list[1] != a || list[2] != b {
+		t.Fatalf("invalid order: %s %s %s", list[0].URL(), list[1].URL(), list[2].URL())
+	}
+	if tier.URL() != "c" {
+		t.Fatalf("invalid current tracker: %s", tier.URL())
+	}
+}
+
+func TestTierListOrder(t *testing.T) {
+	a := &testTracker{url: "a", fail: true}
+	b := &testTracker{url: "b", fail: true}
+	c := &testTracker{url: "c", fail: true}
+	l := NewTierList([]*Tier{{Trackers: []Tracker{a, b}}, {Trackers: []Tracker{c}}})
+
+	// Second tier is tried after all trackers in the first tier have failed.
+	for _, u := range []string{"a", "b", "c", "a"} {
+		if l.URL() != u {
+			t.Fatalf("expected tracker %s, got %s", u, l.URL())
+		}
+		_, _ = l.Announce(context.Background(), AnnounceRequest{})
+	}
+
+	// Working tracker is used until it fails.
+	b.fail = false
+	_, _ = l.Announce(context.Background(), AnnounceRequest{})
+	_, err := l.Announce(context.Background(), AnnounceRequest{})
+	if err != nil || l.URL() != "b" {
+		t.Fatalf("invalid current tracker: %s", l.URL())
+	}
+}
@@ -0,0 +1,72 @@
+package tracker
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// TierList implements the Tracker interface for an announce list that contains multiple tiers.
+// Tiers are tried in order as described in BEP 12.
+// The next tier is tried only after all trackers in the current tier have failed.
+// After all tiers have failed, announcing starts from the first tier again.
+type TierList struct {
+	Tiers    []*Tier
+	m        sync.Mutex
+	index    int
+	failures int
+}
+
+var (
+	_ Tracker = (*TierList)(nil)
+	_ Scraper = (*TierList)(nil)
+)
+
+// NewTierList returns a new TierList.
+func NewTierList(tiers []*Tier) *TierList {
+	return &TierList{
+		Tiers: tiers,
+	}
+}
+
+// Announce a torrent to the current tier.
+func (l *TierList) Announce(ctx context.Context, req AnnounceRequest) (*AnnounceResponse, error) {
+	l.m.Lock()
+	index := l.index
+	tier := l.Tiers[index]
+	l.m.Unlock()
+
+	resp, err := tier.Announce(ctx, req)
+
+	l.m.Lock()
+	defer l.m.Unlock()
+	if errors.Is(err, context.Canceled) || index != l.index {
+		return resp, err
+	}
+	if err == nil {
+		l.failures = 0
+		return resp, err
+	}
+	l.failures++
+	if l.failures >= len(tier.Trackers) {
+		l.failures = 0
+		l.index = (index + 1) % len(l.Tiers)
+	}
+	return resp, err
+}
+
+// Scrape the torrent from the current Tracker in the current tier.
+func (l *TierList) Scrape(ctx context.Context, infoHash [20]byte) (*ScrapeResponse, error) {
+	return l.current().Scrape(ctx, infoHash)
+}
+
+// URL returns the current Tracker in the current tier.
+func (l *TierList) URL() string {
+	return l.current().URL()
+}
+
+func (l *TierList) current() *Tier {
+	l.m.Lock()
+	defer l.m.Unlock()
+	return l.Tiers[l.index]
+}
@@ -1,5 +1,7 @@
 package tracker
 
+import "net"
+
 // Torrent contains fields that are sent in an announce request.
 type Torrent struct {
 	BytesUploaded   int64
@@ -8,4 +10,10 @@ type Torrent struct {
 	InfoHash        [20]byte
 	PeerID          [20]byte
 	Port            int
+	// IP address to announce instead of the source address of the request. Nil means not set.
+	IP net.IP
+	// Random value that lets the tracker identify the client if its IP address changes.
+	Key uint32
+	// PartialSeed is true if the client is not going to download the remaining bytes (BEP 21).
+	PartialSeed bool
 }
@@ -19,6 +19,22 @@ type Tracker interface {
 	URL() string
 }
 
+// Scraper is implemented by the trackers that support scrape requests.
+type Scraper interface {
+	// Scrape returns the size of the swarm of the torrent as known by the tracker.
+	Scrape(ctx context.Context, infoHash [20]byte) (*ScrapeResponse, error)
+}
+
+// ScrapeResponse contains fields from a response to scrape request.
+type ScrapeResponse struct {
+	Seeders   int32
+	Leechers  int32
+	Completed int32
+}
+
+// ErrScrapeNotSupported is returned from Scraper.Scrape method when the tracker does not support scrape requests.
+var ErrScrapeNotSupported = errors.New("scrape is not supported by tracker")
+
 // AnnounceRequest contains the parameters that are sent in an announce request to trackers.
 type AnnounceRequest struct {
 	Torrent Torrent
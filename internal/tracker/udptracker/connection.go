@@ -1,6 +1,7 @@
 package udptracker
 
 import (
+	"context"
 	"net"
 	"time"
 )
@@ -12,6 +13,10 @@ type connection struct {
 	// holds the announce requests that needs to be sent after the connection is successful.
 	requests []*transportRequest
 
+	// Connection has its own context, so it is not cancelled with the context of the request that has started it.
+	// It is cancelled when the connect transaction is finished or abandoned.
+	cancel context.CancelFunc
+
 	// These fields are set by Transport.Run loop if connected successfully.
 	addr        *net.UDPAddr
 	id          int64
@@ -20,10 +25,12 @@ type connection struct {
 
 var _ udpRequest = (*connection)(nil)
 
-func newConnection(req *transportRequest) *connection {
+func newConnection(dest string, reqs []*transportRequest) *connection {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &connection{
-		requestBase:    newRequestBase(req.ctx, req.dest),
+		requestBase:    newRequestBase(ctx, dest),
 		connectRequest: newConnectRequest(),
-		requests:       []*transportRequest{req},
+		requests:       reqs,
+		cancel:         cancel,
 	}
 }
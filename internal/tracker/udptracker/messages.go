@@ -55,6 +55,13 @@ type announceRequest struct {
 	Extensions uint16
 }
 
+type udpScrapeResponse struct {
+	udpMessageHeader
+	Seeders   int32
+	Completed int32
+	Leechers  int32
+}
+
 type transferAnnounceRequest struct {
 	*announceRequest
 	urlData string
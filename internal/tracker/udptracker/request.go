@@ -1,8 +1,10 @@
 package udptracker
 
 import (
+	"bytes"
 	"context"
 	"encoding/binary"
+	"io"
 
 	"github.com/cenkalti/rain/internal/tracker"
 )
@@ -10,6 +12,10 @@ import (
 type transportRequest struct {
 	*requestBase
 	transferAnnounceRequest
+
+	// If true, a scrape request for the info hash is sent instead of the announce request.
+	// Only the header and info hash fields of the announce request are used.
+	scrape bool
 }
 
 var _ udpRequest = (*transportRequest)(nil)
@@ -22,11 +28,15 @@ func newTransportRequest(ctx context.Context, req tracker.AnnounceRequest, dest
 		Left:       req.Torrent.BytesLeft,
 		Uploaded:   req.Torrent.BytesUploaded,
 		Event:      req.Event,
+		Key:        req.Torrent.Key,
 		NumWant:    int32(req.NumWant),
 		Port:       uint16(req.Torrent.Port),
 	}
-	binary.BigEndian.PutUint32(request.PeerID[16:20], request.Key)
 	request.Action = actionAnnounce
+	// The IP field in UDP tracker protocol can only hold an IPv4 address.
+	if ip4 := req.Torrent.IP.To4(); ip4 != nil {
+		request.IP = binary.BigEndian.Uint32(ip4)
+	}
 
 	return &transportRequest{
 		requestBase: newRequestBase(ctx, dest),
@@ -36,3 +46,31 @@ func newTransportRequest(ctx context.Context, req tracker.AnnounceRequest, dest
 		},
 	}
 }
+
+func newScrapeTransportRequest(ctx context.Context, infoHash [20]byte, dest string) *transportRequest {
+	request := &announceRequest{
+		InfoHash: infoHash,
+	}
+	request.Action = actionScrape
+
+	return &transportRequest{
+		requestBase: newRequestBase(ctx, dest),
+		transferAnnounceRequest: transferAnnounceRequest{
+			announceRequest: request,
+		},
+		scrape: true,
+	}
+}
+
+func (r *transportRequest) WriteTo(w io.Writer) (int64, error) {
+	if !r.scrape {
+		return r.transferAnnounceRequest.WriteTo(w)
+	}
+	var buf bytes.Buffer
+	err := binary.Write(&buf, binary.BigEndian, r.udpRequestHeader)
+	if err != nil {
+		return 0, err
+	}
+	buf.Write(r.InfoHash[:])
+	return buf.WriteTo(w)
+}
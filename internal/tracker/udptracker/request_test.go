@@ -0,0 +1,59 @@
+package udptracker
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net"
+	"testing"
+
+	"github.com/cenkalti/rain/internal/tracker"
+)
+
+func TestAnnounceRequestKey(t *testing.T) {
+	var peerID [20]byte
+	copy(peerID[:], "-RN0001-abcdefghijkl")
+	req := tracker.AnnounceRequest{Torrent: tracker.Torrent{PeerID: peerID, Key: 1234}}
+	r := newTransportRequest(context.Background(), req, "127.0.0.1:5000", "")
+	if r.PeerID != peerID {
+		t.Fatalf("peer id is changed: %q", r.PeerID)
+	}
+	if r.Key != 1234 {
+		t.Fatalf("invalid key: %d", r.Key)
+	}
+}
+
+func TestAnnounceRequestIP(t *testing.T) {
+	req := tracker.AnnounceRequest{Torrent: tracker.Torrent{IP: net.ParseIP("1.2.3.4")}}
+	r := newTransportRequest(context.Background(), req, "127.0.0.1:5000", "")
+	if r.IP != 0x01020304 {
+		t.Fatalf("invalid ip: %x", r.IP)
+	}
+	req.Torrent.IP = net.ParseIP("::1")
+	r = newTransportRequest(context.Background(), req, "127.0.0.1:5000", "")
+	if r.IP != 0 {
+		t.Fatalf("ipv6 address must not be sent: %x", r.IP)
+	}
+}
+
+func TestScrapeRequest(t *testing.T) {
+	infoHash := [20]byte{1, 2, 3}
+	r := newScrapeTransportRequest(context.Background(), infoHash, "127.0.0.1:5000")
+	r.ConnectionID = 5
+	r.SetTransactionID(7)
+	var buf bytes.Buffer
+	_, err := r.WriteTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()
+	if len(b) != 36 {
+		t.Fatalf("invalid length: %d", len(b))
+	}
+	if binary.BigEndian.Uint64(b[0:8]) != 5 || binary.BigEndian.Uint32(b[8:12]) != uint32(actionScrape) || binary.BigEndian.Uint32(b[12:16]) != 7 {
+		t.Fatalf("invalid header: %x", b[:16])
+	}
+	if !bytes.Equal(b[16:], infoHash[:]) {
+		t.Fatalf("invalid info hash: %x", b[16:])
+	}
+}
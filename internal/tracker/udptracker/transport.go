@@ -103,7 +103,8 @@ func (t *Transport) Run() {
 	// Connections can be either connecting or connected.
 	connections := make(map[string]*connection)
 	connectDone := make(chan *connectionResult)
-	connectionExpired := make(chan string)
+	connectionExpired := make(chan *connection)
+	ownerCancelled := make(chan *connection)
 
 	// Transaction can be either a connection request or announce request.
 	beginTransaction := func(i udpRequest) (*transaction, error) {
@@ -116,6 +117,37 @@ func (t *Transport) Run() {
 		return trx, nil
 	}
 
+	// Connection ID is cached and shared by all requests to the same destination until it expires.
+	connect := func(reqs []*transportRequest) {
+		conn := newConnection(reqs[0].dest, reqs)
+		connections[conn.dest] = conn
+		trx, err := beginTransaction(conn)
+		if err != nil {
+			conn.cancel()
+			delete(connections, conn.dest)
+			for _, req := range reqs {
+				req.SetResponse(nil, err)
+			}
+			return
+		}
+		go resolveDestinationAndConnect(trx, conn.dest, udpConn, t.dnsTimeout, t.blocklist, connectDone, t.closeC)
+		// The request that has started the connection may be cancelled while others are waiting for the connection.
+		// Connect packet may have been lost, so a new connection is started for the waiting requests instead of
+		// waiting for the next retry.
+		go func(owner *transportRequest) {
+			select {
+			case <-owner.ctx.Done():
+			case <-conn.ctx.Done():
+				return
+			}
+			select {
+			case ownerCancelled <- conn:
+			case <-conn.ctx.Done():
+			case <-t.closeC:
+			}
+		}(reqs[0])
+	}
+
 	for {
 		select {
 		case req := <-t.requestC:
@@ -126,20 +158,13 @@ func (t *Transport) Run() {
 			}
 			conn, ok := connections[req.dest]
 			if !ok {
-				conn = newConnection(req)
-				connections[req.dest] = conn
-				trx, err := beginTransaction(conn)
-				if err != nil {
-					trx.request.SetResponse(nil, err)
-				} else {
-					go resolveDestinationAndConnect(trx, req.dest, udpConn, t.dnsTimeout, t.blocklist, connectDone, t.closeC)
-				}
+				connect([]*transportRequest{req})
 			} else {
 				if !conn.connectedAt.IsZero() {
 					req.ConnectionID = conn.id
 					trx, err := beginTransaction(req)
 					if err != nil {
-						trx.request.SetResponse(nil, err)
+						req.SetResponse(nil, err)
 					} else {
 						go retryTransaction(trx, udpConn, conn.addr)
 					}
@@ -148,16 +173,41 @@ func (t *Transport) Run() {
 					conn.requests = append(conn.requests, req)
 				}
 			}
+		case conn := <-ownerCancelled:
+			if connections[conn.dest] != conn || !conn.connectedAt.IsZero() {
+				break
+			}
+			// Abandon the connect transaction. Its result is ignored when it arrives.
+			delete(connections, conn.dest)
+			conn.cancel()
+			var waiting []*transportRequest
+			for _, req := range conn.requests {
+				if req.ctx.Err() == nil {
+					waiting = append(waiting, req)
+				} else {
+					req.SetResponse(nil, req.ctx.Err())
+				}
+			}
+			conn.requests = nil
+			if len(waiting) > 0 {
+				connect(waiting)
+			}
 		case res := <-connectDone:
 			conn := res.trx.request.(*connection)
+			conn.cancel()
 
 			// Transaction must be finished, successful or not.
 			delete(transactions, res.trx.id)
 
+			// Connection has been abandoned and its requests are moved to a new connection.
+			if connections[res.dest] != conn {
+				break
+			}
+
 			// Handle connection error.
 			if res.err != nil {
-				// Notify all requests waiting for connection about the error.
 				delete(connections, res.dest)
+				// Notify all requests waiting for connection about the error.
 				for _, req := range conn.requests {
 					req.SetResponse(nil, res.err)
 				}
@@ -170,24 +220,24 @@ func (t *Transport) Run() {
 			conn.connectedAt = res.connectedAt
 
 			// Expire the connection after defined period.
-			go func(dest string) {
+			go func(conn *connection) {
 				select {
 				case <-time.After(connectionIDInterval):
 				case <-t.closeC:
 					return
 				}
 				select {
-				case connectionExpired <- dest:
+				case connectionExpired <- conn:
 				case <-t.closeC:
 				}
-			}(res.dest)
+			}(conn)
 
 			// Start announce transaction for all waiting requests.
 			for _, req := range conn.requests {
 				req.ConnectionID = conn.id
 				trx, err := beginTransaction(req)
 				if err != nil {
-					trx.request.SetResponse(nil, err)
+					req.SetResponse(nil, err)
 				} else {
 					go retryTransaction(trx, udpConn, conn.addr)
 				}
@@ -195,8 +245,11 @@ func (t *Transport) Run() {
 
 			// All requests are sent, clear waiting request list.
 			conn.requests = nil
-		case dest := <-connectionExpired:
-			delete(connections, dest)
+		case conn := <-connectionExpired:
+			// Connection may have been dropped and replaced with a new one before it expires.
+			if connections[conn.dest] == conn {
+				delete(connections, conn.dest)
+			}
 		case buf := <-t.readC:
 			var header udpMessageHeader
 			err := binary.Read(bytes.NewReader(buf), binary.BigEndian, &header)
@@ -232,6 +285,12 @@ func (t *Transport) Run() {
 						RetryIn:       time.Duration(retryIn) * time.Minute,
 					}
 				}
+				// Tracker may have rejected the connection ID. Get a new one on next announce.
+				if req, ok := trx.request.(*transportRequest); ok {
+					if conn, ok := connections[req.dest]; ok && conn.id == req.ConnectionID {
+						delete(connections, req.dest)
+					}
+				}
 			}
 			trx.request.SetResponse(buf, err)
 			trx.cancel()
@@ -255,9 +314,10 @@ func (t *Transport) Run() {
 
 // readLoop reads datagrams from connection and sends to the run loop.
 func (t *Transport) readLoop(conn net.Conn) {
-	// Read buffer must be big enough to hold a UDP packet of maximum expected size.
-	const maxNumWant = 1000
-	bigBuf := make([]byte, 20+6*maxNumWant)
+	// Read buffer must be big enough to hold a UDP packet of maximum size,
+	// so responses to announces with a large numwant are not truncated.
+	const maxPacketSize = 65507
+	bigBuf := make([]byte, maxPacketSize)
 	for {
 		n, err := conn.Read(bigBuf)
 		if err != nil {
@@ -25,7 +25,10 @@ type UDPTracker struct {
 	transport *Transport
 }
 
-var _ tracker.Tracker = (*UDPTracker)(nil)
+var (
+	_ tracker.Tracker = (*UDPTracker)(nil)
+	_ tracker.Scraper = (*UDPTracker)(nil)
+)
 
 // New returns a new UDPTracker.
 func New(rawURL string, u *url.URL, t *Transport) *UDPTracker {
@@ -66,6 +69,29 @@ func (t *UDPTracker) Announce(ctx context.Context, req tracker.AnnounceRequest)
 	}, nil
 }
 
+// Scrape the torrent from UDP tracker.
+func (t *UDPTracker) Scrape(ctx context.Context, infoHash [20]byte) (*tracker.ScrapeResponse, error) {
+	scrape := newScrapeTransportRequest(ctx, infoHash, t.dest)
+
+	reply, err := t.transport.Do(scrape)
+	if err != nil {
+		return nil, err
+	}
+
+	var response udpScrapeResponse
+	err = binary.Read(bytes.NewReader(reply), binary.BigEndian, &response)
+	if err != nil || response.Action != actionScrape {
+		return nil, tracker.ErrDecode
+	}
+	t.log.Debugf("Scrape response: %#v", response)
+
+	return &tracker.ScrapeResponse{
+		Seeders:   response.Seeders,
+		Leechers:  response.Leechers,
+		Completed: response.Completed,
+	}, nil
+}
+
 func (t *UDPTracker) parseAnnounceResponse(data []byte) (*udpAnnounceResponse, []*net.TCPAddr, error) {
 	var response udpAnnounceResponse
 	err := binary.Read(bytes.NewReader(data), binary.BigEndian, &response)
@@ -95,4 +95,52 @@ func TestUDPTracker(t *testing.T) {
 		t.Log(addr.String())
 		t.FailNow()
 	}
+
+	sresp, err := trk.Scrape(ctx, [20]byte{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sresp.Seeders != 1 || sresp.Leechers != 1 {
+		t.Fatalf("%#v", sresp)
+	}
+}
+
+func TestUDPTrackerFirstRequestCancelled(t *testing.T) {
+	const rawURL = "udp://127.0.0.1:5001/announce"
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := udptracker.NewTransport(nil, 5*time.Second)
+	go tr.Run()
+	defer tr.Close()
+	trk := udptracker.New(rawURL, u, tr)
+
+	// First connect request is lost because the tracker is not running yet.
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	errC1 := make(chan error, 1)
+	go func() {
+		_, err := trk.Announce(ctx1, tracker.AnnounceRequest{Torrent: tracker.Torrent{Port: 1111, PeerID: [20]byte{1}}})
+		errC1 <- err
+	}()
+	// Make sure that the first request starts the connection.
+	time.Sleep(50 * time.Millisecond)
+	ctx2, cancel2 := context.WithTimeout(context.Background(), timeout)
+	defer cancel2()
+	errC2 := make(chan error, 1)
+	go func() {
+		_, err := trk.Announce(ctx2, tracker.AnnounceRequest{Torrent: tracker.Torrent{Port: 2222, PeerID: [20]byte{2}}})
+		errC2 <- err
+	}()
+	time.Sleep(100 * time.Millisecond)
+	defer startUDPTracker(t, 5001)()
+
+	// Second request waiting for the same connection must get a new connection instead of failing.
+	cancel1()
+	if err := <-errC1; err != context.Canceled {
+		t.Fatal(err)
+	}
+	if err := <-errC2; err != nil {
+		t.Fatal(err)
+	}
 }
@@ -21,15 +21,20 @@ import (
 type TrackerManager struct {
 	httpTransport *http.Transport
 	udpTransport  *udptracker.Transport
+	httpCompact   bool
+	httpNoPeerID  bool
 }
 
 // New returns a new TrackerManager.
-func New(bl *blocklist.Blocklist, dnsTimeout time.Duration, tlsSkipVerify bool) *TrackerManager {
+// httpCompact and httpNoPeerID are sent as preferences in announce requests to HTTP trackers.
+func New(bl *blocklist.Blocklist, dnsTimeout time.Duration, tlsSkipVerify, httpCompact, httpNoPeerID bool) *TrackerManager {
 	m := &TrackerManager{
 		httpTransport: &http.Transport{
 			TLSClientConfig: &tls.Config{InsecureSkipVerify: tlsSkipVerify}, // nolint: gosec
 		},
 		udpTransport: udptracker.NewTransport(bl, dnsTimeout),
+		httpCompact:  httpCompact,
+		httpNoPeerID: httpNoPeerID,
 	}
 	go m.udpTransport.Run()
 	m.httpTransport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
@@ -57,7 +62,7 @@ func (m *TrackerManager) Get(s string, httpTimeout time.Duration, httpUserAgent
 	}
 	switch u.Scheme {
 	case "http", "https":
-		tr := httptracker.New(s, u, httpTimeout, m.httpTransport, httpUserAgent, httpMaxResponseLength)
+		tr := httptracker.New(s, u, httpTimeout, m.httpTransport, httpUserAgent, httpMaxResponseLength, m.httpCompact, m.httpNoPeerID)
 		return tr, nil
 	case "udp":
 		tr := udptracker.New(s, u, m.udpTransport)
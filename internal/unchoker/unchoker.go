@@ -36,6 +36,9 @@ type Peer interface {
 
 	DownloadSpeed() int
 	UploadSpeed() int
+
+	// UploadOnly returns true if the remote peer is a seed or it has announced that it will not download.
+	UploadOnly() bool
 }
 
 // New returns a new Unchoker.
@@ -64,21 +67,35 @@ func (u *Unchoker) candidatesUnchoke(allPeers []Peer) []Peer {
 	return peers
 }
 
-func (u *Unchoker) sortPeers(peers []Peer, completed bool) {
-	byUploadSpeed := func(i, j int) bool { return peers[i].UploadSpeed() > peers[j].UploadSpeed() }
+// sortPeers sorts peers in the order of preference for unchoking.
+// When the torrent is completed, upload-only peers are moved to the end because they are never going to reciprocate.
+// Returns the number of peers that are not upload-only when the torrent is completed, otherwise the number of all peers.
+func (u *Unchoker) sortPeers(peers []Peer, completed bool) int {
+	byUploadSpeed := func(i, j int) bool {
+		if peers[i].UploadOnly() != peers[j].UploadOnly() {
+			return !peers[i].UploadOnly()
+		}
+		return peers[i].UploadSpeed() > peers[j].UploadSpeed()
+	}
 	byDownloadSpeed := func(i, j int) bool { return peers[i].DownloadSpeed() > peers[j].DownloadSpeed() }
-	if completed {
-		sort.Slice(peers, byUploadSpeed)
-	} else {
+	if !completed {
 		sort.Slice(peers, byDownloadSpeed)
+		return len(peers)
 	}
+	sort.Slice(peers, byUploadSpeed)
+	for i, pe := range peers {
+		if pe.UploadOnly() {
+			return i
+		}
+	}
+	return len(peers)
 }
 
 // TickUnchoke must be called at every 10 seconds.
 func (u *Unchoker) TickUnchoke(allPeers []Peer, torrentCompleted bool) {
 	optimistic := u.round == 0
 	peers := u.candidatesUnchoke(allPeers)
-	u.sortPeers(peers, torrentCompleted)
+	preferred := u.sortPeers(peers, torrentCompleted)
 	var i, unchoked int
 	for ; i < len(peers) && unchoked < u.numUnchoked; i++ {
 		if !optimistic && peers[i].Optimistic() {
@@ -89,8 +106,21 @@ func (u *Unchoker) TickUnchoke(allPeers []Peer, torrentCompleted bool) {
 	}
 	peers = peers[i:]
 	if optimistic {
+		// Pick optimistic peers among the preferred ones first.
+		preferred -= i
+		if preferred < 0 {
+			preferred = 0
+		}
 		for i = 0; i < u.numOptimisticUnchoked && len(peers) > 0; i++ {
-			n := rand.Intn(len(peers)) // nolint: gosec
+			var n int
+			if preferred > 0 {
+				n = rand.Intn(preferred) // nolint: gosec
+				preferred--
+				peers[n], peers[preferred] = peers[preferred], peers[n]
+				n = preferred
+			} else {
+				n = rand.Intn(len(peers)) // nolint: gosec
+			}
 			pe := peers[n]
 			u.optimisticUnchokePeer(pe)
 			peers[n], peers = peers[len(peers)-1], peers[:len(peers)-1]
@@ -142,6 +172,13 @@ func (u *Unchoker) optimisticUnchokePeer(pe Peer) {
 	pe.SetOptimistic(true)
 }
 
+// ChokeAll chokes all of the given peers.
+func (u *Unchoker) ChokeAll(allPeers []Peer) {
+	for _, pe := range allPeers {
+		u.chokePeer(pe)
+	}
+}
+
 // FastUnchoke must be called when remote peer is interested.
 // Remote peer is unchoked immediately if there are not enough unchoked peers.
 // Without this function, remote peer would have to wait for next unchoke period.
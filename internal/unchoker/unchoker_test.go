@@ -143,12 +143,91 @@ func TestTickUnchoke(t *testing.T) {
 	}, testPeers)
 }
 
+func TestChokeAll(t *testing.T) {
+	testPeers := []*TestPeer{
+		{interested: true, choking: true, downloadSpeed: 1},
+		{interested: true, choking: true, downloadSpeed: 2},
+	}
+	peers := []Peer{testPeers[0], testPeers[1]}
+	u := New(1, 1)
+	u.TickUnchoke(peers, false)
+	assert.False(t, testPeers[0].choking)
+	assert.False(t, testPeers[1].choking)
+
+	u.ChokeAll(peers)
+	assert.True(t, testPeers[0].choking)
+	assert.True(t, testPeers[1].choking)
+	assert.Empty(t, u.peersUnchoked)
+	assert.Empty(t, u.peersUnchokedOptimistic)
+}
+
+func TestTickUnchokeUploadOnly(t *testing.T) {
+	testPeers := []*TestPeer{
+		{interested: true, choking: true, uploadSpeed: 5, uploadOnly: true},
+		{interested: true, choking: true, uploadSpeed: 1},
+		{interested: true, choking: true, uploadSpeed: 4, uploadOnly: true},
+		{interested: true, choking: true, uploadSpeed: 2},
+	}
+	getPeers := func() []Peer {
+		peers := make([]Peer, len(testPeers))
+		for i := range peers {
+			peers[i] = testPeers[i]
+		}
+		return peers
+	}
+	u := New(2, 1)
+
+	// Leechers are preferred over upload-only peers while seeding.
+	u.round = 1
+	u.TickUnchoke(getPeers(), true)
+	assert.True(t, testPeers[0].choking)
+	assert.False(t, testPeers[1].choking)
+	assert.True(t, testPeers[2].choking)
+	assert.False(t, testPeers[3].choking)
+
+	// Upload-only peers get the remaining slots.
+	u = New(3, 0)
+	u.round = 1
+	u.TickUnchoke(getPeers(), true)
+	assert.False(t, testPeers[0].choking)
+	assert.False(t, testPeers[1].choking)
+	assert.True(t, testPeers[2].choking)
+	assert.False(t, testPeers[3].choking)
+
+	// Upload-only status does not matter while downloading.
+	for _, pe := range testPeers {
+		pe.choking = true
+	}
+	testPeers[0].downloadSpeed = 10
+	u = New(1, 0)
+	u.round = 1
+	u.TickUnchoke(getPeers(), false)
+	assert.False(t, testPeers[0].choking)
+	assert.True(t, testPeers[1].choking)
+}
+
+func TestOptimisticUnchokePrefersLeechers(t *testing.T) {
+	testPeers := []*TestPeer{
+		{interested: true, choking: true, uploadOnly: true},
+		{interested: true, choking: true, uploadOnly: true},
+		{interested: true, choking: true},
+	}
+	peers := []Peer{testPeers[0], testPeers[1], testPeers[2]}
+	u := New(0, 1)
+	u.TickUnchoke(peers, true)
+	assert.True(t, testPeers[0].choking)
+	assert.True(t, testPeers[1].choking)
+	assert.False(t, testPeers[2].choking)
+	assert.True(t, testPeers[2].optimistic)
+}
+
 type TestPeer struct {
 	interested    bool
 	choking       bool
 	optimistic    bool
 	downloadSpeed int
 	uploadSpeed   int
+	uploadOnly    bool
 }
 
 func (p *TestPeer) Choke()                   { p.choking = true }
@@ -159,3 +238,4 @@ func (p *TestPeer) Optimistic() bool         { return p.optimistic }
 func (p *TestPeer) SetOptimistic(value bool) { p.optimistic = value }
 func (p *TestPeer) DownloadSpeed() int       { return p.downloadSpeed }
 func (p *TestPeer) UploadSpeed() int         { return p.uploadSpeed }
+func (p *TestPeer) UploadOnly() bool         { return p.uploadOnly }
@@ -1,10 +1,12 @@
 package verifier
 
 import (
+	"bytes"
 	"crypto/sha1"
 
 	"github.com/cenkalti/rain/internal/bitfield"
 	"github.com/cenkalti/rain/internal/piece"
+	"github.com/cenkalti/rain/metainfo"
 )
 
 // Verifier verifies the pieces on disk.
@@ -12,8 +14,9 @@ type Verifier struct {
 	Bitfield *bitfield.Bitfield
 	Error    error
 
-	closeC chan struct{}
-	doneC  chan struct{}
+	rootHash []byte
+	closeC   chan struct{}
+	doneC    chan struct{}
 }
 
 // Progress information about the verification.
@@ -22,10 +25,13 @@ type Progress struct {
 }
 
 // New returns a new Verifier.
-func New() *Verifier {
+// If rootHash is not nil, pieces are verified against the root of the hash tree of a Merkle torrent.
+// Because hashes of individual pieces are not known, all pieces are marked as done only if the root hash matches.
+func New(rootHash []byte) *Verifier {
 	return &Verifier{
-		closeC: make(chan struct{}),
-		doneC:  make(chan struct{}),
+		rootHash: rootHash,
+		closeC:   make(chan struct{}),
+		doneC:    make(chan struct{}),
 	}
 }
 
@@ -50,14 +56,20 @@ func (v *Verifier) Run(pieces []piece.Piece, progressC chan Progress, resultC ch
 	buf := make([]byte, pieces[0].Length)
 	hash := sha1.New()
 	var numOK uint32
+	var leaves [][]byte
+	if v.rootHash != nil {
+		leaves = make([][]byte, 0, len(pieces))
+	}
 	for _, p := range pieces {
 		buf = buf[:p.Length]
 		_, v.Error = p.Data.ReadAt(buf, 0)
 		if v.Error != nil {
 			return
 		}
-		ok := p.VerifyHash(buf, hash)
-		if ok {
+		if v.rootHash != nil {
+			_, _ = hash.Write(buf)
+			leaves = append(leaves, hash.Sum(nil))
+		} else if p.VerifyHash(buf, hash) {
 			v.Bitfield.Set(p.Index)
 			numOK++
 		}
@@ -68,4 +80,9 @@ func (v *Verifier) Run(pieces []piece.Piece, progressC chan Progress, resultC ch
 		}
 		hash.Reset()
 	}
+	if v.rootHash != nil && bytes.Equal(metainfo.MerkleRoot(leaves), v.rootHash) {
+		for i := range pieces {
+			v.Bitfield.Set(uint32(i))
+		}
+	}
 }
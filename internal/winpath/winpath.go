@@ -0,0 +1,68 @@
+// Package winpath converts file names that cannot be created on Windows into names that can.
+// Illegal characters are replaced with similar looking Unicode characters, so the names are still readable.
+package winpath
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Characters that are not allowed in file names on Windows and their replacements.
+var replacements = map[rune]rune{
+	'<':  '＜',
+	'>':  '＞',
+	':':  '：',
+	'"':  '＂',
+	'/':  '／',
+	'\\': '＼',
+	'|':  '｜',
+	'?':  '？',
+	'*':  '＊',
+}
+
+// Names of devices that cannot be used as file names, with or without extension.
+var reserved = map[string]struct{}{
+	"CON": {}, "PRN": {}, "AUX": {}, "NUL": {},
+	"COM1": {}, "COM2": {}, "COM3": {}, "COM4": {}, "COM5": {}, "COM6": {}, "COM7": {}, "COM8": {}, "COM9": {},
+	"LPT1": {}, "LPT2": {}, "LPT3": {}, "LPT4": {}, "LPT5": {}, "LPT6": {}, "LPT7": {}, "LPT8": {}, "LPT9": {},
+}
+
+// Sanitize returns a path that can be created on Windows.
+// Each element of the path that is separated by filepath.Separator is sanitized separately.
+func Sanitize(path string) string {
+	parts := strings.Split(path, string(filepath.Separator))
+	for i, p := range parts {
+		parts[i] = SanitizeName(p)
+	}
+	return strings.Join(parts, string(filepath.Separator))
+}
+
+// SanitizeName returns a file name that can be created on Windows.
+func SanitizeName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 {
+			// Control Pictures block contains a symbol for each control character.
+			return 0x2400 + r
+		}
+		if rr, ok := replacements[r]; ok {
+			return rr
+		}
+		return r
+	}, name)
+
+	// Windows strips trailing dots and spaces from file names.
+	trimmed := strings.TrimRight(name, ". ")
+	if len(trimmed) < len(name) {
+		suffix := strings.NewReplacer(".", "．", " ", "　").Replace(name[len(trimmed):])
+		name = trimmed + suffix
+	}
+
+	base := name
+	if i := strings.IndexByte(base, '.'); i >= 0 {
+		base = base[:i]
+	}
+	if _, ok := reserved[strings.ToUpper(base)]; ok {
+		name = base + "_" + name[len(base):]
+	}
+	return name
+}
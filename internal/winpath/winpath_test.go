@@ -0,0 +1,35 @@
+package winpath
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitizeName(t *testing.T) {
+	cases := []struct {
+		name      string
+		sanitized string
+	}{
+		{"foo.txt", "foo.txt"},
+		{"a:b?c*d", "a：b？c＊d"},
+		{"<>\"|\\", "＜＞＂｜＼"},
+		{"tab\there", "tab␉here"},
+		{"foo.", "foo．"},
+		{"foo. ", "foo．　"},
+		{"CON", "CON_"},
+		{"con.txt", "con_.txt"},
+		{"LPT1.tar.gz", "LPT1_.tar.gz"},
+		{"CONSOLE", "CONSOLE"},
+		{"COM10", "COM10"},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.sanitized, SanitizeName(c.name), c.name)
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	path := filepath.Join("dir.", "NUL", "a?b")
+	assert.Equal(t, filepath.Join("dir．", "NUL_", "a？b"), Sanitize(path))
+}
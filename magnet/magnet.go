@@ -1,10 +1,11 @@
-// Package magnet provides support for parsing magnet links.
+// Package magnet provides support for parsing and building magnet links.
 package magnet
 
 import (
 	"encoding/base32"
 	"encoding/hex"
 	"errors"
+	"net"
 	"net/url"
 	"sort"
 	"strconv"
@@ -75,11 +76,25 @@ func New(s string) (*Magnet, error) {
 		magnet.Trackers[i] = ti.trackers
 	}
 
-	magnet.Peers = params["x.pe"]
+	// Peer addresses (BEP 9) are hints. Invalid ones are ignored instead of rejecting the link.
+	for _, pe := range params["x.pe"] {
+		if isValidPeerAddress(pe) {
+			magnet.Peers = append(magnet.Peers, pe)
+		}
+	}
 
 	return &magnet, nil
 }
 
+// ParseInfoHash parses a bare info hash that is 40 characters in hex or 32 characters in base32 encoding.
+func ParseInfoHash(s string) ([20]byte, error) {
+	if len(s) == 32 {
+		s = strings.ToUpper(s)
+	}
+	return infoHashString("urn:btih:" + s)
+}
+
+// String returns the magnet link in "magnet:?xt=urn:btih:..." format.
 func (m *Magnet) String() string {
 	var b strings.Builder
 	b.Grow(2048)
@@ -109,6 +124,16 @@ func (m *Magnet) String() string {
 	return b.String()
 }
 
+// isValidPeerAddress returns true if s is in "host:port" format with a non-zero port.
+func isValidPeerAddress(s string) bool {
+	host, port, err := net.SplitHostPort(s)
+	if err != nil || host == "" {
+		return false
+	}
+	p, err := strconv.ParseUint(port, 10, 16)
+	return err == nil && p != 0
+}
+
 type trackerTier struct {
 	trackers []string
 	index    int
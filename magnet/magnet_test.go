@@ -0,0 +1,67 @@
+package magnet
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	u := "magnet:?xt=urn:btih:F60CC95E3566AF84C1AB223FD4CE80FA88E6438A&dn=sample_torrent&tr=udp%3a%2f%2ftracker.rain%3a2710"
+	m, err := New(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hex.EncodeToString(m.InfoHash[:]) != strings.ToLower("F60CC95E3566AF84C1AB223FD4CE80FA88E6438A") {
+		t.Fatal("invalid info hash")
+	}
+	if m.Name != "sample_torrent" {
+		t.Fatal("invalid name")
+	}
+	if len(m.Trackers) != 1 {
+		t.Fatal("invalid trackers")
+	}
+	if m.Trackers[0][0] != "udp://tracker.rain:2710" {
+		t.Fatal("invalid tracker")
+	}
+	s := m.String()
+	if !strings.EqualFold(u, s) {
+		t.Log(u)
+		t.Log(s)
+		t.FailNow()
+	}
+}
+
+func TestParsePeers(t *testing.T) {
+	u := "magnet:?xt=urn:btih:F60CC95E3566AF84C1AB223FD4CE80FA88E6438A&x.pe=1.2.3.4:6881&x.pe=[::1]:6882&x.pe=peer.rain:6883&x.pe=invalid&x.pe=1.2.3.4:0"
+	m, err := New(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"1.2.3.4:6881", "[::1]:6882", "peer.rain:6883"}
+	if len(m.Peers) != len(expected) {
+		t.Fatalf("invalid peers: %v", m.Peers)
+	}
+	for i, pe := range expected {
+		if m.Peers[i] != pe {
+			t.Fatalf("invalid peer: %s", m.Peers[i])
+		}
+	}
+}
+
+func TestParseInfoHash(t *testing.T) {
+	for _, s := range []string{"F60CC95E3566AF84C1AB223FD4CE80FA88E6438A", "f60cc95e3566af84c1ab223fd4ce80fa88e6438a", "6ygmsxrvm2xyjqnlei75jtua7keomq4k"} {
+		ih, err := ParseInfoHash(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(ih[:]) != "f60cc95e3566af84c1ab223fd4ce80fa88e6438a" {
+			t.Fatalf("invalid info hash: %s", s)
+		}
+	}
+	for _, s := range []string{"", "F60CC95E", "magnet:?xt=urn:btih:F60CC95E3566AF84C1AB223FD4CE80FA88E6438A", "Z60CC95E3566AF84C1AB223FD4CE80FA88E6438A"} {
+		if _, err := ParseInfoHash(s); err == nil {
+			t.Fatalf("expected error: %s", s)
+		}
+	}
+}
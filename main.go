@@ -1,8 +1,13 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
 	"crypto/sha1"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 
@@ -11,28 +16,45 @@ import (
 	_ "net/http/pprof"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"runtime"
 	"runtime/pprof"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/boltdb/bolt"
 	"github.com/cenkalti/boltbrowser/boltbrowser"
+	"github.com/cenkalti/rain/internal/bitfield"
 	"github.com/cenkalti/rain/internal/console"
+	"github.com/cenkalti/rain/internal/fuse"
 	"github.com/cenkalti/rain/internal/logger"
-	"github.com/cenkalti/rain/internal/magnet"
-	"github.com/cenkalti/rain/internal/metainfo"
+	"github.com/cenkalti/rain/internal/resumer/boltdbresumer"
+	"github.com/cenkalti/rain/internal/toml"
+	"github.com/cenkalti/rain/magnet"
+	"github.com/cenkalti/rain/metainfo"
 	"github.com/cenkalti/rain/rainrpc"
 	"github.com/cenkalti/rain/torrent"
 	"github.com/hokaccha/go-prettyjson"
 	"github.com/mitchellh/go-homedir"
 	"github.com/urfave/cli"
 	"github.com/zeebo/bencode"
+	"go.etcd.io/bbolt"
 	"gopkg.in/yaml.v2"
 )
 
+// Exit codes of the download command.
+// Other errors exit with code 1.
+const (
+	exitCodeTorrentError = 2
+	exitCodeTimeout      = 3
+	// Download is stopped with SIGINT or SIGTERM before it is completed.
+	// Same as the code returned by shells for a process killed with SIGINT.
+	exitCodeInterrupted = 130
+)
+
 var (
 	app = cli.NewApp()
 	clt *rainrpc.Client
@@ -79,8 +101,9 @@ func main() {
 			Action: printBashAutoComplete,
 		},
 		{
-			Name:  "download",
-			Usage: "download single torrent",
+			Name:        "download",
+			Usage:       "download single torrent",
+			Description: "Exit code is 0 on success, 2 if the torrent stops with an error, 3 on timeout and 130 if interrupted before completion.",
 			Flags: []cli.Flag{
 				cli.StringFlag{
 					Name:  "config,c",
@@ -89,7 +112,7 @@ func main() {
 				},
 				cli.StringFlag{
 					Name:     "torrent,t",
-					Usage:    "torrent file or URI",
+					Usage:    "torrent file, URI or info hash",
 					Required: true,
 				},
 				cli.BoolFlag{
@@ -101,9 +124,170 @@ func main() {
 					Name:  "resume,r",
 					Usage: "path to .resume file",
 				},
+				cli.StringSliceFlag{
+					Name:  "tracker",
+					Usage: "add tracker `URL` to torrent, can be given multiple times",
+				},
+				cli.DurationFlag{
+					Name:  "shutdown-timeout",
+					Usage: "exit without waiting torrent to stop after duration",
+					Value: time.Minute,
+				},
+				cli.BoolFlag{
+					Name:  "json",
+					Usage: "print stats as newline-delimited JSON every second instead of a progress bar",
+				},
+				cli.DurationFlag{
+					Name:  "json-interval",
+					Usage: "print stats as newline-delimited JSON at every `DURATION` instead of a progress bar",
+				},
+				cli.StringFlag{
+					Name:  "on-complete",
+					Usage: "run `COMMAND` with sh when the download is completed",
+				},
+				cli.StringFlag{
+					Name:  "save-torrent",
+					Usage: "save .torrent file into `DIR` after downloading metadata of a magnet link",
+				},
+				cli.DurationFlag{
+					Name:  "timeout",
+					Usage: "stop and exit with code 3 if the download is not completed in `DURATION`",
+				},
 			},
 			Action: handleDownload,
 		},
+		{
+			Name:        "mount",
+			Usage:       "mount files of a torrent as a read-only file system",
+			Description: "Files are downloaded on demand while they are read. Only supported on Linux.",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "config,c",
+					Usage: "read config from `FILE`",
+					Value: "~/rain/config.yaml",
+				},
+				cli.StringFlag{
+					Name:     "torrent,t",
+					Usage:    "torrent file, URI or info hash",
+					Required: true,
+				},
+				cli.StringFlag{
+					Name:     "mountpoint,m",
+					Usage:    "mount files at `DIR`",
+					Required: true,
+				},
+				cli.StringFlag{
+					Name:  "resume,r",
+					Usage: "path to .resume file",
+				},
+				cli.StringSliceFlag{
+					Name:  "tracker",
+					Usage: "add tracker `URL` to torrent, can be given multiple times",
+				},
+				cli.DurationFlag{
+					Name:  "shutdown-timeout",
+					Usage: "exit without waiting torrent to stop after duration",
+					Value: time.Minute,
+				},
+			},
+			Action: handleMount,
+		},
+		{
+			Name:  "serve",
+			Usage: "create a torrent from a file or directory and seed it",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "config,c",
+					Usage: "read config from `FILE`",
+					Value: "~/rain/config.yaml",
+				},
+				cli.StringFlag{
+					Name:     "file,f",
+					Usage:    "seed this file or directory",
+					Required: true,
+				},
+				cli.StringFlag{
+					Name:  "out,o",
+					Usage: "save generated torrent to this `FILE`",
+				},
+				cli.StringSliceFlag{
+					Name:  "tracker,t",
+					Usage: "add tracker `URL`",
+				},
+				cli.BoolFlag{
+					Name:  "private,p",
+					Usage: "create torrent for private trackers",
+				},
+				cli.DurationFlag{
+					Name:  "shutdown-timeout",
+					Usage: "exit without waiting torrent to stop after duration",
+					Value: time.Minute,
+				},
+			},
+			Action: handleServe,
+		},
+		{
+			Name:  "seed",
+			Usage: "seed existing data of a torrent without downloading missing pieces",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "config,c",
+					Usage: "read config from `FILE`",
+					Value: "~/rain/config.yaml",
+				},
+				cli.StringFlag{
+					Name:     "torrent,t",
+					Usage:    "torrent `FILE`",
+					Required: true,
+				},
+				cli.StringFlag{
+					Name:     "data,d",
+					Usage:    "`DIR` containing the files of the torrent",
+					Required: true,
+				},
+				cli.StringSliceFlag{
+					Name:  "tracker",
+					Usage: "add tracker `URL` to torrent, can be given multiple times",
+				},
+				cli.DurationFlag{
+					Name:  "shutdown-timeout",
+					Usage: "exit without waiting torrent to stop after duration",
+					Value: time.Minute,
+				},
+			},
+			Action: handleSeed,
+		},
+		{
+			Name:        "resume",
+			Usage:       "list and restart downloads from .resume files",
+			Description: "Without --file or --all, torrents in the .resume files are listed with their completion.",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "config,c",
+					Usage: "read config from `FILE`",
+					Value: "~/rain/config.yaml",
+				},
+				cli.StringFlag{
+					Name:  "dir,d",
+					Usage: "search .resume files in `DIR`",
+					Value: ".",
+				},
+				cli.StringSliceFlag{
+					Name:  "file,f",
+					Usage: "restart torrent in .resume `FILE`, can be given multiple times",
+				},
+				cli.BoolFlag{
+					Name:  "all,a",
+					Usage: "restart torrents in all .resume files one after another",
+				},
+				cli.DurationFlag{
+					Name:  "shutdown-timeout",
+					Usage: "exit without waiting torrent to stop after duration",
+					Value: time.Minute,
+				},
+			},
+			Action: handleResumeFiles,
+		},
 		{
 			Name:  "magnet-to-torrent",
 			Usage: "download torrent from magnet link",
@@ -139,9 +323,183 @@ func main() {
 					Usage: "read config from `FILE`",
 					Value: "~/rain/config.yaml",
 				},
+				cli.DurationFlag{
+					Name:  "shutdown-timeout",
+					Usage: "exit without waiting torrents to stop after duration",
+					Value: time.Minute,
+				},
+				cli.StringFlag{
+					Name:  "on-complete",
+					Usage: "run `COMMAND` with sh when a torrent is completed",
+				},
 			},
 			Action: handleServer,
 		},
+		{
+			Name:  "config",
+			Usage: "manage config file",
+			Subcommands: []cli.Command{
+				{
+					Name:  "init",
+					Usage: "write config file with default values and descriptions of all keys",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "config,c",
+							Usage: "write config to `FILE`",
+							Value: "~/rain/config.yaml",
+						},
+						cli.BoolFlag{
+							Name:  "force,f",
+							Usage: "overwrite existing file",
+						},
+					},
+					Action: handleConfigInit,
+				},
+				{
+					Name:  "show",
+					Usage: "print effective config after applying config file and flags to defaults",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "config,c",
+							Usage: "read config from `FILE`",
+							Value: "~/rain/config.yaml",
+						},
+						cli.StringFlag{
+							Name:  "on-complete",
+							Usage: "run `COMMAND` with sh when a torrent is completed",
+						},
+						cli.StringFlag{
+							Name:  "save-torrent",
+							Usage: "save .torrent file into `DIR` after downloading metadata of a magnet link",
+						},
+					},
+					Action: handleConfigShow,
+				},
+			},
+		},
+		{
+			Name:  "bench",
+			Usage: "measure hashing, disk and wire throughput with an in-process seeder and leecher",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "config,c",
+					Usage: "read config from `FILE`",
+					Value: "~/rain/config.yaml",
+				},
+				cli.Int64Flag{
+					Name:  "size,s",
+					Usage: "size of synthetic data in `MB`",
+					Value: 256,
+				},
+				cli.UintFlag{
+					Name:  "piece-length",
+					Usage: "piece length in `KB`, 0 for automatic",
+					Value: 256,
+				},
+				cli.StringFlag{
+					Name:  "dir,d",
+					Usage: "create temporary files under `DIR`, default is the system temp dir",
+				},
+				cli.DurationFlag{
+					Name:  "timeout",
+					Usage: "abort if the benchmark does not finish in duration",
+					Value: 10 * time.Minute,
+				},
+			},
+			Action: handleBench,
+		},
+		{
+			Name:  "dht",
+			Usage: "store and retrieve items on dht (BEP 44)",
+			Subcommands: []cli.Command{
+				{
+					Name:      "get",
+					Usage:     "get immutable item by target or mutable item by public key",
+					ArgsUsage: " ",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "config,c",
+							Usage: "read config from `FILE`",
+							Value: "~/rain/config.yaml",
+						},
+						cli.StringFlag{
+							Name:  "target,t",
+							Usage: "target of immutable item in hex",
+						},
+						cli.StringFlag{
+							Name:  "key,k",
+							Usage: "public key of mutable item in hex",
+						},
+						cli.StringFlag{
+							Name:  "salt,s",
+							Usage: "salt of mutable item",
+						},
+						cli.DurationFlag{
+							Name:  "timeout",
+							Usage: "command fails if item cannot be found after duration",
+							Value: time.Minute,
+						},
+					},
+					Action: handleDHTGet,
+				},
+				{
+					Name:  "put",
+					Usage: "put immutable item, or mutable item if key file is given",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "config,c",
+							Usage: "read config from `FILE`",
+							Value: "~/rain/config.yaml",
+						},
+						cli.StringFlag{
+							Name:     "value,v",
+							Usage:    "value of item, stored as bencoded string",
+							Required: true,
+						},
+						cli.BoolFlag{
+							Name:  "bencoded,b",
+							Usage: "value is already bencoded",
+						},
+						cli.StringFlag{
+							Name:  "key-file,k",
+							Usage: "read private key of mutable item from `FILE`, new key is written if the file does not exist",
+						},
+						cli.StringFlag{
+							Name:  "salt,s",
+							Usage: "salt of mutable item",
+						},
+						cli.Int64Flag{
+							Name:  "seq",
+							Usage: "sequence number of mutable item, default is one more than the current item",
+							Value: -1,
+						},
+						cli.DurationFlag{
+							Name:  "timeout",
+							Usage: "command fails if item cannot be stored after duration",
+							Value: time.Minute,
+						},
+					},
+					Action: handleDHTPut,
+				},
+				{
+					Name:  "crawl",
+					Usage: "print info hashes sampled from dht nodes (BEP 51)",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "config,c",
+							Usage: "read config from `FILE`",
+							Value: "~/rain/config.yaml",
+						},
+						cli.DurationFlag{
+							Name:  "duration,d",
+							Usage: "stop crawling after duration",
+							Value: time.Minute,
+						},
+					},
+					Action: handleDHTCrawl,
+				},
+			},
+		},
 		{
 			Name:  "client",
 			Usage: "send rpc request to server",
@@ -179,7 +537,7 @@ func main() {
 					Flags: []cli.Flag{
 						cli.StringFlag{
 							Name:     "torrent,t",
-							Usage:    "file or URI",
+							Usage:    "file, URI or info hash",
 							Required: true,
 						},
 						cli.BoolFlag{
@@ -194,10 +552,27 @@ func main() {
 							Name:  "stop-after-metadata",
 							Usage: "stop the torrent after metadata download is finished",
 						},
+						cli.BoolFlag{
+							Name:  "seed-only",
+							Usage: "only seed existing data, do not download missing pieces",
+						},
 						cli.StringFlag{
 							Name:  "id",
 							Usage: "if id is not given, a unique id is automatically generated",
 						},
+						cli.StringSliceFlag{
+							Name:  "tracker",
+							Usage: "add tracker `URL` to torrent, can be given multiple times",
+						},
+						cli.StringFlag{
+							Name:  "priority",
+							Usage: "priority of the torrent: low, normal or high",
+							Value: "normal",
+						},
+						cli.StringFlag{
+							Name:  "cross-seed",
+							Usage: "seed the files of existing torrent with `ID` instead of downloading them again",
+						},
 					},
 				},
 				{
@@ -234,6 +609,12 @@ func main() {
 						},
 					},
 				},
+				{
+					Name:     "settings",
+					Usage:    "get settings of session that can be changed with set-settings",
+					Category: "Getters",
+					Action:   handleSettings,
+				},
 				{
 					Name:     "session-stats",
 					Usage:    "get stats of session",
@@ -246,6 +627,30 @@ func main() {
 						},
 					},
 				},
+				{
+					Name:     "dht-stats",
+					Usage:    "get stats of DHT node",
+					Category: "Getters",
+					Action:   handleDHTStats,
+				},
+				{
+					Name:     "dht-get-peers",
+					Usage:    "find peers of an info hash on DHT",
+					Category: "Getters",
+					Action:   handleDHTGetPeers,
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:     "infohash,i",
+							Required: true,
+							Usage:    "info hash in hex",
+						},
+						cli.DurationFlag{
+							Name:  "duration,d",
+							Usage: "duration of the lookup, must be less than request timeout",
+							Value: 5 * time.Second,
+						},
+					},
+				},
 				{
 					Name:     "trackers",
 					Usage:    "get trackers of torrent",
@@ -326,6 +731,10 @@ func main() {
 							Name:     "id",
 							Required: true,
 						},
+						cli.BoolFlag{
+							Name:  "force",
+							Usage: "announce immediately, ignoring the minimum interval",
+						},
 					},
 				},
 				{
@@ -364,6 +773,79 @@ func main() {
 						},
 					},
 				},
+				{
+					Name:     "set-priority",
+					Usage:    "change priority of torrent",
+					Category: "Actions",
+					Action:   handleSetPriority,
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:     "id",
+							Required: true,
+						},
+						cli.StringFlag{
+							Name:     "priority",
+							Usage:    "low, normal or high",
+							Required: true,
+						},
+					},
+				},
+				{
+					Name:     "set-settings",
+					Usage:    "change settings of running session, only given values are changed",
+					Category: "Actions",
+					Action:   handleSetSettings,
+					Flags: []cli.Flag{
+						cli.Int64Flag{
+							Name:  "speed-limit-download",
+							Usage: "global download speed limit in KB/s, 0 for unlimited",
+						},
+						cli.Int64Flag{
+							Name:  "speed-limit-upload",
+							Usage: "global upload speed limit in KB/s, 0 for unlimited",
+						},
+						cli.IntFlag{
+							Name:  "max-peer-dial",
+							Usage: "max number of outgoing connections for each torrent",
+						},
+						cli.IntFlag{
+							Name:  "max-peer-accept",
+							Usage: "max number of incoming connections for each torrent",
+						},
+						cli.IntFlag{
+							Name:  "queue-max-active-downloads",
+							Usage: "max number of torrents downloading at the same time, 0 for unlimited",
+						},
+						cli.IntFlag{
+							Name:  "queue-max-active-seeds",
+							Usage: "max number of torrents seeding at the same time, 0 for unlimited",
+						},
+					},
+				},
+				{
+					Name:     "pause",
+					Usage:    "pause torrent",
+					Category: "Actions",
+					Action:   handlePause,
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:     "id",
+							Required: true,
+						},
+					},
+				},
+				{
+					Name:     "resume",
+					Usage:    "resume paused torrent",
+					Category: "Actions",
+					Action:   handleResume,
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:     "id",
+							Required: true,
+						},
+					},
+				},
 				{
 					Name:     "start-all",
 					Usage:    "start all torrents",
@@ -623,9 +1105,42 @@ func handleAfterCommand(c *cli.Context) error {
 	return nil
 }
 
-func prepareConfig(c *cli.Context) (torrent.Config, error) {
-	cfg := torrent.DefaultConfig
+// logConfig contains the logging settings in config file that are not part of torrent.Config.
+type logConfig struct {
+	Debug bool `yaml:"debug"`
+}
 
+// fileConfig contains all keys in the config file.
+type fileConfig struct {
+	torrent.Config `yaml:",inline"`
+	logConfig      `yaml:",inline"`
+}
+
+// parseConfigFile parses the contents of the config file at path, applying it on top of the default config.
+// Format of the file is detected from the extension: TOML for ".toml", YAML otherwise. JSON files are parsed as YAML.
+// Unknown keys are reported as errors.
+func parseConfigFile(path string, b []byte) (torrent.Config, logConfig, error) {
+	fc := fileConfig{Config: torrent.DefaultConfig}
+	if strings.ToLower(filepath.Ext(path)) == ".toml" {
+		m, err := toml.Unmarshal(b)
+		if err != nil {
+			return fc.Config, fc.logConfig, err
+		}
+		// Decoded by yaml package, so values are converted to the types of the fields in the same way.
+		b, err = yaml.Marshal(m)
+		if err != nil {
+			return fc.Config, fc.logConfig, err
+		}
+	}
+	err := yaml.UnmarshalStrict(b, &fc)
+	return fc.Config, fc.logConfig, err
+}
+
+func prepareConfig(c *cli.Context) (torrent.Config, error) {
+	cfg := torrent.DefaultConfig
+	var lc logConfig
+
+	var loadedFrom string
 	configPath := c.String("config")
 	if configPath != "" {
 		cp, err := homedir.Expand(configPath)
@@ -642,17 +1157,41 @@ func prepareConfig(c *cli.Context) (torrent.Config, error) {
 		case err != nil:
 			return cfg, err
 		default:
-			err = yaml.Unmarshal(b, &cfg)
-			if err != nil {
-				return cfg, err
-			}
-			log.Infoln("config loaded from:", cp)
-			b, err = yaml.Marshal(&cfg)
+			cfg, lc, err = parseConfigFile(cp, b)
 			if err != nil {
 				return cfg, err
 			}
-			log.Debug("\n" + string(b))
+			loadedFrom = cp
+		}
+	}
+	envKeys, err := applyConfigEnv(&cfg, &lc, os.Environ())
+	if err != nil {
+		return cfg, err
+	}
+	if loadedFrom != "" || len(envKeys) > 0 {
+		if lc.Debug || c.GlobalBool("debug") {
+			logger.SetDebug()
+		} else {
+			logger.SetInfo()
+		}
+		if loadedFrom != "" {
+			log.Infoln("config loaded from:", loadedFrom)
 		}
+		if len(envKeys) > 0 {
+			log.Infoln("config keys set from environment:", strings.Join(envKeys, ", "))
+		}
+		b, err := yaml.Marshal(&cfg)
+		if err != nil {
+			return cfg, err
+		}
+		log.Debug("\n" + string(b))
+	}
+	if c.IsSet("save-torrent") {
+		cfg.SaveTorrentDir = c.String("save-torrent")
+	}
+	if c.IsSet("on-complete") {
+		// Run with the shell so quoted arguments and pipes work as expected.
+		cfg.OnCompleteCmd = []string{"sh", "-c", c.String("on-complete")}
 	}
 	return cfg, nil
 }
@@ -667,65 +1206,140 @@ func handleServer(c *cli.Context) error {
 		return err
 	}
 	ch := make(chan os.Signal, 1)
-	signal.Notify(ch, syscall.SIGINT, syscall.SIGTERM)
-	s := <-ch
-	log.Noticef("received %s, stopping server", s)
+	signal.Notify(ch, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	for {
+		s := <-ch
+		if s != syscall.SIGHUP {
+			log.Noticef("received %s, stopping server", s)
+			break
+		}
+		log.Noticef("received %s, reloading config", s)
+		cfg, err = prepareConfig(c)
+		if err != nil {
+			log.Errorln("cannot reload config:", err.Error())
+			continue
+		}
+		err = ses.ReloadConfig(cfg)
+		if err != nil {
+			log.Errorln("cannot reload config:", err.Error())
+		}
+	}
 
-	return ses.Close()
+	return closeSession(ses, ch, c.Duration("shutdown-timeout"))
 }
 
-func handleDownload(c *cli.Context) error {
+// closeSession closes the session by waiting at most timeout duration.
+// Receiving another signal from ch while closing causes an immediate return.
+func closeSession(ses *torrent.Session, ch chan os.Signal, timeout time.Duration) error {
+	errC := make(chan error, 1)
+	go func() {
+		errC <- ses.Close()
+	}()
+	select {
+	case err := <-errC:
+		return err
+	case s := <-ch:
+		return fmt.Errorf("received %s, exiting without waiting session to close", s)
+	case <-time.After(timeout):
+		return errors.New("timeout while closing session")
+	}
+}
+
+// formatProgress returns a single line of text that shows the progress of the torrent.
+// isTerminal returns true if f is a character device, e.g. not redirected to a file or pipe.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+func formatProgress(stats torrent.Stats) string {
+	const barWidth = 30
+	var progress int64
+	if stats.Bytes.Total > 0 {
+		progress = (stats.Bytes.Completed * 100) / stats.Bytes.Total
+	}
+	filled := int(progress * barWidth / 100)
+	bar := strings.Repeat("#", filled) + strings.Repeat("-", barWidth-filled)
+	eta := "?"
+	if stats.ETA != nil {
+		eta = stats.ETA.String()
+	}
+	return fmt.Sprintf("%s [%s] %3d%% D: %s U: %s Peers: %d ETA: %s",
+		stats.Status, bar, progress, formatSpeed(stats.Speed.DownloadCurrent), formatSpeed(stats.Speed.UploadCurrent), stats.Peers.Total, eta)
+}
+
+func formatSpeed(bps int) string {
+	switch {
+	case bps < 1<<10:
+		return fmt.Sprintf("%d B/s", bps)
+	case bps < 1<<20:
+		return fmt.Sprintf("%.1f KiB/s", float64(bps)/(1<<10))
+	default:
+		return fmt.Sprintf("%.1f MiB/s", float64(bps)/(1<<20))
+	}
+}
+
+// startTorrent creates a session with the data directory at the working directory and starts the torrent given in the flags.
+// Resume data is used if it exists for the same torrent.
+func startTorrent(c *cli.Context, stopAfterDownload bool) (*torrent.Session, *torrent.Torrent, error) {
 	arg := c.String("torrent")
-	seed := c.Bool("seed")
-	resume := c.String("resume")
 	cfg, err := prepareConfig(c)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 	cfg.DataDir = "."
 	cfg.DataDirIncludesTorrentID = false
+	if link, ok := infoHashToMagnet(arg); ok {
+		arg = link
+	}
 	var ih torrent.InfoHash
 	if strings.HasPrefix(arg, "magnet:") {
 		magnet, err := magnet.New(arg)
 		if err != nil {
-			return err
+			return nil, nil, err
 		}
 		ih = torrent.InfoHash(magnet.InfoHash)
-		cfg.Database = magnet.Name + ".resume"
+		if magnet.Name != "" {
+			cfg.Database = magnet.Name + ".resume"
+		} else {
+			cfg.Database = ih.String() + ".resume"
+		}
 	} else {
 		var rc io.ReadCloser
 
 		if strings.HasPrefix(arg, "http://") || strings.HasPrefix(arg, "https://") {
 			resp, err := http.DefaultClient.Get(arg) // nolint: noctx
 			if err != nil {
-				return err
+				return nil, nil, err
 			}
 			defer resp.Body.Close()
 			rc = resp.Body
 		} else {
 			f, err := os.Open(arg)
 			if err != nil {
-				return err
+				return nil, nil, err
 			}
 			defer f.Close()
 			rc = f
 		}
 		mi, err := metainfo.New(rc)
 		if err != nil {
-			return err
+			return nil, nil, err
 		}
 		rc.Close()
 		ih = mi.Info.Hash
 		cfg.Database = mi.Info.Name + ".resume"
 	}
-	if resume != "" {
+	if resume := c.String("resume"); resume != "" {
 		cfg.Database = resume
 	}
 	ses, err := torrent.NewSession(cfg)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
-	defer ses.Close()
 	var t *torrent.Torrent
 	torrents := ses.ListTorrents()
 	if len(torrents) > 0 && torrents[0].InfoHash() == ih {
@@ -735,50 +1349,533 @@ func handleDownload(c *cli.Context) error {
 	} else {
 		// Add as new torrent
 		opt := &torrent.AddTorrentOptions{
-			StopAfterDownload: !seed,
+			StopAfterDownload: stopAfterDownload,
 		}
 		if isURI(arg) {
 			t, err = ses.AddURI(arg, opt)
 		} else {
 			var f *os.File
 			f, err = os.Open(arg)
-			if err != nil {
-				return err
+			if err == nil {
+				t, err = ses.AddTorrent(f, opt)
+				f.Close()
 			}
-			t, err = ses.AddTorrent(f, opt)
-			f.Close()
 		}
 	}
+	if err != nil {
+		ses.Close()
+		return nil, nil, err
+	}
+	err = t.AddTrackers(c.StringSlice("tracker"))
+	if err != nil {
+		ses.Close()
+		return nil, nil, err
+	}
+	return ses, t, nil
+}
+
+func handleDownload(c *cli.Context) error {
+	ses, t, err := startTorrent(c, !c.Bool("seed"))
 	if err != nil {
 		return err
 	}
 	ch := make(chan os.Signal, 1)
 	signal.Notify(ch, syscall.SIGINT, syscall.SIGTERM)
+	var shutdownTimeoutC <-chan time.Time
+	var timeoutC <-chan time.Time
+	if timeout := c.Duration("timeout"); timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		timeoutC = timer.C
+	}
+	completeC := t.NotifyComplete()
+	timedOut := false
+	interrupted := false
+	jsonInterval := c.Duration("json-interval")
+	statsInterval := time.Second
+	if jsonInterval > 0 {
+		statsInterval = jsonInterval
+	}
+	jsonOutput := jsonInterval > 0 || c.Bool("json")
+	// Progress bar is redrawn in place, which corrupts the output if it is redirected to a file.
+	showProgress := !jsonOutput && isTerminal(os.Stderr)
+	var statsC <-chan torrent.Stats
+	if jsonOutput || showProgress {
+		var stopStats func()
+		statsC, stopStats = t.NotifyStats(statsInterval)
+		defer stopStats()
+	}
+	enc := json.NewEncoder(os.Stdout)
 	for {
 		select {
 		case s := <-ch:
+			if shutdownTimeoutC != nil {
+				return cli.NewExitError(fmt.Sprintf("received %s, exiting without waiting torrent to stop", s), exitCodeInterrupted)
+			}
 			log.Noticef("received %s, stopping torrent", s)
+			interrupted = completeC != nil
 			err = t.Stop()
 			if err != nil {
+				ses.Close()
 				return err
 			}
-		case <-time.After(time.Second):
-			stats := t.Stats()
-			progress := 0
-			if stats.Bytes.Total > 0 {
-				progress = int((stats.Bytes.Completed * 100) / stats.Bytes.Total)
+			shutdownTimeoutC = time.After(c.Duration("shutdown-timeout"))
+		case <-shutdownTimeoutC:
+			if code := downloadExitCode(nil, timedOut, interrupted); code != 0 {
+				return cli.NewExitError("timeout while stopping torrent", code)
+			}
+			return errors.New("timeout while stopping torrent")
+		case <-completeC:
+			completeC = nil
+			timeoutC = nil
+		case <-timeoutC:
+			timeoutC = nil
+			if shutdownTimeoutC != nil {
+				continue
+			}
+			log.Noticef("download is not completed in %s, stopping torrent", c.Duration("timeout"))
+			timedOut = true
+			err = t.Stop()
+			if err != nil {
+				ses.Close()
+				return err
 			}
-			eta := "?"
-			if stats.ETA != nil {
-				eta = stats.ETA.String()
+			shutdownTimeoutC = time.After(c.Duration("shutdown-timeout"))
+		case stats, ok := <-statsC:
+			if !ok {
+				statsC = nil
+				continue
 			}
-			log.Infof("Status: %s, Progress: %d%%, Peers: %d, Speed: %dK/s, ETA: %s\n", stats.Status.String(), progress, stats.Peers.Total, stats.Speed.Download/1024, eta)
+			if jsonOutput {
+				err = enc.Encode(stats)
+				if err != nil {
+					return err
+				}
+			} else {
+				_, _ = os.Stderr.WriteString("\r" + formatProgress(stats) + "\033[K")
+			}
+		case err = <-t.NotifyStop():
+			if showProgress {
+				_, _ = os.Stderr.WriteString("\n")
+			}
+			closeErr := closeSession(ses, ch, c.Duration("shutdown-timeout"))
+			switch code := downloadExitCode(err, timedOut, interrupted); code {
+			case exitCodeTorrentError:
+				return cli.NewExitError(err.Error(), code)
+			case exitCodeTimeout:
+				return cli.NewExitError("download timed out", code)
+			case exitCodeInterrupted:
+				return cli.NewExitError("download interrupted", code)
+			}
+			return closeErr
+		}
+	}
+}
+
+// downloadExitCode returns the exit code of the download command after the torrent is stopped.
+// Zero means the download is completed successfully.
+func downloadExitCode(err error, timedOut, interrupted bool) int {
+	switch {
+	case err != nil:
+		return exitCodeTorrentError
+	case timedOut:
+		return exitCodeTimeout
+	case interrupted:
+		return exitCodeInterrupted
+	default:
+		return 0
+	}
+}
+
+func handleMount(c *cli.Context) error {
+	ses, t, err := startTorrent(c, false)
+	if err != nil {
+		return err
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGINT, syscall.SIGTERM)
+	// Files are known after the metadata is downloaded and the torrent is verified.
+	var files []torrent.File
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for files == nil {
+		select {
+		case s := <-ch:
+			log.Noticef("received %s, stopping torrent", s)
+			return closeSession(ses, ch, c.Duration("shutdown-timeout"))
+		case err = <-t.NotifyStop():
+			ses.Close()
+			return err
+		case <-ticker.C:
+			files, _ = t.Files()
+		}
+	}
+	srv, err := fuse.Mount(c.String("mountpoint"), fileTree(files))
+	if err != nil {
+		ses.Close()
+		return err
+	}
+	log.Noticef("mounted torrent at %s", c.String("mountpoint"))
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- srv.Serve() }()
+	select {
+	case s := <-ch:
+		log.Noticef("received %s, unmounting", s)
+		err = srv.Unmount()
+		if err != nil {
+			log.Error(err)
+		}
+		<-serveErr
+	case err = <-serveErr:
+		// Unmounted by the user.
+		if err != nil {
+			log.Error(err)
+		}
+	}
+	return closeSession(ses, ch, c.Duration("shutdown-timeout"))
+}
+
+// fileTree returns the directory tree of the torrent files for mounting.
+func fileTree(files []torrent.File) *fuse.Node {
+	root := &fuse.Node{Children: []*fuse.Node{}}
+	dirs := map[string]*fuse.Node{".": root}
+	var dir func(p string) *fuse.Node
+	dir = func(p string) *fuse.Node {
+		if n, ok := dirs[p]; ok {
+			return n
+		}
+		parent := dir(filepath.Dir(p))
+		n := &fuse.Node{Name: filepath.Base(p), Children: []*fuse.Node{}}
+		parent.Children = append(parent.Children, n)
+		dirs[p] = n
+		return n
+	}
+	for _, f := range files {
+		f := f
+		parent := dir(filepath.Dir(f.Path()))
+		parent.Children = append(parent.Children, &fuse.Node{
+			Name: filepath.Base(f.Path()),
+			Size: f.Stats().BytesTotal,
+			Open: func() (fuse.File, error) {
+				r, err := f.Open()
+				if err != nil {
+					return nil, err
+				}
+				return &mountedFile{r: r}, nil
+			},
+		})
+	}
+	return root
+}
+
+// mountedFile adapts FileReader to the file interface of the FUSE server, which needs concurrent reads at any offset.
+type mountedFile struct {
+	m sync.Mutex
+	r *torrent.FileReader
+}
+
+func (f *mountedFile) ReadAt(p []byte, off int64) (int, error) {
+	f.m.Lock()
+	defer f.m.Unlock()
+	_, err := f.r.Seek(off, io.SeekStart)
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.ReadFull(f.r, p)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func (f *mountedFile) Close() error {
+	return f.r.Close()
+}
+
+func handleServe(c *cli.Context) error {
+	path, err := homedir.Expand(c.String("file"))
+	if err != nil {
+		return err
+	}
+	path, err = filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	trackers := c.StringSlice("tracker")
+	tiers := make([][]string, len(trackers))
+	for i, tr := range trackers {
+		tiers[i] = []string{tr}
+	}
+	info, err := metainfo.NewInfoBytes("", []string{path}, c.Bool("private"), 0, "", log)
+	if err != nil {
+		return err
+	}
+	mi, err := metainfo.NewBytes(info, tiers, nil, "")
+	if err != nil {
+		return err
+	}
+	if out := c.String("out"); out != "" {
+		err = os.WriteFile(out, mi, 0o666)
+		if err != nil {
+			return err
+		}
+	}
+	cfg, err := prepareConfig(c)
+	if err != nil {
+		return err
+	}
+	// Files of the torrent are under the directory containing the path.
+	cfg.DataDir = filepath.Dir(path)
+	cfg.DataDirIncludesTorrentID = false
+	dbFile, err := os.CreateTemp("", "")
+	if err != nil {
+		return err
+	}
+	dbFileName := dbFile.Name()
+	defer os.Remove(dbFileName)
+	err = dbFile.Close()
+	if err != nil {
+		return err
+	}
+	cfg.Database = dbFileName
+	ses, err := torrent.NewSession(cfg)
+	if err != nil {
+		return err
+	}
+	t, err := ses.AddTorrent(bytes.NewReader(mi), nil)
+	if err != nil {
+		ses.Close()
+		return err
+	}
+	magnet, err := t.Magnet()
+	if err != nil {
+		ses.Close()
+		return err
+	}
+	fmt.Println(magnet)
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGINT, syscall.SIGTERM)
+	select {
+	case s := <-ch:
+		log.Noticef("received %s, stopping torrent", s)
+	case err = <-t.NotifyStop():
+		ses.Close()
+		return err
+	}
+	return closeSession(ses, ch, c.Duration("shutdown-timeout"))
+}
+
+func handleSeed(c *cli.Context) error {
+	cfg, err := prepareConfig(c)
+	if err != nil {
+		return err
+	}
+	cfg.DataDir, err = homedir.Expand(c.String("data"))
+	if err != nil {
+		return err
+	}
+	cfg.DataDirIncludesTorrentID = false
+	// Missing files are not created because their pieces are never downloaded.
+	cfg.LazyFileCreation = true
+	dbFile, err := os.CreateTemp("", "")
+	if err != nil {
+		return err
+	}
+	dbFileName := dbFile.Name()
+	defer os.Remove(dbFileName)
+	err = dbFile.Close()
+	if err != nil {
+		return err
+	}
+	cfg.Database = dbFileName
+	f, err := os.Open(c.String("torrent"))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	ses, err := torrent.NewSession(cfg)
+	if err != nil {
+		return err
+	}
+	t, err := ses.AddTorrent(f, &torrent.AddTorrentOptions{SeedOnly: true, Trackers: c.StringSlice("tracker")})
+	if err != nil {
+		ses.Close()
+		return err
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGINT, syscall.SIGTERM)
+	// Completed bytes are known after the existing data is verified.
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case s := <-ch:
+			log.Noticef("received %s, stopping torrent", s)
+			return closeSession(ses, ch, c.Duration("shutdown-timeout"))
 		case err = <-t.NotifyStop():
+			ses.Close()
 			return err
+		case <-ticker.C:
+			stats := t.Stats()
+			if stats.Status == torrent.Seeding {
+				log.Noticef("seeding %d of %d bytes", stats.Bytes.Completed, stats.Bytes.Total)
+				ticker.Stop()
+			}
 		}
 	}
 }
 
+func handleResumeFiles(c *cli.Context) error {
+	files := c.StringSlice("file")
+	if c.Bool("all") {
+		var err error
+		files, err = filepath.Glob(filepath.Join(c.String("dir"), "*.resume"))
+		if err != nil {
+			return err
+		}
+	}
+	if len(files) == 0 {
+		return listResumeFiles(c.String("dir"))
+	}
+	for _, file := range files {
+		err := resumeDownload(c, file)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resumeEntry is a torrent found in a .resume file.
+type resumeEntry struct {
+	File     string
+	Name     string
+	InfoHash string
+	// Nil if metadata is not downloaded yet.
+	Bitfield *bitfield.Bitfield
+}
+
+func listResumeFiles(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.resume"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		entries, err := readResumeFile(file)
+		if err != nil {
+			log.Warningf("cannot read %s: %s", file, err)
+			continue
+		}
+		for _, e := range entries {
+			progress := "metadata not downloaded"
+			if e.Bitfield != nil && e.Bitfield.Len() > 0 {
+				progress = fmt.Sprintf("%d%%", e.Bitfield.Count()*100/e.Bitfield.Len())
+			}
+			fmt.Printf("%s\t%s\t%s\t%s\n", e.File, e.InfoHash, progress, e.Name)
+		}
+	}
+	return nil
+}
+
+// readResumeFile returns the torrents in a .resume file created by the download command.
+func readResumeFile(file string) ([]resumeEntry, error) {
+	// Database is locked if the download is still running.
+	db, err := bbolt.Open(file, 0600, &bbolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	bucket := []byte("torrents")
+	var ids []string
+	err = db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(bucket)
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, v []byte) error {
+			// Torrents are saved in nested buckets, which have nil values.
+			if v == nil {
+				ids = append(ids, string(k))
+			}
+			return nil
+		})
+	})
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+	res, err := boltdbresumer.New(db, bucket)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]resumeEntry, 0, len(ids))
+	for _, id := range ids {
+		spec, err := res.Read(id)
+		if err != nil {
+			return nil, err
+		}
+		e := resumeEntry{File: file, Name: spec.Name, InfoHash: hex.EncodeToString(spec.InfoHash)}
+		if len(spec.Info) > 0 {
+			info, err := metainfo.NewInfo(spec.Info, true, true, nil)
+			if err != nil {
+				return nil, err
+			}
+			e.Bitfield = bitfield.New(info.NumPieces)
+			if len(spec.Bitfield) > 0 {
+				e.Bitfield, err = bitfield.NewBytes(spec.Bitfield, info.NumPieces)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// resumeDownload starts the torrents in the .resume file and waits until they stop.
+// Files are saved next to the .resume file, same as the download command.
+func resumeDownload(c *cli.Context, file string) error {
+	cfg, err := prepareConfig(c)
+	if err != nil {
+		return err
+	}
+	cfg.DataDir = filepath.Dir(file)
+	cfg.DataDirIncludesTorrentID = false
+	cfg.Database = file
+	ses, err := torrent.NewSession(cfg)
+	if err != nil {
+		return err
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(ch)
+	for _, t := range ses.ListTorrents() {
+		log.Noticef("resuming %s from %s", t.Name(), file)
+		err = t.Start()
+		if err != nil {
+			ses.Close()
+			return err
+		}
+	}
+	for _, t := range ses.ListTorrents() {
+		select {
+		case s := <-ch:
+			log.Noticef("received %s, stopping torrent", s)
+			err = closeSession(ses, ch, c.Duration("shutdown-timeout"))
+			if err != nil {
+				return err
+			}
+			return cli.NewExitError("download interrupted", exitCodeInterrupted)
+		case err = <-t.NotifyStop():
+			if err != nil {
+				ses.Close()
+				return cli.NewExitError(err.Error(), exitCodeTorrentError)
+			}
+		}
+	}
+	return closeSession(ses, ch, c.Duration("shutdown-timeout"))
+}
+
 func handleMagnetToTorrent(c *cli.Context) error {
 	arg := c.String("magnet")
 	output := c.String("output")
@@ -856,6 +1953,213 @@ func handleMagnetToTorrent(c *cli.Context) error {
 	}
 }
 
+// newDHTSession returns a Session with a temporary database that is used only for running DHT queries.
+// DHT runs in read-only mode on a random port, so it does not conflict with a running server.
+func newDHTSession(c *cli.Context) (*torrent.Session, func(), error) {
+	cfg, err := prepareConfig(c)
+	if err != nil {
+		return nil, nil, err
+	}
+	dbFile, err := os.CreateTemp("", "")
+	if err != nil {
+		return nil, nil, err
+	}
+	dbFileName := dbFile.Name()
+	err = dbFile.Close()
+	if err != nil {
+		os.Remove(dbFileName)
+		return nil, nil, err
+	}
+	cfg.Database = dbFileName
+	cfg.RPCEnabled = false
+	cfg.DHTEnabled = true
+	cfg.DHTReadOnly = true
+	cfg.DHTPort = 0
+	ses, err := torrent.NewSession(cfg)
+	if err != nil {
+		os.Remove(dbFileName)
+		return nil, nil, err
+	}
+	return ses, func() {
+		ses.Close()
+		os.Remove(dbFileName)
+	}, nil
+}
+
+func handleDHTGet(c *cli.Context) error {
+	target := c.String("target")
+	key := c.String("key")
+	if (target == "") == (key == "") {
+		return errors.New("one of target or key must be given")
+	}
+	ses, closeSession, err := newDHTSession(c)
+	if err != nil {
+		return err
+	}
+	defer closeSession()
+	ctx, cancel := context.WithTimeout(context.Background(), c.Duration("timeout"))
+	defer cancel()
+	if target != "" {
+		var t [20]byte
+		err = decodeHexFlag(target, t[:])
+		if err != nil {
+			return err
+		}
+		value, err := ses.DHTGetImmutable(ctx, t)
+		if err != nil {
+			return err
+		}
+		printDHTValue(value)
+		return nil
+	}
+	var k [32]byte
+	err = decodeHexFlag(key, k[:])
+	if err != nil {
+		return err
+	}
+	item, err := ses.DHTGetMutable(ctx, k, []byte(c.String("salt")))
+	if err != nil {
+		return err
+	}
+	log.Infof("seq: %d", item.Seq)
+	printDHTValue(item.Value)
+	return nil
+}
+
+func handleDHTPut(c *cli.Context) error {
+	value := []byte(c.String("value"))
+	if !c.Bool("bencoded") {
+		var err error
+		value, err = bencode.EncodeBytes(c.String("value"))
+		if err != nil {
+			return err
+		}
+	}
+	ses, closeSession, err := newDHTSession(c)
+	if err != nil {
+		return err
+	}
+	defer closeSession()
+	ctx, cancel := context.WithTimeout(context.Background(), c.Duration("timeout"))
+	defer cancel()
+	if c.String("key-file") == "" {
+		target, err := ses.DHTPutImmutable(ctx, value)
+		if err != nil {
+			return err
+		}
+		fmt.Println(hex.EncodeToString(target[:]))
+		return nil
+	}
+	key, err := loadDHTKey(c.String("key-file"))
+	if err != nil {
+		return err
+	}
+	salt := []byte(c.String("salt"))
+	seq := c.Int64("seq")
+	if seq < 0 {
+		var pub [32]byte
+		copy(pub[:], key.Public().(ed25519.PublicKey))
+		current, err := ses.DHTGetMutable(ctx, pub, salt)
+		switch {
+		case err == torrent.ErrDHTItemNotFound:
+			seq = 0
+		case err != nil:
+			return err
+		default:
+			seq = current.Seq + 1
+		}
+	}
+	item, err := torrent.NewDHTMutableItem(key, salt, seq, value)
+	if err != nil {
+		return err
+	}
+	err = ses.DHTPutMutable(ctx, item)
+	if err != nil {
+		return err
+	}
+	log.Infof("seq: %d", item.Seq)
+	fmt.Println(hex.EncodeToString(item.PublicKey[:]))
+	return nil
+}
+
+func handleDHTCrawl(c *cli.Context) error {
+	ses, closeSession, err := newDHTSession(c)
+	if err != nil {
+		return err
+	}
+	defer closeSession()
+	ctx, cancel := context.WithTimeout(context.Background(), c.Duration("duration"))
+	defer cancel()
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(ch)
+	go func() {
+		select {
+		case <-ch:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ses.DHTCrawl(ctx, func(infoHash [20]byte) {
+		fmt.Println(hex.EncodeToString(infoHash[:]))
+	})
+}
+
+// loadDHTKey reads the hex encoded seed of the ed25519 key from the file.
+// A new key is generated and written to the file if it does not exist.
+func loadDHTKey(path string) (ed25519.PrivateKey, error) {
+	path, err := homedir.Expand(path)
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		_, key, err := ed25519.GenerateKey(nil)
+		if err != nil {
+			return nil, err
+		}
+		err = os.WriteFile(path, []byte(hex.EncodeToString(key.Seed())+"\n"), 0600)
+		if err != nil {
+			return nil, err
+		}
+		log.Noticef("new key is written to %q", path)
+		return key, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	seed, err := hex.DecodeString(strings.TrimSpace(string(b)))
+	if err != nil {
+		return nil, err
+	}
+	if len(seed) != ed25519.SeedSize {
+		return nil, errors.New("invalid key file")
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+func decodeHexFlag(s string, dst []byte) error {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	if len(b) != len(dst) {
+		return fmt.Errorf("invalid length: %d, must be %d bytes", len(b), len(dst))
+	}
+	copy(dst, b)
+	return nil
+}
+
+// printDHTValue prints the value as string if it is a bencoded string, otherwise prints the bencoded value.
+func printDHTValue(value []byte) {
+	var s string
+	if bencode.DecodeBytes(value, &s) == nil {
+		fmt.Println(s)
+		return
+	}
+	fmt.Println(string(value))
+}
+
 func handleBeforeClient(c *cli.Context) error {
 	clt = rainrpc.NewClient(c.String("url"))
 	clt.SetTimeout(c.Duration("timeout"))
@@ -887,7 +2191,27 @@ func handleList(c *cli.Context) error {
 }
 
 func isURI(arg string) bool {
-	return strings.HasPrefix(arg, "magnet:") || strings.HasPrefix(arg, "http://") || strings.HasPrefix(arg, "https://")
+	return strings.HasPrefix(arg, "magnet:") || strings.HasPrefix(arg, "http://") || strings.HasPrefix(arg, "https://") || isInfoHash(arg)
+}
+
+// isInfoHash returns true if arg is a bare info hash in hex or base32 encoding.
+func isInfoHash(arg string) bool {
+	_, ok := infoHashToMagnet(arg)
+	return ok
+}
+
+// infoHashToMagnet returns a magnet link without trackers if arg is a bare info hash.
+// A torrent file with the same name takes precedence.
+func infoHashToMagnet(arg string) (string, bool) {
+	ih, err := magnet.ParseInfoHash(arg)
+	if err != nil {
+		return "", false
+	}
+	if _, err = os.Stat(arg); !os.IsNotExist(err) {
+		return "", false
+	}
+	ma := magnet.Magnet{InfoHash: ih}
+	return ma.String(), true
 }
 
 func handleAdd(c *cli.Context) error {
@@ -898,7 +2222,11 @@ func handleAdd(c *cli.Context) error {
 		Stopped:           c.Bool("stopped"),
 		StopAfterDownload: c.Bool("stop-after-download"),
 		StopAfterMetadata: c.Bool("stop-after-metadata"),
+		SeedOnly:          c.Bool("seed-only"),
 		ID:                c.String("id"),
+		Priority:          c.String("priority"),
+		Trackers:          c.StringSlice("tracker"),
+		CrossSeed:         c.String("cross-seed"),
 	}
 	if isURI(arg) {
 		resp, err := clt.AddURI(arg, addOpt)
@@ -970,6 +2298,31 @@ func handleSessionStats(c *cli.Context) error {
 	return nil
 }
 
+func handleDHTStats(c *cli.Context) error {
+	s, err := clt.GetDHTStats()
+	if err != nil {
+		return err
+	}
+	b, err := prettyjson.Marshal(s)
+	if err != nil {
+		return err
+	}
+	_, _ = os.Stdout.Write(b)
+	_, _ = os.Stdout.WriteString("\n")
+	return nil
+}
+
+func handleDHTGetPeers(c *cli.Context) error {
+	peers, err := clt.DHTGetPeers(c.String("infohash"), c.Duration("duration"))
+	if err != nil {
+		return err
+	}
+	for _, p := range peers {
+		fmt.Println(p)
+	}
+	return nil
+}
+
 func handleTrackers(c *cli.Context) error {
 	resp, err := clt.GetTorrentTrackers(c.String("id"))
 	if err != nil {
@@ -1021,6 +2374,9 @@ func handleAddTracker(c *cli.Context) error {
 }
 
 func handleAnnounce(c *cli.Context) error {
+	if c.Bool("force") {
+		return clt.ForceAnnounceTorrent(c.String("id"))
+	}
 	return clt.AnnounceTorrent(c.String("id"))
 }
 
@@ -1036,6 +2392,58 @@ func handleStop(c *cli.Context) error {
 	return clt.StopTorrent(c.String("id"))
 }
 
+func handleSetPriority(c *cli.Context) error {
+	return clt.SetTorrentPriority(c.String("id"), c.String("priority"))
+}
+
+func handleSettings(c *cli.Context) error {
+	s, err := clt.GetSettings()
+	if err != nil {
+		return err
+	}
+	b, err := prettyjson.Marshal(s)
+	if err != nil {
+		return err
+	}
+	_, _ = os.Stdout.Write(b)
+	_, _ = os.Stdout.WriteString("\n")
+	return nil
+}
+
+func handleSetSettings(c *cli.Context) error {
+	s, err := clt.GetSettings()
+	if err != nil {
+		return err
+	}
+	if c.IsSet("speed-limit-download") {
+		s.SpeedLimitDownload = c.Int64("speed-limit-download")
+	}
+	if c.IsSet("speed-limit-upload") {
+		s.SpeedLimitUpload = c.Int64("speed-limit-upload")
+	}
+	if c.IsSet("max-peer-dial") {
+		s.MaxPeerDial = c.Int("max-peer-dial")
+	}
+	if c.IsSet("max-peer-accept") {
+		s.MaxPeerAccept = c.Int("max-peer-accept")
+	}
+	if c.IsSet("queue-max-active-downloads") {
+		s.QueueMaxActiveDownloads = c.Int("queue-max-active-downloads")
+	}
+	if c.IsSet("queue-max-active-seeds") {
+		s.QueueMaxActiveSeeds = c.Int("queue-max-active-seeds")
+	}
+	return clt.SetSettings(*s)
+}
+
+func handlePause(c *cli.Context) error {
+	return clt.PauseTorrent(c.String("id"))
+}
+
+func handleResume(c *cli.Context) error {
+	return clt.ResumeTorrent(c.String("id"))
+}
+
 func handleStartAll(c *cli.Context) error {
 	return clt.StartAllTorrents()
 }
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/rain/torrent"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func TestDownloadExitCode(t *testing.T) {
+	assert.Equal(t, 0, downloadExitCode(nil, false, false))
+	assert.Equal(t, exitCodeTorrentError, downloadExitCode(errors.New("error"), true, true))
+	assert.Equal(t, exitCodeTimeout, downloadExitCode(nil, true, true))
+	assert.Equal(t, exitCodeInterrupted, downloadExitCode(nil, false, true))
+}
+
+func TestDefaultConfigFile(t *testing.T) {
+	b, err := defaultConfigFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, string(b), "# Global upload speed limit in KB/s.\nspeedlimitupload: 0\n")
+
+	cfg, _, err := parseConfigFile("config.yaml", b)
+	assert.NoError(t, err)
+	expected, err := yaml.Marshal(torrent.DefaultConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual, err := yaml.Marshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, string(expected), string(actual))
+}
+
+func TestParseConfigFile(t *testing.T) {
+	cfg, lc, err := parseConfigFile("config.toml", []byte(`
+debug = true
+portbegin = 40000
+speedlimitupload = 100
+peerconnecttimeout = "10s"
+oncompletecmd = ["notify-send", "done"]
+
+[[speedlimitschedules]]
+days = ["sat", "sun"]
+start = "08:00"
+end = "18:00"
+`))
+	assert.NoError(t, err)
+	assert.True(t, lc.Debug)
+	assert.Equal(t, uint16(40000), cfg.PortBegin)
+	assert.Equal(t, torrent.DefaultConfig.PortEnd, cfg.PortEnd)
+	assert.Equal(t, int64(100), cfg.SpeedLimitUpload)
+	assert.Equal(t, 10*time.Second, cfg.PeerConnectTimeout)
+	assert.Equal(t, []string{"notify-send", "done"}, cfg.OnCompleteCmd)
+	assert.Equal(t, []torrent.SpeedLimitSchedule{{Days: []string{"sat", "sun"}, Start: "08:00", End: "18:00"}}, cfg.SpeedLimitSchedules)
+
+	cfg, _, err = parseConfigFile("config.yml", []byte("portbegin: 40000\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(40000), cfg.PortBegin)
+
+	cfg, _, err = parseConfigFile("config.json", []byte(`{"portbegin": 40000}`))
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(40000), cfg.PortBegin)
+
+	_, _, err = parseConfigFile("config.yaml", []byte("unknownkey: 1\n"))
+	assert.Error(t, err)
+	_, _, err = parseConfigFile("config.toml", []byte("unknownkey = 1\n"))
+	assert.Error(t, err)
+}
+
+func TestApplyConfigEnv(t *testing.T) {
+	cfg := torrent.DefaultConfig
+	var lc logConfig
+	keys, err := applyConfigEnv(&cfg, &lc, []string{
+		"HOME=/root",
+		"RAIN_PORT=6881",
+		"RAIN_SPEED_LIMIT_UPLOAD=100",
+		"RAIN_DATADIR=/data: downloads",
+		"RAIN_DEBUG=true",
+		"RAIN_PEER_CONNECT_TIMEOUT=10s",
+		"RAIN_ON_COMPLETE_CMD=[notify-send, done]",
+		"RAIN_TORRENT_ID=abc",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"datadir", "debug", "oncompletecmd", "peerconnecttimeout", "portbegin", "portend", "speedlimitupload"}, keys)
+	assert.Equal(t, uint16(6881), cfg.PortBegin)
+	assert.Equal(t, uint16(6882), cfg.PortEnd)
+	assert.Equal(t, int64(100), cfg.SpeedLimitUpload)
+	assert.Equal(t, "/data: downloads", cfg.DataDir)
+	assert.True(t, lc.Debug)
+	assert.Equal(t, 10*time.Second, cfg.PeerConnectTimeout)
+	assert.Equal(t, []string{"notify-send", "done"}, cfg.OnCompleteCmd)
+	assert.Equal(t, torrent.DefaultConfig.RPCPort, cfg.RPCPort)
+
+	_, err = applyConfigEnv(&cfg, &lc, []string{"RAIN_RPC_PORT=abc"})
+	assert.Error(t, err)
+}
+
+func TestBenchDisk(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bench.dat")
+	size := int64(benchChunkSize + 100)
+	res, err := benchDiskWrite(path, size)
+	assert.NoError(t, err)
+	assert.Equal(t, size, res.Bytes)
+	res, err = benchDiskRead(path)
+	assert.NoError(t, err)
+	assert.Equal(t, size, res.Bytes)
+	assert.Equal(t, int(size), benchResult{Bytes: size, Duration: time.Second}.Rate())
+	assert.Equal(t, 0, benchResult{Bytes: size}.Rate())
+}
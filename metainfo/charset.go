@@ -0,0 +1,64 @@
+package metainfo
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Charset decodes file names of legacy torrents that are not encoded in UTF-8.
+type Charset func(b []byte) string
+
+var charsets = map[string]Charset{
+	"latin1":       decodeLatin1,
+	"iso-8859-1":   decodeLatin1,
+	"windows-1252": decodeWindows1252,
+	"cp1252":       decodeWindows1252,
+}
+
+// ParseCharset returns the Charset with the given name.
+// Supported charsets are "latin1" (alias "iso-8859-1") and "windows-1252" (alias "cp1252").
+func ParseCharset(name string) (Charset, error) {
+	cs, ok := charsets[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported charset: %q", name)
+	}
+	return cs, nil
+}
+
+func decodeLatin1(b []byte) string {
+	var sb strings.Builder
+	for _, c := range b {
+		sb.WriteRune(rune(c))
+	}
+	return sb.String()
+}
+
+// Characters that are different from Latin-1 in range 0x80-0x9F.
+// Bytes that are undefined in Windows-1252 are mapped to Latin-1 control characters.
+var windows1252 = [32]rune{
+	0x20AC, 0x0081, 0x201A, 0x0192, 0x201E, 0x2026, 0x2020, 0x2021,
+	0x02C6, 0x2030, 0x0160, 0x2039, 0x0152, 0x008D, 0x017D, 0x008F,
+	0x0090, 0x2018, 0x2019, 0x201C, 0x201D, 0x2022, 0x2013, 0x2014,
+	0x02DC, 0x2122, 0x0161, 0x203A, 0x0153, 0x009D, 0x017E, 0x0178,
+}
+
+func decodeWindows1252(b []byte) string {
+	var sb strings.Builder
+	for _, c := range b {
+		if c >= 0x80 && c < 0xA0 {
+			sb.WriteRune(windows1252[c-0x80])
+		} else {
+			sb.WriteRune(rune(c))
+		}
+	}
+	return sb.String()
+}
+
+// decode converts s with the charset if it is not a valid UTF-8 string.
+func (cs Charset) decode(s string) string {
+	if cs == nil || utf8.ValidString(s) {
+		return s
+	}
+	return cs([]byte(s))
+}
@@ -0,0 +1,49 @@
+package metainfo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zeebo/bencode"
+)
+
+func TestParseCharset(t *testing.T) {
+	cs, err := ParseCharset("Latin1")
+	assert.Nil(t, err)
+	assert.Equal(t, "café", cs([]byte("caf\xe9")))
+
+	cs, err = ParseCharset("windows-1252")
+	assert.Nil(t, err)
+	assert.Equal(t, "“café”", cs([]byte("\x93caf\xe9\x94")))
+
+	_, err = ParseCharset("foo")
+	assert.NotNil(t, err)
+}
+
+func TestNewInfoCharset(t *testing.T) {
+	b, err := bencode.EncodeBytes(map[string]any{
+		"name":         "caf\xe9",
+		"piece length": 32 << 10,
+		"pieces":       make([]byte, 20),
+		"files": []map[string]any{
+			{"length": 10, "path": []string{"na\xefve"}},
+			{"length": 10, "path": []string{"ignored"}, "path.utf-8": []string{"utf8 ✓"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := NewInfo(b, true, true, decodeLatin1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "café", info.Name)
+	assert.Equal(t, "café/naïve", info.Files[0].Path)
+	assert.Equal(t, "café/utf8 ✓", info.Files[1].Path)
+
+	info, err = NewInfo(b, true, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "caf�", info.Name)
+}
@@ -1,6 +1,7 @@
 package metainfo
 
 import (
+	"crypto/md5"
 	"crypto/sha1"
 	"encoding/hex"
 	"errors"
@@ -12,7 +13,6 @@ import (
 	"strings"
 	"unicode"
 
-	"github.com/cenkalti/rain/internal/logger"
 	"github.com/zeebo/bencode"
 )
 
@@ -21,6 +21,7 @@ var (
 	errZeroPieceLength  = errors.New("torrent has zero piece length")
 	errZeroPieces       = errors.New("torrent has zero pieces")
 	errPieceLength      = errors.New("piece length must be multiple of 16K")
+	errInvalidRootHash  = errors.New("invalid root hash")
 )
 
 // Info contains information about torrent.
@@ -33,7 +34,10 @@ type Info struct {
 	Bytes       []byte
 	Private     bool
 	Files       []File
-	pieces      []byte
+	// Root of the hash tree of Merkle torrents (BEP 30). Nil for regular torrents.
+	// Info dictionary of Merkle torrents do not contain piece hashes.
+	RootHash []byte
+	pieces   []byte
 }
 
 // File represents a file inside a Torrent.
@@ -42,6 +46,13 @@ type File struct {
 	Path   string
 	// https://www.bittorrent.org/beps/bep_0047.html
 	Padding bool
+	// Optional MD5 checksum of the file. Nil if not present in info dictionary.
+	MD5Sum []byte
+	// File has the executable attribute.
+	Executable bool
+	// If not empty, the file is a symbolic link to this path.
+	// Path of the target is relative to the storage root, same as Path.
+	Symlink string
 }
 
 type file struct {
@@ -49,6 +60,9 @@ type file struct {
 	Path     []string `bencode:"path"`
 	PathUTF8 []string `bencode:"path.utf-8,omitempty"`
 	Attr     string   `bencode:"attr"`
+	MD5Sum   string   `bencode:"md5sum,omitempty"`
+	// Path of the target if the file is a symlink
+	SymlinkPath []string `bencode:"symlink path,omitempty"`
 }
 
 func (f *file) isPadding() bool {
@@ -56,6 +70,10 @@ func (f *file) isPadding() bool {
 	if strings.ContainsRune(f.Attr, 'p') {
 		return true
 	}
+	// Padding files may be put in ".pad" directory, as suggested in BEP 0047
+	if len(f.Path) == 2 && f.Path[0] == ".pad" {
+		return true
+	}
 	// BitComet convention that do not conform BEP 0047
 	if len(f.Path) > 0 && strings.HasPrefix(f.Path[len(f.Path)-1], "_____padding_file") {
 		return true
@@ -66,11 +84,29 @@ func (f *file) isPadding() bool {
 type infoType struct {
 	PieceLength uint32             `bencode:"piece length"`
 	Pieces      []byte             `bencode:"pieces"`
+	RootHash    []byte             `bencode:"root hash"`
 	Name        string             `bencode:"name"`
 	NameUTF8    string             `bencode:"name.utf-8,omitempty"`
 	Private     bencode.RawMessage `bencode:"private"`
 	Length      int64              `bencode:"length"` // Single File Mode
-	Files       []file             `bencode:"files"`  // Multiple File mode
+	MD5Sum      string             `bencode:"md5sum,omitempty"`
+	Attr        string             `bencode:"attr"`  // Single File Mode
+	Files       []file             `bencode:"files"` // Multiple File mode
+}
+
+// mapNames replaces the name and each part of file paths with the result of fn.
+func (ib *infoType) mapNames(fn func(string) string) {
+	ib.Name = fn(ib.Name)
+	for i := range ib.Files {
+		for j := range ib.Files[i].Path {
+			ib.Files[i].Path[j] = fn(ib.Files[i].Path[j])
+		}
+	}
+}
+
+// replaceInvalidUTF8 replaces invalid UTF-8 sequences in s with the replacement character.
+func replaceInvalidUTF8(s string) string {
+	return strings.ToValidUTF8(s, "\uFFFD")
 }
 
 func (ib *infoType) overrideUTF8Keys() {
@@ -85,7 +121,10 @@ func (ib *infoType) overrideUTF8Keys() {
 }
 
 // NewInfo returns info from bencoded bytes in b.
-func NewInfo(b []byte, utf8 bool, pad bool) (*Info, error) {
+// If utf8 is true, name.utf-8 and path.utf-8 keys are preferred when present.
+// Names that are still not valid UTF-8 are decoded with charset.
+// If charset is nil, invalid bytes are replaced.
+func NewInfo(b []byte, utf8 bool, pad bool, charset Charset) (*Info, error) {
 	var ib infoType
 	if err := bencode.DecodeBytes(b, &ib); err != nil {
 		return nil, err
@@ -97,24 +136,51 @@ func NewInfo(b []byte, utf8 bool, pad bool) (*Info, error) {
 		return nil, errInvalidPieceData
 	}
 	numPieces := len(ib.Pieces) / sha1.Size
+	if len(ib.RootHash) > 0 {
+		if len(ib.RootHash) != sha1.Size || len(ib.Pieces) > 0 {
+			return nil, errInvalidRootHash
+		}
+		length := ib.Length
+		if len(ib.Files) > 0 {
+			length = 0
+			for _, f := range ib.Files {
+				length += f.Length
+			}
+		}
+		numPieces = int((length + int64(ib.PieceLength) - 1) / int64(ib.PieceLength))
+	}
 	if numPieces == 0 {
 		return nil, errZeroPieces
 	}
 	if utf8 {
 		ib.overrideUTF8Keys()
 	}
+	if charset != nil {
+		ib.mapNames(charset.decode)
+	} else {
+		ib.mapNames(replaceInvalidUTF8)
+	}
 	// ".." is not allowed in file names
+	if strings.TrimSpace(ib.Name) == ".." {
+		return nil, fmt.Errorf("invalid torrent name: %q", ib.Name)
+	}
 	for _, file := range ib.Files {
 		for _, path := range file.Path {
 			if strings.TrimSpace(path) == ".." {
 				return nil, fmt.Errorf("invalid file name: %q", filepath.Join(file.Path...))
 			}
 		}
+		for _, path := range file.SymlinkPath {
+			if strings.TrimSpace(path) == ".." {
+				return nil, fmt.Errorf("invalid symlink path: %q", filepath.Join(file.SymlinkPath...))
+			}
+		}
 	}
 	i := Info{
 		PieceLength: ib.PieceLength,
 		NumPieces:   uint32(numPieces),
 		pieces:      ib.Pieces,
+		RootHash:    ib.RootHash,
 		Name:        ib.Name,
 		Private:     parsePrivateField(ib.Private),
 	}
@@ -155,19 +221,47 @@ func NewInfo(b []byte, utf8 bool, pad bool) (*Info, error) {
 				parts = append(parts, cleanName(p))
 			}
 			i.Files[j] = File{
-				Path:   filepath.Join(parts...),
-				Length: f.Length,
+				Path:       filepath.Join(parts...),
+				Length:     f.Length,
+				MD5Sum:     parseMD5Sum(f.MD5Sum),
+				Executable: strings.ContainsRune(f.Attr, 'x'),
 			}
 			if pad {
 				i.Files[j].Padding = f.isPadding()
 			}
+			// Symlinks do not have any data.
+			if strings.ContainsRune(f.Attr, 'l') && len(f.SymlinkPath) > 0 && f.Length == 0 {
+				target := make([]string, 0, len(f.SymlinkPath)+1)
+				target = append(target, cleanName(i.Name))
+				for _, p := range f.SymlinkPath {
+					target = append(target, cleanName(p))
+				}
+				i.Files[j].Symlink = filepath.Join(target...)
+			}
 		}
 	} else {
-		i.Files = []File{{Path: cleanName(i.Name), Length: i.Length}}
+		i.Files = []File{{
+			Path:       cleanName(i.Name),
+			Length:     i.Length,
+			MD5Sum:     parseMD5Sum(ib.MD5Sum),
+			Executable: strings.ContainsRune(ib.Attr, 'x'),
+		}}
 	}
 	return &i, nil
 }
 
+// parseMD5Sum decodes the hex encoded md5sum field. Invalid values are ignored.
+func parseMD5Sum(s string) []byte {
+	if len(s) != 2*md5.Size {
+		return nil
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil
+	}
+	return b
+}
+
 func cleanName(s string) string {
 	return cleanNameN(s, 255)
 }
@@ -176,7 +270,12 @@ func cleanNameN(s string, max int) string {
 	s = strings.ToValidUTF8(s, string(unicode.ReplacementChar))
 	s = trimName(s, max)
 	s = strings.ToValidUTF8(s, "")
-	return replaceSeparator(s)
+	s = replaceSeparator(s)
+	// Empty and "." components would point to the parent directory.
+	if s == "" || s == "." {
+		return "_"
+	}
+	return s
 }
 
 // trimName trims the file name that it won't exceed 255 characters while keeping the extension.
@@ -193,7 +292,7 @@ func trimName(s string, max int) string {
 
 func replaceSeparator(s string) string {
 	return strings.Map(func(r rune) rune {
-		if r == '/' {
+		if r == '/' || r == filepath.Separator {
 			return '_'
 		}
 		return r
@@ -217,8 +316,21 @@ func parsePrivateField(s bencode.RawMessage) bool {
 	return !(stringVal == "" || stringVal == "0")
 }
 
+// Logger is used for reporting the progress of NewInfoBytes.
+type Logger interface {
+	Infof(format string, args ...interface{})
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Infof(format string, args ...interface{}) {}
+
 // NewInfoBytes creates a new Info dictionary by reading and hashing the files on the disk.
-func NewInfoBytes(root string, paths []string, private bool, pieceLength uint32, name string, log logger.Logger) ([]byte, error) {
+// If pieceLength is zero, it is calculated from the total length of the files. log may be nil.
+func NewInfoBytes(root string, paths []string, private bool, pieceLength uint32, name string, log Logger) ([]byte, error) {
+	if log == nil {
+		log = nopLogger{}
+	}
 	var singleFileTorrent bool
 	switch len(paths) {
 	case 0:
@@ -329,8 +441,23 @@ func NewInfoBytes(root string, paths []string, private bool, pieceLength uint32,
 	return bencode.EncodeBytes(b)
 }
 
+// PaddingLength returns the total length of padding files in the torrent.
+func (i *Info) PaddingLength() int64 {
+	var n int64
+	for _, f := range i.Files {
+		if f.Padding {
+			n += f.Length
+		}
+	}
+	return n
+}
+
 // PieceHash returns the hash of a piece at index.
+// Returns nil for Merkle torrents.
 func (i *Info) PieceHash(index uint32) []byte {
+	if i.RootHash != nil {
+		return nil
+	}
 	begin := index * sha1.Size
 	end := begin + sha1.Size
 	return i.pieces[begin:end]
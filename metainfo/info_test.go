@@ -0,0 +1,136 @@
+package metainfo
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zeebo/bencode"
+)
+
+func TestCalculatePieceLength(t *testing.T) {
+	l := calculatePieceLength(1)
+	if l != 32<<10 {
+		t.FailNow()
+	}
+	l = calculatePieceLength(1 << 40)
+	if l != 16<<20 {
+		t.FailNow()
+	}
+	l = calculatePieceLength(500 << 20)
+	if l != 256<<10 {
+		t.FailNow()
+	}
+	l = calculatePieceLength(5 << 30)
+	if l != 4<<20 {
+		t.FailNow()
+	}
+}
+
+func TestCleanName(t *testing.T) {
+	cases := []struct {
+		name    string
+		cleaned string
+		max     int
+	}{
+		{"foo.bar", "foo.bar", 10},
+		{"foo.bar", "foo.bar", 7},
+		{"foo.bar", "fo.bar", 6},
+		{"foo.bar", ".bar", 4},
+		{"foo.bar", "foo", 3},
+		{"foobar", "foobar", 10},
+		{"foobar", "fo", 2},
+		{"ğğğğ", "ğğğğ", 9},
+		{"ğğğğ", "ğğğğ", 8},
+		{"ğğğğ", "ğğğ", 7},
+		{"ğğğğ", "ğğğ", 6},
+		{"", "_", 10},
+		{".", "_", 10},
+		{"a/b", "a_b", 10},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.cleaned, cleanNameN(c.name, c.max))
+	}
+}
+
+func TestParseMD5Sum(t *testing.T) {
+	assert.Equal(t, []byte{0x5e, 0xb6, 0x3b, 0xbb, 0xe0, 0x1e, 0xee, 0xd0, 0x93, 0xcb, 0x22, 0xbb, 0x8f, 0x5a, 0xcd, 0xc3}, parseMD5Sum("5eb63bbbe01eeed093cb22bb8f5acdc3"))
+	assert.Nil(t, parseMD5Sum(""))
+	assert.Nil(t, parseMD5Sum("5eb63bbbe01eeed093cb22bb8f5acdc"))
+	assert.Nil(t, parseMD5Sum("zzb63bbbe01eeed093cb22bb8f5acdc3"))
+}
+
+func TestPaddingFiles(t *testing.T) {
+	b, err := bencode.EncodeBytes(map[string]any{
+		"name":         "foo",
+		"piece length": 32 << 10,
+		"pieces":       make([]byte, 20),
+		"files": []map[string]any{
+			{"length": 10, "path": []string{"a"}},
+			{"length": 20, "path": []string{"b"}, "attr": "p"},
+			{"length": 30, "path": []string{".pad", "30"}},
+			{"length": 40, "path": []string{"_____padding_file_0"}},
+			{"length": 50, "path": []string{".pad", "c", "d"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := NewInfo(b, true, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var padding []bool
+	for _, f := range info.Files {
+		padding = append(padding, f.Padding)
+	}
+	assert.Equal(t, []bool{false, true, true, true, false}, padding)
+	assert.Equal(t, int64(90), info.PaddingLength())
+}
+
+func TestFileAttributes(t *testing.T) {
+	b, err := bencode.EncodeBytes(map[string]any{
+		"name":         "foo",
+		"piece length": 32 << 10,
+		"pieces":       make([]byte, 20),
+		"files": []map[string]any{
+			{"length": 10, "path": []string{"bin", "run"}, "attr": "x"},
+			{"length": 0, "path": []string{"link"}, "attr": "l", "symlink path": []string{"bin", "run"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := NewInfo(b, true, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, info.Files[0].Executable)
+	assert.Equal(t, "", info.Files[0].Symlink)
+	assert.False(t, info.Files[1].Executable)
+	assert.Equal(t, filepath.Join("foo", "bin", "run"), info.Files[1].Symlink)
+}
+
+func TestNewInfoInvalidPaths(t *testing.T) {
+	newInfo := func(name string, path ...string) (*Info, error) {
+		b, err := bencode.EncodeBytes(map[string]any{
+			"name":         name,
+			"piece length": 32 << 10,
+			"pieces":       make([]byte, 20),
+			"files":        []map[string]any{{"length": 10, "path": path}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return NewInfo(b, true, true, nil)
+	}
+	_, err := newInfo("..", "a")
+	assert.Error(t, err)
+	_, err = newInfo("foo", "a", "..", "b")
+	assert.Error(t, err)
+
+	info, err := newInfo("foo", "", "/etc", ".", "passwd")
+	if assert.NoError(t, err) {
+		assert.Equal(t, filepath.Join("foo", "_", "_etc", "_", "passwd"), info.Files[0].Path)
+	}
+}
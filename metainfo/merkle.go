@@ -0,0 +1,31 @@
+package metainfo
+
+import "crypto/sha1"
+
+// MerkleRoot calculates the root of the hash tree from the hashes of pieces as described in BEP 30.
+// Number of leaves is padded to a power of two with zero filled hashes.
+func MerkleRoot(leaves [][]byte) []byte {
+	if len(leaves) == 0 {
+		return nil
+	}
+	n := 1
+	for n < len(leaves) {
+		n *= 2
+	}
+	level := make([][]byte, n)
+	copy(level, leaves)
+	for i := len(leaves); i < n; i++ {
+		level[i] = make([]byte, sha1.Size)
+	}
+	for len(level) > 1 {
+		next := make([][]byte, len(level)/2)
+		for i := range next {
+			h := sha1.New()
+			_, _ = h.Write(level[2*i])
+			_, _ = h.Write(level[2*i+1])
+			next[i] = h.Sum(nil)
+		}
+		level = next
+	}
+	return level[0]
+}
@@ -0,0 +1,58 @@
+package metainfo
+
+import (
+	"crypto/sha1"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zeebo/bencode"
+)
+
+func sha1sum(b ...[]byte) []byte {
+	h := sha1.New()
+	for _, x := range b {
+		_, _ = h.Write(x)
+	}
+	return h.Sum(nil)
+}
+
+func TestMerkleRoot(t *testing.T) {
+	a := sha1sum([]byte("a"))
+	b := sha1sum([]byte("b"))
+	c := sha1sum([]byte("c"))
+	zero := make([]byte, sha1.Size)
+
+	assert.Equal(t, a, MerkleRoot([][]byte{a}))
+	assert.Equal(t, sha1sum(a, b), MerkleRoot([][]byte{a, b}))
+	assert.Equal(t, sha1sum(sha1sum(a, b), sha1sum(c, zero)), MerkleRoot([][]byte{a, b, c}))
+	assert.Nil(t, MerkleRoot(nil))
+}
+
+func TestNewInfoMerkle(t *testing.T) {
+	root := sha1sum([]byte("root"))
+	b, err := bencode.EncodeBytes(map[string]any{
+		"name":         "foo",
+		"piece length": 32 << 10,
+		"length":       100 << 10,
+		"root hash":    root,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := NewInfo(b, true, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, uint32(4), info.NumPieces)
+	assert.Equal(t, root, info.RootHash)
+	assert.Nil(t, info.PieceHash(0))
+
+	b, _ = bencode.EncodeBytes(map[string]any{
+		"name":         "foo",
+		"piece length": 32 << 10,
+		"length":       100 << 10,
+		"root hash":    root[:10],
+	})
+	_, err = NewInfo(b, true, true, nil)
+	assert.Equal(t, errInvalidRootHash, err)
+}
@@ -1,4 +1,6 @@
-// Package metainfo support for reading and writing torrent files.
+// Package metainfo provides support for reading and writing torrent files.
+//
+// It is used by the torrent package and can be used on its own for parsing and creating torrent files.
 package metainfo
 
 import (
@@ -22,6 +24,11 @@ type MetaInfo struct {
 
 // New returns a torrent from bencoded stream.
 func New(r io.Reader) (*MetaInfo, error) {
+	return NewWithCharset(r, nil)
+}
+
+// NewWithCharset is like New but file names that are not valid UTF-8 are decoded with charset.
+func NewWithCharset(r io.Reader, charset Charset) (*MetaInfo, error) {
 	var ret MetaInfo
 	var t struct {
 		Info         bencode.RawMessage `bencode:"info"`
@@ -36,7 +43,7 @@ func New(r io.Reader) (*MetaInfo, error) {
 	if len(t.Info) == 0 {
 		return nil, errors.New("no info dict in torrent file")
 	}
-	info, err := NewInfo(t.Info, true, true)
+	info, err := NewInfo(t.Info, true, true, charset)
 	if err != nil {
 		return nil, err
 	}
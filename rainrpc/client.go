@@ -63,6 +63,14 @@ type AddTorrentOptions struct {
 	Stopped           bool
 	StopAfterDownload bool
 	StopAfterMetadata bool
+	// Only seed the existing data without downloading missing pieces.
+	SeedOnly bool
+	// Priority is one of "low", "normal" or "high". Empty value means "normal".
+	Priority string
+	// Additional tracker URLs. Each one is added as a separate tier.
+	Trackers []string
+	// ID of an existing torrent with the same files to seed from. Not supported for magnet links.
+	CrossSeed string
 }
 
 // AddTorrent adds a new torrent by reading .torrent file.
@@ -77,6 +85,10 @@ func (c *Client) AddTorrent(f io.Reader, options *AddTorrentOptions) (*rpctypes.
 		args.AddTorrentOptions.Stopped = options.Stopped
 		args.AddTorrentOptions.StopAfterDownload = options.StopAfterDownload
 		args.AddTorrentOptions.StopAfterMetadata = options.StopAfterMetadata
+		args.AddTorrentOptions.SeedOnly = options.SeedOnly
+		args.AddTorrentOptions.Priority = options.Priority
+		args.AddTorrentOptions.Trackers = options.Trackers
+		args.AddTorrentOptions.CrossSeed = options.CrossSeed
 	}
 	var reply rpctypes.AddTorrentResponse
 	return &reply.Torrent, c.client.Call("Session.AddTorrent", args, &reply)
@@ -90,6 +102,10 @@ func (c *Client) AddURI(uri string, options *AddTorrentOptions) (*rpctypes.Torre
 		args.AddTorrentOptions.Stopped = options.Stopped
 		args.AddTorrentOptions.StopAfterDownload = options.StopAfterDownload
 		args.AddTorrentOptions.StopAfterMetadata = options.StopAfterMetadata
+		args.AddTorrentOptions.SeedOnly = options.SeedOnly
+		args.AddTorrentOptions.Priority = options.Priority
+		args.AddTorrentOptions.Trackers = options.Trackers
+		args.AddTorrentOptions.CrossSeed = options.CrossSeed
 	}
 	var reply rpctypes.AddURIResponse
 	return &reply.Torrent, c.client.Call("Session.AddURI", args, &reply)
@@ -123,6 +139,20 @@ func (c *Client) GetSessionStats() (*rpctypes.SessionStats, error) {
 	return &reply.Stats, c.client.Call("Session.GetSessionStats", args, &reply)
 }
 
+// GetSettings returns the settings of the Session that can be changed while it is running.
+func (c *Client) GetSettings() (*rpctypes.Settings, error) {
+	args := rpctypes.GetSettingsRequest{}
+	var reply rpctypes.GetSettingsResponse
+	return &reply.Settings, c.client.Call("Session.GetSettings", args, &reply)
+}
+
+// SetSettings changes the settings of the running Session.
+func (c *Client) SetSettings(settings rpctypes.Settings) error {
+	args := rpctypes.SetSettingsRequest{Settings: settings}
+	var reply rpctypes.SetSettingsResponse
+	return c.client.Call("Session.SetSettings", args, &reply)
+}
+
 // GetMagnet returns the torrent as a magnet link.
 func (c *Client) GetMagnet(id string) (string, error) {
 	args := rpctypes.GetMagnetRequest{ID: id}
@@ -177,6 +207,27 @@ func (c *Client) StopTorrent(id string) error {
 	return c.client.Call("Session.StopTorrent", args, &reply)
 }
 
+// SetTorrentPriority changes the priority of the torrent. Priority is one of "low", "normal" or "high".
+func (c *Client) SetTorrentPriority(id string, priority string) error {
+	args := rpctypes.SetTorrentPriorityRequest{ID: id, Priority: priority}
+	var reply rpctypes.SetTorrentPriorityResponse
+	return c.client.Call("Session.SetTorrentPriority", args, &reply)
+}
+
+// PauseTorrent pauses downloading and uploading pieces of the torrent without disconnecting peers.
+func (c *Client) PauseTorrent(id string) error {
+	args := rpctypes.PauseTorrentRequest{ID: id}
+	var reply rpctypes.PauseTorrentResponse
+	return c.client.Call("Session.PauseTorrent", args, &reply)
+}
+
+// ResumeTorrent resumes the paused torrent.
+func (c *Client) ResumeTorrent(id string) error {
+	args := rpctypes.ResumeTorrentRequest{ID: id}
+	var reply rpctypes.ResumeTorrentResponse
+	return c.client.Call("Session.ResumeTorrent", args, &reply)
+}
+
 // AnnounceTorrent forces the torrent to re-announce to trackers and DHT.
 func (c *Client) AnnounceTorrent(id string) error {
 	args := rpctypes.AnnounceTorrentRequest{ID: id}
@@ -184,6 +235,13 @@ func (c *Client) AnnounceTorrent(id string) error {
 	return c.client.Call("Session.AnnounceTorrent", args, &reply)
 }
 
+// ForceAnnounceTorrent forces the torrent to re-announce to trackers and DHT immediately, ignoring the minimum interval.
+func (c *Client) ForceAnnounceTorrent(id string) error {
+	args := rpctypes.ForceAnnounceTorrentRequest{ID: id}
+	var reply rpctypes.ForceAnnounceTorrentResponse
+	return c.client.Call("Session.ForceAnnounceTorrent", args, &reply)
+}
+
 // VerifyTorrent stops the torrent and verifies all of the pieces on disk.
 // After verification is done, the torrent stays in stopped state.
 func (c *Client) VerifyTorrent(id string) error {
@@ -226,3 +284,18 @@ func (c *Client) AddTracker(id string, uri string) error {
 	var reply rpctypes.AddTrackerResponse
 	return c.client.Call("Session.AddTracker", args, &reply)
 }
+
+// GetDHTStats returns statistics about the DHT node of the remote Session.
+func (c *Client) GetDHTStats() (*rpctypes.DHTStats, error) {
+	args := rpctypes.GetDHTStatsRequest{}
+	var reply rpctypes.GetDHTStatsResponse
+	return &reply.Stats, c.client.Call("Session.GetDHTStats", args, &reply)
+}
+
+// DHTGetPeers does a get_peers lookup on DHT for the info hash and returns the peer addresses found in timeout.
+// Timeout must be less than the timeout of the Client.
+func (c *Client) DHTGetPeers(infoHash string, timeout time.Duration) ([]string, error) {
+	args := rpctypes.DHTGetPeersRequest{InfoHash: infoHash, Timeout: int(timeout / time.Second)}
+	var reply rpctypes.DHTGetPeersResponse
+	return reply.Peers, c.client.Call("Session.DHTGetPeers", args, &reply)
+}
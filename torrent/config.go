@@ -2,9 +2,10 @@ package torrent
 
 import (
 	"io/fs"
+	"runtime"
 	"time"
 
-	"github.com/cenkalti/rain/internal/metainfo"
+	"github.com/cenkalti/rain/metainfo"
 )
 
 var (
@@ -23,9 +24,49 @@ type Config struct {
 	Database string
 	// DataDir is where files are downloaded.
 	DataDir string
+	// If set, files of incomplete torrents are written into this directory instead of DataDir.
+	// Files are moved into DataDir when the download completes.
+	// Uses the same layout as DataDir, including torrent ID if DataDirIncludesTorrentID is set.
+	IncompleteDir string
 	// If true, torrent files are saved into <data_dir>/<torrent_id>/<torrent_name>.
 	// Useful if downloading the same torrent from multiple sources.
 	DataDirIncludesTorrentID bool
+	// If true and DataDirIncludesTorrentID is set, files of a new torrent are searched in DataDir and in directories
+	// of torrents that are not in the session anymore. Matching files are verified and only missing pieces are downloaded.
+	AdoptExistingData bool
+	// If set, files are saved in this S3-compatible object storage bucket instead of DataDir.
+	// Pieces are written into DataDir first and each file is uploaded when all of its pieces are downloaded.
+	// Files already in the bucket are seeded directly from there. IncompleteDir is not used with this option.
+	S3Bucket string
+	// URL of the object storage service, like "https://s3.amazonaws.com". Buckets are addressed with path-style URLs.
+	S3Endpoint string
+	// Region of the bucket, used for signing requests.
+	S3Region string
+	// Objects are saved under this prefix in the bucket. Uses the same layout as DataDir after the prefix.
+	S3Prefix string
+	// Credentials for the object storage service.
+	S3AccessKey, S3SecretKey string
+	// If set, files are saved on this WebDAV server instead of DataDir, like "https://nas.local/dav/torrents".
+	// Pieces are written directly with partial PUT requests, so the server must support Content-Range header in PUT requests (e.g. Apache mod_dav).
+	// Uses the same layout as DataDir under the URL. IncompleteDir is not used with this option.
+	WebDAVURL string
+	// Credentials for HTTP basic authentication to the WebDAV server.
+	WebDAVUsername, WebDAVPassword string
+	// Max number of concurrent requests to the WebDAV server.
+	WebDAVMaxConnections int
+	// Failed requests to the WebDAV server are retried until this duration passes.
+	WebDAVRetryTimeout time.Duration
+	// If set, contents of the files are encrypted in the storage with a key derived from this passphrase and the info hash of the torrent.
+	// Pieces are still hashed and sent to peers as plaintext. File names are not encrypted.
+	// Key derivation salt is saved in the database, so the files cannot be decrypted if the database is lost.
+	StorageEncryptionPassphrase string
+	// Charset for decoding file names of legacy torrents that are not encoded in UTF-8.
+	// Supported values are "latin1" and "windows-1252". If empty, invalid bytes are replaced.
+	// UTF-8 names in name.utf-8 and path.utf-8 keys are always preferred when present.
+	FilenameCharset string
+	// Rename files that cannot be created on Windows, such as names with reserved characters or device names.
+	// Renamed paths are saved in resume data, so a torrent keeps using the same paths after this option is changed.
+	WindowsSafeFilenames bool
 	// Host to listen for TCP Acceptor. Port is computed automatically
 	Host string
 	// New torrents will be listened at selected port in this range.
@@ -34,9 +75,16 @@ type Config struct {
 	MaxOpenFiles uint64
 	// Enable peer exchange protocol.
 	PEXEnabled bool
+	// Enable tracker exchange protocol (BEP 28).
+	// Trackers received from peers are added to the torrent until it is removed but they are not saved.
+	// Not used for private torrents.
+	TEXEnabled bool
 	// Resume data (bitfield & stats) are saved to disk at interval to keep IO lower.
 	ResumeWriteInterval time.Duration
 	// Peer id is prefixed with this string. See BEP 20. Remaining bytes of peer id will be randomized.
+	// Only applies to public torrents.
+	PublicPeerIDPrefix string
+	// Peer id is prefixed with this string. See BEP 20. Remaining bytes of peer id will be randomized.
 	// Only applies to private torrents.
 	PrivatePeerIDPrefix string
 	// Client version that is sent in BEP 10 handshake message.
@@ -60,6 +108,9 @@ type Config struct {
 	TorrentAddHTTPTimeout time.Duration
 	// Maximum allowed size to be received by metadata extension.
 	MaxMetadataSize uint
+	// If set, metainfo of torrents added with magnet links is saved into this directory
+	// as <info_hash>.torrent after the metadata is downloaded from peers.
+	SaveTorrentDir string
 	// Maximum allowed size to be read when adding torrent.
 	MaxTorrentSize uint
 	// Maximum allowed number of pieces in a torrent.
@@ -70,14 +121,37 @@ type Config struct {
 	SpeedLimitDownload int64
 	// Global upload speed limit in KB/s.
 	SpeedLimitUpload int64
+	// Speed limits to apply instead of SpeedLimitDownload and SpeedLimitUpload at certain times.
+	// The first schedule that matches the current time is applied.
+	SpeedLimitSchedules []SpeedLimitSchedule
+	// Settings that override the session settings for specific torrents, keyed by hex encoded info hash.
+	TorrentOverrides map[string]TorrentOverride
 	// Start torrent automatically if it was running when previous session was closed.
 	ResumeOnStartup bool
+	// Max number of torrents that are downloading at the same time.
+	// Started torrents wait in queue until an active torrent completes or stops. 0 means unlimited.
+	QueueMaxActiveDownloads int
+	// Max number of torrents that are seeding at the same time. 0 means unlimited.
+	QueueMaxActiveSeeds int
 	// Check each torrent loop for aliveness. Helps to detect bugs earlier.
 	HealthCheckInterval time.Duration
 	// If torrent loop is stuck for more than this duration. Program crashes with stacktrace.
 	HealthCheckTimeout time.Duration
 	// The unix permission of created files, execute bit is removed for files
 	FilePermissions fs.FileMode
+	// The unix permission of created directories.
+	DirPermissions fs.FileMode
+	// If not negative, umask of the process is set to this value on session start.
+	// Useful for shared setups where group needs write permission on downloaded files.
+	Umask int
+	// Open files with O_SYNC, so each piece write is flushed to disk before it is reported as complete.
+	SyncWrites bool
+	// Do not create missing files on start. A file is created when the first piece that belongs to it is written.
+	LazyFileCreation bool
+	// Check free space on the disk before starting a download. Torrent is stopped with an error if there is not enough space.
+	DiskSpaceCheck bool
+	// Free space is checked again periodically while downloading.
+	DiskSpaceCheckInterval time.Duration
 
 	// Enable RPC server
 	RPCEnabled bool
@@ -90,8 +164,17 @@ type Config struct {
 
 	// Enable DHT node.
 	DHTEnabled bool
+	// Run DHT in read-only mode (BEP 43). Lookups and announces are done but queries of other nodes are not answered,
+	// so the Session does not join the routing tables of other nodes. Useful on hosts that are not reachable from outside
+	// or when the traffic must be kept low. IPv6 DHT node is not started in read-only mode.
+	DHTReadOnly bool
 	// DHT node will listen on this IP.
 	DHTHost string
+	// Run a second DHT node on IPv6 with a separate routing table (BEP 32), so IPv6 peers can be found.
+	// The node is not started if the host does not have IPv6 connectivity.
+	DHTIPv6Enabled bool
+	// IPv6 DHT node will listen on this IP.
+	DHTHost6 string
 	// DHT node will listen on this UDP port.
 	DHTPort uint16
 	// DHT announce interval
@@ -100,25 +183,60 @@ type Config struct {
 	DHTMinAnnounceInterval time.Duration
 	// Known routers to bootstrap local DHT node.
 	DHTBootstrapNodes []string
+	// Save addresses of DHT nodes that are seen recently to the database.
+	// Saved nodes are added to the routing table on start, so the node can rejoin the DHT quickly
+	// without waiting for the response of DHTBootstrapNodes.
+	DHTSaveNodes bool
 
 	// Number of peer addresses to request in announce request.
+	// Increase it for huge swarms. -1 lets the tracker decide.
 	TrackerNumWant int
+	// IP address to announce to trackers instead of the address they see the request coming from.
+	// Useful when peers must connect through a different address, e.g. a VPN.
+	TrackerAnnounceIP string
+	// Port to announce to trackers instead of the listening port of the torrent,
+	// e.g. the port forwarded by a VPN provider. Zero means the listening port is announced.
+	// Since every torrent listens on its own port, this is only useful when the forwarded port is mapped to it.
+	TrackerAnnouncePort int
+	// Announce to all trackers simultaneously instead of trying tiers in order and trackers in a tier one by one as described in BEP 12.
+	TrackerAnnounceToAll bool
 	// Time to wait for announcing stopped event.
 	// Stopped event is sent to the tracker when torrent is stopped.
 	TrackerStopTimeout time.Duration
 	// When the client needs new peer addresses to connect, it ask to the tracker.
 	// To prevent spamming the tracker an interval is set to wait before the next announce.
 	TrackerMinAnnounceInterval time.Duration
+	// If not zero, the announce interval sent by the tracker is clamped to this value.
+	TrackerMaxAnnounceInterval time.Duration
+	// If not zero, this value is used instead of the announce interval sent by the tracker.
+	// The "min interval" sent by the tracker is still respected.
+	TrackerAnnounceIntervalOverride time.Duration
+	// After a failed announce, the tracker is retried with exponential backoff.
+	// The tracker is never retried more frequently than TrackerRetryMinInterval.
+	// Must be positive.
+	TrackerRetryMinInterval time.Duration
+	// Max time to wait between retries to a failing tracker.
+	TrackerRetryMaxInterval time.Duration
+	// Trackers are scraped at this interval for the size of the swarm in Stats.Swarm. Zero disables scraping.
+	TrackerScrapeInterval time.Duration
 	// Total time to wait for response to be read.
 	// This includes ConnectTimeout and TLSHandshakeTimeout.
 	TrackerHTTPTimeout time.Duration
 	// User agent sent when communicating with HTTP trackers.
+	// Only applies to public torrents.
+	TrackerHTTPPublicUserAgent string
+	// User agent sent when communicating with HTTP trackers.
 	// Only applies to private torrents.
 	TrackerHTTPPrivateUserAgent string
 	// Max number of bytes in a tracker response.
 	TrackerHTTPMaxResponseSize uint
 	// Check and validate TLS ceritificates.
 	TrackerHTTPVerifyTLS bool
+	// Ask HTTP trackers to return peer list in compact format.
+	// Some private trackers expect this to be disabled.
+	TrackerHTTPCompact bool
+	// Ask HTTP trackers to omit peer IDs from the peer list when it is not in compact format.
+	TrackerHTTPNoPeerID bool
 
 	// Number of unchoked peers.
 	UnchokedPeers int
@@ -139,6 +257,25 @@ type Config struct {
 	MaxPeerDial int
 	// Max number of incoming connections to accept
 	MaxPeerAccept int
+	// Max number of incoming connections accepted in a second, for all torrents.
+	// Connections exceeding the limit are closed before the handshake. Set to 0 for no limit.
+	MaxPeerAcceptPerSecond int
+	// Max number of incoming connections accepted from a single IP address in a minute, for all torrents. Set to 0 for no limit.
+	MaxPeerAcceptPerIP int
+	// Max number of outgoing connection attempts, including BitTorrent handshakes, in progress at the same time for a torrent.
+	MaxConcurrentDials int
+	// Max number of TCP connections that are being opened at the same time in the session, for all torrents.
+	// Some routers drop connections when there are too many half-open connections. Set to 0 for no limit.
+	MaxHalfOpenConnections int
+	// Allow multiple connections to/from the same IP address for a torrent, e.g. for peers on a LAN behind the same NAT.
+	// By default only one connection is made with an IP address.
+	AllowSameIPConnections bool
+	// When the number of outgoing connections reaches MaxPeerDial, lowest-value peers (snubbed, idle or not transferring any data)
+	// are disconnected at this interval to make room for new addresses in the connect queue.
+	// Peers connected for less than this duration are not disconnected. Set to 0 to disable peer replacement.
+	PeerReplaceInterval time.Duration
+	// Max number of peers to disconnect at each peer replacement round.
+	PeerReplaceCount int
 	// Running metadata downloads, snubbed peers don't count
 	ParallelMetadataDownloads int
 	// Time to wait for TCP connection to open.
@@ -147,6 +284,14 @@ type Config struct {
 	PeerHandshakeTimeout time.Duration
 	// When peer has started to send piece block, if it does not send any bytes in PieceReadTimeout, the connection is closed.
 	PieceReadTimeout time.Duration
+	// If no message is received from peer in PeerReadTimeout, the connection is closed.
+	// Peers send keep-alive messages to keep idle connections open.
+	PeerReadTimeout time.Duration
+	// A keep-alive message is sent to peer if no other message is sent in this duration.
+	// Keeps NAT mappings of idle connections from expiring. Must be less than PeerReadTimeout of the remote peer, which is usually 2 minutes.
+	PeerKeepAliveInterval time.Duration
+	// Period of TCP keep-alive probes on peer connections. 0 uses the default of Go net package. Negative value disables TCP keep-alives.
+	PeerTCPKeepAlive time.Duration
 	// Max number of peer addresses to keep in connect queue.
 	MaxPeerAddresses int
 	// Number of allowed-fast messages to send after handshake.
@@ -164,6 +309,16 @@ type Config struct {
 	ParallelWrites uint
 	// Number of bytes allocated in memory for downloading piece data.
 	WriteCacheSize int64
+	// Check MD5 sums of completed files if the torrent contains them.
+	// A mismatch stops the torrent with an error.
+	VerifyMD5 bool
+	// Stop the torrent with ErrTooManyHashFailures after this many downloaded pieces fail hash check.
+	// Counted from the start of the torrent. 0 means no limit.
+	MaxPieceHashFailures int
+	// Keep an index of the piece hashes of all torrents in Session.
+	// Missing pieces that exist in another torrent are copied from its files instead of downloading from peers.
+	// Uses 20 bytes of memory per piece for all torrents.
+	PieceDedup bool
 
 	// When the client want to connect a peer, first it tries to do encrypted handshake.
 	// If it does not work, it connects to same peer again and does unencrypted handshake.
@@ -191,8 +346,12 @@ type Config struct {
 	// Number of maximum simulateous downloads from WebSeed sources.
 	WebseedMaxDownloads int
 
-	// Shell command to execute on torrent completion.
+	// Command to execute on torrent completion. First element is the program, the rest are the arguments.
+	// Details of the torrent are passed in environment variables:
+	// RAIN_TORRENT_ID, RAIN_TORRENT_NAME, RAIN_TORRENT_HASH, RAIN_TORRENT_DIR, RAIN_TORRENT_SIZE and RAIN_TORRENT_ADDED.
 	OnCompleteCmd []string
+	// Time to wait for running completion commands when the session is closing.
+	OnCompleteCmdCloseTimeout time.Duration
 }
 
 // DefaultConfig for Session. Do not pass zero value Config to NewSession. Copy this struct and modify instead.
@@ -201,12 +360,16 @@ var DefaultConfig = Config{
 	Database:                               "~/rain/session.db",
 	DataDir:                                "~/rain/data",
 	DataDirIncludesTorrentID:               true,
+	AdoptExistingData:                      true,
+	WindowsSafeFilenames:                   runtime.GOOS == "windows",
 	Host:                                   "0.0.0.0",
 	PortBegin:                              20000,
 	PortEnd:                                30000,
 	MaxOpenFiles:                           10240,
 	PEXEnabled:                             true,
+	TEXEnabled:                             true,
 	ResumeWriteInterval:                    30 * time.Second,
+	PublicPeerIDPrefix:                     publicPeerIDPrefix,
 	PrivatePeerIDPrefix:                    "-RN" + Version + "-",
 	PrivateExtensionHandshakeClientVersion: "Rain " + Version,
 	BlocklistUpdateInterval:                24 * time.Hour,
@@ -224,6 +387,16 @@ var DefaultConfig = Config{
 	HealthCheckInterval:                    10 * time.Second,
 	HealthCheckTimeout:                     60 * time.Second,
 	FilePermissions:                        0o750,
+	DirPermissions:                         0o750,
+	Umask:                                  -1,
+	SyncWrites:                             true,
+	LazyFileCreation:                       true,
+	S3Endpoint:                             "https://s3.amazonaws.com",
+	S3Region:                               "us-east-1",
+	WebDAVMaxConnections:                   4,
+	WebDAVRetryTimeout:                     time.Minute,
+	DiskSpaceCheck:                         true,
+	DiskSpaceCheckInterval:                 time.Minute,
 
 	// RPC Server
 	RPCEnabled:         true,
@@ -235,14 +408,22 @@ var DefaultConfig = Config{
 	TrackerNumWant:              200,
 	TrackerStopTimeout:          5 * time.Second,
 	TrackerMinAnnounceInterval:  time.Minute,
+	TrackerRetryMinInterval:     5 * time.Second,
+	TrackerRetryMaxInterval:     30 * time.Minute,
+	TrackerScrapeInterval:       30 * time.Minute,
 	TrackerHTTPTimeout:          10 * time.Second,
+	TrackerHTTPPublicUserAgent:  trackerHTTPPublicUserAgent,
 	TrackerHTTPPrivateUserAgent: "Rain/" + Version,
 	TrackerHTTPMaxResponseSize:  2 << 20,
 	TrackerHTTPVerifyTLS:        true,
+	TrackerHTTPCompact:          true,
+	TrackerHTTPNoPeerID:         true,
 
 	// DHT node
 	DHTEnabled:             true,
 	DHTHost:                "0.0.0.0",
+	DHTIPv6Enabled:         true,
+	DHTHost6:               "::",
 	DHTPort:                7246,
 	DHTAnnounceInterval:    30 * time.Minute,
 	DHTMinAnnounceInterval: time.Minute,
@@ -253,6 +434,7 @@ var DefaultConfig = Config{
 		"dht.libtorrent.org:25401",
 		"dht.aelitis.com:6881",
 	},
+	DHTSaveNodes: true,
 
 	// Peer
 	UnchokedPeers:                3,
@@ -264,10 +446,19 @@ var DefaultConfig = Config{
 	EndgameMaxDuplicateDownloads: 20,
 	MaxPeerDial:                  80,
 	MaxPeerAccept:                20,
+	MaxConcurrentDials:           20,
+	MaxPeerAcceptPerSecond:       20,
+	MaxPeerAcceptPerIP:           10,
+	MaxHalfOpenConnections:       100,
+	PeerReplaceInterval:          time.Minute,
+	PeerReplaceCount:             4,
 	ParallelMetadataDownloads:    2,
 	PeerConnectTimeout:           5 * time.Second,
 	PeerHandshakeTimeout:         10 * time.Second,
 	PieceReadTimeout:             30 * time.Second,
+	PeerReadTimeout:              2 * time.Minute,
+	PeerKeepAliveInterval:        time.Minute,
+	PeerTCPKeepAlive:             15 * time.Second,
 	MaxPeerAddresses:             2000,
 	AllowedFastSet:               10,
 
@@ -288,4 +479,6 @@ var DefaultConfig = Config{
 	WebseedVerifyTLS:               true,
 	WebseedMaxSources:              10,
 	WebseedMaxDownloads:            4,
+
+	OnCompleteCmdCloseTimeout: 30 * time.Second,
 }
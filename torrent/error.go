@@ -1,6 +1,9 @@
 package torrent
 
 import (
+	"errors"
+	"net"
+
 	"github.com/cenkalti/rain/internal/announcer"
 )
 
@@ -45,3 +48,58 @@ func (e *AnnounceError) Unwrap() error {
 func (e *AnnounceError) Unknown() bool {
 	return e.err.Unknown
 }
+
+var (
+	// ErrTrackerUnreachable matches the AnnounceErrors that are caused by network errors while contacting the tracker,
+	// as opposed to errors returned from the tracker. Check with errors.Is.
+	ErrTrackerUnreachable = errors.New("tracker is unreachable")
+	// ErrInvalidMetainfo matches the errors caused by invalid torrent files or invalid metadata downloaded from peers.
+	// Check with errors.Is.
+	ErrInvalidMetainfo = errors.New("invalid metainfo")
+	// ErrTooManyHashFailures is returned from NotifyStop when the number of pieces that fail hash check
+	// reaches Config.MaxPieceHashFailures.
+	ErrTooManyHashFailures = errors.New("too many pieces failed hash check")
+)
+
+// Is returns true for ErrTrackerUnreachable if the tracker could not be contacted.
+func (e *AnnounceError) Is(target error) bool {
+	if target != ErrTrackerUnreachable {
+		return false
+	}
+	var nerr net.Error
+	return errors.As(e.err.Err, &nerr)
+}
+
+// StorageError is returned from NotifyStop when reading or writing the files of the torrent fails.
+type StorageError struct {
+	// Describes the failed operation.
+	Op  string
+	Err error
+}
+
+// Error implements error interface.
+func (e *StorageError) Error() string {
+	return e.Op + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *StorageError) Unwrap() error {
+	return e.Err
+}
+
+// metainfoError wraps the errors from parsing torrent files and metadata, so they match ErrInvalidMetainfo.
+type metainfoError struct {
+	err error
+}
+
+func (e metainfoError) Error() string {
+	return e.err.Error()
+}
+
+func (e metainfoError) Unwrap() error {
+	return e.err
+}
+
+func (e metainfoError) Is(target error) bool {
+	return target == ErrInvalidMetainfo
+}
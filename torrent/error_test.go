@@ -0,0 +1,31 @@
+package torrent
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/cenkalti/rain/internal/announcer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorKinds(t *testing.T) {
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+
+	_, err := s.AddTorrent(bytes.NewReader([]byte("invalid")), nil)
+	assert.True(t, errors.Is(err, ErrInvalidMetainfo))
+	var inputErr *InputError
+	assert.True(t, errors.As(err, &inputErr))
+
+	var unreachable error = &AnnounceError{&announcer.AnnounceError{Err: &net.DNSError{Name: "tracker.invalid", IsNotFound: true}}}
+	assert.True(t, errors.Is(unreachable, ErrTrackerUnreachable))
+	var trackerErr error = &AnnounceError{&announcer.AnnounceError{Err: errors.New("failure reason")}}
+	assert.False(t, errors.Is(trackerErr, ErrTrackerUnreachable))
+
+	var storageErr error = &StorageError{Op: "cannot write piece", Err: io.ErrShortWrite}
+	assert.True(t, errors.Is(storageErr, io.ErrShortWrite))
+	assert.Equal(t, "cannot write piece: short write", storageErr.Error())
+}
@@ -3,18 +3,24 @@ package torrent
 
 import (
 	"context"
+	"crypto/rand"
 	"crypto/tls"
+	"encoding/binary"
 	"errors"
 	"net"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/cenkalti/rain/internal/acceptor"
 	"github.com/cenkalti/rain/internal/bitfield"
 	"github.com/cenkalti/rain/internal/blocklist"
+	"github.com/cenkalti/rain/internal/dhtclient"
 	"github.com/cenkalti/rain/internal/logger"
 	"github.com/cenkalti/rain/internal/peer"
 	"github.com/cenkalti/rain/internal/piececache"
@@ -22,9 +28,12 @@ import (
 	"github.com/cenkalti/rain/internal/resourcemanager"
 	"github.com/cenkalti/rain/internal/resumer/boltdbresumer"
 	"github.com/cenkalti/rain/internal/semaphore"
+	"github.com/cenkalti/rain/internal/speedlimit"
+	"github.com/cenkalti/rain/internal/storage/s3storage"
+	"github.com/cenkalti/rain/internal/storage/webdavstorage"
 	"github.com/cenkalti/rain/internal/tracker"
 	"github.com/cenkalti/rain/internal/trackermanager"
-	"github.com/juju/ratelimit"
+	"github.com/cenkalti/rain/metainfo"
 	"github.com/mitchellh/go-homedir"
 	"github.com/nictuku/dht"
 	"go.etcd.io/bbolt"
@@ -36,6 +45,11 @@ var (
 	blocklistKey          = []byte("blocklist")
 	blocklistTimestampKey = []byte("blocklist-timestamp")
 	blocklistURLHashKey   = []byte("blocklist-url-hash")
+	encryptionSaltKey     = []byte("storage-encryption-salt")
+	encryptionCheckKey    = []byte("storage-encryption-check")
+	lifetimeDownloadedKey = []byte("lifetime-bytes-downloaded")
+	lifetimeUploadedKey   = []byte("lifetime-bytes-uploaded")
+	sessionCountKey       = []byte("session-count")
 )
 
 // Session contains torrents, DHT node, caches and other data structures shared by multiple torrents.
@@ -45,33 +59,86 @@ type Session struct {
 	resumer        *boltdbresumer.Resumer
 	log            logger.Logger
 	extensions     [8]byte
+	announceKey    uint32
+	announceIP     net.IP
+	charset        metainfo.Charset
+	s3             *s3storage.Client
+	webdav         *webdavstorage.Client
+	encryptionKey  []byte
+	lifetime       lifetimeStats
 	dht            *dht.DHT
+	dht6           *dht.DHT
+	dhtClient      *dhtclient.Client
+	dhtNodes       *dhtNodes
 	rpc            *rpcServer
 	trackerManager *trackermanager.TrackerManager
 	ram            *resourcemanager.ResourceManager[*peer.Peer]
 	pieceCache     *piececache.Cache
+	pieceIndex     *pieceIndex
 	webseedClient  http.Client
 	createdAt      time.Time
 	semWrite       *semaphore.Semaphore
+	semHalfOpen    *semaphore.Semaphore
+	acceptThrottle *acceptor.Throttle
 	metrics        *sessionMetrics
-	bucketDownload *ratelimit.Bucket
-	bucketUpload   *ratelimit.Bucket
+	bucketDownload *speedlimit.Limiter
+	bucketUpload   *speedlimit.Limiter
 	closeC         chan struct{}
+	doneC          chan struct{}
+
+	// Connection and queue limits that can be changed with SetSettings while the session is running.
+	maxPeerDial             int32
+	maxPeerAccept           int32
+	queueMaxActiveDownloads int32
+	queueMaxActiveSeeds     int32
+
+	// Extensions registered with RegisterExtension. Index in the slice determines the extended message ID.
+	mExtensions      sync.RWMutex
+	customExtensions []customExtension
 
 	mPeerRequests   sync.Mutex
 	dhtPeerRequests map[*torrent]struct{}
 
+	// Channels of the lookups started with DHTGetPeers.
+	mDHTLookups sync.Mutex
+	dhtLookups  map[dht.InfoHash][]chan []*net.TCPAddr
+
+	mDHTItemClient sync.Mutex
+	dhtItemClient  *dhtclient.Client
+
 	mTorrents          sync.RWMutex
 	torrents           map[string]*Torrent
 	torrentsByInfoHash map[dht.InfoHash][]*Torrent
 	invalidTorrentIDs  []string
 
+	// Data directories saved in resume data for each torrent. See getDataDir.
+	mDataDirs sync.RWMutex
+	dataDirs  map[string]string
+
+	// Per-torrent settings from Config.TorrentOverrides, keyed by lowercase hex info hash.
+	torrentOverrides map[string]TorrentOverride
+
+	// Speed limits and schedules that can be changed with SetSettings and ReloadConfig while the session is running.
+	mSchedule          sync.Mutex
+	speedLimitDownload int64
+	speedLimitUpload   int64
+	schedules          []parsedSchedule
+	pausedBySchedule   map[*Torrent]struct{}
+
+	// Torrents waiting for a free slot to start. See Config.QueueMaxActiveDownloads.
+	mQueue sync.Mutex
+	queue  []*Torrent
+	queueC chan struct{}
+
 	mPorts         sync.RWMutex
 	availablePorts map[int]struct{}
 
 	mBlocklist         sync.RWMutex
 	blocklist          *blocklist.Blocklist
 	blocklistTimestamp time.Time
+
+	// Completion commands that are still running. Close waits for them.
+	wgOnCompleteCmds sync.WaitGroup
 }
 
 // NewSession creates a new Session for downloading and seeding torrents.
@@ -80,13 +147,66 @@ func NewSession(cfg Config) (*Session, error) {
 	if cfg.PortBegin >= cfg.PortEnd {
 		return nil, errors.New("invalid port range")
 	}
+	if len(cfg.PublicPeerIDPrefix) > 20 || len(cfg.PrivatePeerIDPrefix) > 20 {
+		return nil, errors.New("peer id prefix must not be longer than 20 bytes")
+	}
+	if cfg.TrackerRetryMinInterval <= 0 {
+		return nil, errors.New("tracker retry min interval must be positive")
+	}
+	if cfg.TrackerRetryMaxInterval < cfg.TrackerRetryMinInterval {
+		return nil, errors.New("tracker retry max interval must not be less than min interval")
+	}
+	var announceIP net.IP
+	if cfg.TrackerAnnounceIP != "" {
+		announceIP = net.ParseIP(cfg.TrackerAnnounceIP)
+		if announceIP == nil {
+			return nil, errors.New("invalid tracker announce ip: " + cfg.TrackerAnnounceIP)
+		}
+	}
+	if cfg.TrackerAnnouncePort < 0 || cfg.TrackerAnnouncePort > 65535 {
+		return nil, errors.New("invalid tracker announce port")
+	}
+	schedules, err := parseSchedules(cfg.SpeedLimitSchedules)
+	if err != nil {
+		return nil, err
+	}
+	torrentOverrides, err := parseTorrentOverrides(cfg.TorrentOverrides)
+	if err != nil {
+		return nil, err
+	}
+	var charset metainfo.Charset
+	if cfg.FilenameCharset != "" {
+		charset, err = metainfo.ParseCharset(cfg.FilenameCharset)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if cfg.S3Bucket != "" && cfg.WebDAVURL != "" {
+		return nil, errors.New("s3 bucket and webdav url cannot be set at the same time")
+	}
+	var s3Client *s3storage.Client
+	if cfg.S3Bucket != "" {
+		s3Client, err = s3storage.NewClient(cfg.S3Endpoint, cfg.S3Region, cfg.S3Bucket, cfg.S3AccessKey, cfg.S3SecretKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var webdavClient *webdavstorage.Client
+	if cfg.WebDAVURL != "" {
+		webdavClient, err = webdavstorage.NewClient(cfg.WebDAVURL, cfg.WebDAVUsername, cfg.WebDAVPassword, cfg.WebDAVMaxConnections, cfg.WebDAVRetryTimeout)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Umask >= 0 {
+		setUmask(cfg.Umask)
+	}
 	if cfg.MaxOpenFiles > 0 {
 		err := setNoFile(cfg.MaxOpenFiles)
 		if err != nil {
 			return nil, errors.New("cannot change max open files limit: " + err.Error())
 		}
 	}
-	var err error
 	cfg.Database, err = homedir.Expand(cfg.Database)
 	if err != nil {
 		return nil, err
@@ -95,7 +215,15 @@ func NewSession(cfg Config) (*Session, error) {
 	if err != nil {
 		return nil, err
 	}
-	err = os.MkdirAll(filepath.Dir(cfg.Database), os.ModeDir|cfg.FilePermissions)
+	cfg.SaveTorrentDir, err = homedir.Expand(cfg.SaveTorrentDir)
+	if err != nil {
+		return nil, err
+	}
+	cfg.IncompleteDir, err = homedir.Expand(cfg.IncompleteDir)
+	if err != nil {
+		return nil, err
+	}
+	err = os.MkdirAll(filepath.Dir(cfg.Database), os.ModeDir|cfg.DirPermissions)
 	if err != nil {
 		return nil, err
 	}
@@ -129,26 +257,44 @@ func NewSession(cfg Config) (*Session, error) {
 	if err != nil {
 		return nil, err
 	}
+	var encryptionKey []byte
+	if cfg.StorageEncryptionPassphrase != "" {
+		encryptionKey, err = loadEncryptionKey(db, cfg.StorageEncryptionPassphrase)
+		if err != nil {
+			return nil, err
+		}
+	}
+	lifetime, err := loadLifetimeStats(db)
+	if err != nil {
+		return nil, err
+	}
 	res, err := boltdbresumer.New(db, torrentsBucket)
 	if err != nil {
 		return nil, err
 	}
-	var dhtNode *dht.DHT
+	var dhtNode, dhtNode6 *dht.DHT
+	var dhtClient *dhtclient.Client
+	var nodes *dhtNodes
 	if cfg.DHTEnabled {
-		dhtConfig := dht.NewConfig()
-		dhtConfig.Address = cfg.DHTHost
-		dhtConfig.Port = int(cfg.DHTPort)
-		dhtConfig.DHTRouters = strings.Join(cfg.DHTBootstrapNodes, ",")
-		dhtConfig.SaveRoutingTable = false
-		dhtConfig.NumTargetPeers = 0
-		dhtNode, err = dht.New(dhtConfig)
-		if err != nil {
-			return nil, err
+		if cfg.DHTSaveNodes {
+			nodes = newDHTNodes(maxSavedDHTNodes)
+		}
+		if cfg.DHTReadOnly {
+			dhtClient, err = startDHTClient(cfg, int(cfg.DHTPort))
+		} else {
+			dhtNode, err = startDHTNode(cfg, cfg.DHTHost, "udp4", nodes)
 		}
-		err = dhtNode.Start()
 		if err != nil {
 			return nil, err
 		}
+		if cfg.DHTIPv6Enabled && !cfg.DHTReadOnly {
+			dhtNode6, err = startDHTNode(cfg, cfg.DHTHost6, "udp6", nodes)
+			if err != nil {
+				// Host may not have IPv6 connectivity.
+				l.Warningln("cannot start dht node on ipv6:", err.Error())
+				dhtNode6 = nil
+			}
+		}
 	}
 	ports := make(map[int]struct{})
 	for p := cfg.PortBegin; p < cfg.PortEnd; p++ {
@@ -159,22 +305,49 @@ func NewSession(cfg Config) (*Session, error) {
 	if cfg.BlocklistEnabledForTrackers {
 		blTracker = bl
 	}
+	var announceKey [4]byte
+	_, err = rand.Read(announceKey[:])
+	if err != nil {
+		return nil, err
+	}
 	c := &Session{
-		config:             cfg,
-		db:                 db,
-		resumer:            res,
-		blocklist:          bl,
-		trackerManager:     trackermanager.New(blTracker, cfg.DNSResolveTimeout, !cfg.TrackerHTTPVerifyTLS),
-		log:                l,
-		torrents:           make(map[string]*Torrent),
-		torrentsByInfoHash: make(map[dht.InfoHash][]*Torrent),
-		availablePorts:     ports,
-		dht:                dhtNode,
-		pieceCache:         piececache.New(cfg.ReadCacheSize, cfg.ReadCacheTTL, cfg.ParallelReads),
-		ram:                resourcemanager.New[*peer.Peer](cfg.WriteCacheSize),
-		createdAt:          time.Now(),
-		semWrite:           semaphore.New(int(cfg.ParallelWrites)),
-		closeC:             make(chan struct{}),
+		config:                  cfg,
+		announceKey:             binary.BigEndian.Uint32(announceKey[:]),
+		announceIP:              announceIP,
+		charset:                 charset,
+		s3:                      s3Client,
+		webdav:                  webdavClient,
+		encryptionKey:           encryptionKey,
+		maxPeerDial:             int32(cfg.MaxPeerDial),
+		maxPeerAccept:           int32(cfg.MaxPeerAccept),
+		queueMaxActiveDownloads: int32(cfg.QueueMaxActiveDownloads),
+		queueMaxActiveSeeds:     int32(cfg.QueueMaxActiveSeeds),
+		db:                      db,
+		resumer:                 res,
+		blocklist:               bl,
+		trackerManager:          trackermanager.New(blTracker, cfg.DNSResolveTimeout, !cfg.TrackerHTTPVerifyTLS, cfg.TrackerHTTPCompact, cfg.TrackerHTTPNoPeerID),
+		log:                     l,
+		torrents:                make(map[string]*Torrent),
+		torrentsByInfoHash:      make(map[dht.InfoHash][]*Torrent),
+		lifetime:                lifetime,
+		dataDirs:                make(map[string]string),
+		availablePorts:          ports,
+		dht:                     dhtNode,
+		dht6:                    dhtNode6,
+		dhtClient:               dhtClient,
+		dhtNodes:                nodes,
+		pieceCache:              piececache.New(cfg.ReadCacheSize, cfg.ReadCacheTTL, cfg.ParallelReads),
+		ram:                     resourcemanager.New[*peer.Peer](cfg.WriteCacheSize),
+		createdAt:               time.Now(),
+		semWrite:                semaphore.New(int(cfg.ParallelWrites)),
+		closeC:                  make(chan struct{}),
+		doneC:                   make(chan struct{}),
+		queueC:                  make(chan struct{}, 1),
+		speedLimitDownload:      cfg.SpeedLimitDownload,
+		speedLimitUpload:        cfg.SpeedLimitUpload,
+		schedules:               schedules,
+		torrentOverrides:        torrentOverrides,
+		pausedBySchedule:        make(map[*Torrent]struct{}),
 		webseedClient: http.Client{
 			Transport: &http.Transport{
 				DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
@@ -194,13 +367,14 @@ func NewSession(cfg Config) (*Session, error) {
 			},
 		},
 	}
-	dlSpeed := cfg.SpeedLimitDownload * 1024
-	if cfg.SpeedLimitDownload > 0 {
-		c.bucketDownload = ratelimit.NewBucketWithRate(float64(dlSpeed), dlSpeed)
+	if cfg.PieceDedup {
+		c.pieceIndex = newPieceIndex()
 	}
-	ulSpeed := cfg.SpeedLimitUpload * 1024
-	if cfg.SpeedLimitUpload > 0 {
-		c.bucketUpload = ratelimit.NewBucketWithRate(float64(ulSpeed), ulSpeed)
+	c.bucketDownload = speedlimit.New(cfg.SpeedLimitDownload)
+	c.bucketUpload = speedlimit.New(cfg.SpeedLimitUpload)
+	c.acceptThrottle = acceptor.NewThrottle(cfg.MaxPeerAcceptPerSecond, cfg.MaxPeerAcceptPerIP)
+	if cfg.MaxHalfOpenConnections > 0 {
+		c.semHalfOpen = semaphore.New(cfg.MaxHalfOpenConnections)
 	}
 	err = c.startBlocklistReloader()
 	if err != nil {
@@ -213,8 +387,11 @@ func NewSession(cfg Config) (*Session, error) {
 	ext.Set(61) // Fast Extension (BEP 6)
 	ext.Set(43) // Extension Protocol (BEP 10)
 	if cfg.DHTEnabled {
-		ext.Set(63) // DHT Protocol (BEP 5)
+		if !cfg.DHTReadOnly {
+			ext.Set(63) // DHT Protocol (BEP 5)
+		}
 		c.dhtPeerRequests = make(map[*torrent]struct{})
+		c.dhtLookups = make(map[dht.InfoHash][]chan []*net.TCPAddr)
 	}
 	c.initMetrics()
 	c.loadExistingTorrents(ids)
@@ -225,15 +402,64 @@ func NewSession(cfg Config) (*Session, error) {
 			return nil, err
 		}
 	}
+	if c.dhtNodes != nil {
+		err = c.loadDHTNodes()
+		if err != nil {
+			c.log.Errorln("cannot load saved dht nodes:", err.Error())
+		}
+	}
 	if cfg.DHTEnabled {
 		go c.processDHTResults()
 	}
 	go c.updateStatsLoop()
+	go c.processQueue()
+	c.applySpeedLimitSchedule()
+	go c.speedLimitScheduler()
 	return c, nil
 }
 
+// startDHTNode starts a new DHT node listening on host with network proto ("udp4" or "udp6").
+// Each node has its own routing table as described in BEP 32.
+func startDHTNode(cfg Config, host, proto string, nodes *dhtNodes) (*dht.DHT, error) {
+	dhtConfig := dht.NewConfig()
+	dhtConfig.Address = host
+	dhtConfig.Port = int(cfg.DHTPort)
+	dhtConfig.DHTRouters = strings.Join(cfg.DHTBootstrapNodes, ",")
+	dhtConfig.SaveRoutingTable = false
+	dhtConfig.NumTargetPeers = 0
+	dhtConfig.UDPProto = proto
+	node, err := dht.New(dhtConfig)
+	if err != nil {
+		return nil, err
+	}
+	if nodes != nil {
+		node.Logger = nodes
+	}
+	err = node.Start()
+	if err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// startDHTClient starts a read-only DHT client (BEP 43) listening on DHTHost and port.
+func startDHTClient(cfg Config, port int) (*dhtclient.Client, error) {
+	conn, err := net.ListenPacket("udp4", net.JoinHostPort(cfg.DHTHost, strconv.Itoa(port)))
+	if err != nil {
+		return nil, err
+	}
+	client, err := dhtclient.New(conn, cfg.DHTBootstrapNodes, dhtQueryTimeout)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	go client.Run()
+	return client, nil
+}
+
 func (s *Session) parseTrackers(tiers [][]string, private bool) []tracker.Tracker {
 	ret := make([]tracker.Tracker, 0, len(tiers))
+	var tierList []*tracker.Tier
 	for _, tier := range tiers {
 		trackers := make([]tracker.Tracker, 0, len(tier))
 		for _, tr := range tier {
@@ -243,11 +469,19 @@ func (s *Session) parseTrackers(tiers [][]string, private bool) []tracker.Tracke
 			}
 			trackers = append(trackers, t)
 		}
-		if len(trackers) > 0 {
-			tra := tracker.NewTier(trackers)
-			ret = append(ret, tra)
+		if s.config.TrackerAnnounceToAll {
+			ret = append(ret, trackers...)
+		} else if len(trackers) > 0 {
+			tierList = append(tierList, tracker.NewTier(trackers))
 		}
 	}
+	switch len(tierList) {
+	case 0:
+	case 1:
+		ret = append(ret, tierList[0])
+	default:
+		ret = append(ret, tracker.NewTierList(tierList))
+	}
 	return ret
 }
 
@@ -255,18 +489,28 @@ func (s *Session) getTrackerUserAgent(private bool) string {
 	if private {
 		return s.config.TrackerHTTPPrivateUserAgent
 	}
-	return trackerHTTPPublicUserAgent
+	return s.config.TrackerHTTPPublicUserAgent
 }
 
 // Close stops all torrents and release the resources.
 func (s *Session) Close() error {
 	close(s.closeC)
+	defer close(s.doneC)
 
-	if s.config.DHTEnabled {
+	if s.dht != nil {
 		s.dht.Stop()
 	}
-
-	s.updateStats()
+	if s.dht6 != nil {
+		s.dht6.Stop()
+	}
+	if s.dhtClient != nil {
+		s.dhtClient.Close()
+	}
+	s.mDHTItemClient.Lock()
+	if s.dhtItemClient != nil {
+		s.dhtItemClient.Close()
+	}
+	s.mDHTItemClient.Unlock()
 
 	var wg sync.WaitGroup
 	s.mTorrents.Lock()
@@ -278,9 +522,14 @@ func (s *Session) Close() error {
 		}(t)
 	}
 	wg.Wait()
+	// Flush final state of torrents after all of them are stopped.
+	s.writeStats()
 	s.torrents = nil
 	s.mTorrents.Unlock()
 
+	// No more completion commands can be started after torrents are closed.
+	s.waitOnCompleteCmds()
+
 	if s.rpc != nil {
 		err := s.rpc.Stop(s.config.RPCShutdownTimeout)
 		if err != nil {
@@ -295,6 +544,12 @@ func (s *Session) Close() error {
 	return s.db.Close()
 }
 
+// Done returns a channel that is closed when Close has finished.
+// At that point all torrents are closed, their final state is written to the database and the database is closed.
+func (s *Session) Done() <-chan struct{} {
+	return s.doneC
+}
+
 // ListTorrents returns all torrents in session as a slice.
 // The order of the torrents returned is different on each call.
 func (s *Session) ListTorrents() []*Torrent {
@@ -348,6 +603,9 @@ func (s *Session) removeTorrentFromClient(id string) (*Torrent, error) {
 	}
 	t.torrent.log.Info("removing torrent")
 	delete(s.torrents, id)
+	if s.pieceIndex != nil {
+		s.pieceIndex.Remove(t.torrent)
+	}
 
 	// Delete from the list of torrents with same info hash
 	ih := dht.InfoHash(t.torrent.InfoHash())
@@ -366,8 +624,15 @@ func (s *Session) removeTorrentFromClient(id string) (*Torrent, error) {
 	// DHT.PeersRequestResults. That's why we are releasing the lock before calling DHT.RemoveInfoHash.
 	s.mTorrents.Unlock()
 
-	if s.config.DHTEnabled && len(s.torrentsByInfoHash[ih]) == 0 {
-		s.dht.RemoveInfoHash(string(ih))
+	s.dequeue(t)
+
+	if len(s.torrentsByInfoHash[ih]) == 0 {
+		if s.dht != nil {
+			s.dht.RemoveInfoHash(string(ih))
+		}
+		if s.dht6 != nil {
+			s.dht6.RemoveInfoHash(string(ih))
+		}
 	}
 	return t, s.db.Update(func(tx *bbolt.Tx) error {
 		return tx.Bucket(torrentsBucket).DeleteBucket([]byte(id))
@@ -380,10 +645,15 @@ func (s *Session) stopAndRemoveData(t *Torrent) error {
 	var err error
 	var dest string
 	if s.config.DataDirIncludesTorrentID {
-		dest = filepath.Join(s.config.DataDir, t.torrent.id)
+		dest = s.getDataDir(t.torrent.id)
 	} else if t.torrent.info != nil {
-		dest = filepath.Join(s.config.DataDir, t.torrent.info.Name)
+		dest = filepath.Join(s.getDataDir(t.torrent.id), t.torrent.info.Name)
 	}
+	if s.filesShared(t.torrent) {
+		s.log.Infof("not removing data of torrent %s, files are used by another torrent", t.torrent.id)
+		dest = ""
+	}
+	s.setDataDir(t.torrent.id, "")
 	if dest != "" {
 		err = os.RemoveAll(dest)
 		if err != nil {
@@ -409,7 +679,7 @@ func (s *Session) StartAll() error {
 		return err
 	}
 	for _, t := range s.torrents {
-		t.torrent.Start()
+		s.startOrQueue(t)
 	}
 	return nil
 }
@@ -430,14 +700,56 @@ func (s *Session) StopAll() error {
 		return err
 	}
 	for _, t := range s.torrents {
+		s.dequeue(t)
 		t.torrent.Stop()
 	}
 	return nil
 }
 
+// getDataDir returns the directory that the files of the torrent are saved in.
+// The directory is saved in resume data when the torrent is added,
+// so existing torrents are not lost if the data directory in Config is changed later.
 func (s *Session) getDataDir(torrentID string) string {
+	s.mDataDirs.RLock()
+	dir, ok := s.dataDirs[torrentID]
+	s.mDataDirs.RUnlock()
+	if ok {
+		return dir
+	}
 	if s.config.DataDirIncludesTorrentID {
 		return filepath.Join(s.config.DataDir, torrentID)
 	}
 	return s.config.DataDir
 }
+
+// setDataDir sets the data directory of the torrent loaded from resume data. Empty dir removes it.
+func (s *Session) setDataDir(torrentID, dir string) {
+	s.mDataDirs.Lock()
+	defer s.mDataDirs.Unlock()
+	if dir == "" {
+		delete(s.dataDirs, torrentID)
+		return
+	}
+	s.dataDirs[torrentID] = dir
+}
+
+func (s *Session) getS3Prefix(torrentID string) string {
+	if s.config.DataDirIncludesTorrentID {
+		return path.Join(s.config.S3Prefix, torrentID)
+	}
+	return s.config.S3Prefix
+}
+
+func (s *Session) getWebDAVDir(torrentID string) string {
+	if s.config.DataDirIncludesTorrentID {
+		return torrentID
+	}
+	return ""
+}
+
+func (s *Session) getIncompleteDir(torrentID string) string {
+	if s.config.DataDirIncludesTorrentID {
+		return filepath.Join(s.config.IncompleteDir, torrentID)
+	}
+	return s.config.IncompleteDir
+}
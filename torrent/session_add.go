@@ -1,6 +1,7 @@
 package torrent
 
 import (
+	"context"
 	"encoding/base64"
 	"errors"
 	"fmt"
@@ -10,12 +11,12 @@ import (
 	"strings"
 	"time"
 
-	"github.com/cenkalti/rain/internal/magnet"
-	"github.com/cenkalti/rain/internal/metainfo"
+	"github.com/cenkalti/rain/internal/bitfield"
 	"github.com/cenkalti/rain/internal/resumer"
 	"github.com/cenkalti/rain/internal/resumer/boltdbresumer"
-	"github.com/cenkalti/rain/internal/storage/filestorage"
 	"github.com/cenkalti/rain/internal/webseedsource"
+	"github.com/cenkalti/rain/magnet"
+	"github.com/cenkalti/rain/metainfo"
 	"github.com/gofrs/uuid"
 	"github.com/nictuku/dht"
 )
@@ -31,6 +32,19 @@ type AddTorrentOptions struct {
 	StopAfterDownload bool
 	// Stop torrent after metadata is downloaded from magnet links.
 	StopAfterMetadata bool
+	// Only upload the pieces that exist in storage. Missing pieces are not downloaded.
+	// The torrent is advertised to peers and trackers as a partial seed (BEP 21).
+	SeedOnly bool
+	// Priority of the torrent in Session queue and bandwidth allocation.
+	Priority Priority
+	// Additional tracker URLs. Each one is added as a separate tier after the trackers in the torrent.
+	Trackers []string
+	// ID of an existing torrent that has the same files, usually the same release from another tracker.
+	// The new torrent uses the files of that torrent instead of downloading them again.
+	// Pieces already verified by the existing torrent are not verified again if both torrents have the same pieces.
+	// The new torrent is added as seed-only, so the files are written only by the existing torrent.
+	// Not supported for magnet links.
+	CrossSeed string
 }
 
 // AddTorrent adds a new torrent to the session by reading .torrent metainfo from reader.
@@ -50,9 +64,9 @@ func (s *Session) AddTorrent(r io.Reader, opt *AddTorrentOptions) (*Torrent, err
 }
 
 func (s *Session) parseMetaInfo(r io.Reader) (*metainfo.MetaInfo, error) {
-	mi, err := metainfo.New(r)
+	mi, err := metainfo.NewWithCharset(r, s.charset)
 	if err != nil {
-		return nil, err
+		return nil, metainfoError{err}
 	}
 	if mi.Info.NumPieces > s.config.MaxPieces {
 		return nil, errTooManyPieces
@@ -66,15 +80,37 @@ func (s *Session) addTorrentStopped(r io.Reader, opt *AddTorrentOptions) (*Torre
 	if err != nil {
 		return nil, newInputError(err)
 	}
-	id, port, sto, err := s.add(opt)
+	announceList, err := s.appendTrackers(mi.AnnounceList, opt.Trackers)
+	if err != nil {
+		return nil, err
+	}
+	id, port, err := s.add(opt)
 	if err != nil {
 		return nil, err
 	}
 	defer func() {
 		if err != nil {
 			s.releasePort(port)
+			s.setDataDir(id, "")
 		}
 	}()
+	renamedFiles := s.renameFiles(&mi.Info, nil)
+	s.overrideDataDir(id, mi.Info.Hash[:])
+	var bf *bitfield.Bitfield
+	seedOnly := opt.SeedOnly
+	if opt.CrossSeed != "" {
+		bf, err = s.crossSeed(id, opt.CrossSeed, &mi.Info)
+		if err != nil {
+			return nil, newInputError(err)
+		}
+		seedOnly = true
+	} else {
+		s.adoptExistingData(id, &mi.Info)
+	}
+	sto, err := s.newStorage(id, &mi.Info, bf)
+	if err != nil {
+		return nil, err
+	}
 	t, err := newTorrent2(
 		s,
 		id,
@@ -83,10 +119,10 @@ func (s *Session) addTorrentStopped(r io.Reader, opt *AddTorrentOptions) (*Torre
 		sto,
 		mi.Info.Name,
 		port,
-		s.parseTrackers(mi.AnnounceList, mi.Info.Private),
+		s.parseTrackers(announceList, mi.Info.Private),
 		nil, // fixedPeers
 		&mi.Info,
-		nil, // bitfield
+		bf,
 		resumer.Stats{},
 		webseedsource.NewList(mi.URLList),
 		opt.StopAfterDownload,
@@ -106,13 +142,22 @@ func (s *Session) addTorrentStopped(r io.Reader, opt *AddTorrentOptions) (*Torre
 		InfoHash:          mi.Info.Hash[:],
 		Port:              port,
 		Name:              mi.Info.Name,
-		Trackers:          mi.AnnounceList,
+		Trackers:          announceList,
 		URLList:           mi.URLList,
 		Info:              mi.Info.Bytes,
+		RenamedFiles:      renamedFiles,
 		AddedAt:           t.addedAt,
 		StopAfterDownload: opt.StopAfterDownload,
 		StopAfterMetadata: opt.StopAfterMetadata,
+		SeedOnly:          seedOnly,
+		Priority:          int(opt.Priority),
+		DataDir:           s.getDataDir(id),
 	}
+	if bf != nil {
+		rspec.Bitfield = bf.Bytes()
+	}
+	t.setPriority(opt.Priority)
+	t.seedOnly = seedOnly
 	err = s.resumer.Write(id, rspec)
 	if err != nil {
 		return nil, err
@@ -122,21 +167,36 @@ func (s *Session) addTorrentStopped(r io.Reader, opt *AddTorrentOptions) (*Torre
 }
 
 // AddURI adds a new torrent to the session from a URI.
-// URI may be a magnet link or a HTTP URL.
+// URI may be a magnet link, a HTTP URL or a bare info hash in hex or base32 encoding.
 // In case of a HTTP address, a torrent is tried to be downloaded from that URL.
 // Nil value can be passed as opt for default options.
 func (s *Session) AddURI(uri string, opt *AddTorrentOptions) (*Torrent, error) {
+	return s.AddURIContext(context.Background(), uri, opt)
+}
+
+// AddURIContext is like AddURI but downloading the torrent from a HTTP URL is canceled when ctx is done.
+// Metadata of magnet links is downloaded after the torrent is added, use Torrent.WaitMetadata to wait for it.
+func (s *Session) AddURIContext(ctx context.Context, uri string, opt *AddTorrentOptions) (*Torrent, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	uri = filterOutControlChars(uri)
 	if opt == nil {
 		opt = &AddTorrentOptions{}
 	}
+	if ih, err := magnet.ParseInfoHash(uri); err == nil {
+		// Bare info hash is added as a magnet link without trackers.
+		// Peers are found on DHT or from the trackers given in options.
+		ma := magnet.Magnet{InfoHash: ih}
+		return s.addMagnet(ma.String(), opt)
+	}
 	u, err := url.Parse(uri)
 	if err != nil {
 		return nil, newInputError(err)
 	}
 	switch u.Scheme {
 	case "http", "https":
-		return s.addURL(uri, opt)
+		return s.addURL(ctx, uri, opt)
 	case "magnet":
 		return s.addMagnet(uri, opt)
 	default:
@@ -144,6 +204,34 @@ func (s *Session) AddURI(uri string, opt *AddTorrentOptions) (*Torrent, error) {
 	}
 }
 
+// appendTrackers adds each uri as a new tier to the tiers unless it already exists.
+// Returns an InputError if any of the uris is not a valid tracker address.
+func (s *Session) appendTrackers(tiers [][]string, uris []string) ([][]string, error) {
+	if len(uris) == 0 {
+		return tiers, nil
+	}
+	existing := make(map[string]struct{})
+	for _, tier := range tiers {
+		for _, uri := range tier {
+			existing[uri] = struct{}{}
+		}
+	}
+	ret := make([][]string, len(tiers), len(tiers)+len(uris))
+	copy(ret, tiers)
+	for _, uri := range uris {
+		if _, ok := existing[uri]; ok {
+			continue
+		}
+		_, err := s.trackerManager.Get(uri, s.config.TrackerHTTPTimeout, s.getTrackerUserAgent(false), int64(s.config.TrackerHTTPMaxResponseSize))
+		if err != nil {
+			return nil, newInputError(err)
+		}
+		existing[uri] = struct{}{}
+		ret = append(ret, []string{uri})
+	}
+	return ret, nil
+}
+
 func filterOutControlChars(s string) string {
 	var sb strings.Builder
 	sb.Grow(len(s))
@@ -157,14 +245,21 @@ func filterOutControlChars(s string) string {
 	return sb.String()
 }
 
-func (s *Session) addURL(u string, opt *AddTorrentOptions) (*Torrent, error) {
+func (s *Session) addURL(ctx context.Context, u string, opt *AddTorrentOptions) (*Torrent, error) {
 	client := http.Client{
 		Timeout: s.config.TorrentAddHTTPTimeout,
 	}
-	resp, err := client.Get(u) // nolint: noctx
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
 	if err != nil {
 		return nil, newInputError(err)
 	}
+	resp, err := client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, newInputError(err)
+	}
 	defer resp.Body.Close()
 
 	if resp.ContentLength > int64(s.config.MaxTorrentSize) {
@@ -179,15 +274,28 @@ func (s *Session) addMagnet(link string, opt *AddTorrentOptions) (*Torrent, erro
 	if err != nil {
 		return nil, newInputError(err)
 	}
-	id, port, sto, err := s.add(opt)
+	if opt.CrossSeed != "" {
+		return nil, newInputError(errCrossSeedMagnet)
+	}
+	ma.Trackers, err = s.appendTrackers(ma.Trackers, opt.Trackers)
+	if err != nil {
+		return nil, err
+	}
+	id, port, err := s.add(opt)
 	if err != nil {
 		return nil, err
 	}
 	defer func() {
 		if err != nil {
 			s.releasePort(port)
+			s.setDataDir(id, "")
 		}
 	}()
+	s.overrideDataDir(id, ma.InfoHash[:])
+	sto, err := s.newStorage(id, nil, nil)
+	if err != nil {
+		return nil, err
+	}
 	t, err := newTorrent2(
 		s,
 		id,
@@ -224,7 +332,12 @@ func (s *Session) addMagnet(link string, opt *AddTorrentOptions) (*Torrent, erro
 		AddedAt:           t.addedAt,
 		StopAfterDownload: opt.StopAfterDownload,
 		StopAfterMetadata: opt.StopAfterMetadata,
+		SeedOnly:          opt.SeedOnly,
+		Priority:          int(opt.Priority),
+		DataDir:           s.getDataDir(id),
 	}
+	t.setPriority(opt.Priority)
+	t.seedOnly = opt.SeedOnly
 	err = s.resumer.Write(id, rspec)
 	if err != nil {
 		return nil, err
@@ -236,7 +349,11 @@ func (s *Session) addMagnet(link string, opt *AddTorrentOptions) (*Torrent, erro
 	return t2, err
 }
 
-func (s *Session) add(opt *AddTorrentOptions) (id string, port int, sto *filestorage.FileStorage, err error) {
+func (s *Session) add(opt *AddTorrentOptions) (id string, port int, err error) {
+	if !opt.Priority.valid() {
+		err = newInputError(fmt.Errorf("invalid priority: %d", int32(opt.Priority)))
+		return
+	}
 	port, err = s.getPort()
 	if err != nil {
 		return
@@ -266,10 +383,6 @@ func (s *Session) add(opt *AddTorrentOptions) (id string, port int, sto *filesto
 		}
 		id = base64.RawURLEncoding.EncodeToString(u1[:])
 	}
-	sto, err = filestorage.New(s.getDataDir(id), s.config.FilePermissions)
-	if err != nil {
-		return
-	}
 	return
 }
 
@@ -281,6 +394,9 @@ func (s *Session) insertTorrent(t *torrent) *Torrent {
 	s.mTorrents.Lock()
 	defer s.mTorrents.Unlock()
 	s.torrents[t.id] = t2
+	if s.pieceIndex != nil && t.info != nil {
+		s.pieceIndex.Add(t, t.info)
+	}
 	ih := dht.InfoHash(t.InfoHash())
 	s.torrentsByInfoHash[ih] = append(s.torrentsByInfoHash[ih], t2)
 	return t2
@@ -1,6 +1,7 @@
 package torrent
 
 import (
+	"os"
 	"strings"
 	"testing"
 
@@ -19,3 +20,29 @@ func TestInvalidTorrentData(t *testing.T) {
 
 	assert.Error(t, err)
 }
+
+func TestAddTorrentInvalidPriority(t *testing.T) {
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+
+	f, err := os.Open(torrentFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	_, err = s.AddTorrent(f, &AddTorrentOptions{Priority: 5})
+	var e *InputError
+	assert.ErrorAs(t, err, &e)
+}
+
+func TestAddInfoHash(t *testing.T) {
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+
+	tor, err := s.AddURI(torrentInfoHashString, &AddTorrentOptions{Stopped: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, torrentInfoHashString, tor.InfoHash().String())
+	assert.Empty(t, tor.torrent.trackers)
+}
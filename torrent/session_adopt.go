@@ -0,0 +1,80 @@
+package torrent
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/cenkalti/rain/metainfo"
+)
+
+// adoptExistingData looks for the files of the torrent in the data directory when they are not in the directory of the torrent.
+// Files may be left from a previous run with lost resume data, in a directory named after another torrent ID,
+// or may be downloaded by another client directly into the data directory.
+// If matching files are found, the directory is used as the data directory of the torrent and returned.
+// Existing files are verified when the torrent is started, so only the missing pieces are downloaded.
+func (s *Session) adoptExistingData(id string, info *metainfo.Info) string {
+	if !s.config.AdoptExistingData || !s.config.DataDirIncludesTorrentID || s.s3 != nil || s.webdav != nil {
+		return ""
+	}
+	dir := s.getDataDir(id)
+	if dir != filepath.Join(s.config.DataDir, id) || filesExist(dir, info) {
+		// Data directory is overridden or files are already in place.
+		return ""
+	}
+	candidates := []string{s.config.DataDir}
+	entries, err := os.ReadDir(s.config.DataDir)
+	if err != nil {
+		return ""
+	}
+	used := s.usedDataDirs()
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		d := filepath.Join(s.config.DataDir, e.Name())
+		if _, ok := used[d]; !ok {
+			candidates = append(candidates, d)
+		}
+	}
+	for _, d := range candidates {
+		if existingFilesMatch(d, info) {
+			s.log.Infof("adopting existing files of torrent %s in %s", id, d)
+			s.setDataDir(id, d)
+			return d
+		}
+	}
+	return ""
+}
+
+// usedDataDirs returns the data directories of the torrents in Session.
+func (s *Session) usedDataDirs() map[string]struct{} {
+	s.mTorrents.RLock()
+	defer s.mTorrents.RUnlock()
+	ret := make(map[string]struct{}, len(s.torrents))
+	for id := range s.torrents {
+		ret[s.getDataDir(id)] = struct{}{}
+	}
+	return ret
+}
+
+// existingFilesMatch returns true if at least one file of the torrent exists in dir with the correct size
+// and no file is larger than its size in the torrent.
+func existingFilesMatch(dir string, info *metainfo.Info) bool {
+	var found bool
+	for _, f := range info.Files {
+		if f.Padding || f.Symlink != "" {
+			continue
+		}
+		fi, err := os.Stat(filepath.Join(dir, f.Path))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil || fi.IsDir() || fi.Size() > f.Length {
+			return false
+		}
+		if fi.Size() == f.Length && f.Length > 0 {
+			found = true
+		}
+	}
+	return found
+}
@@ -0,0 +1,46 @@
+package torrent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cp "github.com/otiai10/copy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdoptExistingData(t *testing.T) {
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+
+	// Files are left in a directory of a torrent that is not in the session anymore.
+	dir := filepath.Join(s.config.DataDir, "old-torrent-id")
+	require.NoError(t, cp.Copy(filepath.Join(torrentDataDir, torrentName), filepath.Join(dir, torrentName)))
+
+	f, err := os.Open(torrentFile)
+	require.NoError(t, err)
+	defer f.Close()
+	tor, err := s.AddTorrent(f, &AddTorrentOptions{Stopped: true})
+	require.NoError(t, err)
+	assert.Equal(t, dir, tor.RootDirectory())
+
+	tor.torrent.trackers = nil
+	require.NoError(t, tor.Start())
+	select {
+	case <-tor.torrent.NotifyComplete():
+	case err = <-tor.torrent.NotifyError():
+		t.Fatal(err)
+	case <-time.After(timeout):
+		t.Fatal("existing files are not verified")
+	}
+	assert.Equal(t, int64(0), tor.Stats().Bytes.Downloaded)
+
+	// Directory of a torrent in the session is not adopted by another torrent.
+	_, err = f.Seek(0, 0)
+	require.NoError(t, err)
+	tor2, err := s.AddTorrent(f, &AddTorrentOptions{Stopped: true})
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(s.config.DataDir, tor2.ID()), tor2.RootDirectory())
+}
@@ -5,9 +5,12 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"time"
 )
 
 func (s *Session) runOnCompleteCmd(torrent *torrent) {
+	defer s.wgOnCompleteCmds.Done()
+
 	command, err := exec.LookPath(s.config.OnCompleteCmd[0])
 	if err != nil {
 		s.log.Errorf("error resolving completion hook command path: %s", err)
@@ -21,10 +24,11 @@ func (s *Session) runOnCompleteCmd(torrent *torrent) {
 
 	cmd.Env = append(os.Environ(),
 		"RAIN_TORRENT_ADDED="+fmt.Sprint(torrent.addedAt.Unix()),
-		"RAIN_TORRENT_DIR="+torrent.storage.RootDir(),
+		"RAIN_TORRENT_DIR="+torrent.RootDirectory(),
 		"RAIN_TORRENT_HASH="+hex.EncodeToString(torrent.infoHash[:]),
 		"RAIN_TORRENT_ID="+torrent.id,
-		"RAIN_TORRENT_NAME="+torrent.name)
+		"RAIN_TORRENT_NAME="+torrent.name,
+		"RAIN_TORRENT_SIZE="+fmt.Sprint(torrent.info.Length))
 
 	s.log.Debugf("executing completion hook for torrent %s: %s", torrent.id, cmd.String())
 
@@ -32,3 +36,16 @@ func (s *Session) runOnCompleteCmd(torrent *torrent) {
 		s.log.Errorf("completion hook execution failed: %s", err)
 	}
 }
+
+func (s *Session) waitOnCompleteCmds() {
+	done := make(chan struct{})
+	go func() {
+		s.wgOnCompleteCmds.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(s.config.OnCompleteCmdCloseTimeout):
+		s.log.Warningln("completion hooks are still running after", s.config.OnCompleteCmdCloseTimeout)
+	}
+}
@@ -0,0 +1,32 @@
+package torrent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cenkalti/rain/internal/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitOnCompleteCmds(t *testing.T) {
+	s := &Session{log: logger.New("session")}
+	s.config.OnCompleteCmdCloseTimeout = time.Minute
+
+	// Waits for the running command.
+	s.wgOnCompleteCmds.Add(1)
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		s.wgOnCompleteCmds.Done()
+	}()
+	start := time.Now()
+	s.waitOnCompleteCmds()
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+
+	// Gives up after the timeout.
+	s.config.OnCompleteCmdCloseTimeout = 100 * time.Millisecond
+	s.wgOnCompleteCmds.Add(1)
+	defer s.wgOnCompleteCmds.Done()
+	start = time.Now()
+	s.waitOnCompleteCmds()
+	assert.Less(t, time.Since(start), time.Minute)
+}
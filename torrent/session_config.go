@@ -0,0 +1,90 @@
+package torrent
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// Settings are the settings of a Session that can be changed while it is running.
+// Initial values are taken from the fields with same names in Config.
+type Settings struct {
+	// Global download speed limit in KB/s.
+	SpeedLimitDownload int64
+	// Global upload speed limit in KB/s.
+	SpeedLimitUpload int64
+	// Max number of outgoing connections to dial for each torrent.
+	MaxPeerDial int
+	// Max number of incoming connections to accept for each torrent.
+	MaxPeerAccept int
+	// Max number of torrents that are downloading at the same time. 0 means unlimited.
+	QueueMaxActiveDownloads int
+	// Max number of torrents that are seeding at the same time. 0 means unlimited.
+	QueueMaxActiveSeeds int
+}
+
+// Settings returns the current settings of the Session.
+func (s *Session) Settings() Settings {
+	s.mSchedule.Lock()
+	download, upload := s.speedLimitDownload, s.speedLimitUpload
+	s.mSchedule.Unlock()
+	return Settings{
+		SpeedLimitDownload:      download,
+		SpeedLimitUpload:        upload,
+		MaxPeerDial:             int(atomic.LoadInt32(&s.maxPeerDial)),
+		MaxPeerAccept:           int(atomic.LoadInt32(&s.maxPeerAccept)),
+		QueueMaxActiveDownloads: int(atomic.LoadInt32(&s.queueMaxActiveDownloads)),
+		QueueMaxActiveSeeds:     int(atomic.LoadInt32(&s.queueMaxActiveSeeds)),
+	}
+}
+
+// SetSettings changes the settings of the running Session. Changes are applied to existing torrents.
+// Speed limits take effect immediately unless a SpeedLimitSchedule is active.
+// Connection limits are checked when the torrents dial or accept new connections, existing connections are not closed.
+// Changes in queue limits start or stop the torrents in the queue as needed.
+func (s *Session) SetSettings(settings Settings) error {
+	if settings.SpeedLimitDownload < 0 || settings.SpeedLimitUpload < 0 {
+		return errors.New("speed limit must not be negative")
+	}
+	if settings.MaxPeerDial < 0 || settings.MaxPeerAccept < 0 {
+		return errors.New("connection limit must not be negative")
+	}
+	if settings.QueueMaxActiveDownloads < 0 || settings.QueueMaxActiveSeeds < 0 {
+		return errors.New("queue limit must not be negative")
+	}
+	s.mSchedule.Lock()
+	s.speedLimitDownload = settings.SpeedLimitDownload
+	s.speedLimitUpload = settings.SpeedLimitUpload
+	s.mSchedule.Unlock()
+	s.applySpeedLimitSchedule()
+	atomic.StoreInt32(&s.maxPeerDial, int32(settings.MaxPeerDial))
+	atomic.StoreInt32(&s.maxPeerAccept, int32(settings.MaxPeerAccept))
+	atomic.StoreInt32(&s.queueMaxActiveDownloads, int32(settings.QueueMaxActiveDownloads))
+	atomic.StoreInt32(&s.queueMaxActiveSeeds, int32(settings.QueueMaxActiveSeeds))
+	s.triggerQueue()
+	return nil
+}
+
+// ReloadConfig applies the changes in cfg to the running Session without restarting torrents.
+// Only the fields in Settings and SpeedLimitSchedules are applied, changes in other fields are ignored.
+func (s *Session) ReloadConfig(cfg Config) error {
+	schedules, err := parseSchedules(cfg.SpeedLimitSchedules)
+	if err != nil {
+		return err
+	}
+	s.mSchedule.Lock()
+	s.schedules = schedules
+	s.mSchedule.Unlock()
+	err = s.SetSettings(Settings{
+		SpeedLimitDownload:      cfg.SpeedLimitDownload,
+		SpeedLimitUpload:        cfg.SpeedLimitUpload,
+		MaxPeerDial:             cfg.MaxPeerDial,
+		MaxPeerAccept:           cfg.MaxPeerAccept,
+		QueueMaxActiveDownloads: cfg.QueueMaxActiveDownloads,
+		QueueMaxActiveSeeds:     cfg.QueueMaxActiveSeeds,
+	})
+	if err != nil {
+		return err
+	}
+	s.log.Infoln("config reloaded")
+	return nil
+}
@@ -0,0 +1,51 @@
+package torrent
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetSettings(t *testing.T) {
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+
+	settings := Settings{
+		SpeedLimitDownload:      100,
+		SpeedLimitUpload:        50,
+		MaxPeerDial:             10,
+		MaxPeerAccept:           5,
+		QueueMaxActiveDownloads: 1,
+	}
+	assert.NoError(t, s.SetSettings(settings))
+	assert.Equal(t, settings, s.Settings())
+	assert.Equal(t, int64(100), s.bucketDownload.Limit())
+	assert.Equal(t, int64(50), s.bucketUpload.Limit())
+
+	settings.MaxPeerDial = -1
+	assert.Error(t, s.SetSettings(settings))
+
+	// Torrents waiting in the queue are started when the queue is disabled.
+	f, err := os.Open(torrentFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	tor, err := s.AddTorrent(f, &AddTorrentOptions{Stopped: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.mQueue.Lock()
+	s.enqueueLocked(tor)
+	s.mQueue.Unlock()
+	assert.NoError(t, s.SetSettings(Settings{}))
+	deadline := time.Now().Add(timeout)
+	for tor.Stats().Status == Queued || tor.Stats().Status == Stopped {
+		if time.Now().After(deadline) {
+			t.Fatalf("torrent is not started, status: %s", tor.Stats().Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,44 @@
+package torrent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitContext(t *testing.T) {
+	addr, cl := seeder(t, true)
+	defer cl()
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := s.AddURIContext(ctx, torrentMagnetLink, nil)
+	assert.Equal(t, context.Canceled, err)
+
+	tor, err := s.AddURI(torrentMagnetLink, &AddTorrentOptions{Stopped: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tor.torrent.trackers = nil
+	assert.NoError(t, tor.Start())
+
+	// There are no peers, so the metadata cannot be downloaded.
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, tor.WaitMetadata(ctx))
+	_, err = tor.StatsContext(ctx)
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	assert.NoError(t, tor.AddPeer(addr))
+	ctx, cancel = context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	assert.NoError(t, tor.WaitMetadata(ctx))
+	assert.NoError(t, tor.WaitComplete(ctx))
+	stats, err := tor.StatsContext(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, stats.Bytes.Total, stats.Bytes.Completed)
+}
@@ -0,0 +1,119 @@
+package torrent
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/cenkalti/rain/internal/bitfield"
+	"github.com/cenkalti/rain/metainfo"
+)
+
+var (
+	errCrossSeedNotFound   = errors.New("torrent to cross-seed with is not found")
+	errCrossSeedNoMetadata = errors.New("torrent to cross-seed with has no metadata")
+	errCrossSeedFiles      = errors.New("files of torrents do not match")
+	errCrossSeedDataDir    = errors.New("files of torrent to cross-seed with are not in data directory")
+	errCrossSeedMagnet     = errors.New("cross-seeding requires a torrent file")
+)
+
+// crossSeed sets the data directory of the new torrent to the directory of the torrent with sourceID,
+// so both torrents use the same files on disk.
+// If the torrents have the same pieces and the source torrent is completed, the bitfield of the source torrent is returned,
+// so the pieces are not verified again. Otherwise nil is returned and the new torrent verifies the existing files when it is started.
+func (s *Session) crossSeed(id, sourceID string, info *metainfo.Info) (*bitfield.Bitfield, error) {
+	s.mTorrents.RLock()
+	_, ok := s.torrents[sourceID]
+	s.mTorrents.RUnlock()
+	if !ok {
+		return nil, errCrossSeedNotFound
+	}
+	spec, err := s.resumer.Read(sourceID)
+	if err != nil {
+		return nil, err
+	}
+	if len(spec.Info) == 0 {
+		return nil, errCrossSeedNoMetadata
+	}
+	srcInfo, err := s.parseInfo(spec.Info, spec.Version)
+	if err != nil {
+		return nil, err
+	}
+	s.renameFiles(srcInfo, spec.RenamedFiles)
+	if !sameFiles(srcInfo, info) {
+		return nil, errCrossSeedFiles
+	}
+	dir := s.getDataDir(sourceID)
+	if !filesExist(dir, srcInfo) {
+		// Source torrent is still in the incomplete directory.
+		return nil, errCrossSeedDataDir
+	}
+	s.setDataDir(id, dir)
+	if len(spec.Bitfield) == 0 || !samePieces(srcInfo, info) {
+		return nil, nil
+	}
+	bf, err := bitfield.NewBytes(append([]byte(nil), spec.Bitfield...), info.NumPieces)
+	if err != nil || !bf.All() {
+		// Saved bitfield of a running torrent may be behind the files on disk.
+		return nil, nil
+	}
+	return bf, nil
+}
+
+// sameFiles returns true if the torrents have the same files at the same paths. Padding files are ignored.
+func sameFiles(a, b *metainfo.Info) bool {
+	if a.Name != b.Name || a.Length-a.PaddingLength() != b.Length-b.PaddingLength() {
+		return false
+	}
+	fa, fb := nonPaddingFiles(a), nonPaddingFiles(b)
+	if len(fa) != len(fb) {
+		return false
+	}
+	for i := range fa {
+		if fa[i].Path != fb[i].Path || fa[i].Length != fb[i].Length {
+			return false
+		}
+	}
+	return true
+}
+
+func nonPaddingFiles(info *metainfo.Info) []metainfo.File {
+	ret := make([]metainfo.File, 0, len(info.Files))
+	for _, f := range info.Files {
+		if !f.Padding {
+			ret = append(ret, f)
+		}
+	}
+	return ret
+}
+
+// samePieces returns true if the torrents have the same piece hashes.
+func samePieces(a, b *metainfo.Info) bool {
+	if a.PieceLength != b.PieceLength || a.NumPieces != b.NumPieces || a.Length != b.Length || a.RootHash != nil || b.RootHash != nil {
+		return false
+	}
+	for i := uint32(0); i < a.NumPieces; i++ {
+		if !bytes.Equal(a.PieceHash(i), b.PieceHash(i)) {
+			return false
+		}
+	}
+	return true
+}
+
+// filesShared returns true if the files of the torrent are used by another torrent in the Session.
+func (s *Session) filesShared(t *torrent) bool {
+	if t.info == nil {
+		return false
+	}
+	dir := s.getDataDir(t.id)
+	s.mTorrents.RLock()
+	defer s.mTorrents.RUnlock()
+	for _, other := range s.torrents {
+		if other.torrent == t || other.torrent.info == nil {
+			continue
+		}
+		if other.torrent.info.Name == t.info.Name && s.getDataDir(other.torrent.id) == dir {
+			return true
+		}
+	}
+	return false
+}
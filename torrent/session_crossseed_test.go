@@ -0,0 +1,45 @@
+package torrent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCrossSeed(t *testing.T) {
+	addr, cl := seeder(t, true)
+	defer cl()
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+
+	f, err := os.Open(torrentFile)
+	require.NoError(t, err)
+	defer f.Close()
+	tor, err := s.AddTorrent(f, &AddTorrentOptions{Stopped: true})
+	require.NoError(t, err)
+	tor.torrent.trackers = nil
+	require.NoError(t, tor.Start())
+	require.NoError(t, tor.AddPeer(addr))
+	assertCompleted(t, tor)
+
+	_, err = f.Seek(0, 0)
+	require.NoError(t, err)
+	tor2, err := s.AddTorrent(f, &AddTorrentOptions{Stopped: true, CrossSeed: tor.ID()})
+	require.NoError(t, err)
+	assert.Equal(t, tor.RootDirectory(), tor2.RootDirectory())
+	assert.True(t, tor2.torrent.seedOnly)
+	assert.True(t, tor2.torrent.bitfield.All())
+
+	require.NoError(t, s.RemoveTorrent(tor2.ID()))
+	_, err = os.Stat(filepath.Join(tor.RootDirectory(), torrentName))
+	assert.NoError(t, err)
+
+	_, err = f.Seek(0, 0)
+	require.NoError(t, err)
+	_, err = s.AddTorrent(f, &AddTorrentOptions{CrossSeed: "missing"})
+	var e *InputError
+	assert.ErrorAs(t, err, &e)
+}
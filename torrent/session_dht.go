@@ -1,37 +1,61 @@
 package torrent
 
 import (
+	"context"
 	"net"
 	"time"
+
+	"github.com/nictuku/dht"
+)
+
+const (
+	// dhtQueryTimeout is the time to wait for a response of a single query in read-only DHT mode.
+	dhtQueryTimeout = 5 * time.Second
+	// dhtLookupTimeout is the max duration of a lookup in read-only DHT mode.
+	dhtLookupTimeout = time.Minute
 )
 
 func (s *Session) processDHTResults() {
 	dhtLimiter := time.NewTicker(time.Second)
 	defer dhtLimiter.Stop()
+	var results, results6 chan map[dht.InfoHash][]string
+	if s.dht != nil {
+		results = s.dht.PeersRequestResults
+	}
+	if s.dht6 != nil {
+		results6 = s.dht6.PeersRequestResults
+	}
 	for {
+		var res map[dht.InfoHash][]string
 		select {
 		case <-dhtLimiter.C:
 			s.handleDHTtick()
-		case res := <-s.dht.PeersRequestResults:
-			for ih, peers := range res {
-				s.mTorrents.RLock()
-				torrents, ok := s.torrentsByInfoHash[ih]
-				s.mTorrents.RUnlock()
-				if !ok {
-					continue
-				}
-				addrs := parseDHTPeers(peers)
-				for _, t := range torrents {
-					select {
-					case t.torrent.dhtPeersC <- addrs:
-					case <-t.torrent.closeC:
-					default:
-					}
-				}
-			}
+		case res = <-results:
+		case res = <-results6:
 		case <-s.closeC:
 			return
 		}
+		for ih, peers := range res {
+			s.handleDHTPeers(ih, parseDHTPeers(peers))
+		}
+	}
+}
+
+// handleDHTPeers sends the peers found on DHT to the torrents with the info hash and to the running lookups.
+func (s *Session) handleDHTPeers(ih dht.InfoHash, addrs []*net.TCPAddr) {
+	s.sendDHTLookupResults(ih, addrs)
+	s.mTorrents.RLock()
+	torrents, ok := s.torrentsByInfoHash[ih]
+	s.mTorrents.RUnlock()
+	if !ok {
+		return
+	}
+	for _, t := range torrents {
+		select {
+		case t.torrent.dhtPeersC <- addrs:
+		case <-t.torrent.closeC:
+		default:
+		}
 	}
 }
 
@@ -39,22 +63,51 @@ func (s *Session) handleDHTtick() {
 	s.mPeerRequests.Lock()
 	defer s.mPeerRequests.Unlock()
 	for t := range s.dhtPeerRequests {
-		s.dht.PeersRequestPort(string(t.infoHash[:]), true, t.port)
+		if s.dhtClient != nil {
+			go s.announceDHTClient(t.infoHash, t.port)
+		} else {
+			s.dht.PeersRequestPort(string(t.infoHash[:]), true, t.port)
+		}
+		if s.dht6 != nil {
+			// Torrents do not accept connections over IPv6, so they are not announced on IPv6 DHT.
+			s.dht6.PeersRequest(string(t.infoHash[:]), false)
+		}
 		delete(s.dhtPeerRequests, t)
 		return
 	}
 }
 
+// announceDHTClient announces the torrent with the read-only DHT client.
+func (s *Session) announceDHTClient(infoHash [20]byte, port int) {
+	ctx, cancel := context.WithTimeout(context.Background(), dhtLookupTimeout)
+	defer cancel()
+	go func() {
+		select {
+		case <-s.closeC:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	addrs, err := s.dhtClient.Announce(ctx, infoHash, port)
+	if err != nil {
+		s.log.Debugln("dht announce error:", err.Error())
+	}
+	if len(addrs) > 0 {
+		s.handleDHTPeers(dht.InfoHash(infoHash[:]), addrs)
+	}
+}
+
 func parseDHTPeers(peers []string) []*net.TCPAddr {
 	addrs := make([]*net.TCPAddr, 0, len(peers))
 	for _, peer := range peers {
-		if len(peer) != 6 {
-			// only IPv4 is supported for now
+		// Compact address is 6 bytes for IPv4 and 18 bytes for IPv6 (BEP 32).
+		if len(peer) != 6 && len(peer) != 18 {
 			continue
 		}
+		n := len(peer) - 2
 		addr := &net.TCPAddr{
-			IP:   net.IP(peer[:4]),
-			Port: int((uint16(peer[4]) << 8) | uint16(peer[5])),
+			IP:   net.IP(peer[:n]),
+			Port: int((uint16(peer[n]) << 8) | uint16(peer[n+1])),
 		}
 		addrs = append(addrs, addr)
 	}
@@ -0,0 +1,25 @@
+package torrent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDHTPeers(t *testing.T) {
+	peers := []string{
+		"\x01\x02\x03\x04\x1a\xe1",
+		"\x20\x01\x0d\xb8\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x01\x1a\xe1",
+		"invalid",
+	}
+	addrs := parseDHTPeers(peers)
+	assert.Len(t, addrs, 2)
+	assert.Equal(t, "1.2.3.4:6881", addrs[0].String())
+	assert.Equal(t, "[2001:db8::1]:6881", addrs[1].String())
+}
+
+func TestDHTNodeFor(t *testing.T) {
+	s := &Session{}
+	assert.Nil(t, s.dhtNodeFor("invalid"))
+	assert.Nil(t, s.dhtNodeFor("[2001:db8::1]:6881"))
+}
@@ -0,0 +1,19 @@
+package torrent
+
+import (
+	"context"
+)
+
+// DHTCrawl walks the DHT by sampling the info hashes stored on the nodes (BEP 51) and calls fn for every info hash
+// seen for the first time. Crawl continues until ctx is done or there are no more nodes to query.
+// fn is not called concurrently.
+//
+// Only the sample_infohashes query is sent. Queries of other nodes are not answered by the Session,
+// because the DHT library does not allow handling custom queries.
+func (s *Session) DHTCrawl(ctx context.Context, fn func(infoHash [20]byte)) error {
+	client, err := s.getDHTItemClient()
+	if err != nil {
+		return err
+	}
+	return client.Crawl(ctx, fn)
+}
@@ -0,0 +1,124 @@
+package torrent
+
+import (
+	"context"
+	"crypto/ed25519"
+
+	"github.com/cenkalti/rain/internal/dhtclient"
+)
+
+// ErrDHTItemNotFound is returned when an item cannot be found on DHT.
+var ErrDHTItemNotFound = dhtclient.ErrNotFound
+
+// DHTMutableItem is an item stored on DHT that can be updated by the owner of the private key (BEP 44).
+type DHTMutableItem struct {
+	PublicKey [32]byte
+	// Optional salt for storing multiple items with the same key.
+	Salt []byte
+	// Sequence number of the item. Must be increased on every update.
+	Seq int64
+	// Bencoded value.
+	Value     []byte
+	Signature [64]byte
+}
+
+// NewDHTMutableItem returns a new item signed with privateKey.
+// Value must be bencoded and less than 1000 bytes.
+func NewDHTMutableItem(privateKey ed25519.PrivateKey, salt []byte, seq int64, value []byte) (*DHTMutableItem, error) {
+	item, err := dhtclient.NewMutableItem(privateKey, salt, seq, value)
+	if err != nil {
+		return nil, err
+	}
+	return &DHTMutableItem{
+		PublicKey: item.Key,
+		Salt:      item.Salt,
+		Seq:       item.Seq,
+		Value:     item.Value,
+		Signature: item.Sig,
+	}, nil
+}
+
+// getDHTItemClient returns the DHT client that is used for storing and retrieving items and crawling.
+// The DHT library used by the DHT node does not support BEP 44 and BEP 51, so a separate read-only client is started
+// on a random port when the first query is made.
+func (s *Session) getDHTItemClient() (*dhtclient.Client, error) {
+	if s.dhtClient != nil {
+		return s.dhtClient, nil
+	}
+	if s.dht == nil {
+		return nil, errDHTDisabled
+	}
+	s.mDHTItemClient.Lock()
+	defer s.mDHTItemClient.Unlock()
+	if s.dhtItemClient != nil {
+		return s.dhtItemClient, nil
+	}
+	client, err := startDHTClient(s.config, 0)
+	if err != nil {
+		return nil, err
+	}
+	if s.dhtNodes != nil {
+		client.AddNodes(s.dhtNodes.List())
+	}
+	s.dhtItemClient = client
+	return client, nil
+}
+
+// DHTGetImmutable returns the bencoded value of the immutable item with the target, which is the SHA-1 hash of the value.
+func (s *Session) DHTGetImmutable(ctx context.Context, target [20]byte) ([]byte, error) {
+	client, err := s.getDHTItemClient()
+	if err != nil {
+		return nil, err
+	}
+	return client.GetImmutable(ctx, target)
+}
+
+// DHTPutImmutable stores the bencoded value on DHT and returns its target.
+// Value must be less than 1000 bytes.
+func (s *Session) DHTPutImmutable(ctx context.Context, value []byte) ([20]byte, error) {
+	client, err := s.getDHTItemClient()
+	if err != nil {
+		return [20]byte{}, err
+	}
+	return client.PutImmutable(ctx, value)
+}
+
+// DHTGetMutable returns the mutable item with the highest sequence number for the public key and salt.
+func (s *Session) DHTGetMutable(ctx context.Context, publicKey [32]byte, salt []byte) (*DHTMutableItem, error) {
+	client, err := s.getDHTItemClient()
+	if err != nil {
+		return nil, err
+	}
+	item, err := client.GetMutable(ctx, publicKey, salt)
+	if err != nil {
+		return nil, err
+	}
+	return &DHTMutableItem{
+		PublicKey: item.Key,
+		Salt:      item.Salt,
+		Seq:       item.Seq,
+		Value:     item.Value,
+		Signature: item.Sig,
+	}, nil
+}
+
+// DHTPutMutable stores the item created with NewDHTMutableItem on DHT.
+// An error is returned if there is an item with a higher sequence number on DHT.
+func (s *Session) DHTPutMutable(ctx context.Context, item *DHTMutableItem) error {
+	client, err := s.getDHTItemClient()
+	if err != nil {
+		return err
+	}
+	return client.PutMutable(ctx, &dhtclient.MutableItem{
+		Key:   item.PublicKey,
+		Salt:  item.Salt,
+		Seq:   item.Seq,
+		Value: item.Value,
+		Sig:   item.Signature,
+	})
+}
+
+// DHTMutableTarget returns the target of the mutable item for the public key and salt.
+func DHTMutableTarget(publicKey [32]byte, salt []byte) [20]byte {
+	return dhtclient.MutableTarget(publicKey, salt)
+}
@@ -0,0 +1,146 @@
+package torrent
+
+import (
+	"encoding/json"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nictuku/dht"
+	"go.etcd.io/bbolt"
+)
+
+var dhtNodesKey = []byte("dht-nodes")
+
+// maxSavedDHTNodes is the max number of DHT node addresses saved to the database.
+const maxSavedDHTNodes = 200
+
+// dhtNodes keeps the addresses of DHT nodes that are seen alive recently.
+// Addresses are saved to the database and added to the routing table on the next start,
+// so the node rejoins the DHT without bootstrapping from DHTBootstrapNodes.
+type dhtNodes struct {
+	m     sync.Mutex
+	addrs map[string]time.Time
+	max   int
+}
+
+func newDHTNodes(max int) *dhtNodes {
+	return &dhtNodes{
+		addrs: make(map[string]time.Time),
+		max:   max,
+	}
+}
+
+// Add the address of a node seen at time now. The oldest address is removed when the limit is exceeded.
+func (n *dhtNodes) Add(addr string, now time.Time) {
+	n.m.Lock()
+	defer n.m.Unlock()
+	n.addrs[addr] = now
+	if len(n.addrs) <= n.max {
+		return
+	}
+	var oldest string
+	for a, t := range n.addrs {
+		if oldest == "" || t.Before(n.addrs[oldest]) {
+			oldest = a
+		}
+	}
+	delete(n.addrs, oldest)
+}
+
+// List returns the addresses, most recently seen first.
+func (n *dhtNodes) List() []string {
+	n.m.Lock()
+	defer n.m.Unlock()
+	ret := make([]string, 0, len(n.addrs))
+	for a := range n.addrs {
+		ret = append(ret, a)
+	}
+	sort.Slice(ret, func(i, j int) bool { return n.addrs[ret[i]].After(n.addrs[ret[j]]) })
+	return ret
+}
+
+// GetPeers is called by the DHT node when a get_peers query is received from another node.
+func (n *dhtNodes) GetPeers(addr net.UDPAddr, queryID string, infoHash dht.InfoHash) {
+	n.Add(addr.String(), time.Now())
+}
+
+// addDHTNode adds the node to the routing table of the DHT node, e.g. when a peer sends its DHT port.
+func (s *Session) addDHTNode(addr string) {
+	node := s.dhtNodeFor(addr)
+	if node == nil {
+		return
+	}
+	node.AddNode(addr)
+	if s.dhtNodes != nil {
+		s.dhtNodes.Add(addr, time.Now())
+	}
+}
+
+// dhtNodeFor returns the DHT node that has the routing table for the address family of addr.
+// Returns nil if there is no such node.
+func (s *Session) dhtNodeFor(addr string) *dht.DHT {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil
+	}
+	ip := net.ParseIP(host)
+	switch {
+	case ip == nil:
+		return nil
+	case ip.To4() != nil:
+		return s.dht
+	default:
+		return s.dht6
+	}
+}
+
+// loadDHTNodes adds the nodes saved in previous session to the routing table.
+func (s *Session) loadDHTNodes() error {
+	var addrs []string
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		val := tx.Bucket(sessionBucket).Get(dhtNodesKey)
+		if val == nil {
+			return nil
+		}
+		return json.Unmarshal(val, &addrs)
+	})
+	if err != nil {
+		return err
+	}
+	s.log.Debugf("adding %d saved nodes to DHT", len(addrs))
+	if s.dhtClient != nil {
+		s.dhtClient.AddNodes(addrs)
+	}
+	now := time.Now()
+	for i, addr := range addrs {
+		if node := s.dhtNodeFor(addr); node != nil {
+			node.AddNode(addr)
+		} else if s.dhtClient == nil {
+			continue
+		}
+		// Keep the order of the saved list.
+		s.dhtNodes.Add(addr, now.Add(-time.Duration(i)*time.Second))
+	}
+	return nil
+}
+
+// saveDHTNodes saves the addresses of recently seen DHT nodes into the session bucket.
+func (s *Session) saveDHTNodes(sb *bbolt.Bucket) {
+	if s.dhtNodes == nil {
+		return
+	}
+	if s.dhtClient != nil {
+		// Read-only client does not receive queries, nodes are collected from the responses.
+		now := time.Now()
+		for _, addr := range s.dhtClient.Nodes() {
+			s.dhtNodes.Add(addr, now)
+		}
+	}
+	val, err := json.Marshal(s.dhtNodes.List())
+	if err != nil {
+		return
+	}
+	_ = sb.Put(dhtNodesKey, val)
+}
@@ -0,0 +1,24 @@
+package torrent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDHTNodes(t *testing.T) {
+	n := newDHTNodes(2)
+	now := time.Now()
+	n.Add("1.1.1.1:6881", now)
+	n.Add("2.2.2.2:6881", now.Add(time.Second))
+	assert.Equal(t, []string{"2.2.2.2:6881", "1.1.1.1:6881"}, n.List())
+
+	// Seen again, moves to top.
+	n.Add("1.1.1.1:6881", now.Add(2*time.Second))
+	assert.Equal(t, []string{"1.1.1.1:6881", "2.2.2.2:6881"}, n.List())
+
+	// Oldest address is removed when limit is exceeded.
+	n.Add("3.3.3.3:6881", now.Add(3*time.Second))
+	assert.Equal(t, []string{"3.3.3.3:6881", "1.1.1.1:6881"}, n.List())
+}
@@ -0,0 +1,189 @@
+package torrent
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"expvar"
+	"net"
+	"strconv"
+
+	"github.com/nictuku/dht"
+)
+
+var errDHTDisabled = errors.New("dht is not enabled")
+
+// DHTStats contains statistics about the DHT node of the Session.
+// Counters are shared by all DHT nodes in the process.
+type DHTStats struct {
+	// ID of the node in hex. Empty until the routing table is saved for the first time, which happens in 5 minutes after start.
+	NodeID string
+	// UDP port that the node is listening.
+	Port int
+	// Number of nodes in the routing table.
+	Nodes int64
+	// Number of nodes in the routing table that have responded to a query.
+	ReachableNodes int64
+	// Number of node addresses that are saved to the database. See Config.DHTSaveNodes.
+	SavedNodes int
+	// DHT is running in read-only mode. See Config.DHTReadOnly.
+	// Only NodeID, Port, Nodes and SavedNodes are set in read-only mode.
+	ReadOnly bool
+
+	PacketsSent     int64
+	PacketsReceived int64
+	PacketsDropped  int64
+	BytesSent       int64
+	BytesReceived   int64
+
+	GetPeersSent         int64
+	GetPeersReceived     int64
+	GetPeersReplies      int64
+	FindNodeSent         int64
+	FindNodeReceived     int64
+	FindNodeReplies      int64
+	PingSent             int64
+	PingReplies          int64
+	PeersFound           int64
+	PacketsFromBlocklist int64
+}
+
+// DHTStats returns statistics about the DHT node.
+func (s *Session) DHTStats() (DHTStats, error) {
+	if s.dhtClient != nil {
+		id := s.dhtClient.ID()
+		st := DHTStats{
+			NodeID:   hex.EncodeToString(id[:]),
+			Port:     s.dhtClient.Port(),
+			Nodes:    int64(len(s.dhtClient.Nodes())),
+			ReadOnly: true,
+		}
+		if s.dhtNodes != nil {
+			st.SavedNodes = len(s.dhtNodes.List())
+		}
+		return st, nil
+	}
+	if s.dht == nil {
+		return DHTStats{}, errDHTDisabled
+	}
+	st := DHTStats{
+		Port:                 s.dht.Port(),
+		Nodes:                expvarInt("totalNodes") - expvarInt("totalKilledNodes"),
+		PacketsSent:          expvarInt("totalSent"),
+		PacketsReceived:      expvarInt("totalRecv"),
+		PacketsDropped:       expvarInt("totalDroppedPackets"),
+		BytesSent:            expvarInt("totalWrittenBytes"),
+		BytesReceived:        expvarInt("totalReadBytes"),
+		GetPeersSent:         expvarInt("totalSentGetPeers"),
+		GetPeersReceived:     expvarInt("totalRecvGetPeers"),
+		GetPeersReplies:      expvarInt("totalRecvGetPeersReply"),
+		FindNodeSent:         expvarInt("totalSentFindNode"),
+		FindNodeReceived:     expvarInt("totalRecvFindNode"),
+		FindNodeReplies:      expvarInt("totalRecvFindNodeReply"),
+		PingSent:             expvarInt("totalSentPing"),
+		PingReplies:          expvarInt("totalRecvPingReply"),
+		PeersFound:           expvarInt("totalPeers"),
+		PacketsFromBlocklist: expvarInt("totalPacketsFromBlockedHosts"),
+	}
+	// DHT library publishes the number of reachable nodes keyed by the node ID.
+	if m, ok := expvar.Get("reachableNodes").(*expvar.Map); ok {
+		var n int
+		m.Do(func(kv expvar.KeyValue) {
+			n++
+			st.NodeID = kv.Key
+			st.ReachableNodes, _ = strconv.ParseInt(kv.Value.String(), 10, 64)
+		})
+		if n != 1 {
+			// Cannot tell which one is ours if there are multiple nodes in the process.
+			st.NodeID = ""
+			st.ReachableNodes = 0
+		}
+	}
+	if s.dhtNodes != nil {
+		st.SavedNodes = len(s.dhtNodes.List())
+	}
+	return st, nil
+}
+
+func expvarInt(name string) int64 {
+	v, ok := expvar.Get(name).(*expvar.Int)
+	if !ok {
+		return 0
+	}
+	return v.Value()
+}
+
+// DHTGetPeers does a get_peers lookup on DHT for the info hash and returns the peer addresses found until ctx is done.
+// The Session is not announced to the DHT for the info hash.
+func (s *Session) DHTGetPeers(ctx context.Context, infoHash [20]byte) ([]*net.TCPAddr, error) {
+	if s.dhtClient != nil {
+		addrs, err := s.dhtClient.GetPeers(ctx, infoHash)
+		if ctx.Err() != nil {
+			// Lookup is interrupted, same as the lookup of the DHT node.
+			return addrs, nil
+		}
+		return addrs, err
+	}
+	if s.dht == nil {
+		return nil, errDHTDisabled
+	}
+	ih := dht.InfoHash(infoHash[:])
+	resultC := make(chan []*net.TCPAddr, 1)
+	s.mDHTLookups.Lock()
+	s.dhtLookups[ih] = append(s.dhtLookups[ih], resultC)
+	s.mDHTLookups.Unlock()
+	defer func() {
+		s.mDHTLookups.Lock()
+		defer s.mDHTLookups.Unlock()
+		lookups := s.dhtLookups[ih]
+		for i, c := range lookups {
+			if c == resultC {
+				lookups = append(lookups[:i], lookups[i+1:]...)
+				break
+			}
+		}
+		if len(lookups) == 0 {
+			delete(s.dhtLookups, ih)
+		} else {
+			s.dhtLookups[ih] = lookups
+		}
+	}()
+
+	s.dht.PeersRequest(string(ih), false)
+	if s.dht6 != nil {
+		s.dht6.PeersRequest(string(ih), false)
+	}
+
+	var ret []*net.TCPAddr
+	seen := make(map[string]struct{})
+	for {
+		select {
+		case addrs := <-resultC:
+			for _, addr := range addrs {
+				key := addr.String()
+				if _, ok := seen[key]; ok {
+					continue
+				}
+				seen[key] = struct{}{}
+				ret = append(ret, addr)
+			}
+		case <-ctx.Done():
+			return ret, nil
+		case <-s.closeC:
+			return ret, nil
+		}
+	}
+}
+
+// sendDHTLookupResults sends the peers found on DHT to the running lookups for the info hash.
+func (s *Session) sendDHTLookupResults(ih dht.InfoHash, addrs []*net.TCPAddr) {
+	s.mDHTLookups.Lock()
+	defer s.mDHTLookups.Unlock()
+	for _, c := range s.dhtLookups[ih] {
+		select {
+		case c <- addrs:
+		default:
+			// Lookup is busy, results will be repeated by later responses.
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package torrent
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDHTDisabled(t *testing.T) {
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+
+	_, err := s.DHTStats()
+	assert.Equal(t, errDHTDisabled, err)
+	_, err = s.DHTGetPeers(context.Background(), [20]byte{})
+	assert.Equal(t, errDHTDisabled, err)
+	_, err = s.DHTGetImmutable(context.Background(), [20]byte{})
+	assert.Equal(t, errDHTDisabled, err)
+	err = s.DHTCrawl(context.Background(), func([20]byte) {})
+	assert.Equal(t, errDHTDisabled, err)
+}
+
+func TestDHTReadOnly(t *testing.T) {
+	tmp, closeTmp := tempdir(t)
+	defer closeTmp()
+	cfg := DefaultConfig
+	cfg.Database = filepath.Join(tmp, "session.db")
+	cfg.DataDir = tmp
+	cfg.RPCEnabled = false
+	cfg.DHTReadOnly = true
+	cfg.DHTHost = "127.0.0.1"
+	cfg.DHTPort = 0
+	cfg.DHTBootstrapNodes = nil
+	s, err := NewSession(cfg)
+	require.NoError(t, err)
+	defer s.Close()
+
+	assert.Nil(t, s.dht)
+	assert.Nil(t, s.dht6)
+	st, err := s.DHTStats()
+	require.NoError(t, err)
+	assert.True(t, st.ReadOnly)
+	assert.NotZero(t, st.Port)
+	assert.Len(t, st.NodeID, 40)
+}
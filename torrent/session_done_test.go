@@ -0,0 +1,53 @@
+package torrent
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDone(t *testing.T) {
+	s, closeSession := newTestSession(t)
+	closed := false
+	defer func() {
+		if !closed {
+			closeSession()
+		}
+	}()
+
+	tor1, err := s.AddURI(torrentMagnetLink, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tor2, err := s.AddURI(torrentMagnetLink, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-tor1.Done():
+		t.Fatal("torrent is done before it is removed")
+	case <-s.Done():
+		t.Fatal("session is done before it is closed")
+	default:
+	}
+
+	err = s.RemoveTorrent(tor1.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-tor1.Done():
+	case <-time.After(timeout):
+		t.Fatal("torrent is not done after removal")
+	}
+
+	closeSession()
+	closed = true
+	for _, ch := range []<-chan struct{}{tor2.Done(), s.Done()} {
+		select {
+		case <-ch:
+		default:
+			t.Fatal("channel is not closed after session is closed")
+		}
+	}
+}
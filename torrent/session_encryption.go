@@ -0,0 +1,54 @@
+package torrent
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+
+	"github.com/cenkalti/rain/internal/storage"
+	"github.com/cenkalti/rain/internal/storage/encryptedstorage"
+	"github.com/cenkalti/rain/metainfo"
+	"go.etcd.io/bbolt"
+)
+
+var errWrongEncryptionPassphrase = errors.New("storage encryption passphrase does not match the one used before")
+
+// loadEncryptionKey derives the storage encryption key from passphrase.
+// Salt is generated on first use and saved in the session bucket with a check value,
+// so files encrypted with a different passphrase are not overwritten.
+func loadEncryptionKey(db *bbolt.DB, passphrase string) (key []byte, err error) {
+	err = db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(sessionBucket)
+		salt := b.Get(encryptionSaltKey)
+		check := b.Get(encryptionCheckKey)
+		if salt == nil {
+			salt = make([]byte, 16)
+			_, err2 := rand.Read(salt)
+			if err2 != nil {
+				return err2
+			}
+			err2 = b.Put(encryptionSaltKey, salt)
+			if err2 != nil {
+				return err2
+			}
+		}
+		key = encryptedstorage.NewKey(passphrase, salt)
+		if check == nil {
+			return b.Put(encryptionCheckKey, encryptedstorage.CheckValue(key))
+		}
+		if !bytes.Equal(check, encryptedstorage.CheckValue(key)) {
+			return errWrongEncryptionPassphrase
+		}
+		return nil
+	})
+	return
+}
+
+// encryptStorage wraps sto for encrypting the files of the torrent if encryption is enabled.
+// Storages of magnet torrents without info are not wrapped because they are replaced after metadata is downloaded.
+func (s *Session) encryptStorage(sto storage.Storage, info *metainfo.Info) storage.Storage {
+	if s.encryptionKey == nil || info == nil {
+		return sto
+	}
+	return encryptedstorage.New(sto, encryptedstorage.TorrentKey(s.encryptionKey, info.Hash[:]))
+}
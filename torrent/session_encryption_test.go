@@ -0,0 +1,36 @@
+package torrent
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStorageEncryptionPassphraseCheck(t *testing.T) {
+	tmp, closeTmp := tempdir(t)
+	defer closeTmp()
+	cfg := DefaultConfig
+	cfg.Database = filepath.Join(tmp, "session.db")
+	cfg.DataDir = tmp
+	cfg.DHTEnabled = false
+	cfg.RPCEnabled = false
+	cfg.StorageEncryptionPassphrase = "secret"
+
+	s, err := NewSession(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, s.encryptionKey, 32)
+	assert.NoError(t, s.Close())
+
+	s, err = NewSession(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, s.Close())
+
+	cfg.StorageEncryptionPassphrase = "wrong"
+	_, err = NewSession(cfg)
+	assert.Equal(t, errWrongEncryptionPassphrase, err)
+}
@@ -0,0 +1,115 @@
+package torrent
+
+import (
+	"errors"
+	"math"
+	"net"
+
+	"github.com/cenkalti/rain/internal/peer"
+	"github.com/cenkalti/rain/internal/peerprotocol"
+)
+
+// ExtensionHandler handles the messages of an extension registered with Session.RegisterExtension.
+// Methods are called from the run loop of the torrent that the peer is connected for, so they must not block.
+type ExtensionHandler interface {
+	// HandleHandshake is called when the peer announces the support of the extension in its extension handshake.
+	HandleHandshake(pe ExtensionPeer)
+	// HandleMessage is called when a message of the extension is received from the peer.
+	// Payload contains the bytes after the extended message ID.
+	HandleMessage(pe ExtensionPeer, payload []byte)
+}
+
+// ExtensionPeer is a remote peer that is passed to an ExtensionHandler.
+type ExtensionPeer struct {
+	pe       *peer.Peer
+	name     string
+	infoHash [20]byte
+}
+
+// ID of the peer.
+func (p ExtensionPeer) ID() [20]byte {
+	return p.pe.ID
+}
+
+// Addr returns the address of the peer.
+func (p ExtensionPeer) Addr() net.Addr {
+	return p.pe.Addr()
+}
+
+// InfoHash returns the info hash of the torrent that the peer is connected for.
+func (p ExtensionPeer) InfoHash() [20]byte {
+	return p.infoHash
+}
+
+// SendMessage sends a message of the extension to the peer. Payload is sent without any encoding after the extended message ID.
+// Message is not sent if the peer has not announced the support of the extension.
+// It is safe to call SendMessage from multiple goroutines.
+func (p ExtensionPeer) SendMessage(payload []byte) {
+	if p.pe.ExtensionHandshake == nil {
+		return
+	}
+	id := p.pe.ExtensionHandshake.M[p.name]
+	if id == 0 {
+		return
+	}
+	p.pe.SendMessage(peerprotocol.ExtensionMessage{
+		ExtendedMessageID: id,
+		Payload:           peerprotocol.ExtensionRawMessage{ExtendedMessageID: id, Data: payload},
+	})
+}
+
+type customExtension struct {
+	name    string
+	id      uint8
+	handler ExtensionHandler
+}
+
+var (
+	errExtensionName       = errors.New("invalid extension name")
+	errExtensionRegistered = errors.New("extension is already registered")
+	errTooManyExtensions   = errors.New("too many extensions")
+)
+
+// RegisterExtension registers a handler for an extension of the BitTorrent extension protocol (BEP 10).
+// The extension is announced with name in extension handshakes sent to peers after the registration,
+// so extensions should be registered before torrents are started.
+// Names of the extensions implemented by the library (ut_metadata, ut_pex, lt_donthave, lt_tex) cannot be registered.
+func (s *Session) RegisterExtension(name string, h ExtensionHandler) error {
+	switch name {
+	case "", peerprotocol.ExtensionKeyMetadata, peerprotocol.ExtensionKeyPEX, peerprotocol.ExtensionKeyDontHave, peerprotocol.ExtensionKeyTEX:
+		return errExtensionName
+	}
+	s.mExtensions.Lock()
+	defer s.mExtensions.Unlock()
+	for _, ext := range s.customExtensions {
+		if ext.name == name {
+			return errExtensionRegistered
+		}
+	}
+	id := peerprotocol.ExtensionIDCustomFirst + len(s.customExtensions)
+	if id > math.MaxUint8 {
+		return errTooManyExtensions
+	}
+	s.customExtensions = append(s.customExtensions, customExtension{name: name, id: uint8(id), handler: h})
+	return nil
+}
+
+// getCustomExtensions returns a copy of the registered extensions.
+func (s *Session) getCustomExtensions() []customExtension {
+	s.mExtensions.RLock()
+	defer s.mExtensions.RUnlock()
+	exts := make([]customExtension, len(s.customExtensions))
+	copy(exts, s.customExtensions)
+	return exts
+}
+
+// getCustomExtension returns the extension registered with the local message ID.
+func (s *Session) getCustomExtension(id uint8) (customExtension, bool) {
+	s.mExtensions.RLock()
+	defer s.mExtensions.RUnlock()
+	i := int(id) - peerprotocol.ExtensionIDCustomFirst
+	if i < 0 || i >= len(s.customExtensions) {
+		return customExtension{}, false
+	}
+	return s.customExtensions[i], true
+}
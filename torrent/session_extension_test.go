@@ -0,0 +1,49 @@
+package torrent
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/cenkalti/rain/internal/peerprotocol"
+	"github.com/stretchr/testify/assert"
+)
+
+type testExtensionHandler struct{}
+
+func (testExtensionHandler) HandleHandshake(pe ExtensionPeer)               {}
+func (testExtensionHandler) HandleMessage(pe ExtensionPeer, payload []byte) {}
+
+func TestRegisterExtension(t *testing.T) {
+	s := &Session{}
+	assert.NoError(t, s.RegisterExtension("foo", testExtensionHandler{}))
+	assert.NoError(t, s.RegisterExtension("bar", testExtensionHandler{}))
+	assert.Equal(t, errExtensionRegistered, s.RegisterExtension("foo", testExtensionHandler{}))
+	assert.Equal(t, errExtensionName, s.RegisterExtension(peerprotocol.ExtensionKeyPEX, testExtensionHandler{}))
+	assert.Equal(t, errExtensionName, s.RegisterExtension("", testExtensionHandler{}))
+
+	ext, ok := s.getCustomExtension(peerprotocol.ExtensionIDCustomFirst + 1)
+	assert.True(t, ok)
+	assert.Equal(t, "bar", ext.name)
+	_, ok = s.getCustomExtension(peerprotocol.ExtensionIDPEX)
+	assert.False(t, ok)
+
+	tor := &torrent{session: s}
+	msg := peerprotocol.NewExtensionHandshake(0, "", nil, 0)
+	tor.addCustomExtensions(&msg)
+	assert.Equal(t, uint8(peerprotocol.ExtensionIDCustomFirst), msg.M["foo"])
+	assert.Equal(t, uint8(peerprotocol.ExtensionIDCustomFirst+1), msg.M["bar"])
+}
+
+func TestExtensionRawMessage(t *testing.T) {
+	msg := peerprotocol.ExtensionMessage{
+		ExtendedMessageID: 200,
+		Payload:           peerprotocol.ExtensionRawMessage{ExtendedMessageID: 200, Data: []byte("hello")},
+	}
+	var buf bytes.Buffer
+	_, err := msg.WriteTo(&buf)
+	assert.NoError(t, err)
+
+	var msg2 peerprotocol.ExtensionMessage
+	assert.NoError(t, msg2.UnmarshalBinary(buf.Bytes()))
+	assert.Equal(t, msg.Payload, msg2.Payload)
+}
@@ -0,0 +1,38 @@
+package torrent
+
+import (
+	"github.com/cenkalti/rain/internal/winpath"
+	"github.com/cenkalti/rain/metainfo"
+)
+
+// renameFiles changes the paths of files in info to the paths on disk.
+// If renamed is nil, new paths are generated according to the config and returned for saving into the resume db.
+// Once the paths are saved, they are used even if the config changes, so existing files are found at the same location.
+func (s *Session) renameFiles(info *metainfo.Info, renamed map[string]string) map[string]string {
+	if renamed == nil {
+		if !s.config.WindowsSafeFilenames {
+			return nil
+		}
+		renamed = make(map[string]string)
+		for _, f := range info.Files {
+			for _, p := range []string{f.Path, f.Symlink} {
+				if p == "" {
+					continue
+				}
+				if sp := winpath.Sanitize(p); sp != p {
+					renamed[p] = sp
+				}
+			}
+		}
+	}
+	for i := range info.Files {
+		f := &info.Files[i]
+		if p, ok := renamed[f.Path]; ok {
+			f.Path = p
+		}
+		if p, ok := renamed[f.Symlink]; ok {
+			f.Symlink = p
+		}
+	}
+	return renamed
+}
@@ -0,0 +1,39 @@
+package torrent
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/cenkalti/rain/metainfo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenameFiles(t *testing.T) {
+	newInfo := func() *metainfo.Info {
+		return &metainfo.Info{Files: []metainfo.File{
+			{Path: filepath.Join("foo", "a:b")},
+			{Path: filepath.Join("foo", "c")},
+			{Path: filepath.Join("foo", "link"), Symlink: filepath.Join("foo", "a:b")},
+		}}
+	}
+	s := &Session{config: DefaultConfig}
+
+	s.config.WindowsSafeFilenames = false
+	info := newInfo()
+	assert.Nil(t, s.renameFiles(info, nil))
+	assert.Equal(t, newInfo(), info)
+
+	s.config.WindowsSafeFilenames = true
+	info = newInfo()
+	renamed := s.renameFiles(info, nil)
+	assert.Equal(t, map[string]string{filepath.Join("foo", "a:b"): filepath.Join("foo", "a：b")}, renamed)
+	assert.Equal(t, filepath.Join("foo", "a：b"), info.Files[0].Path)
+	assert.Equal(t, filepath.Join("foo", "c"), info.Files[1].Path)
+	assert.Equal(t, filepath.Join("foo", "a：b"), info.Files[2].Symlink)
+
+	// Saved paths are used even if the config is changed.
+	s.config.WindowsSafeFilenames = false
+	info = newInfo()
+	s.renameFiles(info, renamed)
+	assert.Equal(t, filepath.Join("foo", "a：b"), info.Files[0].Path)
+}
@@ -5,11 +5,10 @@ import (
 	"fmt"
 
 	"github.com/cenkalti/rain/internal/bitfield"
-	"github.com/cenkalti/rain/internal/metainfo"
 	"github.com/cenkalti/rain/internal/resumer"
 	"github.com/cenkalti/rain/internal/resumer/boltdbresumer"
-	"github.com/cenkalti/rain/internal/storage/filestorage"
 	"github.com/cenkalti/rain/internal/webseedsource"
+	"github.com/cenkalti/rain/metainfo"
 	"go.etcd.io/bbolt"
 )
 
@@ -34,7 +33,7 @@ func (s *Session) loadExistingTorrents(ids []string) {
 	s.log.Infof("loaded %d existing torrents", loaded)
 	if s.config.ResumeOnStartup {
 		for _, t := range started {
-			t.torrent.Start()
+			s.startOrQueue(t)
 		}
 	}
 }
@@ -52,7 +51,7 @@ func (s *Session) parseInfo(b []byte, version int) (*metainfo.Info, error) {
 	default:
 		return nil, fmt.Errorf("unknown resume data version: %d", version)
 	}
-	i, err := metainfo.NewInfo(b, useUTF8Keys, hidePaddings)
+	i, err := metainfo.NewInfo(b, useUTF8Keys, hidePaddings, s.charset)
 	if err != nil {
 		return nil, err
 	}
@@ -68,6 +67,7 @@ func (s *Session) loadExistingTorrent(id string) (tt *Torrent, hasStarted bool,
 		return
 	}
 	hasStarted = spec.Started
+	s.setDataDir(id, spec.DataDir)
 	var info *metainfo.Info
 	var bf *bitfield.Bitfield
 	var private bool
@@ -78,6 +78,13 @@ func (s *Session) loadExistingTorrent(id string) (tt *Torrent, hasStarted bool,
 		}
 		info = info2
 		private = info.Private
+		renamed := s.renameFiles(info, spec.RenamedFiles)
+		if spec.RenamedFiles == nil && renamed != nil {
+			err = s.resumer.WriteRenamedFiles(id, renamed)
+			if err != nil {
+				return nil, spec.Started, err
+			}
+		}
 		if len(spec.Bitfield) > 0 {
 			bf3, err3 := bitfield.NewBytes(spec.Bitfield, info.NumPieces)
 			if err3 != nil {
@@ -86,7 +93,7 @@ func (s *Session) loadExistingTorrent(id string) (tt *Torrent, hasStarted bool,
 			bf = bf3
 		}
 	}
-	sto, err := filestorage.New(s.getDataDir(id), s.config.FilePermissions)
+	sto, err := s.newStorage(id, info, bf)
 	if err != nil {
 		return
 	}
@@ -116,6 +123,10 @@ func (s *Session) loadExistingTorrent(id string) (tt *Torrent, hasStarted bool,
 	if err != nil {
 		return
 	}
+	t.setPriority(Priority(spec.Priority))
+	t.seedOnly = spec.SeedOnly
+	// Paused torrents stay paused when they are started again. Stop clears the pause.
+	t.paused = spec.Paused && spec.Started
 	t.rawTrackers = spec.Trackers
 	t.rawWebseedSources = spec.URLList
 	go s.checkTorrent(t)
@@ -176,6 +187,9 @@ func (s *Session) CompactDatabase(output string) error {
 			AddedAt:           t.torrent.addedAt,
 			StopAfterDownload: t.torrent.stopAfterDownload,
 			StopAfterMetadata: t.torrent.stopAfterMetadata,
+			SeedOnly:          t.torrent.seedOnly,
+			DataDir:           s.getDataDir(t.torrent.id),
+			Priority:          int(t.torrent.getPriority()),
 		}
 		err = res.Write(t.torrent.id, spec)
 		if err != nil {
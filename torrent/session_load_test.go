@@ -0,0 +1,58 @@
+package torrent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRestoreSession(t *testing.T) {
+	tmp, closeTmp := tempdir(t)
+	defer closeTmp()
+	cfg := DefaultConfig
+	cfg.Database = filepath.Join(tmp, "session.db")
+	cfg.DataDir = filepath.Join(tmp, "data")
+	cfg.DHTEnabled = false
+	cfg.RPCEnabled = false
+
+	s, err := NewSession(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(torrentFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	tor, err := s.AddTorrent(f, &AddTorrentOptions{StopAfterDownload: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, tor.Pause())
+	id := tor.ID()
+	assert.NoError(t, s.Close())
+
+	// Torrents added before are still saved in the old data directory.
+	cfg.DataDir = filepath.Join(tmp, "data2")
+	s, err = NewSession(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	tor = s.GetTorrent(id)
+	if tor == nil {
+		t.Fatal("torrent is not restored")
+	}
+	assert.True(t, strings.HasPrefix(tor.RootDirectory(), filepath.Join(tmp, "data")+string(filepath.Separator)))
+	deadline := time.Now().Add(timeout)
+	for tor.Stats().Status != Paused {
+		if time.Now().After(deadline) {
+			t.Fatalf("torrent is not paused, status: %s", tor.Stats().Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
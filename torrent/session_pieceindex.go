@@ -0,0 +1,89 @@
+package torrent
+
+import (
+	"sync"
+
+	"github.com/cenkalti/rain/metainfo"
+)
+
+// pieceIndex maps the piece hashes to the torrents that have a piece with the same hash.
+// It is used for copying pieces from other torrents in Session instead of downloading them. See Config.PieceDedup.
+type pieceIndex struct {
+	m         sync.RWMutex
+	pieces    map[[20]byte]map[*torrent]uint32
+	byTorrent map[*torrent]*metainfo.Info
+}
+
+type pieceRef struct {
+	torrent *torrent
+	index   uint32
+}
+
+func newPieceIndex() *pieceIndex {
+	return &pieceIndex{
+		pieces:    make(map[[20]byte]map[*torrent]uint32),
+		byTorrent: make(map[*torrent]*metainfo.Info),
+	}
+}
+
+// Add the pieces of the torrent to the index. Merkle torrents are not indexed because piece hashes are not known.
+func (x *pieceIndex) Add(t *torrent, info *metainfo.Info) {
+	if info.RootHash != nil {
+		return
+	}
+	x.m.Lock()
+	defer x.m.Unlock()
+	if _, ok := x.byTorrent[t]; ok {
+		return
+	}
+	x.byTorrent[t] = info
+	for i := uint32(0); i < info.NumPieces; i++ {
+		var h [20]byte
+		copy(h[:], info.PieceHash(i))
+		refs, ok := x.pieces[h]
+		if !ok {
+			refs = make(map[*torrent]uint32, 1)
+			x.pieces[h] = refs
+		}
+		// Torrent may contain identical pieces. Any of them can be the source, keep the first one.
+		if _, ok := refs[t]; !ok {
+			refs[t] = i
+		}
+	}
+}
+
+// Remove the pieces of the torrent from the index.
+func (x *pieceIndex) Remove(t *torrent) {
+	x.m.Lock()
+	defer x.m.Unlock()
+	info, ok := x.byTorrent[t]
+	if !ok {
+		return
+	}
+	delete(x.byTorrent, t)
+	for i := uint32(0); i < info.NumPieces; i++ {
+		var h [20]byte
+		copy(h[:], info.PieceHash(i))
+		refs := x.pieces[h]
+		delete(refs, t)
+		if len(refs) == 0 {
+			delete(x.pieces, h)
+		}
+	}
+}
+
+// Find returns the pieces in other torrents that have the hash.
+func (x *pieceIndex) Find(hash []byte, exclude *torrent) []pieceRef {
+	var h [20]byte
+	copy(h[:], hash)
+	x.m.RLock()
+	defer x.m.RUnlock()
+	refs := x.pieces[h]
+	ret := make([]pieceRef, 0, len(refs))
+	for t, i := range refs {
+		if t != exclude {
+			ret = append(ret, pieceRef{torrent: t, index: i})
+		}
+	}
+	return ret
+}
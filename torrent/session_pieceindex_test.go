@@ -0,0 +1,60 @@
+package torrent
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPieceIndex(t *testing.T) {
+	f, err := os.Open(torrentFile)
+	require.NoError(t, err)
+	defer f.Close()
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+	mi, err := s.parseMetaInfo(f)
+	require.NoError(t, err)
+
+	t1, t2 := &torrent{}, &torrent{}
+	x := newPieceIndex()
+	x.Add(t1, &mi.Info)
+	x.Add(t2, &mi.Info)
+	refs := x.Find(mi.Info.PieceHash(1), t1)
+	require.Len(t, refs, 1)
+	assert.Equal(t, t2, refs[0].torrent)
+	assert.Equal(t, uint32(1), refs[0].index)
+
+	x.Remove(t2)
+	assert.Empty(t, x.Find(mi.Info.PieceHash(1), t1))
+	x.Remove(t1)
+	assert.Empty(t, x.pieces)
+}
+
+func TestPieceDedup(t *testing.T) {
+	addr, cl := seeder(t, true)
+	defer cl()
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+	s.pieceIndex = newPieceIndex()
+
+	f, err := os.Open(torrentFile)
+	require.NoError(t, err)
+	defer f.Close()
+	tor, err := s.AddTorrent(f, &AddTorrentOptions{Stopped: true})
+	require.NoError(t, err)
+	tor.torrent.trackers = nil
+	require.NoError(t, tor.Start())
+	require.NoError(t, tor.AddPeer(addr))
+	assertCompleted(t, tor)
+
+	// Same torrent with another ID has no peers, so all pieces are copied from the first one.
+	_, err = f.Seek(0, 0)
+	require.NoError(t, err)
+	tor2, err := s.AddTorrent(f, &AddTorrentOptions{Stopped: true})
+	require.NoError(t, err)
+	tor2.torrent.trackers = nil
+	require.NoError(t, tor2.Start())
+	assertCompleted(t, tor2)
+}
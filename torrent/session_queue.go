@@ -0,0 +1,214 @@
+package torrent
+
+import (
+	"sort"
+	"sync/atomic"
+)
+
+func (s *Session) queueEnabled() bool {
+	return atomic.LoadInt32(&s.queueMaxActiveDownloads) > 0 || atomic.LoadInt32(&s.queueMaxActiveSeeds) > 0
+}
+
+// startOrQueue starts the torrent immediately if the queue is not enabled.
+// Otherwise, the torrent is added to the end of the queue and it is started when there is a free slot.
+func (s *Session) startOrQueue(t *Torrent) {
+	if !s.queueEnabled() {
+		t.torrent.Start()
+		return
+	}
+	s.mQueue.Lock()
+	s.enqueueLocked(t)
+	s.mQueue.Unlock()
+	s.triggerQueue()
+}
+
+func (s *Session) enqueueLocked(t *Torrent) {
+	if s.isQueuedLocked(t) {
+		return
+	}
+	s.queue = append(s.queue, t)
+	atomic.StoreInt32(&t.torrent.queued, 1)
+}
+
+// dequeue removes the torrent from the queue if it is waiting in there.
+func (s *Session) dequeue(t *Torrent) bool {
+	s.mQueue.Lock()
+	defer s.mQueue.Unlock()
+	return s.dequeueLocked(t)
+}
+
+func (s *Session) dequeueLocked(t *Torrent) bool {
+	for i, qt := range s.queue {
+		if qt == t {
+			s.queue = append(s.queue[:i], s.queue[i+1:]...)
+			atomic.StoreInt32(&t.torrent.queued, 0)
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Session) isQueuedLocked(t *Torrent) bool {
+	for _, qt := range s.queue {
+		if qt == t {
+			return true
+		}
+	}
+	return false
+}
+
+// isQueued returns true if the torrent is waiting in the Session queue.
+// It is safe to call from torrent loop.
+func (t *torrent) isQueued() bool {
+	return atomic.LoadInt32(&t.queued) == 1
+}
+
+// triggerQueue makes the queue to be checked for torrents that can be started.
+// It does not block, hence it is safe to call from torrent loop.
+func (s *Session) triggerQueue() {
+	select {
+	case s.queueC <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Session) processQueue() {
+	for {
+		select {
+		case <-s.queueC:
+			s.checkQueue()
+		case <-s.closeC:
+			return
+		}
+	}
+}
+
+type queueStats struct {
+	torrent *Torrent
+	stats   Stats
+}
+
+func (q queueStats) complete() bool {
+	return q.stats.Bytes.Total > 0 && q.stats.Bytes.Incomplete == 0
+}
+
+// checkQueue starts the queued torrents if there are free slots and moves excess seeds back into the queue.
+// Torrents are not contacted while holding the queue lock because their loops may be waiting for the lock.
+func (s *Session) checkQueue() {
+	if !s.queueEnabled() {
+		s.startQueued()
+		return
+	}
+
+	s.mQueue.Lock()
+	queued := make([]*Torrent, len(s.queue))
+	copy(queued, s.queue)
+	s.mQueue.Unlock()
+
+	isQueued := make(map[*Torrent]struct{}, len(queued))
+	for _, t := range queued {
+		isQueued[t] = struct{}{}
+	}
+	var active, waiting []queueStats
+	for _, t := range s.ListTorrents() {
+		if _, ok := isQueued[t]; ok {
+			continue
+		}
+		active = append(active, queueStats{torrent: t, stats: t.torrent.Stats()})
+	}
+	for _, t := range queued {
+		waiting = append(waiting, queueStats{torrent: t, stats: t.torrent.Stats()})
+	}
+
+	maxDownloads := int(atomic.LoadInt32(&s.queueMaxActiveDownloads))
+	maxSeeds := int(atomic.LoadInt32(&s.queueMaxActiveSeeds))
+	evict, start := planQueue(active, waiting, maxDownloads, maxSeeds)
+
+	s.mQueue.Lock()
+	for _, t := range evict {
+		s.enqueueLocked(t)
+	}
+	// Torrent may be removed from the queue by Stop() or RemoveTorrent() while the lock is released.
+	started := start[:0]
+	for _, t := range start {
+		if s.dequeueLocked(t) {
+			started = append(started, t)
+		}
+	}
+	s.mQueue.Unlock()
+
+	for _, t := range evict {
+		t.torrent.log.Info("seed limit is reached, moving torrent into queue")
+		t.torrent.Stop()
+	}
+	for _, t := range started {
+		t.torrent.log.Info("starting torrent from queue")
+		t.torrent.Start()
+	}
+}
+
+// startQueued starts all torrents in the queue. Called when the queue is disabled while torrents are waiting in it.
+func (s *Session) startQueued() {
+	s.mQueue.Lock()
+	queued := s.queue
+	s.queue = nil
+	for _, t := range queued {
+		atomic.StoreInt32(&t.torrent.queued, 0)
+	}
+	s.mQueue.Unlock()
+
+	for _, t := range queued {
+		t.torrent.log.Info("queue is disabled, starting torrent")
+		t.torrent.Start()
+	}
+}
+
+// planQueue decides which active torrents must be moved into the queue and which queued torrents must be started.
+// Zero or negative limit means unlimited.
+func planQueue(active, queued []queueStats, maxDownloads, maxSeeds int) (evict, start []*Torrent) {
+	var downloading int
+	var seeding []queueStats
+	for _, qs := range active {
+		switch qs.stats.Status {
+		case Stopped, Stopping:
+		case Seeding:
+			seeding = append(seeding, qs)
+		case Paused:
+			if qs.complete() {
+				seeding = append(seeding, qs)
+			} else {
+				downloading++
+			}
+		default:
+			downloading++
+		}
+	}
+
+	// Torrents that have completed downloading may exceed the seed limit.
+	// Put the ones that have been seeding for longest time back into the queue.
+	if maxSeeds > 0 && len(seeding) > maxSeeds {
+		sort.SliceStable(seeding, func(i, j int) bool { return seeding[i].stats.SeededFor > seeding[j].stats.SeededFor })
+		for _, qs := range seeding[:len(seeding)-maxSeeds] {
+			evict = append(evict, qs.torrent)
+		}
+		seeding = seeding[len(seeding)-maxSeeds:]
+	}
+	numSeeding := len(seeding)
+
+	// Start torrents with higher priority first. Torrents with same priority are started in the order they are queued.
+	sorted := make([]queueStats, len(queued))
+	copy(sorted, queued)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].stats.Priority > sorted[j].stats.Priority })
+	for _, qs := range sorted {
+		switch {
+		case qs.complete() && (maxSeeds <= 0 || numSeeding < maxSeeds):
+			numSeeding++
+		case !qs.complete() && (maxDownloads <= 0 || downloading < maxDownloads):
+			downloading++
+		default:
+			continue
+		}
+		start = append(start, qs.torrent)
+	}
+	return evict, start
+}
@@ -0,0 +1,65 @@
+package torrent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newQueueStats(status Status, complete bool, seededFor time.Duration, priority Priority) queueStats {
+	qs := queueStats{torrent: &Torrent{}}
+	qs.stats.Status = status
+	qs.stats.Bytes.Total = 100
+	if !complete {
+		qs.stats.Bytes.Incomplete = 50
+	}
+	qs.stats.SeededFor = seededFor
+	qs.stats.Priority = priority
+	return qs
+}
+
+func TestPlanQueueDownloadLimit(t *testing.T) {
+	active := []queueStats{
+		newQueueStats(Downloading, false, 0, PriorityNormal),
+		newQueueStats(Stopped, false, 0, PriorityNormal),
+	}
+	queued := []queueStats{
+		newQueueStats(Stopped, false, 0, PriorityNormal),
+		newQueueStats(Stopped, false, 0, PriorityHigh),
+		newQueueStats(Stopped, false, 0, PriorityNormal),
+	}
+
+	evict, start := planQueue(active, queued, 2, 0)
+	assert.Empty(t, evict)
+	assert.Equal(t, []*Torrent{queued[1].torrent}, start)
+
+	_, start = planQueue(active, queued, 3, 0)
+	assert.Equal(t, []*Torrent{queued[1].torrent, queued[0].torrent}, start)
+
+	_, start = planQueue(active, queued, 0, 0)
+	assert.Len(t, start, 3)
+}
+
+func TestPlanQueueSeedLimit(t *testing.T) {
+	active := []queueStats{
+		newQueueStats(Seeding, true, time.Hour, PriorityNormal),
+		newQueueStats(Seeding, true, time.Minute, PriorityNormal),
+		newQueueStats(Paused, true, 2*time.Hour, PriorityNormal),
+		newQueueStats(Paused, false, 0, PriorityNormal),
+	}
+	queued := []queueStats{
+		newQueueStats(Stopped, true, 0, PriorityNormal),
+		newQueueStats(Stopped, false, 0, PriorityNormal),
+	}
+
+	// Seeds that have been seeding for longest time are moved into the queue.
+	evict, start := planQueue(active, queued, 1, 2)
+	assert.Equal(t, []*Torrent{active[2].torrent}, evict)
+	assert.Empty(t, start)
+
+	// Complete torrents in queue are started when there is a free seed slot.
+	evict, start = planQueue(active, queued, 2, 4)
+	assert.Empty(t, evict)
+	assert.Equal(t, []*Torrent{queued[0].torrent, queued[1].torrent}, start)
+}
@@ -0,0 +1,72 @@
+package torrent
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// handleFiles serves the files of torrents at "/files/<torrent id>/<file path>".
+// Range requests are supported, so media players can seek in the file while it is being downloaded.
+// Pieces that are not downloaded yet are prioritized and the response waits for them.
+// Requesting "/files/<torrent id>/" lists the files of the torrent.
+func (h *rpcHandler) handleFiles(w http.ResponseWriter, r *http.Request) {
+	id, name, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/files/"), "/")
+	t := h.session.GetTorrent(id)
+	if t == nil {
+		http.NotFound(w, r)
+		return
+	}
+	files, err := t.Files()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if name == "" {
+		if !strings.HasSuffix(r.URL.Path, "/") {
+			http.Redirect(w, r, r.URL.Path+"/", http.StatusMovedPermanently)
+			return
+		}
+		listFiles(w, files)
+		return
+	}
+	for _, f := range files {
+		if filepath.ToSlash(f.Path()) != name {
+			continue
+		}
+		fr, err := f.Open()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		defer fr.Close()
+		// Content of an incomplete file changes while it is being downloaded,
+		// so it must not be validated with Last-Modified or If-Range until it is complete.
+		var modtime time.Time
+		if st := f.Stats(); st.BytesCompleted == st.BytesTotal {
+			modtime = t.AddedAt()
+		}
+		// Content-Type is detected from the file extension.
+		http.ServeContent(w, r, path.Base(name), modtime, fr)
+		return
+	}
+	http.NotFound(w, r)
+}
+
+func listFiles(w http.ResponseWriter, files []File) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintln(w, "<pre>")
+	for _, f := range files {
+		parts := strings.Split(filepath.ToSlash(f.Path()), "/")
+		for i := range parts {
+			parts[i] = url.PathEscape(parts[i])
+		}
+		fmt.Fprintf(w, "<a href=\"%s\">%s</a>\n", strings.Join(parts, "/"), html.EscapeString(f.Path()))
+	}
+	fmt.Fprintln(w, "</pre>")
+}
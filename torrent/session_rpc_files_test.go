@@ -0,0 +1,98 @@
+package torrent
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilesHandler(t *testing.T) {
+	addr, closeSeeder := seeder(t, true)
+	defer closeSeeder()
+
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+
+	f, err := os.Open(torrentFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	tor, err := s.AddTorrent(f, &AddTorrentOptions{Stopped: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tor.torrent.trackers = nil
+	assert.NoError(t, tor.Start())
+
+	var files []File
+	deadline := time.Now().Add(timeout)
+	for {
+		files, err = tor.Files()
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal(err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.NoError(t, tor.AddPeer(addr))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/files/", (&rpcHandler{session: s}).handleFiles)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	var file File
+	for _, file = range files {
+		if file.Stats().BytesTotal > 20 {
+			break
+		}
+	}
+	expected, err := os.ReadFile(filepath.Join(torrentDataDir, file.Path()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/files/"+tor.ID()+"/"+filepath.ToSlash(file.Path()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Range", "bytes=10-19")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusPartialContent, resp.StatusCode)
+	b, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, expected[10:20], b)
+
+	// Complete files can be validated with Last-Modified.
+	select {
+	case <-tor.NotifyComplete():
+	case <-time.After(timeout):
+		t.Fatal("download did not complete")
+	}
+	resp2, err := http.Get(srv.URL + "/files/" + tor.ID() + "/" + filepath.ToSlash(file.Path()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp2.Body.Close()
+	assert.Equal(t, http.StatusOK, resp2.StatusCode)
+	assert.Equal(t, tor.AddedAt().UTC().Format(http.TimeFormat), resp2.Header.Get("Last-Modified"))
+
+	resp, err = http.Get(srv.URL + "/files/" + tor.ID() + "/missing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
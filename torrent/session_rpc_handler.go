@@ -2,6 +2,7 @@ package torrent
 
 import (
 	"archive/tar"
+	"context"
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
@@ -46,6 +47,14 @@ func (h *rpcHandler) AddTorrent(args *rpctypes.AddTorrentRequest, reply *rpctype
 		ID:                args.AddTorrentOptions.ID,
 		StopAfterDownload: args.StopAfterDownload,
 		StopAfterMetadata: args.StopAfterMetadata,
+		SeedOnly:          args.SeedOnly,
+		Trackers:          args.Trackers,
+		CrossSeed:         args.CrossSeed,
+	}
+	var err error
+	opt.Priority, err = ParsePriority(args.Priority)
+	if err != nil {
+		return jsonrpc2.NewError(2, err.Error())
 	}
 	t, err := h.session.AddTorrent(r, opt)
 	var e *InputError
@@ -65,6 +74,14 @@ func (h *rpcHandler) AddURI(args *rpctypes.AddURIRequest, reply *rpctypes.AddURI
 		ID:                args.AddTorrentOptions.ID,
 		StopAfterDownload: args.StopAfterDownload,
 		StopAfterMetadata: args.StopAfterMetadata,
+		SeedOnly:          args.SeedOnly,
+		Trackers:          args.Trackers,
+		CrossSeed:         args.CrossSeed,
+	}
+	var err error
+	opt.Priority, err = ParsePriority(args.Priority)
+	if err != nil {
+		return jsonrpc2.NewError(2, err.Error())
 	}
 	t, err := h.session.AddURI(args.URI, opt)
 	var e *InputError
@@ -155,6 +172,38 @@ func (h *rpcHandler) GetSessionStats(args *rpctypes.GetSessionStatsRequest, repl
 		BytesUploaded:   s.BytesUploaded,
 		BytesRead:       s.BytesRead,
 		BytesWritten:    s.BytesWritten,
+
+		LifetimeBytesDownloaded: s.LifetimeBytesDownloaded,
+		LifetimeBytesUploaded:   s.LifetimeBytesUploaded,
+		SessionCount:            s.SessionCount,
+	}
+	return nil
+}
+
+func (h *rpcHandler) GetSettings(args *rpctypes.GetSettingsRequest, reply *rpctypes.GetSettingsResponse) error {
+	s := h.session.Settings()
+	reply.Settings = rpctypes.Settings{
+		SpeedLimitDownload:      s.SpeedLimitDownload,
+		SpeedLimitUpload:        s.SpeedLimitUpload,
+		MaxPeerDial:             s.MaxPeerDial,
+		MaxPeerAccept:           s.MaxPeerAccept,
+		QueueMaxActiveDownloads: s.QueueMaxActiveDownloads,
+		QueueMaxActiveSeeds:     s.QueueMaxActiveSeeds,
+	}
+	return nil
+}
+
+func (h *rpcHandler) SetSettings(args *rpctypes.SetSettingsRequest, reply *rpctypes.SetSettingsResponse) error {
+	err := h.session.SetSettings(Settings{
+		SpeedLimitDownload:      args.Settings.SpeedLimitDownload,
+		SpeedLimitUpload:        args.Settings.SpeedLimitUpload,
+		MaxPeerDial:             args.Settings.MaxPeerDial,
+		MaxPeerAccept:           args.Settings.MaxPeerAccept,
+		QueueMaxActiveDownloads: args.Settings.QueueMaxActiveDownloads,
+		QueueMaxActiveSeeds:     args.Settings.QueueMaxActiveSeeds,
+	})
+	if err != nil {
+		return jsonrpc2.NewError(2, err.Error())
 	}
 	return nil
 }
@@ -170,17 +219,21 @@ func (h *rpcHandler) GetTorrentStats(args *rpctypes.GetTorrentStatsRequest, repl
 		Port:     s.Port,
 		Status:   s.Status.String(),
 		Pieces: struct {
-			Checked   uint32
-			Have      uint32
-			Missing   uint32
-			Available uint32
-			Total     uint32
+			Checked           uint32
+			Have              uint32
+			Missing           uint32
+			Available         uint32
+			Total             uint32
+			Availability      []int
+			DistributedCopies float64
 		}{
-			Checked:   s.Pieces.Checked,
-			Have:      s.Pieces.Have,
-			Missing:   s.Pieces.Missing,
-			Available: s.Pieces.Available,
-			Total:     s.Pieces.Total,
+			Checked:           s.Pieces.Checked,
+			Have:              s.Pieces.Have,
+			Missing:           s.Pieces.Missing,
+			Available:         s.Pieces.Available,
+			Total:             s.Pieces.Total,
+			Availability:      s.Pieces.Availability,
+			DistributedCopies: s.Pieces.DistributedCopies,
 		},
 		Bytes: struct {
 			Total      int64
@@ -250,20 +303,38 @@ func (h *rpcHandler) GetTorrentStats(args *rpctypes.GetTorrentStatsRequest, repl
 		},
 		Name:        s.Name,
 		Private:     s.Private,
+		DHTEnabled:  s.DHTEnabled,
+		PEXEnabled:  s.PEXEnabled,
 		FileCount:   s.FileCount,
 		PieceLength: s.PieceLength,
 		SeededFor:   uint(s.SeededFor / time.Second),
 		Speed: struct {
-			Download int
-			Upload   int
+			Download        int
+			Upload          int
+			DownloadCurrent int
+			UploadCurrent   int
 		}{
-			Download: s.Speed.Download,
-			Upload:   s.Speed.Upload,
+			Download:        s.Speed.Download,
+			Upload:          s.Speed.Upload,
+			DownloadCurrent: s.Speed.DownloadCurrent,
+			UploadCurrent:   s.Speed.UploadCurrent,
 		},
+		Trackers: newRPCTrackers(s.Trackers),
+		Priority: s.Priority.String(),
 	}
 	if s.Error != nil {
 		reply.Stats.Error = s.Error.Error()
 	}
+	reply.Stats.Swarm.Seeders = s.Swarm.Seeders
+	reply.Stats.Swarm.Leechers = s.Swarm.Leechers
+	reply.Stats.Swarm.Snatches = s.Swarm.Snatches
+	if !s.Swarm.LastScrape.IsZero() {
+		reply.Stats.Swarm.LastScrape = rpctypes.Time{Time: s.Swarm.LastScrape}
+	}
+	reply.Stats.RecentErrors = make([]rpctypes.RecentError, len(s.RecentErrors))
+	for i, e := range s.RecentErrors {
+		reply.Stats.RecentErrors[i] = rpctypes.RecentError{Time: rpctypes.Time{Time: e.Time}, Error: e.Error.Error()}
+	}
 	if s.ETA != nil {
 		reply.Stats.ETA = int(*s.ETA / time.Second)
 	} else {
@@ -277,29 +348,38 @@ func (h *rpcHandler) GetTorrentTrackers(args *rpctypes.GetTorrentTrackersRequest
 	if t == nil {
 		return errTorrentNotFound
 	}
-	trackers := t.Trackers()
-	reply.Trackers = make([]rpctypes.Tracker, len(trackers))
+	reply.Trackers = newRPCTrackers(t.Trackers())
+	return nil
+}
+
+func newRPCTrackers(trackers []Tracker) []rpctypes.Tracker {
+	ret := make([]rpctypes.Tracker, len(trackers))
 	for i, t := range trackers {
-		reply.Trackers[i] = rpctypes.Tracker{
-			URL:      t.URL,
-			Status:   trackerStatusToString(t.Status),
-			Leechers: t.Leechers,
-			Seeders:  t.Seeders,
-			Warning:  t.Warning,
+		ret[i] = rpctypes.Tracker{
+			URL:             t.URL,
+			Status:          trackerStatusToString(t.Status),
+			Leechers:        t.Leechers,
+			Seeders:         t.Seeders,
+			Warning:         t.Warning,
+			Failures:        t.Failures,
+			PeersReceived:   t.PeersReceived,
+			PeersConnected:  t.PeersConnected,
+			BytesDownloaded: t.BytesDownloaded,
+			BytesUploaded:   t.BytesUploaded,
 		}
 		if t.Error != nil {
-			reply.Trackers[i].Error = t.Error.Error()
-			reply.Trackers[i].ErrorInternal = t.Error.err.ErrorWithType()
-			reply.Trackers[i].ErrorUnknown = t.Error.Unknown()
+			ret[i].Error = t.Error.Error()
+			ret[i].ErrorInternal = t.Error.err.ErrorWithType()
+			ret[i].ErrorUnknown = t.Error.Unknown()
 		}
 		if !t.LastAnnounce.IsZero() {
-			reply.Trackers[i].LastAnnounce = rpctypes.Time{Time: t.LastAnnounce}
+			ret[i].LastAnnounce = rpctypes.Time{Time: t.LastAnnounce}
 		}
 		if !t.NextAnnounce.IsZero() {
-			reply.Trackers[i].NextAnnounce = rpctypes.Time{Time: t.NextAnnounce}
+			ret[i].NextAnnounce = rpctypes.Time{Time: t.NextAnnounce}
 		}
 	}
-	return nil
+	return ret
 }
 
 func (h *rpcHandler) GetTorrentPeers(args *rpctypes.GetTorrentPeersRequest, reply *rpctypes.GetTorrentPeersResponse) error {
@@ -310,26 +390,12 @@ func (h *rpcHandler) GetTorrentPeers(args *rpctypes.GetTorrentPeersRequest, repl
 	peers := t.Peers()
 	reply.Peers = make([]rpctypes.Peer, len(peers))
 	for i, p := range peers {
-		var source string
-		switch p.Source {
-		case SourceTracker:
-			source = "TRACKER"
-		case SourceDHT:
-			source = "DHT"
-		case SourcePEX:
-			source = "PEX"
-		case SourceIncoming:
-			source = "INCOMING"
-		case SourceManual:
-			source = "MANUAL"
-		default:
-			panic("unhandled peer source")
-		}
 		reply.Peers[i] = rpctypes.Peer{
 			ID:                 hex.EncodeToString(p.ID[:]),
 			Client:             p.Client,
 			Addr:               p.Addr.String(),
-			Source:             source,
+			Source:             p.Source.String(),
+			Tracker:            p.Tracker,
 			ConnectedAt:        rpctypes.Time{Time: p.ConnectedAt},
 			Downloading:        p.Downloading,
 			ClientInterested:   p.ClientInterested,
@@ -342,6 +408,10 @@ func (h *rpcHandler) GetTorrentPeers(args *rpctypes.GetTorrentPeersRequest, repl
 			EncryptedStream:    p.EncryptedStream,
 			DownloadSpeed:      p.DownloadSpeed,
 			UploadSpeed:        p.UploadSpeed,
+			BytesDownloaded:    p.BytesDownloaded,
+			BytesUploaded:      p.BytesUploaded,
+			Incoming:           p.Incoming,
+			Progress:           p.Progress,
 		}
 	}
 	return nil
@@ -382,6 +452,34 @@ func (h *rpcHandler) StopTorrent(args *rpctypes.StopTorrentRequest, reply *rpcty
 	return t.Stop()
 }
 
+func (h *rpcHandler) SetTorrentPriority(args *rpctypes.SetTorrentPriorityRequest, reply *rpctypes.SetTorrentPriorityResponse) error {
+	t := h.session.GetTorrent(args.ID)
+	if t == nil {
+		return errTorrentNotFound
+	}
+	p, err := ParsePriority(args.Priority)
+	if err != nil {
+		return jsonrpc2.NewError(2, err.Error())
+	}
+	return t.SetPriority(p)
+}
+
+func (h *rpcHandler) PauseTorrent(args *rpctypes.PauseTorrentRequest, reply *rpctypes.PauseTorrentResponse) error {
+	t := h.session.GetTorrent(args.ID)
+	if t == nil {
+		return errTorrentNotFound
+	}
+	return t.Pause()
+}
+
+func (h *rpcHandler) ResumeTorrent(args *rpctypes.ResumeTorrentRequest, reply *rpctypes.ResumeTorrentResponse) error {
+	t := h.session.GetTorrent(args.ID)
+	if t == nil {
+		return errTorrentNotFound
+	}
+	return t.Resume()
+}
+
 func (h *rpcHandler) AnnounceTorrent(args *rpctypes.AnnounceTorrentRequest, reply *rpctypes.AnnounceTorrentResponse) error {
 	t := h.session.GetTorrent(args.ID)
 	if t == nil {
@@ -391,6 +489,15 @@ func (h *rpcHandler) AnnounceTorrent(args *rpctypes.AnnounceTorrentRequest, repl
 	return nil
 }
 
+func (h *rpcHandler) ForceAnnounceTorrent(args *rpctypes.ForceAnnounceTorrentRequest, reply *rpctypes.ForceAnnounceTorrentResponse) error {
+	t := h.session.GetTorrent(args.ID)
+	if t == nil {
+		return errTorrentNotFound
+	}
+	t.ForceAnnounce()
+	return nil
+}
+
 func (h *rpcHandler) VerifyTorrent(args *rpctypes.VerifyTorrentRequest, reply *rpctypes.VerifyTorrentResponse) error {
 	t := h.session.GetTorrent(args.ID)
 	if t == nil {
@@ -423,6 +530,59 @@ func (h *rpcHandler) AddTracker(args *rpctypes.AddTrackerRequest, reply *rpctype
 	return t.AddTracker(args.URL)
 }
 
+func (h *rpcHandler) GetDHTStats(args *rpctypes.GetDHTStatsRequest, reply *rpctypes.GetDHTStatsResponse) error {
+	s, err := h.session.DHTStats()
+	if err != nil {
+		return err
+	}
+	reply.Stats = rpctypes.DHTStats{
+		NodeID:         s.NodeID,
+		Port:           s.Port,
+		Nodes:          s.Nodes,
+		ReachableNodes: s.ReachableNodes,
+		SavedNodes:     s.SavedNodes,
+		ReadOnly:       s.ReadOnly,
+
+		PacketsSent:     s.PacketsSent,
+		PacketsReceived: s.PacketsReceived,
+		PacketsDropped:  s.PacketsDropped,
+		BytesSent:       s.BytesSent,
+		BytesReceived:   s.BytesReceived,
+
+		GetPeersSent:         s.GetPeersSent,
+		GetPeersReceived:     s.GetPeersReceived,
+		GetPeersReplies:      s.GetPeersReplies,
+		FindNodeSent:         s.FindNodeSent,
+		FindNodeReceived:     s.FindNodeReceived,
+		FindNodeReplies:      s.FindNodeReplies,
+		PingSent:             s.PingSent,
+		PingReplies:          s.PingReplies,
+		PeersFound:           s.PeersFound,
+		PacketsFromBlocklist: s.PacketsFromBlocklist,
+	}
+	return nil
+}
+
+func (h *rpcHandler) DHTGetPeers(args *rpctypes.DHTGetPeersRequest, reply *rpctypes.DHTGetPeersResponse) error {
+	b, err := hex.DecodeString(args.InfoHash)
+	if err != nil || len(b) != 20 {
+		return errors.New("invalid info hash")
+	}
+	var ih [20]byte
+	copy(ih[:], b)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(args.Timeout)*time.Second)
+	defer cancel()
+	addrs, err := h.session.DHTGetPeers(ctx, ih)
+	if err != nil {
+		return err
+	}
+	reply.Peers = make([]string, len(addrs))
+	for i, addr := range addrs {
+		reply.Peers[i] = addr.String()
+	}
+	return nil
+}
+
 func (h *rpcHandler) MoveTorrent(args *rpctypes.MoveTorrentRequest, reply *rpctypes.MoveTorrentResponse) error {
 	t := h.session.GetTorrent(args.ID)
 	if t == nil {
@@ -508,6 +668,8 @@ func (h *rpcHandler) handleMoveTorrent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	s.Port = port
+	// Files are saved in the data directory of this session.
+	s.DataDir = h.session.getDataDir(id)
 	spec := &s
 	// case "data":
 	p, err = mr.NextPart()
@@ -520,7 +682,7 @@ func (h *rpcHandler) handleMoveTorrent(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "data expected in multipart form", http.StatusBadRequest)
 		return
 	}
-	err = readData(p, h.session.getDataDir(id), h.session.config.FilePermissions)
+	err = readData(p, h.session.getDataDir(id), h.session.config.DirPermissions)
 	if err != nil {
 		h.session.log.Error(err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
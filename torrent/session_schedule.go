@@ -0,0 +1,156 @@
+package torrent
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SpeedLimitSchedule overrides the global speed limits in Config during a time range of the day.
+type SpeedLimitSchedule struct {
+	// Days of the week that the schedule is applied on.
+	// Values are three-letter lowercase abbreviations of day names, e.g. "mon". Empty means every day.
+	Days []string
+	// Start and end of the time range in "15:04" format, in local time.
+	// If End is before Start, the range continues until End on the next day.
+	Start, End string
+	// Download speed limit in KiB/s during the time range. Zero means no limit.
+	SpeedLimitDownload int64
+	// Upload speed limit in KiB/s during the time range. Zero means no limit.
+	SpeedLimitUpload int64
+	// Pause all torrents during the time range.
+	PauseAll bool
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+type parsedSchedule struct {
+	SpeedLimitSchedule
+	days       [7]bool
+	start, end int // minutes since midnight
+}
+
+func parseSchedules(schedules []SpeedLimitSchedule) ([]parsedSchedule, error) {
+	ret := make([]parsedSchedule, 0, len(schedules))
+	for _, s := range schedules {
+		p, err := parseSchedule(s)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, p)
+	}
+	return ret, nil
+}
+
+func parseSchedule(s SpeedLimitSchedule) (parsedSchedule, error) {
+	p := parsedSchedule{SpeedLimitSchedule: s}
+	if len(s.Days) == 0 {
+		for i := range p.days {
+			p.days[i] = true
+		}
+	}
+	for _, d := range s.Days {
+		wd, ok := weekdays[strings.ToLower(d)]
+		if !ok {
+			return p, fmt.Errorf("invalid day in speed limit schedule: %q", d)
+		}
+		p.days[wd] = true
+	}
+	var err error
+	p.start, err = parseClock(s.Start)
+	if err != nil {
+		return p, err
+	}
+	p.end, err = parseClock(s.End)
+	return p, err
+}
+
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time in speed limit schedule: %q", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func (p parsedSchedule) active(now time.Time) bool {
+	minute := now.Hour()*60 + now.Minute()
+	today := now.Weekday()
+	if p.start <= p.end {
+		return p.days[today] && minute >= p.start && minute < p.end
+	}
+	// Time range spans midnight.
+	yesterday := (today + 6) % 7
+	return (p.days[today] && minute >= p.start) || (p.days[yesterday] && minute < p.end)
+}
+
+func activeSchedule(schedules []parsedSchedule, now time.Time) *parsedSchedule {
+	for i := range schedules {
+		if schedules[i].active(now) {
+			return &schedules[i]
+		}
+	}
+	return nil
+}
+
+func (s *Session) speedLimitScheduler() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.applySpeedLimitSchedule()
+		case <-s.closeC:
+			return
+		}
+	}
+}
+
+// applySpeedLimitSchedule sets the global speed limits from the active schedule, or from the Config if there is none.
+func (s *Session) applySpeedLimitSchedule() {
+	s.mSchedule.Lock()
+	defer s.mSchedule.Unlock()
+
+	download, upload := s.speedLimitDownload, s.speedLimitUpload
+	var pause bool
+	if sc := activeSchedule(s.schedules, time.Now()); sc != nil {
+		download, upload = sc.SpeedLimitDownload, sc.SpeedLimitUpload
+		pause = sc.PauseAll
+	}
+	s.bucketDownload.SetLimit(download)
+	s.bucketUpload.SetLimit(upload)
+
+	if pause {
+		for _, t := range s.ListTorrents() {
+			if _, ok := s.pausedBySchedule[t]; ok {
+				continue
+			}
+			switch t.Stats().Status {
+			case Paused:
+				// Paused by user, must not be resumed at the end of the schedule.
+				continue
+			case Stopped, Stopping, Queued:
+				// Will be paused on next check if it is started.
+				continue
+			}
+			t.torrent.log.Info("pausing torrent by speed limit schedule")
+			// Not saved in resume data, because the schedule is checked again after restart.
+			t.torrent.Pause()
+			s.pausedBySchedule[t] = struct{}{}
+		}
+	} else {
+		for t := range s.pausedBySchedule {
+			t.torrent.log.Info("resuming torrent by speed limit schedule")
+			t.torrent.Resume()
+			delete(s.pausedBySchedule, t)
+		}
+	}
+}
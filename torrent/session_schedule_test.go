@@ -0,0 +1,34 @@
+package torrent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpeedLimitSchedule(t *testing.T) {
+	schedules, err := parseSchedules([]SpeedLimitSchedule{
+		{Days: []string{"sat", "sun"}, Start: "10:00", End: "12:00", SpeedLimitDownload: 1},
+		{Start: "23:00", End: "07:00", SpeedLimitDownload: 2},
+	})
+	assert.NoError(t, err)
+
+	at := func(day, clock string) time.Time {
+		tm, err2 := time.ParseInLocation("2006-01-02 15:04", day+" "+clock, time.Local)
+		assert.NoError(t, err2)
+		return tm
+	}
+	// 2023-04-01 is a Saturday.
+	assert.Equal(t, int64(1), activeSchedule(schedules, at("2023-04-01", "11:00")).SpeedLimitDownload)
+	assert.Nil(t, activeSchedule(schedules, at("2023-04-03", "11:00")))
+	assert.Nil(t, activeSchedule(schedules, at("2023-04-01", "12:00")))
+	assert.Equal(t, int64(2), activeSchedule(schedules, at("2023-04-03", "23:30")).SpeedLimitDownload)
+	assert.Equal(t, int64(2), activeSchedule(schedules, at("2023-04-04", "06:59")).SpeedLimitDownload)
+	assert.Nil(t, activeSchedule(schedules, at("2023-04-04", "07:00")))
+
+	_, err = parseSchedules([]SpeedLimitSchedule{{Days: []string{"xyz"}, Start: "10:00", End: "12:00"}})
+	assert.Error(t, err)
+	_, err = parseSchedules([]SpeedLimitSchedule{{Start: "25:00", End: "12:00"}})
+	assert.Error(t, err)
+}
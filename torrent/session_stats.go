@@ -73,6 +73,46 @@ type SessionStats struct {
 	BytesRead int64
 	// Number of bytes written to disk.
 	BytesWritten int64
+
+	// Number of bytes downloaded from peers in all sessions using the same database, including this one.
+	LifetimeBytesDownloaded int64
+	// Number of bytes uploaded to peers in all sessions using the same database, including this one.
+	LifetimeBytesUploaded int64
+	// Number of times a session is created with the same database, including this one.
+	SessionCount int64
+}
+
+// lifetimeStats contains the totals of the previous sessions loaded from the database.
+type lifetimeStats struct {
+	bytesDownloaded int64
+	bytesUploaded   int64
+	// Includes the current session.
+	sessions int64
+}
+
+// loadLifetimeStats reads the totals of the previous sessions and increments the session count.
+func loadLifetimeStats(db *bbolt.DB) (ls lifetimeStats, err error) {
+	err = db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(sessionBucket)
+		for key, val := range map[string]*int64{
+			string(lifetimeDownloadedKey): &ls.bytesDownloaded,
+			string(lifetimeUploadedKey):   &ls.bytesUploaded,
+			string(sessionCountKey):       &ls.sessions,
+		} {
+			v := b.Get([]byte(key))
+			if v == nil {
+				continue
+			}
+			n, err2 := strconv.ParseInt(string(v), 10, 64)
+			if err2 != nil {
+				return err2
+			}
+			*val = n
+		}
+		ls.sessions++
+		return b.Put(sessionCountKey, []byte(strconv.FormatInt(ls.sessions, 10)))
+	})
+	return
 }
 
 // Stats returns current statistics about the Session.
@@ -111,6 +151,10 @@ func (s *Session) Stats() SessionStats {
 		BytesUploaded:   s.metrics.SpeedUpload.Count(),
 		BytesRead:       s.metrics.SpeedRead.Count(),
 		BytesWritten:    s.metrics.SpeedWrite.Count(),
+
+		LifetimeBytesDownloaded: s.lifetime.bytesDownloaded + s.metrics.SpeedDownload.Count(),
+		LifetimeBytesUploaded:   s.lifetime.bytesUploaded + s.metrics.SpeedUpload.Count(),
+		SessionCount:            s.lifetime.sessions,
 	}
 }
 
@@ -130,7 +174,17 @@ func (s *Session) updateStatsLoop() {
 func (s *Session) updateStats() {
 	s.mTorrents.RLock()
 	defer s.mTorrents.RUnlock()
+	s.writeStats()
+}
+
+// writeStats saves the stats of torrents to the database. Caller must hold the mTorrents lock.
+func (s *Session) writeStats() {
 	err := s.db.Update(func(tx *bbolt.Tx) error {
+		sb := tx.Bucket(sessionBucket)
+		_ = sb.Put(lifetimeDownloadedKey, []byte(strconv.FormatInt(s.lifetime.bytesDownloaded+s.metrics.SpeedDownload.Count(), 10)))
+		_ = sb.Put(lifetimeUploadedKey, []byte(strconv.FormatInt(s.lifetime.bytesUploaded+s.metrics.SpeedUpload.Count(), 10)))
+		s.saveDHTNodes(sb)
+
 		mb := tx.Bucket(torrentsBucket)
 		for _, t := range s.torrents {
 			b := mb.Bucket([]byte(t.torrent.id))
@@ -0,0 +1,37 @@
+package torrent
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLifetimeStats(t *testing.T) {
+	tmp, closeTmp := tempdir(t)
+	defer closeTmp()
+	cfg := DefaultConfig
+	cfg.Database = filepath.Join(tmp, "session.db")
+	cfg.DataDir = tmp
+	cfg.DHTEnabled = false
+	cfg.RPCEnabled = false
+
+	s, err := NewSession(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(1), s.Stats().SessionCount)
+	s.metrics.SpeedDownload.Mark(100)
+	s.metrics.SpeedUpload.Mark(50)
+	assert.NoError(t, s.Close())
+
+	s, err = NewSession(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	stats := s.Stats()
+	assert.Equal(t, int64(2), stats.SessionCount)
+	assert.Equal(t, int64(100), stats.LifetimeBytesDownloaded)
+	assert.Equal(t, int64(50), stats.LifetimeBytesUploaded)
+}
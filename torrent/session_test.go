@@ -0,0 +1,50 @@
+package torrent
+
+import (
+	"testing"
+
+	"github.com/cenkalti/rain/internal/tracker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTrackers(t *testing.T) {
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+
+	tiers := [][]string{
+		{"http://a.rain/announce", "http://b.rain/announce"},
+		{"http://c.rain/announce"},
+		{"invalid://d.rain"},
+	}
+
+	trackers := s.parseTrackers(tiers, false)
+	assert.Len(t, trackers, 1)
+	list := trackers[0].(*tracker.TierList).Tiers
+	assert.Len(t, list, 2)
+	assert.Len(t, list[0].Trackers, 2)
+	assert.Len(t, list[1].Trackers, 1)
+
+	s.config.TrackerAnnounceToAll = true
+	trackers = s.parseTrackers(tiers, false)
+	var urls []string
+	for _, tr := range trackers {
+		urls = append(urls, tr.URL())
+	}
+	assert.Equal(t, []string{"http://a.rain/announce", "http://b.rain/announce", "http://c.rain/announce"}, urls)
+}
+
+func TestPeerIDPrefix(t *testing.T) {
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+
+	s.config.PublicPeerIDPrefix = "-XX1234-"
+	tor := &torrent{session: s}
+	n := tor.copyPeerIDPrefix()
+	assert.Equal(t, "-XX1234-", string(tor.peerID[:n]))
+	assert.Equal(t, "Rain/"+Version, s.getTrackerUserAgent(false))
+
+	cfg := DefaultConfig
+	cfg.PublicPeerIDPrefix = "-XX1234-abcdefghijklmnopqrstuvwxyz"
+	_, err := NewSession(cfg)
+	assert.Error(t, err)
+}
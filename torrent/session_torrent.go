@@ -2,6 +2,7 @@ package torrent
 
 import (
 	"archive/tar"
+	"context"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
@@ -55,7 +56,7 @@ func (t *Torrent) FilePaths() ([]string, error) {
 }
 
 // The files in the torrent with completion info. An error is returned
-// when metainfo isn't ready.
+// when metainfo isn't ready. Files can be read while they are being downloaded with File.Open.
 func (t *Torrent) Files() ([]File, error) {
 	return t.torrent.Files()
 }
@@ -74,10 +75,53 @@ func (t *Torrent) AddedAt() time.Time {
 }
 
 // Stats returns statistics about the torrent.
+// Stats does not block. The returned value is a snapshot that is refreshed every second
+// and immediately after the status of the torrent changes.
 func (t *Torrent) Stats() Stats {
 	return t.torrent.Stats()
 }
 
+// StatsContext is like Stats but returns ctx.Err() if ctx is done.
+// Returns ErrTorrentRemoved if the torrent is removed from the Session.
+func (t *Torrent) StatsContext(ctx context.Context) (Stats, error) {
+	return t.torrent.StatsContext(ctx)
+}
+
+// NotifyStats returns a channel that receives statistics about the torrent at every interval.
+// Intervals shorter than 100ms are rounded up to 100ms.
+// Stats are produced inside the torrent loop, so they are consistent with each other.
+// Values are dropped if the receiver is not ready to receive.
+// The returned function must be called to stop receiving. The channel is closed after stop or when the torrent is removed.
+func (t *Torrent) NotifyStats(interval time.Duration) (<-chan Stats, func()) {
+	return t.torrent.NotifyStats(interval)
+}
+
+// NotifyEvent returns a channel that receives events about the torrent such as completed pieces and peer connections.
+// Multiple subscribers may exist at the same time, each receiving all events.
+// Events are dropped if the receiver falls too far behind.
+// The returned function must be called to stop receiving. The channel is closed after stop or when the torrent is removed.
+func (t *Torrent) NotifyEvent() (<-chan Event, func()) {
+	return t.torrent.NotifyEvent()
+}
+
+// ReadPiece returns the data of the piece at index after its hash is verified.
+// If wait is false, ErrPieceNotDownloaded is returned if the piece is not downloaded yet.
+// If wait is true, ReadPiece blocks until the piece is downloaded and returns ErrTorrentStopped if the torrent is stopped before that.
+// Files are read from the storage, so the torrent must be running.
+func (t *Torrent) ReadPiece(index uint32, wait bool) ([]byte, error) {
+	return t.torrent.ReadPiece(index, wait)
+}
+
+// NewDataReader returns an io.ReaderAt for reading the files of the torrent as a single byte space.
+// Reads wait for the pieces to be downloaded if wait is true. See ReadPiece for details.
+// Returns ErrNoMetadata if the torrent is added with a magnet link and the metadata is not downloaded yet.
+func (t *Torrent) NewDataReader(wait bool) (*DataReader, error) {
+	if t.torrent.info == nil {
+		return nil, ErrNoMetadata
+	}
+	return &DataReader{torrent: t.torrent, wait: wait}, nil
+}
+
 // Magnet returns the magnet link.
 // Returns error if torrent is private.
 func (t *Torrent) Magnet() (string, error) {
@@ -117,8 +161,17 @@ func (t *Torrent) NotifyClose() <-chan struct{} {
 	return t.torrent.NotifyClose()
 }
 
+// Done returns a channel that is closed after the torrent is closed by RemoveTorrent() or Session.Close().
+// When the channel is closed, all goroutines of the torrent have exited, the bitfield is written to the database and the files are closed.
+// Statistics of the torrent are written by Session.Close(), use Session.Done() for waiting it.
+func (t *Torrent) Done() <-chan struct{} {
+	return t.torrent.doneC
+}
+
 // NotifyStop returns a new channel for notifying stop event.
 // Value from the channel contains the error if there is any, otherwise the value is nil.
+// It may be called multiple times, each returned channel receives the value.
+// Non-fatal errors that do not stop the torrent are available in Stats.RecentErrors.
 // NotifyStop must be called after calling Start().
 func (t *Torrent) NotifyStop() <-chan error {
 	return t.torrent.NotifyError()
@@ -133,11 +186,52 @@ func (t *Torrent) NotifyComplete() <-chan struct{} {
 
 // NotifyMetadata returns a channel for notifying completion of metadata download from magnet links.
 // The channel is closed once all metadata pieces are downloaded successfully.
+// It is already closed for torrents that are added with metadata.
 // NotifyMetadata must be called after calling Start().
 func (t *Torrent) NotifyMetadata() <-chan struct{} {
 	return t.torrent.NotifyMetadata()
 }
 
+// WaitMetadata blocks until the metadata of a torrent added with a magnet link is downloaded.
+// Returns immediately for torrents that already have metadata.
+// Returns the error of the torrent, or ErrTorrentStopped if the torrent stops before that.
+// Returns ctx.Err() if ctx is done before the metadata is downloaded.
+// WaitMetadata must be called after calling Start().
+func (t *Torrent) WaitMetadata(ctx context.Context) error {
+	return t.wait(ctx, t.torrent.NotifyMetadata())
+}
+
+// WaitComplete blocks until all pieces of the torrent are downloaded.
+// Returns the error of the torrent, or ErrTorrentStopped if the torrent stops before that.
+// Returns ctx.Err() if ctx is done before the download completes.
+// WaitComplete must be called after calling Start().
+func (t *Torrent) WaitComplete(ctx context.Context) error {
+	return t.wait(ctx, t.torrent.NotifyComplete())
+}
+
+// wait blocks until doneC is closed, the torrent stops or ctx is done.
+func (t *Torrent) wait(ctx context.Context, doneC <-chan struct{}) error {
+	select {
+	case <-doneC:
+		return nil
+	case err := <-t.torrent.NotifyError():
+		// Torrent may be stopped right after it is done, e.g. when StopAfterDownload is set.
+		select {
+		case <-doneC:
+			return nil
+		default:
+		}
+		if err == nil {
+			err = ErrTorrentStopped
+		}
+		return err
+	case <-t.torrent.NotifyClose():
+		return ErrTorrentRemoved
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // AddPeer adds a new peer to the torrent. Does nothing if torrent is stopped.
 func (t *Torrent) AddPeer(addr string) error {
 	return t.torrent.addPeerString(addr)
@@ -145,23 +239,50 @@ func (t *Torrent) AddPeer(addr string) error {
 
 // AddTracker adds a new tracker to the torrent.
 func (t *Torrent) AddTracker(uri string) error {
+	return t.AddTrackers([]string{uri})
+}
+
+// AddTrackers adds new trackers to the torrent. Each tracker is added in a separate tier.
+// Trackers that already exist in the torrent are ignored.
+func (t *Torrent) AddTrackers(uris []string) error {
 	var private bool
 	if t.torrent.info != nil {
 		private = t.torrent.info.Private
 	}
-	tr, err := t.torrent.session.trackerManager.Get(uri, t.torrent.session.config.TrackerHTTPTimeout, t.torrent.session.getTrackerUserAgent(private), int64(t.torrent.session.config.TrackerHTTPMaxResponseSize))
-	if err != nil {
-		return err
+	trs := make(map[string]tracker.Tracker, len(uris))
+	for _, uri := range uris {
+		tr, err := t.torrent.session.trackerManager.Get(uri, t.torrent.session.config.TrackerHTTPTimeout, t.torrent.session.getTrackerUserAgent(private), int64(t.torrent.session.config.TrackerHTTPMaxResponseSize))
+		if err != nil {
+			return err
+		}
+		trs[uri] = tr
 	}
-	err = t.torrent.session.db.Update(func(tx *bbolt.Tx) error {
+	var added []tracker.Tracker
+	err := t.torrent.session.db.Update(func(tx *bbolt.Tx) error {
 		b := tx.Bucket(torrentsBucket).Bucket([]byte(t.torrent.id))
 		value := b.Get(boltdbresumer.Keys.Trackers)
 		var trackers [][]string
-		err = json.Unmarshal(value, &trackers)
+		err := json.Unmarshal(value, &trackers)
 		if err != nil {
 			return err
 		}
-		trackers = append(trackers, []string{uri})
+		for _, tier := range trackers {
+			for _, uri := range tier {
+				delete(trs, uri)
+			}
+		}
+		for _, uri := range uris {
+			tr, ok := trs[uri]
+			if !ok {
+				continue
+			}
+			delete(trs, uri)
+			trackers = append(trackers, []string{uri})
+			added = append(added, tr)
+		}
+		if len(added) == 0 {
+			return nil
+		}
 		value, err = json.Marshal(trackers)
 		if err != nil {
 			return err
@@ -171,21 +292,24 @@ func (t *Torrent) AddTracker(uri string) error {
 	if err != nil {
 		return err
 	}
-	t.torrent.AddTrackers([]tracker.Tracker{tr})
+	if len(added) > 0 {
+		t.torrent.AddTrackers(added)
+	}
 	return nil
 }
 
 // Start downloading the torrent. If all pieces are completed, starts seeding them.
+// If the number of active torrents in Session has reached the limits, the torrent waits in queue until there is a free slot.
 func (t *Torrent) Start() error {
 	err := t.torrent.session.resumer.WriteStarted(t.torrent.id, true)
 	if err != nil {
 		return err
 	}
-	t.torrent.Start()
+	t.torrent.session.startOrQueue(t)
 	return nil
 }
 
-// Stop the torrent. Does not block. After Stop is called, the torrent switches into Stopping state.
+// Stop the torrent. Does not wait for trackers. When Stop returns, the torrent is in Stopping state.
 // During Stopping state, a stop event sent to trackers with a timeout.
 // At most 5 seconds later, the torrent switches into Stopped state.
 func (t *Torrent) Stop() error {
@@ -193,15 +317,70 @@ func (t *Torrent) Stop() error {
 	if err != nil {
 		return err
 	}
+	err = t.torrent.session.resumer.WritePaused(t.torrent.id, false)
+	if err != nil {
+		return err
+	}
+	t.torrent.session.dequeue(t)
 	t.torrent.Stop()
 	return nil
 }
 
+// Priority returns the priority of the torrent in Session.
+func (t *Torrent) Priority() Priority {
+	return t.torrent.getPriority()
+}
+
+// SetPriority changes the priority of the torrent in Session.
+// Torrents with higher priority are started first from the queue and get a larger share of the bandwidth
+// when the speed limits are reached.
+func (t *Torrent) SetPriority(p Priority) error {
+	if !p.valid() {
+		return newInputError(fmt.Errorf("invalid priority: %d", int32(p)))
+	}
+	err := t.torrent.session.resumer.WritePriority(t.torrent.id, int(p))
+	if err != nil {
+		return err
+	}
+	t.torrent.setPriority(p)
+	t.torrent.session.triggerQueue()
+	return nil
+}
+
+// Pause the torrent. Stats show the paused torrent when Pause returns. While paused, no pieces are downloaded or uploaded
+// but peer connections are kept and trackers are still announced, so the torrent can be resumed immediately.
+// Pause has no effect on stopped torrents.
+// The torrent is paused again if the session is restarted.
+func (t *Torrent) Pause() error {
+	err := t.torrent.session.resumer.WritePaused(t.torrent.id, true)
+	if err != nil {
+		return err
+	}
+	t.torrent.Pause()
+	return nil
+}
+
+// Resume the torrent that is paused with Pause.
+func (t *Torrent) Resume() error {
+	err := t.torrent.session.resumer.WritePaused(t.torrent.id, false)
+	if err != nil {
+		return err
+	}
+	t.torrent.Resume()
+	return nil
+}
+
 // Announce the torrent to all trackers and DHT. It does not overrides the minimum interval value sent by the trackers or set in Config.
 func (t *Torrent) Announce() {
 	t.torrent.Announce()
 }
 
+// ForceAnnounce announces the torrent to all trackers and DHT immediately.
+// Unlike Announce, it does not wait for the minimum interval to pass.
+func (t *Torrent) ForceAnnounce() {
+	t.torrent.ForceAnnounce()
+}
+
 // Verify pieces of torrent by reading all of the torrents files from disk.
 // After Verify called, the torrent is stopped, then verification starts and the torrent switches into Verifying state.
 // The torrent stays stopped after verification finishes.
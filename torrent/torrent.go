@@ -2,10 +2,12 @@ package torrent
 
 import (
 	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"net"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/cenkalti/rain/internal/acceptor"
@@ -19,8 +21,9 @@ import (
 	"github.com/cenkalti/rain/internal/handshaker/incominghandshaker"
 	"github.com/cenkalti/rain/internal/handshaker/outgoinghandshaker"
 	"github.com/cenkalti/rain/internal/infodownloader"
+	"github.com/cenkalti/rain/internal/lockfile"
 	"github.com/cenkalti/rain/internal/logger"
-	"github.com/cenkalti/rain/internal/metainfo"
+	"github.com/cenkalti/rain/internal/md5verifier"
 	"github.com/cenkalti/rain/internal/mse"
 	"github.com/cenkalti/rain/internal/peer"
 	"github.com/cenkalti/rain/internal/pexlist"
@@ -29,6 +32,8 @@ import (
 	"github.com/cenkalti/rain/internal/piecepicker"
 	"github.com/cenkalti/rain/internal/piecewriter"
 	"github.com/cenkalti/rain/internal/resumer"
+	"github.com/cenkalti/rain/internal/smartban"
+	"github.com/cenkalti/rain/internal/speedlimit"
 	"github.com/cenkalti/rain/internal/storage"
 	"github.com/cenkalti/rain/internal/suspendchan"
 	"github.com/cenkalti/rain/internal/tracker"
@@ -36,6 +41,7 @@ import (
 	"github.com/cenkalti/rain/internal/urldownloader"
 	"github.com/cenkalti/rain/internal/verifier"
 	"github.com/cenkalti/rain/internal/webseedsource"
+	"github.com/cenkalti/rain/metainfo"
 	"github.com/rcrowley/go-metrics"
 )
 
@@ -59,7 +65,9 @@ type torrent struct {
 	name string
 
 	// Storage implementation to save the files in torrent.
-	storage storage.Storage
+	// It is replaced when the files are moved out of the incomplete directory, so it is guarded by a mutex.
+	storage  storage.Storage
+	mStorage sync.RWMutex
 
 	// TCP Port to listen for peer connections.
 	port int
@@ -71,7 +79,7 @@ type torrent struct {
 	// Bits are set only after data is written to file.
 	bitfield *bitfield.Bitfield
 
-	// Protects bitfield writing from torrent loop and reading from announcer loop.
+	// Protects bitfield and pieces writing from torrent loop and reading from announcer loop and data readers.
 	mBitfield sync.RWMutex
 
 	// Unique peer ID is generated per downloader.
@@ -116,6 +124,12 @@ type torrent struct {
 	infoDownloaders        map[*peer.Peer]*infodownloader.InfoDownloader
 	infoDownloadersSnubbed map[*peer.Peer]*infodownloader.InfoDownloader
 
+	// Blocks of the info dictionary downloaded from peers. Shared by all info downloaders.
+	metadata *infodownloader.Metadata
+	// Last downloaded metadata that does not match the info hash.
+	// Compared with the correct one to find out which peers have sent wrong blocks.
+	failedMetadata *infodownloader.Metadata
+
 	pieceWriterResultC chan *piecewriter.PieceWriter
 
 	// This channel is closed once all torrent pieces are downloaded and verified.
@@ -130,6 +144,12 @@ type torrent struct {
 	// If any unrecoverable error occurs, it will be sent to this channel and download will be stopped.
 	errC chan error
 
+	// Channels returned from NotifyError. Each one receives the error when the torrent stops.
+	errSubscribers []chan error
+
+	// Non-fatal errors that have occurred recently. See addRecentError.
+	recentErrors []RecentError
+
 	// After listener has started, port will be sent to this channel.
 	portC chan int
 
@@ -140,24 +160,60 @@ type torrent struct {
 	closeC chan struct{}
 
 	// Close() blocks until doneC is closed.
+	// It is closed after run() returns and all goroutines started by the torrent have exited.
 	doneC chan struct{}
 
+	// Piece writers that are still running. They are waited before files are closed in stop().
+	pieceWriters sync.WaitGroup
+
 	// These are the channels for sending a message to run() loop.
-	statsCommandC        chan statsRequest        // Stats()
-	trackersCommandC     chan trackersRequest     // Trackers()
-	peersCommandC        chan peersRequest        // Peers()
-	webseedsCommandC     chan webseedsRequest     // Webseeds()
-	startCommandC        chan struct{}            // Start()
-	stopCommandC         chan struct{}            // Stop()
-	announceCommandC     chan struct{}            // Announce()
-	verifyCommandC       chan struct{}            // Verify()
-	notifyErrorCommandC  chan notifyErrorCommand  // NotifyError()
-	notifyListenCommandC chan notifyListenCommand // NotifyListen()
-	addPeersCommandC     chan []*net.TCPAddr      // AddPeers()
-	addTrackersCommandC  chan []tracker.Tracker   // AddTrackers()
+	trackersCommandC        chan trackersRequest     // Trackers()
+	peersCommandC           chan peersRequest        // Peers()
+	webseedsCommandC        chan webseedsRequest     // Webseeds()
+	startCommandC           chan chan struct{}       // Start()
+	stopCommandC            chan chan struct{}       // Stop()
+	pauseCommandC           chan chan struct{}       // Pause()
+	resumeCommandC          chan chan struct{}       // Resume()
+	announceCommandC        chan struct{}            // Announce()
+	forceAnnounceCommandC   chan struct{}            // ForceAnnounce()
+	verifyCommandC          chan struct{}            // Verify()
+	notifyErrorCommandC     chan notifyErrorCommand  // NotifyError()
+	notifyListenCommandC    chan notifyListenCommand // NotifyListen()
+	addPeersCommandC        chan []*net.TCPAddr      // AddPeers()
+	addTrackersCommandC     chan []tracker.Tracker   // AddTrackers()
+	notifyStatsCommandC     chan *statsSubscriber    // NotifyStats()
+	stopNotifyStatsCommandC chan *statsSubscriber    // NotifyStats() stop function
+	notifyEventCommandC     chan *eventSubscriber    // NotifyEvent()
+	stopNotifyEventCommandC chan *eventSubscriber    // NotifyEvent() stop function
+	readerPriorityCommandC  chan readerPriority      // FileReader
+
+	// Subscribers of NotifyEvent().
+	eventSubscribers map[*eventSubscriber]struct{}
+
+	// Pieces needed by open FileReaders, downloaded before other pieces.
+	readerPriorities map[*FileReader][]uint32
+
+	// Subscribers of NotifyStats() and the timer that fires when the next one is due.
+	statsSubscribers map[*statsSubscriber]struct{}
+	statsTimer       *time.Timer
+	statsTimerC      <-chan time.Time
+
+	// Last value of stats() returned from Stats(). Updated in the torrent loop.
+	statsSnapshot       atomic.Value
+	statsSnapshotStatus Status
 
 	// Trackers send announce responses to this channel.
-	addrsFromTrackers chan []*net.TCPAddr
+	addrsFromTrackers chan announcer.TrackerPeers
+
+	// Counters of peers and bytes for each tracker URL. See trackerTraffic.
+	trackerTraffic map[string]*trackerTraffic
+	// Connected peers whose addresses are received from trackers, mapped to tracker URL.
+	peerTrackers map[*peer.Peer]string
+	// URLs of trackers that are received from peers with tracker exchange extension.
+	texTrackers map[string]struct{}
+
+	// Announcers send errors to this channel.
+	announceErrorC chan *announcer.AnnounceError
 
 	// Keeps a list of peer addresses to connect.
 	addrList *addrlist.AddrList
@@ -185,6 +241,13 @@ type torrent struct {
 	dhtAnnouncer *announcer.DHTAnnouncer
 	dhtPeersC    chan []*net.TCPAddr
 
+	// Scrapes the trackers periodically for the size of the swarm.
+	scraper       *announcer.Scraper
+	scrapeResultC chan announcer.ScrapeResult
+	// Last result received from scraper and its time.
+	swarm          announcer.ScrapeResult
+	swarmScrapedAt time.Time
+
 	// List of peers in handshake state.
 	incomingHandshakers map[*incominghandshaker.IncomingHandshaker]struct{}
 	outgoingHandshakers map[*outgoinghandshaker.OutgoingHandshaker]struct{}
@@ -211,6 +274,13 @@ type torrent struct {
 	verifierResultC   chan *verifier.Verifier
 	checkedPieces     uint32
 
+	// Lock on the destination directory that prevents other processes from writing the same files.
+	dataLock *lockfile.Lockfile
+
+	// Workers that check MD5 sums of completed files.
+	md5Verifiers       map[*md5verifier.MD5Verifier]struct{}
+	md5VerifierResultC chan *md5verifier.MD5Verifier
+
 	// Metrics
 	downloadSpeed   metrics.Meter
 	uploadSpeed     metrics.Meter
@@ -219,15 +289,30 @@ type torrent struct {
 	bytesWasted     metrics.Counter
 	seededFor       metrics.Counter
 
+	// Speeds averaged over last few seconds.
+	currentDownloadSpeed ewmaSpeed
+	currentUploadSpeed   ewmaSpeed
+	speedTicker          *time.Ticker
+
 	seedDurationUpdatedAt time.Time
 	seedDurationTicker    *time.Ticker
 
-	// Holds connected peer IPs so we don't dial/accept multiple connections to/from same IP.
-	connectedPeerIPs map[string]struct{}
+	// Holds the number of connections for each peer IP so we don't dial/accept multiple connections to/from same IP.
+	// See Config.AllowSameIPConnections.
+	connectedPeerIPs map[string]int
+
+	// Addresses that we have failed to connect recently are not dialed again until their backoff expires.
+	dialFailures map[string]*dialFailure
 
 	// Peers that are sending corrupt data are banned.
 	bannedPeerIPs map[string]struct{}
 
+	// Keeps block hashes of corrupt pieces to find out the peer sending corrupt data.
+	smartBan *smartban.SmartBan
+
+	// Number of pieces that failed hash check since the torrent is started. See Config.MaxPieceHashFailures.
+	hashFailures int
+
 	// A signal sent to run() loop when announcers are stopped.
 	announcersStoppedC chan struct{}
 
@@ -248,6 +333,26 @@ type torrent struct {
 	webseedRetryC          chan *webseedsource.WebseedSource
 	webseedActiveDownloads int
 
+	// Pieces copied from other torrents in Session. See Config.PieceDedup.
+	dedupResultC *suspendchan.Chan[*dedupPiece]
+	dedupStopC   chan struct{}
+
+	// True when the torrent is paused. Pieces are not downloaded or uploaded while paused.
+	paused bool
+
+	// Priority of the torrent in Session. Must be accessed atomically.
+	priority int32
+
+	// 1 if the torrent is waiting in Session queue. Must be accessed atomically.
+	queued int32
+
+	// Speed limiters of Session that are weighted by the priority of this torrent.
+	bucketDownload *speedlimit.Limiter
+	bucketUpload   *speedlimit.Limiter
+
+	// Settings from Config.TorrentOverrides for this torrent.
+	override TorrentOverride
+
 	// Set to true when manual verification is requested
 	doVerify bool
 
@@ -257,6 +362,9 @@ type torrent struct {
 	// If true, the torrent is stopped automatically when all metadata pieces are downloaded.
 	stopAfterMetadata bool
 
+	// If true, missing pieces are not downloaded and the torrent only uploads the pieces in storage.
+	seedOnly bool
+
 	// True means that completeCmd has run before.
 	completeCmdRun bool
 
@@ -318,11 +426,13 @@ func newTorrent2(
 		completeC:                 make(chan struct{}),
 		completeMetadataC:         make(chan struct{}),
 		closeC:                    make(chan struct{}),
-		startCommandC:             make(chan struct{}),
-		stopCommandC:              make(chan struct{}),
+		startCommandC:             make(chan chan struct{}),
+		stopCommandC:              make(chan chan struct{}),
+		pauseCommandC:             make(chan chan struct{}),
+		resumeCommandC:            make(chan chan struct{}),
 		announceCommandC:          make(chan struct{}),
+		forceAnnounceCommandC:     make(chan struct{}),
 		verifyCommandC:            make(chan struct{}),
-		statsCommandC:             make(chan statsRequest),
 		trackersCommandC:          make(chan trackersRequest),
 		peersCommandC:             make(chan peersRequest),
 		webseedsCommandC:          make(chan webseedsRequest),
@@ -330,7 +440,19 @@ func newTorrent2(
 		notifyListenCommandC:      make(chan notifyListenCommand),
 		addPeersCommandC:          make(chan []*net.TCPAddr),
 		addTrackersCommandC:       make(chan []tracker.Tracker),
-		addrsFromTrackers:         make(chan []*net.TCPAddr),
+		notifyStatsCommandC:       make(chan *statsSubscriber),
+		stopNotifyStatsCommandC:   make(chan *statsSubscriber),
+		statsSubscribers:          make(map[*statsSubscriber]struct{}),
+		notifyEventCommandC:       make(chan *eventSubscriber),
+		stopNotifyEventCommandC:   make(chan *eventSubscriber),
+		eventSubscribers:          make(map[*eventSubscriber]struct{}),
+		readerPriorityCommandC:    make(chan readerPriority),
+		readerPriorities:          make(map[*FileReader][]uint32),
+		addrsFromTrackers:         make(chan announcer.TrackerPeers),
+		trackerTraffic:            make(map[string]*trackerTraffic),
+		peerTrackers:              make(map[*peer.Peer]string),
+		texTrackers:               make(map[string]struct{}),
+		announceErrorC:            make(chan *announcer.AnnounceError),
 		peerIDs:                   make(map[[20]byte]struct{}),
 		incomingConnC:             make(chan net.Conn),
 		sKeyHash:                  mse.HashSKey(ih[:]),
@@ -343,9 +465,14 @@ func newTorrent2(
 		allocatorResultC:          make(chan *allocator.Allocator),
 		verifierProgressC:         make(chan verifier.Progress),
 		verifierResultC:           make(chan *verifier.Verifier),
-		connectedPeerIPs:          make(map[string]struct{}),
+		md5Verifiers:              make(map[*md5verifier.MD5Verifier]struct{}),
+		md5VerifierResultC:        make(chan *md5verifier.MD5Verifier),
+		connectedPeerIPs:          make(map[string]int),
+		dialFailures:              make(map[string]*dialFailure),
 		bannedPeerIPs:             make(map[string]struct{}),
+		smartBan:                  smartban.New(maxCorruptPieces),
 		announcersStoppedC:        make(chan struct{}),
+		scrapeResultC:             make(chan announcer.ScrapeResult),
 		dhtPeersC:                 make(chan []*net.TCPAddr, 1),
 		externalIP:                externalip.FirstExternalIP(),
 		downloadSpeed:             metrics.NilMeter{},
@@ -359,6 +486,7 @@ func newTorrent2(
 		webseedSources:            ws,
 		webseedPieceResultC:       suspendchan.New[*urldownloader.PieceResult](0),
 		webseedRetryC:             make(chan *webseedsource.WebseedSource),
+		dedupResultC:              suspendchan.New[*dedupPiece](0),
 		doneC:                     make(chan struct{}),
 		stopAfterDownload:         stopAfterDownload,
 		stopAfterMetadata:         stopAfterMetadata,
@@ -367,6 +495,11 @@ func newTorrent2(
 	if len(t.webseedSources) > s.config.WebseedMaxSources {
 		t.webseedSources = t.webseedSources[:10]
 	}
+	t.override = s.torrentOverrides[hex.EncodeToString(infoHash)]
+	t.bucketDownload = s.bucketDownload.WithPriority(&t.priority)
+	t.bucketUpload = s.bucketUpload.WithPriority(&t.priority)
+	t.bucketDownload.SetLimit(t.override.SpeedLimitDownload)
+	t.bucketUpload.SetLimit(t.override.SpeedLimitUpload)
 	t.bytesDownloaded.Inc(stats.BytesDownloaded)
 	t.bytesUploaded.Inc(stats.BytesUploaded)
 	t.bytesWasted.Inc(stats.BytesWasted)
@@ -378,6 +511,8 @@ func newTorrent2(
 	t.addrList = addrlist.New(cfg.MaxPeerAddresses, blocklistForOutgoingConns, port, &t.externalIP)
 	if t.info != nil {
 		t.piecePool = bufferpool.New(int(t.info.PieceLength))
+		// There is no metadata to download.
+		close(t.completeMetadataC)
 	}
 	n := t.copyPeerIDPrefix()
 	_, err := rand.Read(t.peerID[n:])
@@ -385,6 +520,7 @@ func newTorrent2(
 		return nil, err
 	}
 	t.unchoker = unchoker.New(cfg.UnchokedPeers, cfg.OptimisticUnchokedPeers)
+	t.updateStatsSnapshot(true)
 	go t.run()
 	return t, nil
 }
@@ -393,7 +529,7 @@ func (t *torrent) copyPeerIDPrefix() int {
 	if t.info != nil && t.info.Private {
 		return copy(t.peerID[:], t.session.config.PrivatePeerIDPrefix)
 	}
-	return copy(t.peerID[:], publicPeerIDPrefix)
+	return copy(t.peerID[:], t.session.config.PublicPeerIDPrefix)
 }
 
 func (t *torrent) getPeersForUnchoker() []unchoker.Peer {
@@ -415,6 +551,8 @@ func (t *torrent) InfoHash() []byte {
 }
 
 func (t *torrent) RootDirectory() string {
+	t.mStorage.RLock()
+	defer t.mStorage.RUnlock()
 	return t.storage.RootDir()
 }
 
@@ -433,13 +571,17 @@ func (t *torrent) FilePaths() ([]string, error) {
 }
 
 func (t *torrent) Files() ([]File, error) {
+	// Called from outside of the run loop.
+	// Pieces and bitfield are only replaced while holding mBitfield.
+	t.mBitfield.RLock()
+	defer t.mBitfield.RUnlock()
 	if t.info == nil || len(t.pieces) == 0 {
 		return nil, errors.New("torrent not running so file stats unavailable")
 	}
 
 	fileComp := make(map[string]int64)
-	for _, p := range t.pieces {
-		if p.Done {
+	for i, p := range t.pieces {
+		if t.bitfield != nil && t.bitfield.Test(uint32(i)) {
 			for _, d := range p.Data {
 				if !d.Padding {
 					fileComp[d.Name] += d.Length
@@ -449,6 +591,7 @@ func (t *torrent) Files() ([]File, error) {
 	}
 
 	var files []File
+	var offset int64
 	for _, f := range t.info.Files {
 		if !f.Padding {
 			files = append(files,
@@ -458,8 +601,11 @@ func (t *torrent) Files() ([]File, error) {
 						BytesTotal:     f.Length,
 						BytesCompleted: fileComp[f.Path],
 					},
+					torrent: t,
+					offset:  offset,
 				})
 		}
+		offset += f.Length
 	}
 
 	return files, nil
@@ -473,6 +619,10 @@ type FileStats struct {
 type File struct {
 	path  string
 	stats FileStats
+
+	torrent *torrent
+	// Position of the file in the torrent.
+	offset int64
 }
 
 func (f File) Path() string {
@@ -1,7 +1,7 @@
 package torrent
 
 import (
-	"fmt"
+	"errors"
 
 	"github.com/cenkalti/rain/internal/allocator"
 	"github.com/cenkalti/rain/internal/bitfield"
@@ -16,7 +16,7 @@ func (t *torrent) handleAllocationDone(al *allocator.Allocator) {
 	t.allocator = nil
 
 	if al.Error != nil {
-		t.stop(fmt.Errorf("file allocation error: %s", al.Error))
+		t.stop(&StorageError{Op: "file allocation error", Err: al.Error})
 		return
 	}
 
@@ -30,10 +30,12 @@ func (t *torrent) handleAllocationDone(al *allocator.Allocator) {
 	}
 	pieces := piece.NewPieces(t.info, t.files)
 	if len(pieces) == 0 {
-		t.stop(fmt.Errorf("torrent has zero pieces"))
+		t.stop(metainfoError{errors.New("torrent has zero pieces")})
 		return
 	}
+	t.mBitfield.Lock()
 	t.pieces = pieces
+	t.mBitfield.Unlock()
 
 	for pe := range t.peers {
 		pe.GenerateAndSendAllowedFastMessages(t.session.config.AllowedFastSet, t.info.NumPieces, t.infoHash, t.pieces)
@@ -43,16 +45,23 @@ func (t *torrent) handleAllocationDone(al *allocator.Allocator) {
 		panic("piece picker exists")
 	}
 	t.piecePicker = piecepicker.New(t.pieces, t.session.config.EndgameMaxDuplicateDownloads, t.webseedSources)
+	t.setPiecePriority()
 
 	for pe := range t.peers {
 		pe.Bitfield = bitfield.New(t.info.NumPieces)
 	}
 
 	// If we already have bitfield from resume db, skip verification and start downloading.
-	if t.bitfield != nil && !al.HasMissing {
+	// Merkle torrents are always verified because missing pieces cannot be downloaded.
+	if t.bitfield != nil && !al.HasMissing && t.info.RootHash == nil {
 		for i := uint32(0); i < t.bitfield.Len(); i++ {
 			t.pieces[i].Done = t.bitfield.Test(i)
 		}
+		err := t.notifyCompletedFiles()
+		if err != nil {
+			t.stop(err)
+			return
+		}
 		if t.checkCompletion() && t.stopAfterDownload {
 			t.stopAndSetStoppedOnComplete()
 			return
@@ -66,7 +75,7 @@ func (t *torrent) handleAllocationDone(al *allocator.Allocator) {
 	}
 
 	// No need to verify files if they didn't exist when we create them.
-	if !al.HasExisting {
+	if !al.HasExisting && t.info.RootHash == nil {
 		t.mBitfield.Lock()
 		t.bitfield = bitfield.New(t.info.NumPieces)
 		t.mBitfield.Unlock()
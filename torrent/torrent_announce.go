@@ -13,6 +13,9 @@ func (t *torrent) handleNewTrackers(trackers []tracker.Tracker) {
 		for _, tr := range trackers {
 			t.startNewAnnouncer(tr)
 		}
+		// Restart the scraper for including new trackers.
+		t.stopScraper()
+		t.startScraper()
 	}
 }
 
@@ -20,9 +23,15 @@ func (t *torrent) announcerFields() tracker.Torrent {
 	tr := tracker.Torrent{
 		InfoHash:        t.infoHash,
 		PeerID:          t.peerID,
+		Key:             t.session.announceKey,
 		Port:            t.port,
 		BytesDownloaded: t.bytesDownloaded.Count(),
 		BytesUploaded:   t.bytesUploaded.Count(),
+		PartialSeed:     t.seedOnly,
+		IP:              t.session.announceIP,
+	}
+	if t.session.config.TrackerAnnouncePort != 0 {
+		tr.Port = t.session.config.TrackerAnnouncePort
 	}
 	// t.bytesComplete() uses t.bitfied for calculation.
 	t.mBitfield.RLock()
@@ -30,7 +39,7 @@ func (t *torrent) announcerFields() tracker.Torrent {
 		// Some trackers don't send any peer address if don't tell we have missing bytes.
 		tr.BytesLeft = math.MaxUint32
 	} else {
-		tr.BytesLeft = t.info.Length - t.bytesComplete()
+		tr.BytesLeft = t.bytesTotal() - t.bytesComplete()
 	}
 	t.mBitfield.RUnlock()
 	return tr
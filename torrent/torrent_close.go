@@ -12,16 +12,27 @@ import (
 var errClosed = errors.New("torrent is closed")
 
 func (t *torrent) close() {
+	// handleStopped is not going to be called after close, so the event must be sent from here.
+	wasRunning := t.status() != Stopped
+
 	// Stop if running.
 	t.stop(errClosed)
 
-	// Maybe we are in "Stopping" state. Close "stopped" event announcer.
+	// Maybe we are in "Stopping" state. Wait for "stopped" event to be sent to trackers.
+	// StopAnnouncer gives up after TrackerStopTimeout.
 	if t.stoppedEventAnnouncer != nil {
+		<-t.announcersStoppedC
 		t.stoppedEventAnnouncer.Close()
 	}
 
 	t.downloadSpeed.Stop()
 	t.uploadSpeed.Stop()
+
+	t.closeStatsSubscribers()
+	if wasRunning {
+		t.publishEvent(Event{Type: EventStopped, Error: t.lastError})
+	}
+	t.closeEventSubscribers()
 }
 
 func (t *torrent) closePeer(pe *peer.Peer) {
@@ -36,7 +47,8 @@ func (t *torrent) closePeer(pe *peer.Peer) {
 	delete(t.incomingPeers, pe)
 	delete(t.outgoingPeers, pe)
 	delete(t.peerIDs, pe.ID)
-	delete(t.connectedPeerIPs, pe.Conn.IP())
+	t.removeTrackerPeer(pe)
+	t.removePeerIP(pe.Conn.IP())
 	if t.piecePicker != nil {
 		t.piecePicker.HandleDisconnect(pe)
 	}
@@ -44,6 +56,7 @@ func (t *torrent) closePeer(pe *peer.Peer) {
 	t.pexDropPeer(pe.Addr())
 	t.dialAddresses()
 	t.session.metrics.Peers.Dec(1)
+	t.publishEvent(Event{Type: EventPeerDisconnected, Peer: pe.Addr()})
 }
 
 func (t *torrent) closeWebseedDownloader(src *webseedsource.WebseedSource) {
@@ -69,6 +82,7 @@ func (t *torrent) closePieceDownloader(pd *piecedownloader.PieceDownloader) {
 }
 
 func (t *torrent) closeInfoDownloader(id *infodownloader.InfoDownloader) {
+	id.Close()
 	delete(t.infoDownloaders, id.Peer.(*peer.Peer))
 	delete(t.infoDownloadersSnubbed, id.Peer.(*peer.Peer))
 }
@@ -1,20 +1,23 @@
 package torrent
 
 import (
+	"context"
 	"errors"
 	"net"
 	"time"
 
-	"github.com/cenkalti/rain/internal/magnet"
-	"github.com/cenkalti/rain/internal/metainfo"
 	"github.com/cenkalti/rain/internal/tracker"
+	"github.com/cenkalti/rain/magnet"
+	"github.com/cenkalti/rain/metainfo"
 )
 
 // Start downloading.
 // After all files are downloaded, seeding continues until the torrent is stopped.
 func (t *torrent) Start() {
+	done := make(chan struct{})
 	select {
-	case t.startCommandC <- struct{}{}:
+	case t.startCommandC <- done:
+		<-done
 	case <-t.closeC:
 	}
 }
@@ -22,8 +25,31 @@ func (t *torrent) Start() {
 // Stop downloading and seeding.
 // Stop closes all peer connections.
 func (t *torrent) Stop() {
+	done := make(chan struct{})
 	select {
-	case t.stopCommandC <- struct{}{}:
+	case t.stopCommandC <- done:
+		<-done
+	case <-t.closeC:
+	}
+}
+
+// Pause downloading and uploading pieces.
+// Unlike Stop, peer connections and tracker announces are kept alive.
+func (t *torrent) Pause() {
+	done := make(chan struct{})
+	select {
+	case t.pauseCommandC <- done:
+		<-done
+	case <-t.closeC:
+	}
+}
+
+// Resume downloading and uploading pieces after Pause.
+func (t *torrent) Resume() {
+	done := make(chan struct{})
+	select {
+	case t.resumeCommandC <- done:
+		<-done
 	case <-t.closeC:
 	}
 }
@@ -36,6 +62,14 @@ func (t *torrent) Announce() {
 	}
 }
 
+// ForceAnnounce announces the torrent immediately, ignoring the min interval.
+func (t *torrent) ForceAnnounce() {
+	select {
+	case t.forceAnnounceCommandC <- struct{}{}:
+	case <-t.closeC:
+	}
+}
+
 // Verify pieces by checking files.
 func (t *torrent) Verify() {
 	select {
@@ -77,6 +111,46 @@ func (t *torrent) NotifyError() <-chan error {
 	}
 }
 
+// NotifyStats returns a channel that receives the stats of the torrent at every interval.
+// If the receiver is slow, some values are dropped.
+// The returned function must be called to stop receiving stats. The channel is closed after stop or when the torrent is closed.
+func (t *torrent) NotifyStats(interval time.Duration) (<-chan Stats, func()) {
+	if interval < minStatsInterval {
+		interval = minStatsInterval
+	}
+	sub := &statsSubscriber{C: make(chan Stats, 1), interval: interval}
+	select {
+	case t.notifyStatsCommandC <- sub:
+	case <-t.closeC:
+		close(sub.C)
+	}
+	stop := func() {
+		select {
+		case t.stopNotifyStatsCommandC <- sub:
+		case <-t.closeC:
+		}
+	}
+	return sub.C, stop
+}
+
+// NotifyEvent returns a channel that receives events of the torrent.
+// The returned function must be called to stop receiving events. The channel is closed after stop or when the torrent is closed.
+func (t *torrent) NotifyEvent() (<-chan Event, func()) {
+	sub := &eventSubscriber{C: make(chan Event, eventBufferSize)}
+	select {
+	case t.notifyEventCommandC <- sub:
+	case <-t.closeC:
+		close(sub.C)
+	}
+	stop := func() {
+		select {
+		case t.stopNotifyEventCommandC <- sub:
+		case <-t.closeC:
+		}
+	}
+	return sub.C, stop
+}
+
 type notifyListenCommand struct {
 	portCC chan chan int
 }
@@ -120,36 +194,45 @@ func (t *torrent) Torrent() ([]byte, error) {
 func (t *torrent) getTieredTrackers() [][]string {
 	var trackers [][]string
 	for _, tr := range t.trackers {
-		if tier, ok := tr.(*tracker.Tier); ok {
-			urls := make([]string, len(tier.Trackers))
-			for i, tt := range tier.Trackers {
-				urls[i] = tt.URL()
+		switch tr := tr.(type) {
+		case *tracker.TierList:
+			for _, tier := range tr.Tiers {
+				trackers = append(trackers, tierURLs(tier))
 			}
-			trackers = append(trackers, urls)
-		} else {
+		case *tracker.Tier:
+			trackers = append(trackers, tierURLs(tr))
+		default:
 			trackers = append(trackers, []string{tr.URL()})
 		}
 	}
 	return trackers
 }
 
-type statsRequest struct {
-	Response chan Stats
+func tierURLs(tier *tracker.Tier) []string {
+	trackers := tier.List()
+	urls := make([]string, len(trackers))
+	for i, tr := range trackers {
+		urls[i] = tr.URL()
+	}
+	return urls
 }
 
 // Stats returns statistics about the Torrent.
+// The value is read from the snapshot that is updated by the torrent loop, so it does not wait for the loop.
 func (t *torrent) Stats() Stats {
-	var stats Stats
-	req := statsRequest{Response: make(chan Stats, 1)}
-	select {
-	case t.statsCommandC <- req:
-	case <-t.closeC:
-	}
+	stats, _ := t.StatsContext(context.Background())
+	return stats
+}
+
+func (t *torrent) StatsContext(ctx context.Context) (Stats, error) {
 	select {
-	case stats = <-req.Response:
 	case <-t.closeC:
+		return Stats{}, ErrTorrentRemoved
+	case <-ctx.Done():
+		return Stats{}, ctx.Err()
+	default:
 	}
-	return stats
+	return t.loadStatsSnapshot(), nil
 }
 
 func (t *torrent) AddPeers(peers []*net.TCPAddr) {
@@ -200,6 +283,15 @@ type Tracker struct {
 	Warning      string
 	LastAnnounce time.Time
 	NextAnnounce time.Time
+	// Number of consecutive failed announces to the tracker that has returned the last error.
+	Failures int
+	// Number of peer addresses received from the tracker since the torrent is loaded.
+	PeersReceived int
+	// Number of connections made to the peer addresses received from the tracker.
+	PeersConnected int
+	// Number of piece bytes transferred with the peers received from the tracker.
+	BytesDownloaded int64
+	BytesUploaded   int64
 }
 
 type trackersRequest struct {
@@ -207,17 +299,27 @@ type trackersRequest struct {
 }
 
 func (t *torrent) Trackers() []Tracker {
-	var trackers []Tracker
+	trackers, _ := t.TrackersContext(context.Background())
+	return trackers
+}
+
+func (t *torrent) TrackersContext(ctx context.Context) ([]Tracker, error) {
 	req := trackersRequest{Response: make(chan []Tracker, 1)}
 	select {
 	case t.trackersCommandC <- req:
 	case <-t.closeC:
+		return nil, ErrTorrentRemoved
+	case <-ctx.Done():
+		return nil, ctx.Err()
 	}
 	select {
-	case trackers = <-req.Response:
+	case trackers := <-req.Response:
+		return trackers, nil
 	case <-t.closeC:
+		return nil, ErrTorrentRemoved
+	case <-ctx.Done():
+		return nil, ctx.Err()
 	}
-	return trackers
 }
 
 // Peer is a remote peer that is connected and completed protocol handshake.
@@ -238,6 +340,15 @@ type Peer struct {
 	EncryptedStream    bool
 	DownloadSpeed      int
 	UploadSpeed        int
+	// Number of piece bytes transferred over this connection.
+	BytesDownloaded int64
+	BytesUploaded   int64
+	// True if the peer has connected to us.
+	Incoming bool
+	// Percentage of pieces that the peer has.
+	Progress int
+	// URL of the tracker that the peer address is received from. Empty if Source is not SourceTracker.
+	Tracker string
 }
 
 // PeerSource indicates that how the peer is found.
@@ -254,8 +365,30 @@ const (
 	SourceIncoming
 	// SourceManual indicates that the peer is added manually via AddPeer method.
 	SourceManual
+	// SourceLSD indicates that the peer is found with Local Service Discovery.
+	SourceLSD
 )
 
+// String returns the name of the source in upper case.
+func (s PeerSource) String() string {
+	switch s {
+	case SourceTracker:
+		return "TRACKER"
+	case SourceDHT:
+		return "DHT"
+	case SourcePEX:
+		return "PEX"
+	case SourceIncoming:
+		return "INCOMING"
+	case SourceManual:
+		return "MANUAL"
+	case SourceLSD:
+		return "LSD"
+	default:
+		panic("unhandled peer source")
+	}
+}
+
 type peersRequest struct {
 	Response chan []Peer
 }
@@ -4,41 +4,71 @@ import (
 	"net"
 
 	"github.com/cenkalti/rain/internal/handshaker/incominghandshaker"
+	"github.com/cenkalti/rain/internal/peer"
 )
 
 func (t *torrent) handleNewConnection(conn net.Conn) {
-	if len(t.incomingHandshakers)+len(t.incomingPeers) >= t.session.config.MaxPeerAccept {
-		t.log.Debugln("peer limit reached, rejecting peer", conn.RemoteAddr().String())
-		conn.Close()
-		return
-	}
-	ip := conn.RemoteAddr().(*net.TCPAddr).IP
+	addr := conn.RemoteAddr().(*net.TCPAddr)
+	ip := addr.IP
 	ipstr := ip.String()
 	if t.session.config.BlocklistEnabledForIncomingConnections && t.session.blocklist != nil && t.session.blocklist.Blocked(ip) {
 		t.log.Debugln("peer is blocked:", conn.RemoteAddr().String())
 		conn.Close()
 		return
 	}
-	if _, ok := t.connectedPeerIPs[ipstr]; ok {
-		t.log.Debugln("received duplicate connection from same IP: ", ipstr)
+	if _, ok := t.bannedPeerIPs[ipstr]; ok {
+		t.log.Debugln("connection attempt from banned IP: ", ipstr)
 		conn.Close()
 		return
 	}
-	if _, ok := t.bannedPeerIPs[ipstr]; ok {
-		t.log.Debugln("connection attempt from banned IP: ", ipstr)
+	var replaced *peer.Peer
+	if len(t.incomingHandshakers)+len(t.incomingPeers) >= t.maxPeerAccept() {
+		// Replace the connected peer with the lowest canonical priority (BEP 40) if the new one has a higher priority.
+		pe, priority := t.lowestPriorityPeer(t.incomingPeers)
+		if pe == nil || len(t.incomingHandshakers)+len(t.incomingPeers) > t.maxPeerAccept() || t.peerPriority(addr) <= priority {
+			t.log.Debugln("peer limit reached, rejecting peer", addr.String())
+			conn.Close()
+			return
+		}
+		replaced = pe
+	}
+	if !t.addPeerIP(ipstr) {
+		t.log.Debugln("received duplicate connection from same IP: ", ipstr)
 		conn.Close()
 		return
 	}
+	if replaced != nil {
+		replaced.Logger().Debugln("disconnecting peer with lower priority than", addr.String())
+		t.closePeer(replaced)
+	}
 	h := incominghandshaker.New(conn)
 	t.incomingHandshakers[h] = struct{}{}
-	t.connectedPeerIPs[ipstr] = struct{}{}
 	go h.Run(
 		t.peerID,
 		t.getSKey,
 		t.checkInfoHash,
 		t.incomingHandshakerResultC,
 		t.session.config.PeerHandshakeTimeout,
-		t.session.extensions,
+		t.extensions(),
 		t.session.config.ForceIncomingEncryption,
 	)
 }
+
+// addPeerIP counts a new connection with the IP.
+// Returns false if there is already a connection with the IP and Config.AllowSameIPConnections is false.
+func (t *torrent) addPeerIP(ip string) bool {
+	if t.connectedPeerIPs[ip] > 0 && !t.session.config.AllowSameIPConnections {
+		return false
+	}
+	t.connectedPeerIPs[ip]++
+	return true
+}
+
+// removePeerIP must be called when a connection counted with addPeerIP is closed.
+func (t *torrent) removePeerIP(ip string) {
+	if t.connectedPeerIPs[ip] <= 1 {
+		delete(t.connectedPeerIPs, ip)
+	} else {
+		t.connectedPeerIPs[ip]--
+	}
+}
@@ -0,0 +1,26 @@
+package torrent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSameIPConnections(t *testing.T) {
+	tor := &torrent{
+		session:          &Session{config: DefaultConfig},
+		connectedPeerIPs: make(map[string]int),
+	}
+	assert.True(t, tor.addPeerIP("1.2.3.4"))
+	assert.False(t, tor.addPeerIP("1.2.3.4"))
+	assert.True(t, tor.addPeerIP("5.6.7.8"))
+	tor.removePeerIP("1.2.3.4")
+	assert.True(t, tor.addPeerIP("1.2.3.4"))
+
+	tor.session.config.AllowSameIPConnections = true
+	assert.True(t, tor.addPeerIP("1.2.3.4"))
+	assert.Equal(t, 2, tor.connectedPeerIPs["1.2.3.4"])
+	tor.removePeerIP("1.2.3.4")
+	tor.removePeerIP("1.2.3.4")
+	assert.NotContains(t, tor.connectedPeerIPs, "1.2.3.4")
+}
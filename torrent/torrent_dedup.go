@@ -0,0 +1,97 @@
+package torrent
+
+import (
+	"bytes"
+	"crypto/sha1" // nolint: gosec
+
+	"github.com/cenkalti/rain/internal/bufferpool"
+	"github.com/cenkalti/rain/internal/piecewriter"
+	"github.com/cenkalti/rain/metainfo"
+)
+
+// dedupPiece is the data of a piece that is copied from another torrent in Session.
+type dedupPiece struct {
+	Index  uint32
+	Buffer bufferpool.Buffer
+	Source *dedupSource
+}
+
+// dedupSource is the source of the piece written by the piece writer when the piece is copied from another torrent.
+type dedupSource struct {
+	TorrentID string
+}
+
+// startDedup starts copying the missing pieces that exist in other torrents in Session.
+// It is run once every time the torrent starts downloading.
+func (t *torrent) startDedup() {
+	if t.session.pieceIndex == nil || t.dedupStopC != nil || t.info == nil || t.bitfield == nil {
+		return
+	}
+	var missing []uint32
+	for i := uint32(0); i < t.bitfield.Len(); i++ {
+		if !t.bitfield.Test(i) {
+			missing = append(missing, i)
+		}
+	}
+	t.dedupStopC = make(chan struct{})
+	go t.runDedup(t.info, missing, t.piecePool, t.dedupResultC.SendC(), t.dedupStopC)
+}
+
+func (t *torrent) stopDedup() {
+	if t.dedupStopC != nil {
+		close(t.dedupStopC)
+		t.dedupStopC = nil
+	}
+}
+
+func (t *torrent) runDedup(info *metainfo.Info, missing []uint32, pool *bufferpool.Pool, resultC chan *dedupPiece, stopC chan struct{}) {
+	for _, index := range missing {
+		hash := info.PieceHash(index)
+		for _, ref := range t.session.pieceIndex.Find(hash, t) {
+			select {
+			case <-stopC:
+				return
+			default:
+			}
+			data, err := ref.torrent.ReadPiece(ref.index, false)
+			if err != nil {
+				continue
+			}
+			sum := sha1.Sum(data) // nolint: gosec
+			if !bytes.Equal(sum[:], hash) {
+				continue
+			}
+			buf := pool.Get(len(data))
+			copy(buf.Data, data)
+			select {
+			case resultC <- &dedupPiece{Index: index, Buffer: buf, Source: &dedupSource{TorrentID: ref.torrent.id}}:
+			case <-stopC:
+				buf.Release()
+				return
+			}
+			break
+		}
+	}
+}
+
+func (t *torrent) handleDedupPiece(dp *dedupPiece) {
+	if t.pieces == nil || t.bitfield == nil || t.bitfield.Test(dp.Index) {
+		dp.Buffer.Release()
+		return
+	}
+	piece := &t.pieces[dp.Index]
+	if piece.Writing || piece.Done {
+		dp.Buffer.Release()
+		return
+	}
+	t.log.Debugf("copying piece #%d from torrent %s", dp.Index, dp.Source.TorrentID)
+	piece.Writing = true
+
+	// Prevent receiving piece messages to avoid more than 1 write per torrent.
+	t.pieceMessagesC.Suspend()
+	t.webseedPieceResultC.Suspend()
+	t.dedupResultC.Suspend()
+
+	pw := piecewriter.New(piece, dp.Source, dp.Buffer)
+	t.startPieceWriter(pw)
+}
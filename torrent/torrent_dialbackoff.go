@@ -0,0 +1,57 @@
+package torrent
+
+import (
+	"net"
+	"time"
+)
+
+const (
+	// Wait duration before dialing an address again after the first failed attempt.
+	// Doubled after each consecutive failure up to dialBackoffMax.
+	dialBackoffMin = 30 * time.Second
+	dialBackoffMax = 30 * time.Minute
+)
+
+type dialFailure struct {
+	count   int
+	retryAt time.Time
+}
+
+// canDial returns false if the address has failed recently and its backoff has not expired yet.
+func (t *torrent) canDial(addr *net.TCPAddr, now time.Time) bool {
+	f, ok := t.dialFailures[addr.String()]
+	return !ok || !now.Before(f.retryAt)
+}
+
+// addDialFailure records a failed connection attempt to addr and schedules the next allowed attempt.
+func (t *torrent) addDialFailure(addr *net.TCPAddr, now time.Time) {
+	key := addr.String()
+	f, ok := t.dialFailures[key]
+	if !ok {
+		t.pruneDialFailures(now)
+		f = &dialFailure{}
+		t.dialFailures[key] = f
+	}
+	f.count++
+	backoff := dialBackoffMin
+	for i := 1; i < f.count && backoff < dialBackoffMax; i++ {
+		backoff *= 2
+	}
+	if backoff > dialBackoffMax {
+		backoff = dialBackoffMax
+	}
+	f.retryAt = now.Add(backoff)
+}
+
+// pruneDialFailures removes the records of addresses that may be dialed again
+// so the map does not grow larger than the address list.
+func (t *torrent) pruneDialFailures(now time.Time) {
+	if len(t.dialFailures) < t.session.config.MaxPeerAddresses {
+		return
+	}
+	for key, f := range t.dialFailures {
+		if now.After(f.retryAt) {
+			delete(t.dialFailures, key)
+		}
+	}
+}
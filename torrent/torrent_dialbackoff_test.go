@@ -0,0 +1,32 @@
+package torrent
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDialBackoff(t *testing.T) {
+	tor := &torrent{
+		session:      &Session{config: DefaultConfig},
+		dialFailures: make(map[string]*dialFailure),
+	}
+	addr := &net.TCPAddr{IP: net.IPv4(1, 2, 3, 4), Port: 5}
+	now := time.Now()
+	assert.True(t, tor.canDial(addr, now))
+
+	tor.addDialFailure(addr, now)
+	assert.False(t, tor.canDial(addr, now))
+	assert.True(t, tor.canDial(addr, now.Add(dialBackoffMin)))
+
+	tor.addDialFailure(addr, now)
+	assert.False(t, tor.canDial(addr, now.Add(dialBackoffMin)))
+	assert.True(t, tor.canDial(addr, now.Add(2*dialBackoffMin)))
+
+	for i := 0; i < 20; i++ {
+		tor.addDialFailure(addr, now)
+	}
+	assert.Equal(t, now.Add(dialBackoffMax), tor.dialFailures[addr.String()].retryAt)
+}
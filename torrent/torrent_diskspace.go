@@ -0,0 +1,50 @@
+package torrent
+
+import (
+	"fmt"
+
+	"github.com/cenkalti/rain/internal/diskspace"
+)
+
+// bytesMissing returns the number of bytes that are not downloaded yet.
+// Unlike bytesComplete, it can be calculated before pieces are created.
+func (t *torrent) bytesMissing() int64 {
+	if t.bitfield == nil {
+		return t.info.Length
+	}
+	n := int64(t.info.PieceLength) * int64(t.info.NumPieces-t.bitfield.Count())
+	if !t.bitfield.Test(t.info.NumPieces - 1) {
+		lastPieceLength := t.info.Length - int64(t.info.PieceLength)*int64(t.info.NumPieces-1)
+		n -= int64(t.info.PieceLength) - lastPieceLength
+	}
+	return n
+}
+
+// checkDiskSpace returns an error if there is not enough free space on the disk for the remaining data of the torrent.
+func (t *torrent) checkDiskSpace() error {
+	if !t.session.config.DiskSpaceCheck || t.info == nil || t.completed || !t.localStorage() {
+		return nil
+	}
+	dir := t.storage.RootDir()
+	free, err := diskspace.Free(dir)
+	if err != nil {
+		t.log.Warningln("cannot check free disk space:", err.Error())
+		t.addRecentError(err)
+		return nil
+	}
+	need := t.bytesMissing()
+	if free < need {
+		return fmt.Errorf("not enough disk space in %s: %d bytes needed, %d bytes free", dir, need, free)
+	}
+	return nil
+}
+
+func (t *torrent) handleDiskSpaceTick() {
+	if t.status() != Downloading {
+		return
+	}
+	err := t.checkDiskSpace()
+	if err != nil {
+		t.stop(err)
+	}
+}
@@ -0,0 +1,70 @@
+package torrent
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/cenkalti/rain/internal/bitfield"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBytesMissing(t *testing.T) {
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+
+	f, err := os.Open(torrentFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	tor, err := s.AddTorrent(f, &AddTorrentOptions{Stopped: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := tor.torrent.info
+	bf := bitfield.New(info.NumPieces)
+	tor.torrent.bitfield = bf
+	assert.Equal(t, info.Length, tor.torrent.bytesMissing())
+
+	last := info.NumPieces - 1
+	bf.Set(last)
+	lastPieceLength := info.Length - int64(info.PieceLength)*int64(last)
+	assert.Equal(t, info.Length-lastPieceLength, tor.torrent.bytesMissing())
+
+	bf.Set(0)
+	assert.Equal(t, info.Length-lastPieceLength-int64(info.PieceLength), tor.torrent.bytesMissing())
+}
+
+func TestDiskSpaceCheck(t *testing.T) {
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+
+	f, err := os.Open(torrentFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	tor, err := s.AddTorrent(f, &AddTorrentOptions{Stopped: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The check is run on a copy that has no run loop, so the fields can be changed without a data race.
+	info := *tor.torrent.info
+	info.Length = 1 << 62
+	cfg := s.config
+	cfg.DiskSpaceCheck = true
+	tt := &torrent{
+		session: &Session{config: cfg},
+		info:    &info,
+		storage: tor.torrent.storage,
+		log:     tor.torrent.log,
+	}
+	err = tt.checkDiskSpace()
+	if assert.Error(t, err) {
+		assert.True(t, strings.HasPrefix(err.Error(), "not enough disk space"))
+	}
+
+	tt.session.config.DiskSpaceCheck = false
+	assert.NoError(t, tt.checkDiskSpace())
+}
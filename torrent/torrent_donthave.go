@@ -0,0 +1,58 @@
+package torrent
+
+import (
+	"github.com/cenkalti/rain/internal/peer"
+	"github.com/cenkalti/rain/internal/peerconn/peerwriter"
+	"github.com/cenkalti/rain/internal/peerprotocol"
+)
+
+func (t *torrent) handlePieceReadError(pe *peer.Peer, msg peerwriter.PieceReadError) {
+	if t.pieces == nil || msg.Index >= uint32(len(t.pieces)) {
+		return
+	}
+	t.log.Errorf("cannot read piece #%d: %s", msg.Index, msg.Error)
+	t.addRecentError(&StorageError{Op: "piece read error", Err: msg.Error})
+	t.dropPiece(msg.Index)
+}
+
+// dropPiece marks the piece as missing and notifies the peers that support lt_donthave extension,
+// so they do not request the piece from us anymore.
+func (t *torrent) dropPiece(i uint32) {
+	if t.bitfield == nil || !t.bitfield.Test(i) {
+		return
+	}
+	t.mBitfield.Lock()
+	t.bitfield.Clear(i)
+	t.mBitfield.Unlock()
+	t.pieces[i].Done = false
+	if err := t.writeBitfield(); err != nil {
+		t.stop(err)
+		return
+	}
+	for pe := range t.peers {
+		t.sendDontHave(pe, i)
+	}
+	if t.completed && t.info.RootHash == nil {
+		// Piece picker and peer addresses are released when the torrent is completed.
+		// Restart the torrent to download the missing piece again.
+		t.log.Infof("piece #%d is lost, restarting torrent", i)
+		t.completed = false
+		t.completeC = make(chan struct{})
+		t.stop(nil)
+		t.start()
+	}
+}
+
+func (t *torrent) sendDontHave(pe *peer.Peer, i uint32) {
+	if pe.ExtensionHandshake == nil {
+		return
+	}
+	id, ok := pe.ExtensionHandshake.M[peerprotocol.ExtensionKeyDontHave]
+	if !ok || id == 0 {
+		return
+	}
+	pe.SendMessage(peerprotocol.ExtensionMessage{
+		ExtendedMessageID: id,
+		Payload:           peerprotocol.ExtensionDontHaveMessage{Index: i},
+	})
+}
@@ -0,0 +1,34 @@
+package torrent
+
+import "time"
+
+// Max number of non-fatal errors kept in the history of a torrent.
+const maxRecentErrors = 20
+
+// RecentError is a non-fatal error that has occurred while the torrent is running,
+// such as a failed announce to a tracker or a failed write to the resume database.
+type RecentError struct {
+	Time  time.Time
+	Error error
+}
+
+// addRecentError adds err into the error history of the torrent. The oldest error is dropped if the history is full.
+func (t *torrent) addRecentError(err error) {
+	if len(t.recentErrors) == maxRecentErrors {
+		copy(t.recentErrors, t.recentErrors[1:])
+		t.recentErrors = t.recentErrors[:maxRecentErrors-1]
+	}
+	t.recentErrors = append(t.recentErrors, RecentError{Time: time.Now(), Error: err})
+}
+
+// handleNotifyError returns a new channel that receives the error when the torrent stops.
+// Returns nil if the torrent is not running.
+func (t *torrent) handleNotifyError(cmd notifyErrorCommand) {
+	if t.errC == nil {
+		cmd.errCC <- nil
+		return
+	}
+	ch := make(chan error, 1)
+	t.errSubscribers = append(t.errSubscribers, ch)
+	cmd.errCC <- ch
+}
@@ -0,0 +1,44 @@
+package torrent
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotifyStopMultiple(t *testing.T) {
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+
+	tor, err := s.AddURI(torrentMagnetLink, &AddTorrentOptions{Stopped: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tor.torrent.trackers = nil
+	assert.NoError(t, tor.Start())
+	stop1 := tor.NotifyStop()
+	stop2 := tor.NotifyStop()
+	assert.NotNil(t, stop1)
+	assert.NotNil(t, stop2)
+	assert.NoError(t, tor.Stop())
+	for _, ch := range []<-chan error{stop1, stop2} {
+		select {
+		case err := <-ch:
+			assert.NoError(t, err)
+		case <-time.After(timeout):
+			t.Fatal("torrent is not stopped")
+		}
+	}
+}
+
+func TestRecentErrors(t *testing.T) {
+	var tor torrent
+	for i := 0; i < maxRecentErrors+5; i++ {
+		tor.addRecentError(fmt.Errorf("error %d", i))
+	}
+	assert.Len(t, tor.recentErrors, maxRecentErrors)
+	assert.EqualError(t, tor.recentErrors[0].Error, "error 5")
+	assert.EqualError(t, tor.recentErrors[maxRecentErrors-1].Error, fmt.Sprintf("error %d", maxRecentErrors+4))
+}
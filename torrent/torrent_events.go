@@ -0,0 +1,104 @@
+package torrent
+
+import (
+	"fmt"
+	"net"
+	"time"
+)
+
+// Number of events buffered for each subscriber. Events are dropped if the subscriber falls behind.
+const eventBufferSize = 256
+
+// EventType is the type of an Event.
+type EventType int
+
+// Types of the events sent to subscribers of Torrent.NotifyEvent.
+const (
+	// EventMetadataReceived is sent when the info dictionary is downloaded from peers.
+	EventMetadataReceived EventType = iota
+	// EventPieceCompleted is sent when a piece is downloaded and its hash is verified.
+	EventPieceCompleted
+	// EventPieceFailed is sent when a downloaded piece does not match its hash.
+	EventPieceFailed
+	// EventPeerConnected is sent when a new peer connection is established.
+	EventPeerConnected
+	// EventPeerDisconnected is sent when a peer connection is closed.
+	EventPeerDisconnected
+	// EventTrackerError is sent when an announce to a tracker fails.
+	EventTrackerError
+	// EventCompleted is sent when all pieces are downloaded.
+	EventCompleted
+	// EventStopped is sent when the torrent has stopped.
+	EventStopped
+)
+
+var eventTypeStrings = [...]string{
+	EventMetadataReceived: "MetadataReceived",
+	EventPieceCompleted:   "PieceCompleted",
+	EventPieceFailed:      "PieceFailed",
+	EventPeerConnected:    "PeerConnected",
+	EventPeerDisconnected: "PeerDisconnected",
+	EventTrackerError:     "TrackerError",
+	EventCompleted:        "Completed",
+	EventStopped:          "Stopped",
+}
+
+func (e EventType) String() string {
+	if e < 0 || int(e) >= len(eventTypeStrings) {
+		return fmt.Sprintf("EventType(%d)", int(e))
+	}
+	return eventTypeStrings[e]
+}
+
+// Event is a notable change in the state of a torrent.
+// Only the fields related with the Type are set.
+type Event struct {
+	Type EventType
+	Time time.Time
+	// Index of the piece for EventPieceCompleted and EventPieceFailed.
+	Piece uint32
+	// Address of the peer for EventPeerConnected and EventPeerDisconnected.
+	Peer net.Addr
+	// URL of the tracker for EventTrackerError.
+	Tracker string
+	// Error for EventTrackerError, and for EventStopped if the torrent has stopped with an error.
+	Error error
+}
+
+type eventSubscriber struct {
+	C chan Event
+}
+
+func (t *torrent) handleNotifyEvent(sub *eventSubscriber) {
+	t.eventSubscribers[sub] = struct{}{}
+}
+
+func (t *torrent) handleStopNotifyEvent(sub *eventSubscriber) {
+	if _, ok := t.eventSubscribers[sub]; !ok {
+		return
+	}
+	delete(t.eventSubscribers, sub)
+	close(sub.C)
+}
+
+// publishEvent sends the event to all subscribers without blocking the torrent loop.
+func (t *torrent) publishEvent(e Event) {
+	if len(t.eventSubscribers) == 0 {
+		return
+	}
+	e.Time = time.Now()
+	for sub := range t.eventSubscribers {
+		select {
+		case sub.C <- e:
+		default:
+			t.log.Debugf("event subscriber is not receiving, dropping %s event", e.Type)
+		}
+	}
+}
+
+func (t *torrent) closeEventSubscribers() {
+	for sub := range t.eventSubscribers {
+		close(sub.C)
+	}
+	t.eventSubscribers = nil
+}
@@ -0,0 +1,74 @@
+package torrent
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotifyEvent(t *testing.T) {
+	s, closeSession := newTestSession(t)
+	closed := false
+	defer func() {
+		if !closed {
+			closeSession()
+		}
+	}()
+
+	f, err := os.Open(torrentFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	tor, err := s.AddTorrent(f, &AddTorrentOptions{Stopped: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tor.torrent.trackers = nil
+
+	eventsA, stopA := tor.NotifyEvent()
+	eventsB, stopB := tor.NotifyEvent()
+	defer stopB()
+
+	waitStopped := func(c <-chan Event) Event {
+		for {
+			select {
+			case e, ok := <-c:
+				if !ok {
+					t.Fatal("channel is closed")
+				}
+				if e.Type == EventStopped {
+					return e
+				}
+			case <-time.After(timeout):
+				t.Fatal("stopped event is not received")
+			}
+		}
+	}
+
+	// All subscribers receive the same events.
+	assert.NoError(t, tor.Start())
+	assert.NoError(t, tor.Stop())
+	assert.Nil(t, waitStopped(eventsA).Error)
+	assert.Nil(t, waitStopped(eventsB).Error)
+
+	// Channel is closed after stop.
+	stopA()
+	for range eventsA {
+	}
+
+	// Stopped event is sent when the torrent is closed while running.
+	assert.NoError(t, tor.Start())
+	closeSession()
+	closed = true
+	assert.Equal(t, errClosed, waitStopped(eventsB).Error)
+	_, ok := <-eventsB
+	assert.False(t, ok)
+}
+
+func TestEventTypeString(t *testing.T) {
+	assert.Equal(t, "PieceCompleted", EventPieceCompleted.String())
+	assert.Equal(t, "EventType(100)", EventType(100).String())
+}
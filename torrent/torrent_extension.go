@@ -0,0 +1,32 @@
+package torrent
+
+import (
+	"github.com/cenkalti/rain/internal/peer"
+	"github.com/cenkalti/rain/internal/peerprotocol"
+)
+
+// addCustomExtensions adds the extensions registered with Session.RegisterExtension to the extension handshake message.
+func (t *torrent) addCustomExtensions(msg *peerprotocol.ExtensionHandshakeMessage) {
+	for _, ext := range t.session.getCustomExtensions() {
+		msg.M[ext.name] = ext.id
+	}
+}
+
+// handleCustomExtensionHandshake notifies the handlers of the extensions that are supported by the peer.
+func (t *torrent) handleCustomExtensionHandshake(pe *peer.Peer, msg peerprotocol.ExtensionHandshakeMessage) {
+	for _, ext := range t.session.getCustomExtensions() {
+		if msg.M[ext.name] == 0 {
+			continue
+		}
+		ext.handler.HandleHandshake(ExtensionPeer{pe: pe, name: ext.name, infoHash: t.infoHash})
+	}
+}
+
+func (t *torrent) handleCustomExtensionMessage(pe *peer.Peer, msg peerprotocol.ExtensionRawMessage) {
+	ext, ok := t.session.getCustomExtension(msg.ExtendedMessageID)
+	if !ok {
+		pe.Logger().Debugln("received message of unknown extension:", msg.ExtendedMessageID)
+		return
+	}
+	ext.handler.HandleMessage(ExtensionPeer{pe: pe, name: ext.name, infoHash: t.infoHash}, msg.Data)
+}
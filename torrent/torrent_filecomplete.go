@@ -0,0 +1,86 @@
+package torrent
+
+import (
+	"fmt"
+
+	"github.com/cenkalti/rain/internal/storage"
+)
+
+// completedFiles returns the indexes of files that are completed with the piece at index.
+// Padding and empty files are not included.
+func (t *torrent) completedFiles(index uint32) []int {
+	pieceLength := int64(t.info.PieceLength)
+	var files []int
+	var offset int64
+	for i, f := range t.info.Files {
+		begin := offset
+		offset += f.Length
+		if f.Padding || f.Length == 0 {
+			continue
+		}
+		first := uint32(begin / pieceLength)
+		last := uint32((offset - 1) / pieceLength)
+		if index < first || index > last {
+			continue
+		}
+		if !t.piecesDone(first, last) {
+			continue
+		}
+		files = append(files, i)
+	}
+	return files
+}
+
+func (t *torrent) piecesDone(first, last uint32) bool {
+	for i := first; i <= last; i++ {
+		if !t.pieces[i].Done {
+			return false
+		}
+	}
+	return true
+}
+
+// notifyFileCompleted tells the storage about the files that are completed with the piece at index.
+func (t *torrent) notifyFileCompleted(index uint32) error {
+	for _, i := range t.completedFiles(index) {
+		err := t.completeFile(i)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// notifyCompletedFiles tells the storage about all completed files.
+// It is called when the torrent is started, for files that were completed in a previous run.
+func (t *torrent) notifyCompletedFiles() error {
+	pieceLength := int64(t.info.PieceLength)
+	var offset int64
+	for i, f := range t.info.Files {
+		begin := offset
+		offset += f.Length
+		if f.Padding || f.Length == 0 {
+			continue
+		}
+		if !t.piecesDone(uint32(begin/pieceLength), uint32((offset-1)/pieceLength)) {
+			continue
+		}
+		err := t.completeFile(i)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *torrent) completeFile(i int) error {
+	c, ok := t.files[i].Storage.(storage.Completer)
+	if !ok {
+		return nil
+	}
+	err := c.Complete()
+	if err != nil {
+		return fmt.Errorf("cannot complete file %s: %w", t.files[i].Name, err)
+	}
+	return nil
+}
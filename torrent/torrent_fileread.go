@@ -0,0 +1,157 @@
+package torrent
+
+import (
+	"errors"
+	"io"
+)
+
+// Pieces after the current position of a FileReader are also prioritized, so they are ready when the reader reaches them.
+const fileReadahead = 8 << 20
+
+var errFileReaderClosed = errors.New("file reader is closed")
+
+type readerPriority struct {
+	reader *FileReader
+	// Nil when the reader is closed.
+	pieces []uint32
+}
+
+func (t *torrent) handleReaderPriority(rp readerPriority) {
+	if rp.pieces == nil {
+		delete(t.readerPriorities, rp.reader)
+	} else {
+		t.readerPriorities[rp.reader] = rp.pieces
+	}
+	t.setPiecePriority()
+	t.startPieceDownloaders()
+}
+
+// setPiecePriority makes the piece picker download the pieces needed by FileReaders first.
+func (t *torrent) setPiecePriority() {
+	if t.piecePicker == nil {
+		return
+	}
+	var indexes []uint32
+	for _, pieces := range t.readerPriorities {
+		indexes = append(indexes, pieces...)
+	}
+	t.piecePicker.SetPriority(indexes)
+}
+
+// FileReader reads a file in the torrent while it is being downloaded.
+// Pieces at the current position are downloaded before other pieces, and reads wait for them to be downloaded.
+// FileReader must be closed after use. It is not safe for concurrent use.
+type FileReader struct {
+	torrent *torrent
+	data    *DataReader
+	// Position of the file in the torrent.
+	offset int64
+	length int64
+	// Current position in the file.
+	pos int64
+	// First piece of the last priority sent to the torrent.
+	priorityPiece int64
+	closed        bool
+}
+
+var _ io.ReadSeekCloser = (*FileReader)(nil)
+
+// Open returns a FileReader for reading the contents of the file.
+// The torrent must be running to read the file.
+func (f File) Open() (*FileReader, error) {
+	if f.torrent == nil {
+		return nil, ErrNoMetadata
+	}
+	return &FileReader{
+		torrent:       f.torrent,
+		data:          &DataReader{torrent: f.torrent, wait: true},
+		offset:        f.offset,
+		length:        f.stats.BytesTotal,
+		priorityPiece: -1,
+	}, nil
+}
+
+// Read reads from the current position.
+// It blocks until the piece at the current position is downloaded and returns the data up to the end of that piece.
+// ErrTorrentStopped is returned if the torrent is stopped while waiting.
+func (r *FileReader) Read(p []byte) (int, error) {
+	if r.closed {
+		return 0, errFileReaderClosed
+	}
+	if r.pos >= r.length {
+		return 0, io.EOF
+	}
+	off := r.offset + r.pos
+	pieceLength := int64(r.torrent.info.PieceLength)
+	n := (off/pieceLength+1)*pieceLength - off
+	if left := r.length - r.pos; n > left {
+		n = left
+	}
+	if int64(len(p)) > n {
+		p = p[:n]
+	}
+	r.setPriority(off)
+	m, err := r.data.ReadAt(p, off)
+	r.pos += int64(m)
+	return m, err
+}
+
+// Seek sets the position for the next Read.
+func (r *FileReader) Seek(offset int64, whence int) (int64, error) {
+	if r.closed {
+		return 0, errFileReaderClosed
+	}
+	var pos int64
+	switch whence {
+	case io.SeekStart:
+		pos = offset
+	case io.SeekCurrent:
+		pos = r.pos + offset
+	case io.SeekEnd:
+		pos = r.length + offset
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if pos < 0 {
+		return 0, errors.New("negative position")
+	}
+	r.pos = pos
+	return pos, nil
+}
+
+// Close releases the priority of the pieces needed by the reader.
+func (r *FileReader) Close() error {
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	r.sendPriority(nil)
+	return nil
+}
+
+// setPriority prioritizes the pieces from the position at off to the end of readahead, within the file.
+func (r *FileReader) setPriority(off int64) {
+	pieceLength := int64(r.torrent.info.PieceLength)
+	first := off / pieceLength
+	if first == r.priorityPiece {
+		return
+	}
+	r.priorityPiece = first
+	end := off + fileReadahead
+	if fileEnd := r.offset + r.length; end > fileEnd {
+		end = fileEnd
+	}
+	last := (end - 1) / pieceLength
+	pieces := make([]uint32, 0, last-first+1)
+	for i := first; i <= last; i++ {
+		pieces = append(pieces, uint32(i))
+	}
+	r.sendPriority(pieces)
+}
+
+func (r *FileReader) sendPriority(pieces []uint32) {
+	select {
+	case r.torrent.readerPriorityCommandC <- readerPriority{reader: r, pieces: pieces}:
+	case <-r.torrent.closeC:
+	}
+}
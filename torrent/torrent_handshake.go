@@ -2,6 +2,7 @@ package torrent
 
 import (
 	"net"
+	"time"
 
 	"github.com/cenkalti/rain/internal/handshaker/incominghandshaker"
 	"github.com/cenkalti/rain/internal/handshaker/outgoinghandshaker"
@@ -22,7 +23,7 @@ func (t *torrent) checkInfoHash(infoHash [20]byte) bool {
 func (t *torrent) handleIncomingHandshakeDone(ih *incominghandshaker.IncomingHandshaker) {
 	delete(t.incomingHandshakers, ih)
 	if ih.Error != nil {
-		delete(t.connectedPeerIPs, ih.Conn.RemoteAddr().(*net.TCPAddr).IP.String())
+		t.removePeerIP(ih.Conn.RemoteAddr().(*net.TCPAddr).IP.String())
 		return
 	}
 	t.startPeer(ih.Conn, peersource.Incoming, t.incomingPeers, ih.PeerID, ih.Extensions, ih.Cipher)
@@ -31,9 +32,16 @@ func (t *torrent) handleIncomingHandshakeDone(ih *incominghandshaker.IncomingHan
 func (t *torrent) handleOutgoingHandshakeDone(oh *outgoinghandshaker.OutgoingHandshaker) {
 	delete(t.outgoingHandshakers, oh)
 	if oh.Error != nil {
-		delete(t.connectedPeerIPs, oh.Addr.IP.String())
+		t.addDialFailure(oh.Addr, time.Now())
+		t.removePeerIP(oh.Addr.IP.String())
 		t.dialAddresses()
 		return
 	}
-	t.startPeer(oh.Conn, oh.Source, t.outgoingPeers, oh.PeerID, oh.Extensions, oh.Cipher)
+	delete(t.dialFailures, oh.Addr.String())
+	pe := t.startPeer(oh.Conn, oh.Source, t.outgoingPeers, oh.PeerID, oh.Extensions, oh.Cipher)
+	if pe != nil && oh.Tracker != "" {
+		t.addTrackerPeer(pe, oh.Tracker)
+	}
+	// A dial slot is freed.
+	t.dialAddresses()
 }
@@ -0,0 +1,174 @@
+package torrent
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cenkalti/rain/internal/allocator"
+	"github.com/cenkalti/rain/internal/bitfield"
+	"github.com/cenkalti/rain/internal/piece"
+	"github.com/cenkalti/rain/internal/storage"
+	"github.com/cenkalti/rain/internal/storage/filestorage"
+	"github.com/cenkalti/rain/internal/storage/s3storage"
+	"github.com/cenkalti/rain/internal/storage/webdavstorage"
+	"github.com/cenkalti/rain/metainfo"
+)
+
+// newStorage returns the storage for the torrent. Files are encrypted if a passphrase is set.
+func (s *Session) newStorage(id string, info *metainfo.Info, bf *bitfield.Bitfield) (storage.Storage, error) {
+	sto, err := s.newUnencryptedStorage(id, info, bf)
+	if err != nil {
+		return nil, err
+	}
+	return s.encryptStorage(sto, info), nil
+}
+
+// newUnencryptedStorage returns the storage that the files of the torrent are saved in.
+// If incomplete directory is set, files of the torrents that are not completed are saved there,
+// unless the files already exist in the data directory.
+// Completed torrents are kept in the incomplete directory only if their files have not been moved yet.
+// If a bucket is set, files are saved there and the data directory is used for staging pieces.
+// If a WebDAV server is set, files are saved there directly.
+func (s *Session) newUnencryptedStorage(id string, info *metainfo.Info, bf *bitfield.Bitfield) (storage.Storage, error) {
+	dir := s.getDataDir(id)
+	if s.webdav != nil {
+		return webdavstorage.New(s.webdav, s.getWebDAVDir(id)), nil
+	}
+	if s.s3 != nil {
+		staging, err := filestorage.New(dir, s.config.FilePermissions, s.config.DirPermissions, s.config.SyncWrites, true)
+		if err != nil {
+			return nil, err
+		}
+		return s3storage.New(s.s3, s.getS3Prefix(id), staging), nil
+	}
+	if s.config.IncompleteDir != "" && !filesExist(dir, info) {
+		incompleteDir := s.getIncompleteDir(id)
+		if bf == nil || !bf.All() || filesExist(incompleteDir, info) {
+			dir = incompleteDir
+		}
+	}
+	return filestorage.New(dir, s.config.FilePermissions, s.config.DirPermissions, s.config.SyncWrites, s.config.LazyFileCreation)
+}
+
+// topLevelNames returns the names of files and directories at the root of the torrent.
+func topLevelNames(info *metainfo.Info) []string {
+	var names []string
+	seen := make(map[string]struct{})
+	for _, f := range info.Files {
+		name := strings.SplitN(f.Path, string(filepath.Separator), 2)[0]
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+		names = append(names, name)
+	}
+	return names
+}
+
+func filesExist(dir string, info *metainfo.Info) bool {
+	if info == nil {
+		return false
+	}
+	for _, name := range topLevelNames(info) {
+		if _, err := os.Lstat(filepath.Join(dir, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *torrent) setStorage(sto storage.Storage) {
+	t.mStorage.Lock()
+	t.storage = sto
+	t.mStorage.Unlock()
+}
+
+// moveToDataDir moves the files of a completed torrent from the incomplete directory into the data directory.
+// Each top level file or directory of the torrent is renamed, so the files appear at the destination at once.
+func (t *torrent) moveToDataDir() error {
+	if t.session.config.IncompleteDir == "" || t.session.s3 != nil || t.session.webdav != nil {
+		return nil
+	}
+	src := t.storage.RootDir()
+	dest, err := filepath.Abs(t.session.getDataDir(t.id))
+	if err != nil {
+		return err
+	}
+	if src == dest {
+		return nil
+	}
+	t.log.Infof("moving files to %s", dest)
+
+	// Peers may be reading from the files.
+	for pe := range t.peers {
+		t.closePeer(pe)
+	}
+	for _, f := range t.files {
+		err = f.Storage.Close()
+		if err != nil {
+			t.log.Error(err)
+		}
+	}
+	t.files = nil
+	t.mBitfield.Lock()
+	t.pieces = nil
+	t.mBitfield.Unlock()
+	t.unlockData()
+
+	err = os.MkdirAll(dest, os.ModeDir|t.session.config.DirPermissions)
+	if err != nil {
+		return err
+	}
+	for _, name := range topLevelNames(t.info) {
+		newPath := filepath.Join(dest, name)
+		if _, err = os.Lstat(newPath); err == nil {
+			return fmt.Errorf("cannot move files, destination exists: %s", newPath)
+		}
+		err = os.Rename(filepath.Join(src, name), newPath)
+		if err != nil {
+			return err
+		}
+	}
+	sto, err := filestorage.New(dest, t.session.config.FilePermissions, t.session.config.DirPermissions, t.session.config.SyncWrites, t.session.config.LazyFileCreation)
+	if err != nil {
+		return err
+	}
+	t.setStorage(t.session.encryptStorage(sto, t.info))
+	err = t.lockData()
+	if err != nil {
+		return err
+	}
+	return t.reopenFiles()
+}
+
+// reopenFiles opens the files in the storage after they are moved and creates pieces from them.
+func (t *torrent) reopenFiles() error {
+	files := make([]allocator.File, len(t.info.Files))
+	for i, f := range t.info.Files {
+		var sf storage.File
+		if f.Padding || f.Symlink != "" {
+			sf = storage.NewPaddingFile(f.Length)
+		} else {
+			var err error
+			sf, _, err = t.storage.Open(f.Path, f.Length)
+			if err != nil {
+				for _, f2 := range files[:i] {
+					f2.Storage.Close()
+				}
+				return err
+			}
+		}
+		files[i] = allocator.File{Storage: sf, Name: f.Path, Padding: f.Padding}
+	}
+	pieces := piece.NewPieces(t.info, files)
+	for i := range pieces {
+		pieces[i].Done = t.bitfield.Test(uint32(i))
+	}
+	t.files = files
+	t.mBitfield.Lock()
+	t.pieces = pieces
+	t.mBitfield.Unlock()
+	return nil
+}
@@ -0,0 +1,46 @@
+package torrent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIncompleteDir(t *testing.T) {
+	addr, cl := seeder(t, true)
+	defer cl()
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+	s.config.IncompleteDir = filepath.Join(s.config.DataDir, "incomplete")
+
+	f, err := os.Open(torrentFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	tor, err := s.AddTorrent(f, &AddTorrentOptions{Stopped: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tor.torrent.trackers = nil
+	incompleteDir := filepath.Join(s.config.IncompleteDir, tor.ID())
+	if tor.RootDirectory() != incompleteDir {
+		t.Fatalf("invalid root directory: %s", tor.RootDirectory())
+	}
+	err = tor.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = tor.AddPeer(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertCompleted(t, tor)
+
+	if tor.RootDirectory() != filepath.Join(s.config.DataDir, tor.ID()) {
+		t.Fatalf("invalid root directory: %s", tor.RootDirectory())
+	}
+	if _, err = os.Stat(filepath.Join(incompleteDir, torrentName)); !os.IsNotExist(err) {
+		t.Fatal("files are not moved from incomplete directory")
+	}
+}
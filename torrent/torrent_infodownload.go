@@ -24,8 +24,15 @@ func (t *torrent) nextInfoDownload() *infodownloader.InfoDownloader {
 		if !ok {
 			continue
 		}
+		if t.metadata == nil {
+			t.metadata = infodownloader.NewMetadata(pe.MetadataSize())
+		} else if t.metadata.Size() != pe.MetadataSize() {
+			// Blocks can only be combined if all peers advertise the same size.
+			t.log.Debugf("peer %s advertises different metadata size: %d", pe.String(), pe.MetadataSize())
+			continue
+		}
 		t.log.Debugln("downloading info from", pe.String())
-		return infodownloader.New(pe)
+		return infodownloader.New(pe, t.metadata)
 	}
 	return nil
 }
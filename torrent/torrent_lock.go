@@ -0,0 +1,50 @@
+package torrent
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/cenkalti/rain/internal/lockfile"
+)
+
+// lockData takes a lock on a file in the destination directory,
+// so another process cannot download the same torrent into the same directory at the same time.
+// Files on remote storages are not locked.
+func (t *torrent) lockData() error {
+	if t.dataLock != nil || !t.localStorage() {
+		return nil
+	}
+	dir := t.storage.RootDir()
+	err := os.MkdirAll(dir, os.ModeDir|t.session.config.DirPermissions)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dir, "."+hex.EncodeToString(t.infoHash[:])+".lock")
+	l, err := lockfile.Lock(path, t.session.config.FilePermissions&^0111)
+	if err == lockfile.ErrLocked {
+		return fmt.Errorf("destination is in use by another process: %s", dir)
+	}
+	if err != nil {
+		return err
+	}
+	t.dataLock = l
+	return nil
+}
+
+// localStorage returns true if files of the torrent are saved on the local disk, including staging for remote storages.
+func (t *torrent) localStorage() bool {
+	return t.session.webdav == nil
+}
+
+func (t *torrent) unlockData() {
+	if t.dataLock == nil {
+		return
+	}
+	err := t.dataLock.Unlock()
+	if err != nil {
+		t.log.Error(err)
+	}
+	t.dataLock = nil
+}
@@ -0,0 +1,59 @@
+package torrent
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDestinationLock(t *testing.T) {
+	s1, close1 := newTestSession(t)
+	defer close1()
+	s2, close2 := newTestSession(t)
+	defer close2()
+	s1.config.DataDirIncludesTorrentID = false
+	s2.config.DataDirIncludesTorrentID = false
+	s2.config.DataDir = s1.config.DataDir
+
+	add := func(s *Session) *Torrent {
+		f, err := os.Open(torrentFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		tor, err := s.AddTorrent(f, &AddTorrentOptions{Stopped: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		tor.torrent.trackers = nil
+		return tor
+	}
+	t1 := add(s1)
+	t2 := add(s2)
+
+	assert.NoError(t, t1.Start())
+	time.Sleep(100 * time.Millisecond)
+	assert.NoError(t, t2.Start())
+	deadline := time.Now().Add(timeout)
+	for t2.Stats().Status != Stopped {
+		if time.Now().After(deadline) {
+			t.Fatal("torrent is not stopped")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	err := t2.Stats().Error
+	if assert.Error(t, err) {
+		assert.True(t, strings.Contains(err.Error(), "in use by another process"), err.Error())
+	}
+
+	// Lock is released after stop.
+	stopC := t1.NotifyStop()
+	assert.NoError(t, t1.Stop())
+	<-stopC
+	assert.NoError(t, t2.Start())
+	time.Sleep(100 * time.Millisecond)
+	assert.Nil(t, t2.Stats().Error)
+}
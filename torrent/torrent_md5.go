@@ -0,0 +1,44 @@
+package torrent
+
+import (
+	"fmt"
+
+	"github.com/cenkalti/rain/internal/md5verifier"
+)
+
+// startMD5Verifiers starts checking MD5 sums of the files that are completed with the piece at index.
+func (t *torrent) startMD5Verifiers(index uint32) {
+	for _, i := range t.completedFiles(index) {
+		f := t.info.Files[i]
+		if f.MD5Sum == nil {
+			continue
+		}
+		v := md5verifier.New(i, f.Path)
+		t.md5Verifiers[v] = struct{}{}
+		go v.Run(t.files[i].Storage, f.Length, f.MD5Sum, t.md5VerifierResultC)
+	}
+}
+
+func (t *torrent) handleMD5VerificationDone(v *md5verifier.MD5Verifier) {
+	if _, ok := t.md5Verifiers[v]; !ok {
+		panic("invalid md5 verifier")
+	}
+	delete(t.md5Verifiers, v)
+
+	if v.Error != nil {
+		t.stop(fmt.Errorf("md5 verification error: %s", v.Error))
+		return
+	}
+	if !v.OK {
+		t.stop(fmt.Errorf("md5 mismatch for file: %s", v.Name))
+		return
+	}
+	t.log.Debugf("md5 sum of file is correct: %s", v.Name)
+}
+
+func (t *torrent) stopMD5Verifiers() {
+	for v := range t.md5Verifiers {
+		v.Close()
+		delete(t.md5Verifiers, v)
+	}
+}
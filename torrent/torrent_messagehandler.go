@@ -107,9 +107,11 @@ func (t *torrent) handlePieceMessage(pm peer.PieceMessage) {
 	// Prevent receiving piece messages to avoid more than 1 write per torrent.
 	t.pieceMessagesC.Suspend()
 	t.webseedPieceResultC.Suspend()
+	t.dedupResultC.Suspend()
 
 	pw := piecewriter.New(piece, pe, pd.Buffer)
-	go pw.Run(t.pieceWriterResultC, t.doneC, t.session.metrics.WritesPerSecond, t.session.metrics.SpeedWrite, t.session.semWrite)
+	pw.HashBlocks = t.smartBan.Has(piece.Index)
+	t.startPieceWriter(pw)
 }
 
 func (t *torrent) handlePeerMessage(pm peer.Message) {
@@ -220,7 +222,9 @@ func (t *torrent) handlePeerMessage(pm peer.Message) {
 		t.startPieceDownloaders()
 	case peerprotocol.InterestedMessage:
 		pe.PeerInterested = true
-		t.unchoker.FastUnchoke(pe)
+		if !t.paused {
+			t.unchoker.FastUnchoke(pe)
+		}
 	case peerprotocol.NotInterestedMessage:
 		pe.PeerInterested = false
 	case peerprotocol.RequestMessage:
@@ -240,7 +244,7 @@ func (t *torrent) handlePeerMessage(pm peer.Message) {
 			break
 		}
 		pi := &t.pieces[msg.Index]
-		if !pi.Done {
+		if !pi.Done || t.paused {
 			m := peerprotocol.RejectMessage{RequestMessage: msg}
 			pe.SendMessage(m)
 			break
@@ -302,8 +306,8 @@ func (t *torrent) handlePeerMessage(pm peer.Message) {
 			}})
 		}
 	case peerprotocol.PortMessage:
-		if t.session.dht != nil {
-			t.session.dht.AddNode(fmt.Sprintf("%s:%d", pe.IP(), msg.Port))
+		if t.session.dht != nil && t.dhtEnabled() {
+			t.session.addDHTNode(fmt.Sprintf("%s:%d", pe.IP(), msg.Port))
 		}
 	case peerwriter.BlockUploaded:
 		l := int64(msg.Length)
@@ -317,6 +321,7 @@ func (t *torrent) handlePeerMessage(pm peer.Message) {
 			break
 		}
 		pe.ExtensionHandshake = &msg
+		pe.Logger().Debugln("client:", pe.Client())
 
 		if len(msg.YourIP) == 4 {
 			t.externalIP = net.IP(msg.YourIP)
@@ -324,17 +329,42 @@ func (t *torrent) handlePeerMessage(pm peer.Message) {
 		if _, ok := msg.M[peerprotocol.ExtensionKeyMetadata]; ok {
 			t.startInfoDownloaders()
 		}
-		if t.session.config.PEXEnabled {
+		if t.pexEnabled() {
 			if _, ok := msg.M[peerprotocol.ExtensionKeyPEX]; ok {
-				if t.info != nil && !t.info.Private {
+				if t.info != nil {
 					pe.StartPEX(t.peers, &t.recentlySeen)
 				}
 			}
 		}
+		if t.texEnabled() {
+			t.sendTEX(pe, t.workingTrackers())
+		}
+		t.handleCustomExtensionHandshake(pe, msg)
 	case peerprotocol.ExtensionMetadataMessage:
 		t.handleMetadataMessage(pe, msg)
+	case peerwriter.PieceReadError:
+		t.handlePieceReadError(pe, msg)
+	case peerprotocol.ExtensionDontHaveMessage:
+		if t.pieces == nil || t.bitfield == nil {
+			break
+		}
+		if msg.Index >= t.info.NumPieces {
+			pe.Logger().Errorln("unexpected piece index:", msg.Index)
+			t.closePeer(pe)
+			break
+		}
+		if t.piecePicker != nil {
+			t.piecePicker.HandleDontHave(pe, msg.Index)
+		} else {
+			pe.Bitfield.Clear(msg.Index)
+		}
+		t.updateInterestedState(pe)
+	case peerprotocol.ExtensionTEXMessage:
+		t.handleTEXMessage(pe, msg)
+	case peerprotocol.ExtensionRawMessage:
+		t.handleCustomExtensionMessage(pe, msg)
 	case peerprotocol.ExtensionPEXMessage:
-		if !t.session.config.PEXEnabled {
+		if !t.pexEnabled() {
 			break
 		}
 		addrs, err := tracker.DecodePeersCompact([]byte(msg.Added))
@@ -359,7 +389,7 @@ func (t *torrent) updateInterestedState(pe *peer.Peer) {
 		return
 	}
 	interested := false
-	if !t.completed {
+	if !t.completed && !t.seedOnly {
 		for i := uint32(0); i < t.bitfield.Len(); i++ {
 			weHave := t.bitfield.Test(i)
 			peerHave := pe.Bitfield.Test(i)
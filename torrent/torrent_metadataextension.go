@@ -3,8 +3,11 @@ package torrent
 import (
 	"bytes"
 	"crypto/sha1"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 
 	"github.com/cenkalti/rain/internal/bufferpool"
 	"github.com/cenkalti/rain/internal/peer"
@@ -58,7 +61,12 @@ func (t *torrent) handleMetadataMessage(pe *peer.Peer, msg peerprotocol.Extensio
 		if !ok {
 			break
 		}
-		err := id.GotBlock(msg.Piece, msg.Data)
+		var err error
+		if msg.TotalSize != int(id.Metadata.Size()) {
+			err = fmt.Errorf("peer sent invalid total size for metadata message: %d", msg.TotalSize)
+		} else {
+			err = id.GotBlock(msg.Piece, msg.Data)
+		}
 		if err != nil {
 			pe.Logger().Error(err)
 			t.closePeer(pe)
@@ -67,42 +75,103 @@ func (t *torrent) handleMetadataMessage(pe *peer.Peer, msg peerprotocol.Extensio
 		}
 		if !id.Done() {
 			id.RequestBlocks(t.maxAllowedRequests(pe))
-			pe.ResetSnubTimer()
+			if id.Pending() > 0 {
+				pe.ResetSnubTimer()
+			} else {
+				// Remaining blocks are pending from other peers.
+				pe.StopSnubTimer()
+			}
 			break
 		}
 		pe.StopSnubTimer()
 
+		metadata := t.metadata
+		t.stopInfoDownloaders()
+		t.metadata = nil
+
 		hash := sha1.New()
-		_, _ = hash.Write(id.Bytes)
+		_, _ = hash.Write(metadata.Bytes)
 		if !bytes.Equal(hash.Sum(nil), t.infoHash[:]) {
-			pe.Logger().Errorln("received info does not match with hash")
-			t.closePeer(id.Peer.(*peer.Peer))
+			t.log.Errorln("received info does not match with hash")
+			peers := metadata.Peers()
+			if len(peers) == 1 {
+				t.banPeerIP(peers[0].(*peer.Peer).IP())
+			} else {
+				// Blocks are received from different peers. Any of them could have sent the wrong data.
+				// Wrong blocks are found after the correct info is downloaded.
+				for _, mp := range peers {
+					t.closePeer(mp.(*peer.Peer))
+				}
+				t.failedMetadata = metadata
+			}
 			t.startInfoDownloaders()
 			break
 		}
-		t.stopInfoDownloaders()
+		if t.failedMetadata != nil {
+			for _, mp := range t.failedMetadata.Mismatching(metadata.Bytes) {
+				ip := mp.(*peer.Peer).IP()
+				t.log.Debugf("peer %s has sent wrong metadata", ip)
+				t.banPeerIP(ip)
+			}
+			t.failedMetadata = nil
+		}
 
-		info, err := t.session.parseInfo(id.Bytes, boltdbresumer.LatestVersion)
+		info, err := t.session.parseInfo(metadata.Bytes, boltdbresumer.LatestVersion)
 		if err != nil {
-			t.stop(fmt.Errorf("cannot parse info bytes: %s", err))
+			t.stop(metainfoError{fmt.Errorf("cannot parse info bytes: %w", err)})
 			break
 		}
 		if info.Private {
 			t.stop(errors.New("private torrent from magnet"))
 			break
 		}
+		renamed := t.session.renameFiles(info, nil)
+		if dir := t.session.adoptExistingData(t.id, info); dir != "" {
+			err = t.session.resumer.WriteDataDir(t.id, dir)
+			if err != nil {
+				t.stop(fmt.Errorf("cannot write resume info: %s", err))
+				break
+			}
+		}
+		// Files may exist in the data directory already.
+		sto, err := t.session.newStorage(t.id, info, nil)
+		if err != nil {
+			t.stop(err)
+			break
+		}
+		t.setStorage(sto)
+		t.mBitfield.Lock()
 		t.info = info
+		t.mBitfield.Unlock()
 		t.piecePool = bufferpool.New(int(info.PieceLength))
+		if t.session.pieceIndex != nil {
+			t.session.pieceIndex.Add(t, info)
+		}
 		err = t.session.resumer.WriteInfo(t.id, t.info.Bytes)
 		if err != nil {
 			t.stop(fmt.Errorf("cannot write resume info: %s", err))
 			break
 		}
+		if renamed != nil {
+			err = t.session.resumer.WriteRenamedFiles(t.id, renamed)
+			if err != nil {
+				t.stop(fmt.Errorf("cannot write resume info: %s", err))
+				break
+			}
+		}
+		if t.session.config.SaveTorrentDir != "" {
+			err = t.saveTorrentFile(t.session.config.SaveTorrentDir)
+			if err != nil {
+				t.log.Errorln("cannot save torrent file:", err.Error())
+				t.addRecentError(err)
+			}
+		}
 		select {
 		case <-t.completeMetadataC:
 		default:
 			close(t.completeMetadataC)
 		}
+		t.publishEvent(Event{Type: EventMetadataReceived})
 		if t.stopAfterMetadata {
 			t.stopAndSetStoppedOnMetadata()
 		} else {
@@ -117,6 +186,20 @@ func (t *torrent) handleMetadataMessage(pe *peer.Peer, msg peerprotocol.Extensio
 	}
 }
 
+// saveTorrentFile writes the metainfo of the torrent into dir.
+func (t *torrent) saveTorrentFile(dir string) error {
+	data, err := t.Torrent()
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(dir, os.ModeDir|t.session.config.DirPermissions)
+	if err != nil {
+		return err
+	}
+	name := filepath.Join(dir, hex.EncodeToString(t.infoHash[:])+".torrent")
+	return os.WriteFile(name, data, t.session.config.FilePermissions&^0o111)
+}
+
 func (t *torrent) sendMetadataReject(pe *peer.Peer, i uint32, msgID uint8) {
 	dataMsg := peerprotocol.ExtensionMetadataMessage{
 		Type:  peerprotocol.ExtensionMetadataMessageTypeReject,
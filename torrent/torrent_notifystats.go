@@ -0,0 +1,79 @@
+package torrent
+
+import "time"
+
+// Stats are not sent to subscribers more frequently than this interval.
+const minStatsInterval = 100 * time.Millisecond
+
+type statsSubscriber struct {
+	C        chan Stats
+	interval time.Duration
+	next     time.Time
+}
+
+func (t *torrent) handleNotifyStats(sub *statsSubscriber) {
+	t.statsSubscribers[sub] = struct{}{}
+	t.scheduleStats()
+}
+
+func (t *torrent) handleStopNotifyStats(sub *statsSubscriber) {
+	if _, ok := t.statsSubscribers[sub]; !ok {
+		return
+	}
+	delete(t.statsSubscribers, sub)
+	close(sub.C)
+	t.scheduleStats()
+}
+
+// publishStats sends the stats to the subscribers whose interval has elapsed.
+// If the subscriber has not received the previous value yet, the new value is dropped.
+func (t *torrent) publishStats(now time.Time) {
+	var stats *Stats
+	for sub := range t.statsSubscribers {
+		if now.Before(sub.next) {
+			continue
+		}
+		if stats == nil {
+			s := t.stats()
+			stats = &s
+		}
+		select {
+		case sub.C <- *stats:
+		default:
+		}
+		sub.next = now.Add(sub.interval)
+	}
+	t.scheduleStats()
+}
+
+// scheduleStats sets the timer to fire when the next subscriber is due.
+func (t *torrent) scheduleStats() {
+	if t.statsTimer != nil {
+		t.statsTimer.Stop()
+		t.statsTimer = nil
+		t.statsTimerC = nil
+	}
+	if len(t.statsSubscribers) == 0 {
+		return
+	}
+	var next time.Time
+	first := true
+	for sub := range t.statsSubscribers {
+		if first || sub.next.Before(next) {
+			next = sub.next
+			first = false
+		}
+	}
+	t.statsTimer = time.NewTimer(time.Until(next))
+	t.statsTimerC = t.statsTimer.C
+}
+
+func (t *torrent) closeStatsSubscribers() {
+	if t.statsTimer != nil {
+		t.statsTimer.Stop()
+	}
+	for sub := range t.statsSubscribers {
+		close(sub.C)
+	}
+	t.statsSubscribers = nil
+}
@@ -0,0 +1,55 @@
+package torrent
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotifyStats(t *testing.T) {
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+
+	f, err := os.Open(torrentFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	tor, err := s.AddTorrent(f, &AddTorrentOptions{Stopped: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statsC, stop := tor.NotifyStats(200 * time.Millisecond)
+	statsC2, stop2 := tor.NotifyStats(0)
+	defer stop2()
+
+	receive := func(c <-chan Stats) time.Time {
+		select {
+		case stats, ok := <-c:
+			assert.True(t, ok)
+			assert.Equal(t, tor.InfoHash(), stats.InfoHash)
+		case <-time.After(timeout):
+			t.Fatal("stats are not received")
+		}
+		return time.Now()
+	}
+
+	// Each subscriber receives the stats at its own interval.
+	first := receive(statsC)
+	second := receive(statsC)
+	assert.GreaterOrEqual(t, second.Sub(first), 150*time.Millisecond)
+
+	// Non-positive interval does not make the torrent loop busy.
+	first = receive(statsC2)
+	second = receive(statsC2)
+	assert.GreaterOrEqual(t, second.Sub(first), minStatsInterval/2)
+
+	// Channel is closed after stop.
+	stop()
+	for range statsC {
+	}
+	receive(statsC2)
+}
@@ -0,0 +1,83 @@
+package torrent
+
+import (
+	"encoding/hex"
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+// TorrentOverride overrides the settings in Config for a single torrent.
+// Zero values do not override the setting.
+type TorrentOverride struct {
+	// Directory to download the files of the torrent into instead of DataDir.
+	// Only applied when the torrent is added.
+	DataDir string
+	// Download speed limit in KiB/s for the torrent. Global limits still apply.
+	SpeedLimitDownload int64
+	// Upload speed limit in KiB/s for the torrent. Global limits still apply.
+	SpeedLimitUpload int64
+	// Stop seeding when uploaded bytes reach this ratio of the torrent size.
+	SeedRatio float64
+	// Max number of outgoing connections to dial.
+	MaxPeerDial int
+	// Max number of incoming connections to accept.
+	MaxPeerAccept int
+}
+
+// parseTorrentOverrides validates the keys of overrides and returns a new map with lowercase keys.
+func parseTorrentOverrides(overrides map[string]TorrentOverride) (map[string]TorrentOverride, error) {
+	ret := make(map[string]TorrentOverride, len(overrides))
+	for key, o := range overrides {
+		b, err := hex.DecodeString(key)
+		if err != nil || len(b) != 20 {
+			return nil, errors.New("invalid info hash in torrent overrides: " + key)
+		}
+		o.DataDir, err = homedir.Expand(o.DataDir)
+		if err != nil {
+			return nil, err
+		}
+		if o.SeedRatio < 0 {
+			return nil, errors.New("seed ratio must not be negative in torrent override: " + key)
+		}
+		ret[strings.ToLower(key)] = o
+	}
+	return ret, nil
+}
+
+// overrideDataDir sets the data directory of the torrent if there is an override for its info hash.
+func (s *Session) overrideDataDir(torrentID string, infoHash []byte) {
+	dir := s.torrentOverrides[hex.EncodeToString(infoHash)].DataDir
+	if dir == "" {
+		return
+	}
+	if s.config.DataDirIncludesTorrentID {
+		dir = filepath.Join(dir, torrentID)
+	}
+	s.setDataDir(torrentID, dir)
+}
+
+func (t *torrent) maxPeerDial() int {
+	if t.override.MaxPeerDial > 0 {
+		return t.override.MaxPeerDial
+	}
+	return int(atomic.LoadInt32(&t.session.maxPeerDial))
+}
+
+func (t *torrent) maxPeerAccept() int {
+	if t.override.MaxPeerAccept > 0 {
+		return t.override.MaxPeerAccept
+	}
+	return int(atomic.LoadInt32(&t.session.maxPeerAccept))
+}
+
+// seedRatioReached returns true if the torrent has uploaded enough to stop seeding.
+func (t *torrent) seedRatioReached() bool {
+	if t.override.SeedRatio <= 0 || t.info == nil {
+		return false
+	}
+	return float64(t.bytesUploaded.Count()) >= t.override.SeedRatio*float64(t.info.Length)
+}
@@ -0,0 +1,64 @@
+package torrent
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cenkalti/rain/metainfo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTorrentOverrides(t *testing.T) {
+	tmp, closeTmp := tempdir(t)
+	defer closeTmp()
+
+	f, err := os.Open(torrentFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	mi, err := metainfo.New(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = f.Seek(0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := DefaultConfig
+	cfg.Database = filepath.Join(tmp, "session.db")
+	cfg.DataDir = filepath.Join(tmp, "data")
+	cfg.DHTEnabled = false
+	cfg.RPCEnabled = false
+	cfg.TorrentOverrides = map[string]TorrentOverride{
+		"invalid": {},
+	}
+	_, err = NewSession(cfg)
+	assert.Error(t, err)
+
+	cfg.TorrentOverrides = map[string]TorrentOverride{
+		strings.ToUpper(hex.EncodeToString(mi.Info.Hash[:])): {
+			DataDir:          filepath.Join(tmp, "override"),
+			SpeedLimitUpload: 10,
+			MaxPeerDial:      3,
+		},
+	}
+	s, err := NewSession(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	tor, err := s.AddTorrent(f, &AddTorrentOptions{Stopped: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, strings.HasPrefix(tor.RootDirectory(), filepath.Join(tmp, "override")+string(filepath.Separator)))
+	assert.Equal(t, 3, tor.torrent.maxPeerDial())
+	assert.Equal(t, cfg.MaxPeerAccept, tor.torrent.maxPeerAccept())
+	assert.Equal(t, int64(10), tor.torrent.bucketUpload.Limit())
+	assert.Equal(t, int64(0), tor.torrent.bucketDownload.Limit())
+}
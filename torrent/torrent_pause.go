@@ -0,0 +1,38 @@
+package torrent
+
+func (t *torrent) pause() {
+	if t.paused {
+		return
+	}
+	if s := t.status(); s == Stopped || s == Stopping {
+		return
+	}
+	t.log.Info("pausing torrent")
+	t.paused = true
+
+	t.stopPiecedownloaders()
+	t.stopInfoDownloaders()
+	if t.piecePicker != nil {
+		for _, src := range t.webseedSources {
+			if src.Downloader != nil {
+				t.closeWebseedDownloader(src)
+				t.webseedActiveDownloads--
+			}
+		}
+	}
+
+	// Peers are kept connected but no piece is uploaded to them until the torrent is resumed.
+	t.unchoker.ChokeAll(t.getPeersForUnchoker())
+}
+
+func (t *torrent) resume() {
+	if !t.paused {
+		return
+	}
+	t.log.Info("resuming torrent")
+	t.paused = false
+
+	t.unchoker.TickUnchoke(t.getPeersForUnchoker(), t.completed)
+	t.startInfoDownloaders()
+	t.startPieceDownloaders()
+}
@@ -0,0 +1,55 @@
+package torrent
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPause(t *testing.T) {
+	src, addr, closeSeeder := seederTorrent(t, true)
+	defer closeSeeder()
+	assert.NoError(t, src.Pause())
+
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+
+	f, err := os.Open(torrentFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	tor, err := s.AddTorrent(f, &AddTorrentOptions{Stopped: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tor.torrent.trackers = nil
+	assert.NoError(t, tor.Start())
+	assert.NoError(t, tor.Pause())
+	assert.NoError(t, tor.AddPeer(addr))
+
+	// Peers stay connected while paused.
+	deadline := time.Now().Add(timeout)
+	for tor.Stats().Peers.Total == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("peer is not connected")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, Paused, tor.Stats().Status)
+
+	// Piece downloaders are not started while the leecher is paused.
+	time.Sleep(statsSnapshotInterval + 100*time.Millisecond)
+	assert.Zero(t, tor.Stats().Bytes.Downloaded)
+
+	// Requests are rejected while the seeder is paused.
+	assert.NoError(t, tor.Resume())
+	time.Sleep(statsSnapshotInterval + 100*time.Millisecond)
+	assert.Zero(t, tor.Stats().Bytes.Downloaded)
+	assert.Zero(t, src.Stats().Bytes.Uploaded)
+
+	assert.NoError(t, src.Resume())
+	assertCompleted(t, tor)
+}
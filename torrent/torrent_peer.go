@@ -4,6 +4,7 @@ import (
 	"context"
 	"net"
 	"strconv"
+	"time"
 
 	"github.com/cenkalti/rain/internal/bitfield"
 	"github.com/cenkalti/rain/internal/handshaker/outgoinghandshaker"
@@ -23,6 +24,15 @@ func (t *torrent) setNeedMorePeers(val bool) {
 	}
 }
 
+func (t *torrent) forceAnnounce() {
+	for _, an := range t.announcers {
+		an.ForceAnnounce()
+	}
+	if t.dhtAnnouncer != nil {
+		t.announceDHT()
+	}
+}
+
 func (t *torrent) addPeerString(addr string) error {
 	hoststr, portstr, err := net.SplitHostPort(addr)
 	if err != nil {
@@ -65,6 +75,12 @@ func (t *torrent) resolveAndAddPeer(host string, port int) {
 }
 
 func (t *torrent) handleNewPeers(addrs []*net.TCPAddr, source peersource.Source) {
+	t.addNewPeers(addrs, source, "")
+}
+
+// addNewPeers adds the addresses to the list of addresses to dial.
+// trackerURL is the URL of the tracker if the source is peersource.Tracker.
+func (t *torrent) addNewPeers(addrs []*net.TCPAddr, source peersource.Source, trackerURL string) {
 	t.log.Debugf("received %d peers from %s", len(addrs), source)
 	t.setNeedMorePeers(false)
 	if status := t.status(); status == Stopped || status == Stopping {
@@ -72,7 +88,11 @@ func (t *torrent) handleNewPeers(addrs []*net.TCPAddr, source peersource.Source)
 	}
 	if !t.completed {
 		addrs = t.filterBannedIPs(addrs)
-		t.addrList.Push(addrs, source)
+		if trackerURL != "" {
+			t.addrList.PushFromTracker(addrs, trackerURL)
+		} else {
+			t.addrList.Push(addrs, source)
+		}
 		t.dialAddresses()
 	}
 }
@@ -87,6 +107,16 @@ func (t *torrent) filterBannedIPs(a []*net.TCPAddr) []*net.TCPAddr {
 	return b
 }
 
+// banPeerIP disconnects the peers with ip and prevents further connections from/to that IP.
+func (t *torrent) banPeerIP(ip string) {
+	t.bannedPeerIPs[ip] = struct{}{}
+	for pe := range t.peers {
+		if pe.IP() == ip {
+			t.closePeer(pe)
+		}
+	}
+}
+
 func (t *torrent) dialAddresses() {
 	if t.completed {
 		return
@@ -94,28 +124,33 @@ func (t *torrent) dialAddresses() {
 	peersConnected := func() int {
 		return len(t.outgoingPeers) + len(t.outgoingHandshakers)
 	}
-	for peersConnected() < t.session.config.MaxPeerDial {
-		addr, src := t.addrList.Pop()
+	now := time.Now()
+	for peersConnected() < t.maxPeerDial() && len(t.outgoingHandshakers) < t.session.config.MaxConcurrentDials {
+		addr, src, trackerURL := t.addrList.Pop()
 		if addr == nil {
 			t.setNeedMorePeers(true)
 			return
 		}
-		ip := addr.IP.String()
-		if _, ok := t.connectedPeerIPs[ip]; ok {
+		if !t.canDial(addr, now) {
+			continue
+		}
+		if !t.addPeerIP(addr.IP.String()) {
 			continue
 		}
 		h := outgoinghandshaker.New(addr, src)
+		h.Tracker = trackerURL
 		t.outgoingHandshakers[h] = struct{}{}
-		t.connectedPeerIPs[ip] = struct{}{}
 		go h.Run(
 			t.session.config.PeerConnectTimeout,
 			t.session.config.PeerHandshakeTimeout,
+			t.session.config.PeerTCPKeepAlive,
 			t.peerID,
 			t.infoHash,
 			t.outgoingHandshakerResultC,
-			t.session.extensions,
+			t.extensions(),
 			t.session.config.DisableOutgoingEncryption,
 			t.session.config.ForceOutgoingEncryption,
+			t.session.semHalfOpen,
 		)
 	}
 }
@@ -127,20 +162,25 @@ func (t *torrent) startPeer(
 	peerID [20]byte,
 	extensions [8]byte,
 	cipher mse.CryptoMethod,
-) {
+) *peer.Peer {
 	addr := conn.RemoteAddr().(*net.TCPAddr)
 	t.pexAddPeer(addr)
 	_, ok := t.peerIDs[peerID]
 	if ok {
 		t.log.Debugf("peer with same id already connected. addr: %s id: %s", addr, peerID)
 		conn.Close()
+		t.removePeerIP(addr.IP.String())
 		t.pexDropPeer(addr)
 		t.dialAddresses()
-		return
+		return nil
 	}
 	t.peerIDs[peerID] = struct{}{}
 
-	pe := peer.New(conn, source, peerID, extensions, cipher, t.session.config.PieceReadTimeout, t.session.config.RequestTimeout, t.session.config.MaxRequestsIn, t.session.bucketDownload, t.session.bucketUpload)
+	pe := peer.New(conn, source, peerID, extensions, cipher, t.session.config.PieceReadTimeout, t.session.config.PeerReadTimeout, t.session.config.PeerKeepAliveInterval, t.session.config.RequestTimeout, t.session.config.MaxRequestsIn, t.bucketDownload, t.bucketUpload)
+	if !pe.ExtensionsEnabled {
+		// Otherwise, client name is logged after receiving extension handshake.
+		pe.Logger().Debugln("connected to client:", pe.Client())
+	}
 	t.peers[pe] = struct{}{}
 	peers[pe] = struct{}{}
 	if t.info != nil {
@@ -150,6 +190,8 @@ func (t *torrent) startPeer(
 	t.session.metrics.Peers.Inc(1)
 	t.sendFirstMessage(pe)
 	t.recentlySeen.Add(pe.Addr())
+	t.publishEvent(Event{Type: EventPeerConnected, Peer: pe.Addr()})
+	return pe
 }
 
 func (t *torrent) sendFirstMessage(p *peer.Peer) {
@@ -173,13 +215,24 @@ func (t *torrent) sendFirstMessage(p *peer.Peer) {
 	}
 	if p.ExtensionsEnabled {
 		extHandshakeMsg := peerprotocol.NewExtensionHandshake(metadataSize, t.getClientVersion(), p.Addr().IP, t.session.config.MaxRequestsIn)
+		if !t.pexEnabled() {
+			delete(extHandshakeMsg.M, peerprotocol.ExtensionKeyPEX)
+		}
+		if !t.texEnabled() {
+			delete(extHandshakeMsg.M, peerprotocol.ExtensionKeyTEX)
+		}
+		if t.seedOnly || t.completed {
+			// BEP 21: Tell the peer that we are not going to download any pieces.
+			extHandshakeMsg.UploadOnly = 1
+		}
+		t.addCustomExtensions(&extHandshakeMsg)
 		msg := peerprotocol.ExtensionMessage{
 			ExtendedMessageID: peerprotocol.ExtensionIDHandshake,
 			Payload:           extHandshakeMsg,
 		}
 		p.SendMessage(msg)
 	}
-	if p.DHTEnabled {
+	if p.DHTEnabled && t.dhtEnabled() && !t.session.config.DHTReadOnly {
 		msg := peerprotocol.PortMessage{Port: t.session.config.DHTPort}
 		p.SendMessage(msg)
 	}
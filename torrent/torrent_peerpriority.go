@@ -0,0 +1,30 @@
+package torrent
+
+import (
+	"net"
+
+	"github.com/cenkalti/rain/internal/peer"
+	"github.com/cenkalti/rain/internal/peerpriority"
+)
+
+// peerPriority returns the canonical priority (BEP 40) of the connection between the address and our client.
+func (t *torrent) peerPriority(addr *net.TCPAddr) peerpriority.Priority {
+	ip := t.externalIP
+	if ip == nil {
+		ip = net.IPv4(0, 0, 0, 0)
+	}
+	return peerpriority.Calculate(addr, &net.TCPAddr{IP: ip, Port: t.port})
+}
+
+// lowestPriorityPeer returns the peer with the lowest canonical priority in peers.
+func (t *torrent) lowestPriorityPeer(peers map[*peer.Peer]struct{}) (*peer.Peer, peerpriority.Priority) {
+	var lowest *peer.Peer
+	var lowestPriority peerpriority.Priority
+	for pe := range peers {
+		p := t.peerPriority(pe.Addr())
+		if lowest == nil || p < lowestPriority {
+			lowest, lowestPriority = pe, p
+		}
+	}
+	return lowest, lowestPriority
+}
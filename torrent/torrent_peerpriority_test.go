@@ -0,0 +1,41 @@
+package torrent
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/rain/internal/peer"
+	"github.com/cenkalti/rain/internal/peerpriority"
+	"github.com/cenkalti/rain/internal/peersource"
+	"github.com/stretchr/testify/assert"
+)
+
+type testAddrConn struct {
+	net.Conn
+	addr *net.TCPAddr
+}
+
+func (c testAddrConn) RemoteAddr() net.Addr { return c.addr }
+
+func TestLowestPriorityPeer(t *testing.T) {
+	tor := &torrent{externalIP: net.IPv4(98, 76, 54, 32), port: 6881}
+	peers := make(map[*peer.Peer]struct{})
+	pe, _ := tor.lowestPriorityPeer(peers)
+	assert.Nil(t, pe)
+
+	var lowest *peer.Peer
+	var lowestPriority peerpriority.Priority
+	for _, ip := range []string{"123.213.32.10", "1.2.3.4", "5.6.7.8"} {
+		conn, _ := net.Pipe()
+		addr := &net.TCPAddr{IP: net.ParseIP(ip), Port: 6881}
+		pe := peer.New(testAddrConn{conn, addr}, peersource.Incoming, [20]byte{}, [8]byte{}, 0, time.Minute, time.Minute, time.Minute, time.Minute, 1, nil, nil)
+		peers[pe] = struct{}{}
+		if p := tor.peerPriority(addr); lowest == nil || p < lowestPriority {
+			lowest, lowestPriority = pe, p
+		}
+	}
+	pe, priority := tor.lowestPriorityPeer(peers)
+	assert.Equal(t, lowest, pe)
+	assert.Equal(t, lowestPriority, priority)
+}
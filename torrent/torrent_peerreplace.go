@@ -0,0 +1,71 @@
+package torrent
+
+import (
+	"sort"
+	"time"
+
+	"github.com/cenkalti/rain/internal/peer"
+)
+
+// replacePeers disconnects lowest-value outgoing peers if the torrent has reached the dial limit
+// and there are addresses waiting in the connect queue.
+func (t *torrent) replacePeers(now time.Time) {
+	if t.completed || t.paused || t.addrList.Len() == 0 {
+		return
+	}
+	if len(t.outgoingPeers)+len(t.outgoingHandshakers) < t.maxPeerDial() {
+		return
+	}
+	peers := t.lowValuePeers(now, t.session.config.PeerReplaceCount)
+	if len(peers) == 0 {
+		return
+	}
+	t.log.Debugf("replacing %d low-value peers", len(peers))
+	for _, pe := range peers {
+		pe.Logger().Debugln("disconnecting low-value peer")
+		t.closePeer(pe)
+	}
+}
+
+// lowValuePeers returns at most n outgoing peers that are worth replacing, worst first.
+// Peers with the same value are ordered by canonical peer priority.
+// Peers that are connected for less than Config.PeerReplaceInterval are not returned.
+func (t *torrent) lowValuePeers(now time.Time, n int) []*peer.Peer {
+	minAge := t.session.config.PeerReplaceInterval
+	peers := make([]*peer.Peer, 0, len(t.outgoingPeers))
+	for pe := range t.outgoingPeers {
+		if now.Sub(pe.ConnectedAt) < minAge {
+			continue
+		}
+		if !pe.Snubbed && (pe.DownloadSpeed() > 0 || pe.UploadSpeed() > 0) {
+			continue
+		}
+		peers = append(peers, pe)
+	}
+	sort.Slice(peers, func(i, j int) bool {
+		si, sj := peerValue(peers[i]), peerValue(peers[j])
+		if si != sj {
+			return si < sj
+		}
+		// Prefer keeping the connections with higher canonical priority (BEP 40).
+		return t.peerPriority(peers[i].Addr()) < t.peerPriority(peers[j].Addr())
+	})
+	if len(peers) > n {
+		peers = peers[:n]
+	}
+	return peers
+}
+
+// peerValue returns a rough score of how useful the connection is. Higher is better.
+func peerValue(pe *peer.Peer) int {
+	switch {
+	case pe.Snubbed:
+		return 0
+	case !pe.ClientInterested && !pe.PeerInterested:
+		return 1
+	case pe.PeerChoking && !pe.PeerInterested:
+		return 2
+	default:
+		return 3
+	}
+}
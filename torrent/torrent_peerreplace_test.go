@@ -0,0 +1,40 @@
+package torrent
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/rain/internal/peer"
+	"github.com/cenkalti/rain/internal/peersource"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLowValuePeers(t *testing.T) {
+	now := time.Now()
+	newPeer := func(age time.Duration) *peer.Peer {
+		conn, _ := net.Pipe()
+		pe := peer.New(conn, peersource.Tracker, [20]byte{}, [8]byte{}, 0, time.Minute, time.Minute, time.Minute, time.Minute, 1, nil, nil)
+		pe.ConnectedAt = now.Add(-age)
+		return pe
+	}
+	interested := newPeer(3 * time.Minute)
+	interested.ClientInterested = true
+	interested.PeerInterested = true
+	idle := newPeer(2 * time.Minute)
+	snubbed := newPeer(2 * time.Minute)
+	snubbed.Snubbed = true
+	fresh := newPeer(time.Second)
+
+	tor := &torrent{
+		session: &Session{config: DefaultConfig},
+		outgoingPeers: map[*peer.Peer]struct{}{
+			interested: {},
+			idle:       {},
+			snubbed:    {},
+			fresh:      {},
+		},
+	}
+	assert.Equal(t, []*peer.Peer{snubbed, idle, interested}, tor.lowValuePeers(now, 10))
+	assert.Equal(t, []*peer.Peer{snubbed}, tor.lowValuePeers(now, 1))
+}
@@ -10,6 +10,7 @@ func (t *torrent) writeBitfield() error {
 	err := t.session.resumer.WriteBitfield(t.id, t.bitfield.Bytes())
 	if err != nil {
 		t.log.Errorf("cannot write bitfield to resume db: %s", err)
+		t.addRecentError(err)
 	}
 	return err
 }
@@ -22,7 +23,6 @@ func (t *torrent) checkCompletion() bool {
 		return false
 	}
 	t.completed = true
-	close(t.completeC)
 	for h := range t.outgoingHandshakers {
 		h.Close()
 	}
@@ -42,7 +42,20 @@ func (t *torrent) checkCompletion() bool {
 	}
 	t.piecePicker = nil
 	t.updateSeedDuration(time.Now())
+	err := t.moveToDataDir()
+	if err != nil {
+		// Moving is retried on next start.
+		t.completed = false
+		t.stop(&StorageError{Op: "cannot move completed files", Err: err})
+		return false
+	}
+	// Stats must show the completed torrent when the waiters of completeC and the queue are notified.
+	t.updateStatsSnapshot(false)
+	close(t.completeC)
+	t.publishEvent(Event{Type: EventCompleted})
+	t.session.triggerQueue()
 	if !t.completeCmdRun && len(t.session.config.OnCompleteCmd) > 0 {
+		t.session.wgOnCompleteCmds.Add(1)
 		go t.session.runOnCompleteCmd(t)
 		t.completeCmdRun = true
 		err := t.session.resumer.WriteCompleteCmdRun(t.id)
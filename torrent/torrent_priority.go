@@ -0,0 +1,62 @@
+package torrent
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/cenkalti/rain/internal/speedlimit"
+)
+
+// Priority of a Torrent in Session.
+// Torrents with higher priority are started first from the queue
+// and they get a larger share of the bandwidth when the speed limits are reached.
+type Priority int32
+
+const (
+	// PriorityLow torrents are started after all other torrents in queue.
+	PriorityLow Priority = speedlimit.PriorityLow
+	// PriorityNormal is the default priority of torrents.
+	PriorityNormal Priority = speedlimit.PriorityNormal
+	// PriorityHigh torrents are started before all other torrents in queue.
+	PriorityHigh Priority = speedlimit.PriorityHigh
+)
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityLow:
+		return "low"
+	case PriorityNormal:
+		return "normal"
+	case PriorityHigh:
+		return "high"
+	default:
+		return fmt.Sprintf("Priority(%d)", int32(p))
+	}
+}
+
+func (p Priority) valid() bool {
+	return p >= PriorityLow && p <= PriorityHigh
+}
+
+// ParsePriority returns the Priority from its string representation.
+// Empty string is parsed as PriorityNormal.
+func ParsePriority(s string) (Priority, error) {
+	switch s {
+	case "low":
+		return PriorityLow, nil
+	case "normal", "":
+		return PriorityNormal, nil
+	case "high":
+		return PriorityHigh, nil
+	default:
+		return PriorityNormal, fmt.Errorf("invalid priority: %q", s)
+	}
+}
+
+func (t *torrent) getPriority() Priority {
+	return Priority(atomic.LoadInt32(&t.priority))
+}
+
+func (t *torrent) setPriority(p Priority) {
+	atomic.StoreInt32(&t.priority, int32(p))
+}
@@ -0,0 +1,18 @@
+package torrent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPriority(t *testing.T) {
+	for _, p := range []Priority{PriorityLow, PriorityNormal, PriorityHigh} {
+		assert.True(t, p.valid())
+		parsed, err := ParsePriority(p.String())
+		assert.NoError(t, err)
+		assert.Equal(t, p, parsed)
+	}
+	assert.False(t, Priority(2).valid())
+	assert.Equal(t, "Priority(2)", Priority(2).String())
+}
@@ -0,0 +1,38 @@
+package torrent
+
+import "github.com/cenkalti/rain/internal/bitfield"
+
+// Peers of private torrents (BEP 27) must only be received from trackers of the torrent.
+// Info hash of a private torrent must not be leaked to DHT or other peers via PEX.
+
+func (t *torrent) private() bool {
+	return t.info != nil && t.info.Private
+}
+
+// dhtEnabled returns true if DHT can be used for finding peers of the torrent.
+func (t *torrent) dhtEnabled() bool {
+	return t.session.config.DHTEnabled && !t.private()
+}
+
+// pexEnabled returns true if peers can be exchanged with other peers of the torrent.
+func (t *torrent) pexEnabled() bool {
+	return t.session.config.PEXEnabled && !t.private()
+}
+
+// texEnabled returns true if trackers can be exchanged with other peers of the torrent.
+// Trackers are not exchanged before metadata is downloaded,
+// because tracker URLs of private torrents may contain secrets of the user.
+func (t *torrent) texEnabled() bool {
+	return t.session.config.TEXEnabled && t.info != nil && !t.info.Private
+}
+
+// extensions returns the reserved bytes to be sent in handshake.
+// DHT support is not advertised for private torrents.
+func (t *torrent) extensions() [8]byte {
+	ext := t.session.extensions
+	if !t.dhtEnabled() {
+		bf, _ := bitfield.NewBytes(ext[:], 64)
+		bf.Clear(63)
+	}
+	return ext
+}
@@ -0,0 +1,34 @@
+package torrent
+
+import (
+	"testing"
+
+	"github.com/cenkalti/rain/internal/bitfield"
+	"github.com/cenkalti/rain/metainfo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrivateTorrent(t *testing.T) {
+	s := &Session{config: DefaultConfig}
+	ext, _ := bitfield.NewBytes(s.extensions[:], 64)
+	ext.Set(63)
+	tor := &torrent{session: s}
+
+	// Private flag is not known before metadata is downloaded.
+	assert.True(t, tor.dhtEnabled())
+	assert.True(t, tor.pexEnabled())
+	assert.False(t, tor.texEnabled())
+	assert.Equal(t, s.extensions, tor.extensions())
+
+	tor.info = &metainfo.Info{Private: true}
+	assert.False(t, tor.dhtEnabled())
+	assert.False(t, tor.pexEnabled())
+	assert.False(t, tor.texEnabled())
+	torExt := tor.extensions()
+	bf, _ := bitfield.NewBytes(torExt[:], 64)
+	assert.False(t, bf.Test(63))
+	assert.True(t, ext.Test(63))
+
+	tor.info = &metainfo.Info{}
+	assert.True(t, tor.texEnabled())
+}
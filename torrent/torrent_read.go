@@ -0,0 +1,147 @@
+package torrent
+
+import (
+	"errors"
+	"io"
+	"time"
+
+	"github.com/cenkalti/rain/internal/filesection"
+)
+
+var (
+	// ErrPieceNotDownloaded is returned when reading data that is not downloaded yet without waiting.
+	ErrPieceNotDownloaded = errors.New("piece is not downloaded")
+	// ErrTorrentStopped is returned when the torrent is stopped while waiting for data.
+	ErrTorrentStopped = errors.New("torrent is stopped")
+	// ErrTorrentRemoved is returned when the torrent is removed from the Session while waiting.
+	ErrTorrentRemoved = errors.New("torrent is removed")
+	// ErrNoMetadata is returned when reading data of a magnet link before the metadata is downloaded.
+	ErrNoMetadata = errors.New("torrent has no metadata yet")
+)
+
+// Pieces are checked again at this interval while waiting, in case completion events are dropped.
+const pieceWaitInterval = time.Second
+
+// pieceData returns the data of the piece at index if it is downloaded and verified.
+// Files are closed when the torrent stops, so the returned data cannot be read after that.
+func (t *torrent) pieceData(index uint32) (data filesection.Piece, length uint32, ok bool) {
+	t.mBitfield.RLock()
+	defer t.mBitfield.RUnlock()
+	if t.pieces == nil || t.bitfield == nil || !t.bitfield.Test(index) {
+		return nil, 0, false
+	}
+	pi := &t.pieces[index]
+	return pi.Data, pi.Length, true
+}
+
+// waitPiece returns the data of the piece at index.
+// If wait is true, it blocks until the piece is downloaded, the torrent is stopped or the torrent is closed.
+func (t *torrent) waitPiece(index uint32, wait bool) (filesection.Piece, uint32, error) {
+	if t.info == nil {
+		return nil, 0, ErrNoMetadata
+	}
+	if index >= t.info.NumPieces {
+		return nil, 0, errors.New("invalid piece index")
+	}
+	if data, length, ok := t.pieceData(index); ok {
+		return data, length, nil
+	}
+	if !wait {
+		return nil, 0, ErrPieceNotDownloaded
+	}
+	eventC, stop := t.NotifyEvent()
+	defer stop()
+	if t.Stats().Status == Stopped {
+		return nil, 0, ErrTorrentStopped
+	}
+	ticker := time.NewTicker(pieceWaitInterval)
+	defer ticker.Stop()
+	for {
+		if data, length, ok := t.pieceData(index); ok {
+			return data, length, nil
+		}
+		select {
+		case e, ok := <-eventC:
+			if !ok {
+				return nil, 0, errClosed
+			}
+			if e.Type == EventStopped {
+				return nil, 0, ErrTorrentStopped
+			}
+		case <-ticker.C:
+		}
+	}
+}
+
+// ReadPiece returns the data of the piece at index.
+func (t *torrent) ReadPiece(index uint32, wait bool) ([]byte, error) {
+	data, length, err := t.waitPiece(index, wait)
+	if err != nil {
+		return nil, err
+	}
+	b := make([]byte, length)
+	_, err = data.ReadAt(b, 0)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// DataReader reads the data of a torrent as a single byte space, in which the files are placed one after another.
+// Only data of verified pieces is read.
+type DataReader struct {
+	torrent *torrent
+	wait    bool
+}
+
+var _ io.ReaderAt = (*DataReader)(nil)
+
+// Size returns the total length of the files in the torrent, including padding files.
+func (r *DataReader) Size() int64 {
+	return r.torrent.info.Length
+}
+
+// ReadAt reads len(p) bytes starting from off.
+// If the reader is not waiting, ErrPieceNotDownloaded is returned when the range is not downloaded completely.
+// In that case, n is the number of bytes read before the first missing piece.
+func (r *DataReader) ReadAt(p []byte, off int64) (n int, err error) {
+	size := r.Size()
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+	if off >= size {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > size-off {
+		p = p[:size-off]
+		defer func() {
+			if err == nil {
+				err = io.EOF
+			}
+		}()
+	}
+	pieceLength := int64(r.torrent.info.PieceLength)
+	for len(p) > 0 {
+		index := uint32(off / pieceLength)
+		begin := off % pieceLength
+		var data filesection.Piece
+		var length uint32
+		data, length, err = r.torrent.waitPiece(index, r.wait)
+		if err != nil {
+			return
+		}
+		m := int64(length) - begin
+		if m > int64(len(p)) {
+			m = int64(len(p))
+		}
+		var k int
+		k, err = data.ReadAt(p[:m], begin)
+		n += k
+		if err != nil {
+			return
+		}
+		p = p[m:]
+		off += m
+	}
+	return n, nil
+}
@@ -0,0 +1,119 @@
+package torrent
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDataReader(t *testing.T) {
+	addr, closeSeeder := seeder(t, true)
+	defer closeSeeder()
+
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+
+	f, err := os.Open(torrentFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	tor, err := s.AddTorrent(f, &AddTorrentOptions{Stopped: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tor.torrent.trackers = nil
+
+	_, err = tor.ReadPiece(0, false)
+	assert.Equal(t, ErrPieceNotDownloaded, err)
+	_, err = tor.ReadPiece(0, true)
+	assert.Equal(t, ErrTorrentStopped, err)
+
+	var expected []byte
+	for _, fi := range tor.torrent.info.Files {
+		b, err2 := os.ReadFile(filepath.Join(torrentDataDir, fi.Path))
+		if err2 != nil {
+			t.Fatal(err2)
+		}
+		expected = append(expected, b...)
+	}
+
+	assert.NoError(t, tor.Start())
+	assert.NoError(t, tor.AddPeer(addr))
+
+	r, err := tor.NewDataReader(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(len(expected)), r.Size())
+	b := make([]byte, r.Size())
+	n, err := r.ReadAt(b, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, len(expected), n)
+	assert.Equal(t, expected, b)
+
+	pieceLength := int(tor.torrent.info.PieceLength)
+	piece, err := tor.ReadPiece(1, false)
+	assert.NoError(t, err)
+	assert.Equal(t, expected[pieceLength:2*pieceLength], piece)
+}
+
+func TestFileReader(t *testing.T) {
+	addr, closeSeeder := seeder(t, true)
+	defer closeSeeder()
+
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+
+	f, err := os.Open(torrentFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	tor, err := s.AddTorrent(f, &AddTorrentOptions{Stopped: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tor.torrent.trackers = nil
+	assert.NoError(t, tor.Start())
+
+	// Files are available after allocation.
+	var files []File
+	deadline := time.Now().Add(timeout)
+	for {
+		files, err = tor.Files()
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal(err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.NoError(t, tor.AddPeer(addr))
+
+	last := files[len(files)-1]
+	expected, err := os.ReadFile(filepath.Join(torrentDataDir, last.Path()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := last.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	b, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, b)
+
+	pos, err := r.Seek(-1, io.SeekEnd)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(expected)-1), pos)
+	b, err = io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, expected[len(expected)-1:], b)
+}
@@ -14,28 +14,60 @@ func (t *torrent) run() {
 	t.unchokeTicker = time.NewTicker(10 * time.Second)
 	defer t.unchokeTicker.Stop()
 
+	t.speedTicker = time.NewTicker(speedSampleInterval)
+	defer t.speedTicker.Stop()
+
+	statsSnapshotTicker := time.NewTicker(statsSnapshotInterval)
+	defer statsSnapshotTicker.Stop()
+
+	var diskSpaceTickerC <-chan time.Time
+	if t.session.config.DiskSpaceCheck && t.session.config.DiskSpaceCheckInterval > 0 {
+		diskSpaceTicker := time.NewTicker(t.session.config.DiskSpaceCheckInterval)
+		defer diskSpaceTicker.Stop()
+		diskSpaceTickerC = diskSpaceTicker.C
+	}
+
+	var peerReplaceTickerC <-chan time.Time
+	if t.session.config.PeerReplaceInterval > 0 {
+		peerReplaceTicker := time.NewTicker(t.session.config.PeerReplaceInterval)
+		defer peerReplaceTicker.Stop()
+		peerReplaceTickerC = peerReplaceTicker.C
+	}
+
 	for {
 		select {
 		case <-t.closeC:
 			t.close()
 			close(t.doneC)
 			return
-		case <-t.startCommandC:
+		case done := <-t.startCommandC:
 			t.start()
-		case <-t.stopCommandC:
+			t.updateStatsSnapshot(false)
+			close(done)
+		case done := <-t.stopCommandC:
 			t.stop(nil)
+			t.updateStatsSnapshot(false)
+			close(done)
+		case done := <-t.pauseCommandC:
+			t.pause()
+			t.updateStatsSnapshot(false)
+			close(done)
+		case done := <-t.resumeCommandC:
+			t.resume()
+			t.updateStatsSnapshot(false)
+			close(done)
 		case <-t.announceCommandC:
 			t.setNeedMorePeers(true)
+		case <-t.forceAnnounceCommandC:
+			t.forceAnnounce()
 		case <-t.verifyCommandC:
 			t.handleVerifyCommand()
 		case <-t.announcersStoppedC:
 			t.handleStopped()
 		case cmd := <-t.notifyErrorCommandC:
-			cmd.errCC <- t.errC
+			t.handleNotifyError(cmd)
 		case cmd := <-t.notifyListenCommandC:
 			cmd.portCC <- t.portC
-		case req := <-t.statsCommandC:
-			req.Response <- t.stats()
 		case req := <-t.trackersCommandC:
 			req.Response <- t.getTrackers()
 		case req := <-t.peersCommandC:
@@ -50,30 +82,65 @@ func (t *torrent) run() {
 			t.checkedPieces = p.Checked
 		case ve := <-t.verifierResultC:
 			t.handleVerificationDone(ve)
+		case v := <-t.md5VerifierResultC:
+			t.handleMD5VerificationDone(v)
 		case data := <-t.ramNotifyC:
 			t.startSinglePieceDownloader(data)
-		case addrs := <-t.addrsFromTrackers:
-			t.handleNewPeers(addrs, peersource.Tracker)
+		case tp := <-t.addrsFromTrackers:
+			t.handleTrackerPeers(tp)
 		case addrs := <-t.addPeersCommandC:
 			t.handleNewPeers(addrs, peersource.Manual)
 		case addrs := <-t.dhtPeersC:
 			t.handleNewPeers(addrs, peersource.DHT)
 		case trackers := <-t.addTrackersCommandC:
 			t.handleNewTrackers(trackers)
+		case sub := <-t.notifyStatsCommandC:
+			t.handleNotifyStats(sub)
+		case sub := <-t.stopNotifyStatsCommandC:
+			t.handleStopNotifyStats(sub)
+		case now := <-t.statsTimerC:
+			t.publishStats(now)
+		case sub := <-t.notifyEventCommandC:
+			t.handleNotifyEvent(sub)
+		case sub := <-t.stopNotifyEventCommandC:
+			t.handleStopNotifyEvent(sub)
+		case rp := <-t.readerPriorityCommandC:
+			t.handleReaderPriority(rp)
+		case res := <-t.scrapeResultC:
+			t.handleScrapeResult(res)
+		case err := <-t.announceErrorC:
+			t.addRecentError(&AnnounceError{err})
+			t.publishEvent(Event{Type: EventTrackerError, Tracker: err.URL, Error: &AnnounceError{err}})
 		case conn := <-t.incomingConnC:
 			t.handleNewConnection(conn)
 		case res := <-t.webseedPieceResultC.ReceiveC():
 			t.handleWebseedPieceResult(res)
+		case dp := <-t.dedupResultC.ReceiveC():
+			t.handleDedupPiece(dp)
 		case src := <-t.webseedRetryC:
 			t.startPieceDownloaderForWebseed(src)
 		case pw := <-t.pieceWriterResultC:
 			t.handlePieceWriteDone(pw)
 		case now := <-t.seedDurationTicker.C:
 			t.updateSeedDuration(now)
+			if t.status() == Seeding && t.seedRatioReached() {
+				t.log.Infoln("seed ratio reached, stopping torrent")
+				t.stopAndSetStoppedOnRatio()
+			}
+		case <-t.speedTicker.C:
+			t.sampleSpeeds()
+		case <-statsSnapshotTicker.C:
+			t.updateStatsSnapshot(true)
 		case pe := <-t.peerSnubbedC:
 			t.handlePeerSnubbed(pe)
+		case <-diskSpaceTickerC:
+			t.handleDiskSpaceTick()
+		case now := <-peerReplaceTickerC:
+			t.replacePeers(now)
 		case <-t.unchokeTicker.C:
-			t.unchoker.TickUnchoke(t.getPeersForUnchoker(), t.completed)
+			if !t.paused {
+				t.unchoker.TickUnchoke(t.getPeersForUnchoker(), t.completed)
+			}
 		case ih := <-t.incomingHandshakerResultC:
 			t.handleIncomingHandshakeDone(ih)
 		case oh := <-t.outgoingHandshakerResultC:
@@ -85,5 +152,8 @@ func (t *torrent) run() {
 		case pm := <-t.messages:
 			t.handlePeerMessage(pm)
 		}
+		if t.status() != t.statsSnapshotStatus {
+			t.updateStatsSnapshot(false)
+		}
 	}
 }
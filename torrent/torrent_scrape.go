@@ -0,0 +1,30 @@
+package torrent
+
+import (
+	"time"
+
+	"github.com/cenkalti/rain/internal/announcer"
+)
+
+// Max time to wait for responses to scrape requests.
+const scrapeTimeout = time.Minute
+
+func (t *torrent) startScraper() {
+	if len(t.trackers) == 0 || t.session.config.TrackerScrapeInterval <= 0 {
+		return
+	}
+	t.scraper = announcer.NewScraper(t.trackers, t.infoHash, t.session.config.TrackerScrapeInterval, scrapeTimeout, t.scrapeResultC, t.log)
+	go t.scraper.Run()
+}
+
+func (t *torrent) stopScraper() {
+	if t.scraper != nil {
+		t.scraper.Close()
+		t.scraper = nil
+	}
+}
+
+func (t *torrent) handleScrapeResult(res announcer.ScrapeResult) {
+	t.swarm = res
+	t.swarmScrapedAt = time.Now()
+}
@@ -0,0 +1,43 @@
+package torrent
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeedOnly(t *testing.T) {
+	addr, closeSeeder := seeder(t, true)
+	defer closeSeeder()
+
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+
+	f, err := os.Open(torrentFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	tor, err := s.AddTorrent(f, &AddTorrentOptions{Stopped: true, SeedOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tor.torrent.trackers = nil
+	assert.NoError(t, tor.Start())
+	assert.NoError(t, tor.AddPeer(addr))
+
+	deadline := time.Now().Add(timeout)
+	for tor.Stats().Peers.Total == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("peer is not connected")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(time.Second)
+	stats := tor.Stats()
+	assert.Equal(t, Seeding, stats.Status)
+	assert.Equal(t, int64(0), stats.Bytes.Completed)
+	assert.Equal(t, int64(0), stats.Bytes.Downloaded)
+}
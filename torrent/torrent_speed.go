@@ -0,0 +1,48 @@
+package torrent
+
+import (
+	"math"
+	"time"
+)
+
+// Interval for sampling the byte counters for calculating current speeds.
+const speedSampleInterval = time.Second
+
+// Smoothing factor for current speeds. Most of the weight is given to the samples in the last 5 seconds.
+var speedAlpha = 1 - math.Exp(-float64(speedSampleInterval)/float64(5*time.Second))
+
+// ewmaSpeed calculates the exponentially weighted moving average of a transfer speed
+// from a byte counter that is sampled at every speedSampleInterval.
+type ewmaSpeed struct {
+	rate    float64
+	last    int64
+	sampled bool
+}
+
+func (e *ewmaSpeed) Sample(total int64) {
+	if !e.sampled {
+		e.last = total
+		e.sampled = true
+		return
+	}
+	instant := float64(total-e.last) / speedSampleInterval.Seconds()
+	e.last = total
+	e.rate += speedAlpha * (instant - e.rate)
+}
+
+// Rate returns the speed in bytes/s.
+func (e *ewmaSpeed) Rate() int {
+	return int(e.rate)
+}
+
+func (e *ewmaSpeed) Reset() {
+	*e = ewmaSpeed{}
+}
+
+func (t *torrent) sampleSpeeds() {
+	if t.status() == Stopped {
+		return
+	}
+	t.currentDownloadSpeed.Sample(t.bytesDownloaded.Count())
+	t.currentUploadSpeed.Sample(t.bytesUploaded.Count())
+}
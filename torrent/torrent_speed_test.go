@@ -0,0 +1,29 @@
+package torrent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEWMASpeed(t *testing.T) {
+	var e ewmaSpeed
+	e.Sample(1000)
+	assert.Zero(t, e.Rate())
+
+	var total int64 = 1000
+	for i := 0; i < 60; i++ {
+		total += 100
+		e.Sample(total)
+	}
+	assert.InDelta(t, 100, e.Rate(), 1)
+
+	// Speed decays when nothing is transferred.
+	for i := 0; i < 60; i++ {
+		e.Sample(total)
+	}
+	assert.InDelta(t, 0, e.Rate(), 1)
+
+	e.Reset()
+	assert.Zero(t, e.Rate())
+}
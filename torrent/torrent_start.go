@@ -1,6 +1,7 @@
 package torrent
 
 import (
+	"context"
 	"net"
 
 	"github.com/cenkalti/rain/internal/acceptor"
@@ -32,6 +33,7 @@ func (t *torrent) start() {
 	t.errC = make(chan error, 1)
 	t.portC = make(chan int, 1)
 	t.lastError = nil
+	t.hashFailures = 0
 	t.downloadSpeed = metrics.NewMeter()
 	t.uploadSpeed = metrics.NewMeter()
 
@@ -63,7 +65,7 @@ func (t *torrent) startVerifier() {
 	if len(t.pieces) == 0 {
 		panic("zero length pieces")
 	}
-	t.verifier = verifier.New()
+	t.verifier = verifier.New(t.info.RootHash)
 	go t.verifier.Run(t.pieces, t.verifierProgressC, t.verifierResultC)
 }
 
@@ -71,13 +73,26 @@ func (t *torrent) startAllocator() {
 	if t.allocator != nil {
 		panic("allocator exists")
 	}
+	err := t.lockData()
+	if err != nil {
+		t.stop(err)
+		return
+	}
+	err = t.checkDiskSpace()
+	if err != nil {
+		t.stop(err)
+		return
+	}
 	t.allocator = allocator.New()
 	go t.allocator.Run(t.info, t.storage, t.allocatorProgressC, t.allocatorResultC)
 }
 
 func (t *torrent) addFixedPeers() {
 	for _, pe := range t.fixedPeers {
-		_ = t.addPeerString(pe)
+		err := t.addPeerString(pe)
+		if err != nil {
+			t.log.Warningf("cannot add peer %q: %s", pe, err)
+		}
 	}
 }
 
@@ -87,10 +102,17 @@ func (t *torrent) startAnnouncers() {
 			t.startNewAnnouncer(tr)
 		}
 	}
-	if t.dhtAnnouncer == nil && t.session.config.DHTEnabled && (t.info == nil || !t.info.Private) {
+	if t.scraper == nil {
+		t.startScraper()
+	}
+	if t.dhtAnnouncer == nil && t.dhtEnabled() {
 		t.dhtAnnouncer = announcer.NewDHTAnnouncer()
 		go t.dhtAnnouncer.Run(t.announceDHT, t.session.config.DHTAnnounceInterval, t.session.config.DHTMinAnnounceInterval, t.log)
 	}
+	if len(t.announcers) == 0 && t.dhtAnnouncer == nil && len(t.fixedPeers) == 0 && len(t.webseedSources) == 0 {
+		// Trackerless torrents rely on DHT for finding peers. Other peers may still connect to us.
+		t.log.Warningln("torrent has no trackers and DHT is not available, waiting for incoming connections")
+	}
 }
 
 func (t *torrent) startNewAnnouncer(tr tracker.Tracker) {
@@ -98,9 +120,14 @@ func (t *torrent) startNewAnnouncer(tr tracker.Tracker) {
 		tr,
 		t.session.config.TrackerNumWant,
 		t.session.config.TrackerMinAnnounceInterval,
+		t.session.config.TrackerMaxAnnounceInterval,
+		t.session.config.TrackerAnnounceIntervalOverride,
+		t.session.config.TrackerRetryMinInterval,
+		t.session.config.TrackerRetryMaxInterval,
 		t.announcerFields,
 		t.completeC,
 		t.addrsFromTrackers,
+		t.announceErrorC,
 		t.log,
 	)
 	t.announcers = append(t.announcers, an)
@@ -112,20 +139,22 @@ func (t *torrent) startAcceptor() {
 		return
 	}
 	ip := net.ParseIP(t.session.config.Host)
-	listener, err := net.ListenTCP("tcp4", &net.TCPAddr{IP: ip, Port: t.port})
+	lc := net.ListenConfig{KeepAlive: t.session.config.PeerTCPKeepAlive}
+	listener, err := lc.Listen(context.Background(), "tcp4", (&net.TCPAddr{IP: ip, Port: t.port}).String())
 	if err != nil {
 		t.log.Warningf("cannot listen port %d: %s", t.port, err)
+		t.addRecentError(err)
 	} else {
 		t.log.Info("Listening peers on tcp://" + listener.Addr().String())
 		t.port = listener.Addr().(*net.TCPAddr).Port
 		t.portC <- t.port
-		t.acceptor = acceptor.New(listener, t.incomingConnC, t.log)
+		t.acceptor = acceptor.New(listener, t.incomingConnC, t.session.acceptThrottle, t.log)
 		go t.acceptor.Run()
 	}
 }
 
 func (t *torrent) startInfoDownloaders() {
-	if t.info != nil {
+	if t.info != nil || t.paused {
 		return
 	}
 	for len(t.infoDownloaders)-len(t.infoDownloadersSnubbed) < t.session.config.ParallelMetadataDownloads {
@@ -144,6 +173,7 @@ func (t *torrent) startPieceDownloaders() {
 	if t.status() != Downloading {
 		return
 	}
+	t.startDedup()
 	for _, src := range t.webseedSources {
 		if !src.Downloading() && !src.Disabled {
 			started := t.startPieceDownloaderForWebseed(src)
@@ -177,7 +207,7 @@ func (t *torrent) startPieceDownloaderForWebseed(src *webseedsource.WebseedSourc
 
 func (t *torrent) startWebseedDownloader(sp *piecepicker.WebseedDownloadSpec) {
 	t.log.Debugf("downloading pieces %d-%d from webseed %s", sp.Begin, sp.End, sp.Source.URL)
-	ud := urldownloader.New(sp.Source.URL, sp.Begin, sp.End, t.session.bucketDownload)
+	ud := urldownloader.New(sp.Source.URL, sp.Begin, sp.End, t.bucketDownload)
 	for _, src := range t.webseedSources {
 		if src != sp.Source {
 			continue
@@ -29,6 +29,14 @@ type Stats struct {
 		// Number of unique pieces available on swarm.
 		// If this number is less then the number of total pieces, the download may never finish.
 		Available uint32
+		// Distribution of piece availability among connected peers.
+		// The value at index i is the number of pieces that are available from exactly i peers.
+		// The last value is for the highest availability, so it is never zero.
+		Availability []int
+		// Number of complete copies of the torrent among connected peers.
+		// Integer part is the availability of the rarest piece,
+		// fractional part is the ratio of pieces that are more available than the rarest ones.
+		DistributedCopies float64
 		// Number of total pieces in torrent.
 		Total uint32
 	}
@@ -57,6 +65,18 @@ type Stats struct {
 		// Number of peers that we have connected to.
 		Outgoing int
 	}
+	// Size of the swarm reported by the trackers in scrape responses. See Config.TrackerScrapeInterval.
+	// Values are the largest ones among the trackers because swarms of different trackers overlap.
+	Swarm struct {
+		// Number of peers that have completed the download.
+		Seeders int
+		// Number of peers that are downloading.
+		Leechers int
+		// Number of times that the torrent has been downloaded completely.
+		Snatches int
+		// Time of the last successful scrape. Zero if no tracker has responded yet.
+		LastScrape time.Time
+	}
 	Handshakes struct {
 		// Number of peers that are not handshaked yet.
 		Total int
@@ -97,21 +117,69 @@ type Stats struct {
 	Name string
 	// Is private torrent?
 	Private bool
+	// Is DHT used for finding peers? Always false for private torrents.
+	DHTEnabled bool
+	// Are peers exchanged with other peers? Always false for private torrents.
+	PEXEnabled bool
 	// Number of files.
 	FileCount int
 	// Length of a single piece.
 	PieceLength uint32
 	// Duration while the torrent is in Seeding status.
 	SeededFor time.Duration
-	// Speed is calculated as 1-minute moving average.
+	// Download and Upload speeds are calculated as 1-minute moving average.
 	Speed struct {
 		// Downloaded bytes per second.
 		Download int
 		// Uploaded bytes per second.
 		Upload int
+		// Downloaded bytes per second, averaged over last few seconds.
+		DownloadCurrent int
+		// Uploaded bytes per second, averaged over last few seconds.
+		UploadCurrent int
 	}
-	// Time remaining to complete download. nil value means infinity.
+	// Time remaining to complete download, calculated from the current download speed.
+	// nil value means infinity, i.e. the torrent is not downloading or the download is stalled.
+	// Zero value means that all pieces are downloaded.
 	ETA *time.Duration
+	// Status of each tracker in the torrent.
+	// Only set in the value returned from Torrent.Stats because it requires contacting each announcer.
+	// Refreshed every statsSnapshotInterval.
+	Trackers []Tracker
+	// Priority of the torrent in Session.
+	Priority Priority
+	// Non-fatal errors that have occurred recently, oldest first.
+	RecentErrors []RecentError
+}
+
+// Interval for refreshing the snapshot returned from Stats.
+// The snapshot is also refreshed immediately after the status of the torrent changes.
+const statsSnapshotInterval = time.Second
+
+// updateStatsSnapshot stores the current stats of the torrent, so Stats can return it without contacting the torrent loop.
+// Trackers are copied from the previous snapshot unless updateTrackers is true.
+func (t *torrent) updateStatsSnapshot(updateTrackers bool) {
+	s := t.stats()
+	if updateTrackers {
+		s.Trackers = t.getTrackers()
+	} else if prev, ok := t.statsSnapshot.Load().(Stats); ok {
+		s.Trackers = prev.Trackers
+	}
+	t.statsSnapshot.Store(s)
+	t.statsSnapshotStatus = t.status()
+}
+
+// loadStatsSnapshot returns the last stats stored by the torrent loop.
+// Values in the snapshot must not be modified because they are shared between callers.
+func (t *torrent) loadStatsSnapshot() Stats {
+	s, _ := t.statsSnapshot.Load().(Stats)
+	// Queue state is changed by the Session, outside of the torrent loop.
+	if s.Status == Stopped && t.isQueued() {
+		s.Status = Queued
+	} else if s.Status == Queued && !t.isQueued() {
+		s.Status = Stopped
+	}
+	return s
 }
 
 func (t *torrent) stats() Stats {
@@ -121,7 +189,12 @@ func (t *torrent) stats() Stats {
 	s.InfoHash = t.infoHash
 	s.Port = t.port
 	s.Status = t.status()
+	if s.Status == Stopped && t.isQueued() {
+		s.Status = Queued
+	}
 	s.Error = t.lastError
+	s.RecentErrors = make([]RecentError, len(t.recentErrors))
+	copy(s.RecentErrors, t.recentErrors)
 	s.Addresses.Total = t.addrList.Len()
 	s.Addresses.Tracker = t.addrList.LenSource(peersource.Tracker)
 	s.Addresses.DHT = t.addrList.LenSource(peersource.DHT)
@@ -129,6 +202,10 @@ func (t *torrent) stats() Stats {
 	s.Handshakes.Incoming = len(t.incomingHandshakers)
 	s.Handshakes.Outgoing = len(t.outgoingHandshakers)
 	s.Handshakes.Total = len(t.incomingHandshakers) + len(t.outgoingHandshakers)
+	s.Swarm.Seeders = int(t.swarm.Seeders)
+	s.Swarm.Leechers = int(t.swarm.Leechers)
+	s.Swarm.Snatches = int(t.swarm.Completed)
+	s.Swarm.LastScrape = t.swarmScrapedAt
 	s.Peers.Total = len(t.peers)
 	s.Peers.Incoming = len(t.incomingPeers)
 	s.Peers.Outgoing = len(t.outgoingPeers)
@@ -140,6 +217,10 @@ func (t *torrent) stats() Stats {
 	s.Downloads.Choked = len(t.pieceDownloadersChoked)
 	s.Downloads.Running = len(t.pieceDownloaders) - len(t.pieceDownloadersChoked) - len(t.pieceDownloadersSnubbed)
 	s.Pieces.Available = t.avaliablePieceCount()
+	if t.piecePicker != nil {
+		s.Pieces.Availability = t.piecePicker.AvailabilityHistogram()
+		s.Pieces.DistributedCopies = distributedCopies(s.Pieces.Availability)
+	}
 	s.Bytes.Downloaded = t.bytesDownloaded.Count()
 	s.Bytes.Uploaded = t.bytesUploaded.Count()
 	s.Bytes.Wasted = t.bytesWasted.Count()
@@ -148,15 +229,20 @@ func (t *torrent) stats() Stats {
 	s.Pieces.Checked = t.checkedPieces
 	s.Speed.Download = int(t.downloadSpeed.Rate1())
 	s.Speed.Upload = int(t.uploadSpeed.Rate1())
+	s.Speed.DownloadCurrent = t.currentDownloadSpeed.Rate()
+	s.Speed.UploadCurrent = t.currentUploadSpeed.Rate()
+	s.Priority = t.getPriority()
+	s.DHTEnabled = t.dhtEnabled()
+	s.PEXEnabled = t.pexEnabled()
 
 	if t.info != nil {
-		s.Bytes.Total = t.info.Length
+		s.Bytes.Total = t.bytesTotal()
 		s.Bytes.Completed = t.bytesComplete()
 		s.Bytes.Incomplete = s.Bytes.Total - s.Bytes.Completed
 
 		s.Name = t.info.Name
 		s.Private = t.info.Private
-		s.FileCount = len(t.info.Files)
+		s.FileCount = t.fileCount()
 		s.PieceLength = t.info.PieceLength
 		s.Pieces.Total = t.info.NumPieces
 	} else {
@@ -167,34 +253,45 @@ func (t *torrent) stats() Stats {
 		s.Pieces.Have = t.bitfield.Count()
 		s.Pieces.Missing = s.Pieces.Total - s.Pieces.Have
 	}
-	if s.Status == Downloading {
-		bps := int64(s.Speed.Download)
-		if bps != 0 {
-			eta := time.Duration(s.Bytes.Incomplete/bps) * time.Second
-			switch {
-			case eta > 8*time.Hour:
-				eta = eta.Round(time.Hour)
-			case eta > 4*time.Hour:
-				eta = eta.Round(30 * time.Minute)
-			case eta > 2*time.Hour:
-				eta = eta.Round(15 * time.Minute)
-			case eta > time.Hour:
-				eta = eta.Round(5 * time.Minute)
-			case eta > 30*time.Minute:
-				eta = eta.Round(1 * time.Minute)
-			case eta > 15*time.Minute:
-				eta = eta.Round(30 * time.Second)
-			case eta > 5*time.Minute:
-				eta = eta.Round(15 * time.Second)
-			case eta > time.Minute:
-				eta = eta.Round(5 * time.Second)
-			}
-			s.ETA = &eta
-		}
+	switch {
+	case t.info != nil && s.Bytes.Incomplete == 0:
+		var eta time.Duration
+		s.ETA = &eta
+	case s.Status == Downloading:
+		s.ETA = calculateETA(s.Bytes.Incomplete, s.Speed.DownloadCurrent)
 	}
 	return s
 }
 
+// calculateETA returns the estimated time to download remaining bytes at speed in bytes/s.
+// Returns nil if the download is stalled.
+// The result is rounded, coarser as the duration gets longer, to keep it stable between calls.
+func calculateETA(remaining int64, speed int) *time.Duration {
+	if speed <= 0 {
+		return nil
+	}
+	eta := time.Duration(remaining/int64(speed)) * time.Second
+	switch {
+	case eta > 8*time.Hour:
+		eta = eta.Round(time.Hour)
+	case eta > 4*time.Hour:
+		eta = eta.Round(30 * time.Minute)
+	case eta > 2*time.Hour:
+		eta = eta.Round(15 * time.Minute)
+	case eta > time.Hour:
+		eta = eta.Round(5 * time.Minute)
+	case eta > 30*time.Minute:
+		eta = eta.Round(1 * time.Minute)
+	case eta > 15*time.Minute:
+		eta = eta.Round(30 * time.Second)
+	case eta > 5*time.Minute:
+		eta = eta.Round(15 * time.Second)
+	case eta > time.Minute:
+		eta = eta.Round(5 * time.Second)
+	}
+	return &eta
+}
+
 func (t *torrent) avaliablePieceCount() uint32 {
 	if t.piecePicker == nil {
 		return 0
@@ -202,6 +299,36 @@ func (t *torrent) avaliablePieceCount() uint32 {
 	return t.piecePicker.Available()
 }
 
+// distributedCopies calculates the number of distributed copies from the availability histogram of pieces.
+func distributedCopies(histogram []int) float64 {
+	var total int
+	for _, n := range histogram {
+		total += n
+	}
+	for i, n := range histogram {
+		if n > 0 {
+			return float64(i) + float64(total-n)/float64(total)
+		}
+	}
+	return 0
+}
+
+// bytesTotal returns the size of the torrent excluding padding files.
+func (t *torrent) bytesTotal() int64 {
+	return t.info.Length - t.info.PaddingLength()
+}
+
+// fileCount returns the number of files excluding padding files.
+func (t *torrent) fileCount() int {
+	var n int
+	for _, f := range t.info.Files {
+		if !f.Padding {
+			n++
+		}
+	}
+	return n
+}
+
 func (t *torrent) bytesComplete() int64 {
 	if t.bitfield == nil || len(t.pieces) == 0 {
 		return 0
@@ -211,6 +338,14 @@ func (t *torrent) bytesComplete() int64 {
 		n -= int64(t.info.PieceLength)
 		n += int64(t.pieces[t.bitfield.Len()-1].Length)
 	}
+	// Padding files are not counted as downloaded data.
+	if t.info.PaddingLength() > 0 {
+		for i := range t.pieces {
+			if t.bitfield.Test(uint32(i)) {
+				n -= t.pieces[i].Data.PaddingLength()
+			}
+		}
+	}
 	return n
 }
 
@@ -218,14 +353,20 @@ func (t *torrent) getTrackers() []Tracker {
 	trackers := make([]Tracker, len(t.announcers))
 	for i, an := range t.announcers {
 		st := an.Stats()
+		tt := t.trackerTrafficOf(an.Tracker)
 		trackers[i] = Tracker{
-			URL:          an.Tracker.URL(),
-			Status:       TrackerStatus(st.Status),
-			Seeders:      st.Seeders,
-			Leechers:     st.Leechers,
-			Warning:      st.Warning,
-			LastAnnounce: st.LastAnnounce,
-			NextAnnounce: st.NextAnnounce,
+			URL:             an.Tracker.URL(),
+			Status:          TrackerStatus(st.Status),
+			Seeders:         st.Seeders,
+			Leechers:        st.Leechers,
+			Warning:         st.Warning,
+			LastAnnounce:    st.LastAnnounce,
+			NextAnnounce:    st.NextAnnounce,
+			Failures:        st.Failures,
+			PeersReceived:   tt.peersReceived,
+			PeersConnected:  tt.peersConnected,
+			BytesDownloaded: tt.bytesDownloaded,
+			BytesUploaded:   tt.bytesUploaded,
 		}
 		if st.Error != nil {
 			trackers[i].Error = &AnnounceError{st.Error}
@@ -249,6 +390,8 @@ func (t *torrent) getPeers() []Peer {
 			source = SourceIncoming
 		case peersource.Manual:
 			source = SourceManual
+		case peersource.LSD:
+			source = SourceLSD
 		default:
 			panic("unhandled peer source")
 		}
@@ -267,8 +410,15 @@ func (t *torrent) getPeers() []Peer {
 			EncryptedHandshake: pe.EncryptionCipher != 0,
 			EncryptedStream:    pe.EncryptionCipher == mse.RC4,
 			Source:             source,
+			Tracker:            t.peerTrackers[pe],
 			DownloadSpeed:      pe.DownloadSpeed(),
 			UploadSpeed:        pe.UploadSpeed(),
+			BytesDownloaded:    pe.BytesDownloaded(),
+			BytesUploaded:      pe.BytesUploaded(),
+		}
+		_, p.Incoming = t.incomingPeers[pe]
+		if pe.Bitfield != nil && pe.Bitfield.Len() > 0 {
+			p.Progress = int(pe.Bitfield.Count() * 100 / pe.Bitfield.Len())
 		}
 		peers = append(peers, p)
 	}
@@ -0,0 +1,57 @@
+package torrent
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculateETA(t *testing.T) {
+	assert.Nil(t, calculateETA(1000, 0))
+	assert.Equal(t, 10*time.Second, *calculateETA(1000, 100))
+	assert.Equal(t, time.Duration(0), *calculateETA(0, 100))
+	assert.Equal(t, 9*time.Hour, *calculateETA(9*3600*100+1234, 100))
+}
+
+func TestStatsSnapshot(t *testing.T) {
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+
+	tor, err := s.AddURI(torrentMagnetLink, &AddTorrentOptions{Stopped: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tor.torrent.trackers = nil
+	assert.Equal(t, Stopped, tor.Stats().Status)
+
+	// Queue state is read outside of the torrent loop.
+	atomic.StoreInt32(&tor.torrent.queued, 1)
+	assert.Equal(t, Queued, tor.Stats().Status)
+	atomic.StoreInt32(&tor.torrent.queued, 0)
+	assert.Equal(t, Stopped, tor.Stats().Status)
+
+	assert.NoError(t, tor.Start())
+	deadline := time.Now().Add(timeout)
+	for tor.Stats().Status != DownloadingMetadata {
+		if time.Now().After(deadline) {
+			t.Fatalf("torrent is not started, status: %s", tor.Stats().Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Snapshot is updated before the stop is notified.
+	stopC := tor.NotifyStop()
+	assert.NoError(t, tor.Stop())
+	assert.NoError(t, <-stopC)
+	assert.Equal(t, Stopped, tor.Stats().Status)
+}
+
+func TestDistributedCopies(t *testing.T) {
+	assert.Equal(t, float64(0), distributedCopies(nil))
+	assert.Equal(t, float64(0), distributedCopies([]int{4}))
+	assert.Equal(t, 0.75, distributedCopies([]int{1, 2, 1}))
+	assert.Equal(t, float64(1), distributedCopies([]int{0, 4}))
+	assert.Equal(t, 1.5, distributedCopies([]int{0, 2, 0, 2}))
+}
@@ -20,6 +20,12 @@ const (
 	Seeding
 	// Stopping the torrent. This is the status after Stop() is called. All peers are disconnected and files are closed. A stop event sent to all trackers. After trackers responded the torrent switches into Stopped state.
 	Stopping
+	// Paused indicates that the torrent does not download or upload any pieces.
+	// Unlike Stopped, peers stay connected and trackers keep being announced so the torrent can be resumed immediately.
+	Paused
+	// Queued indicates that the torrent is started but waiting for other torrents to finish
+	// because the limit of active torrents in Session is reached.
+	Queued
 )
 
 func (s Status) String() string {
@@ -31,6 +37,8 @@ func (s Status) String() string {
 		Downloading:         "Downloading",
 		Seeding:             "Seeding",
 		Stopping:            "Stopping",
+		Paused:              "Paused",
+		Queued:              "Queued",
 	}
 	return m[s]
 }
@@ -45,8 +53,12 @@ func (t *torrent) status() Status {
 		return Allocating
 	case t.verifier != nil:
 		return Verifying
+	case t.paused:
+		return Paused
 	case t.completed:
 		return Seeding
+	case t.seedOnly && t.info != nil:
+		return Seeding
 	case t.info == nil:
 		return DownloadingMetadata
 	default:
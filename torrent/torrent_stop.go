@@ -10,21 +10,42 @@ import (
 
 func (t *torrent) handleStopped() {
 	t.stoppedEventAnnouncer = nil
-	t.errC <- t.lastError
+	errC := t.errC
 	t.errC = nil
 	t.portC = nil
+	// Stats must show the stopped torrent when the subscribers are notified.
+	t.updateStatsSnapshot(false)
+	errC <- t.lastError
+	for _, ch := range t.errSubscribers {
+		ch <- t.lastError
+	}
+	t.errSubscribers = nil
 	if t.doVerify {
+		t.mBitfield.Lock()
 		t.bitfield = nil
+		t.mBitfield.Unlock()
 		t.start()
 	} else {
 		t.log.Info("torrent has stopped")
+		t.publishEvent(Event{Type: EventStopped, Error: t.lastError})
 	}
+	t.session.triggerQueue()
 }
 
 func (t *torrent) stopAndSetStoppedOnComplete() {
 	err := t.session.resumer.HandleStopAfterDownload(t.id)
 	if err != nil {
 		t.log.Errorf("cannot write status to resume db: %s", err)
+		t.addRecentError(err)
+	}
+	t.stop(nil)
+}
+
+func (t *torrent) stopAndSetStoppedOnRatio() {
+	err := t.session.resumer.WriteStarted(t.id, false)
+	if err != nil {
+		t.log.Errorf("cannot write status to resume db: %s", err)
+		t.addRecentError(err)
 	}
 	t.stop(nil)
 }
@@ -33,6 +54,7 @@ func (t *torrent) stopAndSetStoppedOnMetadata() {
 	err := t.session.resumer.HandleStopAfterMetadata(t.id)
 	if err != nil {
 		t.log.Errorf("cannot write status to resume db: %s", err)
+		t.addRecentError(err)
 	}
 	t.stop(nil)
 }
@@ -44,6 +66,7 @@ func (t *torrent) stop(err error) {
 	}
 
 	t.log.Info("stopping torrent")
+	t.paused = false
 	t.lastError = err
 	if err != nil && err != errClosed {
 		t.log.Error(err)
@@ -54,6 +77,8 @@ func (t *torrent) stop(err error) {
 	t.stopPiecedownloaders()
 	t.stopInfoDownloaders()
 	t.stopWebseedDownloads()
+	t.stopDedup()
+	t.waitPieceWriters()
 
 	if t.bitfield != nil {
 		_ = t.writeBitfield()
@@ -72,9 +97,14 @@ func (t *torrent) stop(err error) {
 	t.stopAllocator()
 	// Data must be closed before closing Verifier.
 	t.stopVerifier()
+	t.stopMD5Verifiers()
+	// Files are not used after this point.
+	t.unlockData()
 
 	t.stopOutgoingHandshakers()
 	t.stopIncomingHandshakers()
+	// Peers and handshakers are closed, there are no connections left.
+	t.connectedPeerIPs = make(map[string]int)
 
 	t.resetSpeeds()
 
@@ -95,6 +125,9 @@ func (t *torrent) stop(err error) {
 	go t.stoppedEventAnnouncer.Run()
 
 	t.addrList.Reset()
+
+	// Stats must show the error as soon as the torrent is stopping.
+	t.updateStatsSnapshot(false)
 }
 
 func (t *torrent) stopAllocator() {
@@ -124,6 +157,8 @@ func (t *torrent) resetSpeeds() {
 	t.downloadSpeed = metrics.NilMeter{}
 	t.uploadSpeed.Stop()
 	t.uploadSpeed = metrics.NilMeter{}
+	t.currentDownloadSpeed.Reset()
+	t.currentUploadSpeed.Reset()
 }
 
 func (t *torrent) stopOutgoingHandshakers() {
@@ -151,8 +186,11 @@ func (t *torrent) closeData() {
 		}
 	}
 	t.files = nil
+	t.mBitfield.Lock()
 	t.pieces = nil
+	t.mBitfield.Unlock()
 	t.piecePicker = nil
+	t.smartBan.Reset()
 	t.bytesAllocated = 0
 	t.checkedPieces = 0
 }
@@ -163,6 +201,7 @@ func (t *torrent) stopPeriodicalAnnouncers() {
 		an.Close()
 	}
 	t.announcers = nil
+	t.stopScraper()
 	if t.dhtAnnouncer != nil {
 		t.dhtAnnouncer.Close()
 		t.dhtAnnouncer = nil
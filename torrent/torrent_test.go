@@ -1,6 +1,7 @@
 package torrent
 
 import (
+	"bytes"
 	"net"
 	"net/http"
 	"os"
@@ -12,6 +13,7 @@ import (
 
 	"github.com/cenkalti/rain/internal/logger"
 	"github.com/cenkalti/rain/internal/webseedsource"
+	"github.com/cenkalti/rain/metainfo"
 	fhttp "github.com/chihaya/chihaya/frontend/http"
 	"github.com/chihaya/chihaya/middleware"
 	"github.com/chihaya/chihaya/storage"
@@ -62,6 +64,12 @@ func CopyDir(src, dst string) error {
 }
 
 func seeder(t *testing.T, clearTrackers bool) (addr string, c func()) {
+	_, addr, c = seederTorrent(t, clearTrackers)
+	return addr, c
+}
+
+// seederTorrent is like seeder but also returns the seeding torrent.
+func seederTorrent(t *testing.T, clearTrackers bool) (tor *Torrent, addr string, c func()) {
 	f, err := os.Open(torrentFile)
 	if err != nil {
 		t.Fatal(err)
@@ -69,7 +77,7 @@ func seeder(t *testing.T, clearTrackers bool) (addr string, c func()) {
 	defer f.Close()
 	s, closeSession := newTestSession(t)
 	opt := &AddTorrentOptions{Stopped: true}
-	tor, err := s.AddTorrent(f, opt)
+	tor, err = s.AddTorrent(f, opt)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -95,7 +103,7 @@ func seeder(t *testing.T, clearTrackers bool) (addr string, c func()) {
 	case <-time.After(timeout):
 		t.Fatal("seeder is not ready")
 	}
-	return "127.0.0.1:" + strconv.Itoa(port), func() {
+	return tor, "127.0.0.1:" + strconv.Itoa(port), func() {
 		closeSession()
 	}
 }
@@ -119,12 +127,26 @@ func TestDownloadMagnet(t *testing.T) {
 	defer cl()
 	s, closeSession := newTestSession(t)
 	defer closeSession()
+	s.config.SaveTorrentDir = filepath.Join(s.config.DataDir, "torrents")
 
 	tor, err := s.AddURI(torrentMagnetLink+"&x.pe="+addr, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
 	assertCompleted(t, tor)
+
+	ih := tor.InfoHash()
+	b, err := os.ReadFile(filepath.Join(s.config.SaveTorrentDir, ih.String()+".torrent"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mi, err := metainfo.New(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if InfoHash(mi.Info.Hash) != ih {
+		t.Fatal("invalid info hash in saved torrent")
+	}
 }
 
 func TestDownloadTorrent(t *testing.T) {
@@ -0,0 +1,98 @@
+package torrent
+
+import (
+	"net/url"
+	"sort"
+
+	"github.com/cenkalti/rain/internal/peer"
+	"github.com/cenkalti/rain/internal/peerprotocol"
+	"github.com/cenkalti/rain/internal/tracker"
+)
+
+// maxTEXTrackers is the max number of trackers that can be added to a torrent from peers.
+const maxTEXTrackers = 20
+
+// workingTrackers returns the URLs of trackers that have responded to an announce.
+// BEP 28: Only the trackers that are verified to work are sent to peers.
+func (t *torrent) workingTrackers() []string {
+	urls := make([]string, 0, len(t.trackerTraffic))
+	for u := range t.trackerTraffic {
+		urls = append(urls, u)
+	}
+	sort.Strings(urls)
+	return urls
+}
+
+func (t *torrent) sendTEX(pe *peer.Peer, urls []string) {
+	if len(urls) == 0 || pe.ExtensionHandshake == nil {
+		return
+	}
+	id, ok := pe.ExtensionHandshake.M[peerprotocol.ExtensionKeyTEX]
+	if !ok {
+		return
+	}
+	msg := peerprotocol.ExtensionMessage{
+		ExtendedMessageID: id,
+		Payload:           peerprotocol.ExtensionTEXMessage{Added: urls},
+	}
+	pe.SendMessage(msg)
+}
+
+// texAddTracker sends the URL of a tracker that has responded for the first time to connected peers.
+func (t *torrent) texAddTracker(u string) {
+	if !t.texEnabled() {
+		return
+	}
+	for pe := range t.peers {
+		t.sendTEX(pe, []string{u})
+	}
+}
+
+func (t *torrent) handleTEXMessage(pe *peer.Peer, msg peerprotocol.ExtensionTEXMessage) {
+	if !t.texEnabled() {
+		return
+	}
+	known := make(map[string]struct{})
+	for _, tier := range t.getTieredTrackers() {
+		for _, u := range tier {
+			known[u] = struct{}{}
+		}
+	}
+	var trackers []tracker.Tracker
+	for _, u := range msg.Added {
+		if len(t.texTrackers) >= maxTEXTrackers {
+			break
+		}
+		if _, ok := known[u]; ok {
+			continue
+		}
+		if !isTrackerURL(u) {
+			pe.Logger().Debugln("invalid tracker url in tex message:", u)
+			continue
+		}
+		tr, err := t.session.trackerManager.Get(u, t.session.config.TrackerHTTPTimeout, t.session.getTrackerUserAgent(false), int64(t.session.config.TrackerHTTPMaxResponseSize))
+		if err != nil {
+			pe.Logger().Debugln("cannot add tracker from tex message:", err)
+			continue
+		}
+		known[u] = struct{}{}
+		t.texTrackers[u] = struct{}{}
+		trackers = append(trackers, tr)
+	}
+	if len(trackers) > 0 {
+		t.log.Infof("adding %d trackers received from peer %s", len(trackers), pe.String())
+		t.handleNewTrackers(trackers)
+	}
+}
+
+func isTrackerURL(s string) bool {
+	u, err := url.Parse(s)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	switch u.Scheme {
+	case "http", "https", "udp":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package torrent
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/rain/internal/peer"
+	"github.com/cenkalti/rain/internal/peerprotocol"
+	"github.com/cenkalti/rain/internal/peersource"
+	"github.com/cenkalti/rain/metainfo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleTEXMessage(t *testing.T) {
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+
+	tor := &torrent{
+		session:        s,
+		trackers:       s.parseTrackers([][]string{{"http://a.rain/announce"}}, false),
+		trackerTraffic: make(map[string]*trackerTraffic),
+		texTrackers:    make(map[string]struct{}),
+		log:            s.log,
+	}
+	conn, _ := net.Pipe()
+	pe := peer.New(conn, peersource.Incoming, [20]byte{}, [8]byte{}, 0, time.Minute, time.Minute, time.Minute, time.Minute, 1, nil, nil)
+	msg := peerprotocol.ExtensionTEXMessage{Added: []string{
+		"http://a.rain/announce",
+		"udp://b.rain:1234",
+		"invalid://c.rain",
+		"http://",
+	}}
+
+	// Trackers are not accepted before the private flag is known.
+	tor.handleTEXMessage(pe, msg)
+	assert.Len(t, tor.trackers, 1)
+
+	tor.info = &metainfo.Info{}
+	tor.handleTEXMessage(pe, msg)
+	assert.Equal(t, [][]string{{"http://a.rain/announce"}, {"udp://b.rain:1234"}}, tor.getTieredTrackers())
+
+	// Known trackers are not added again.
+	tor.handleTEXMessage(pe, msg)
+	assert.Len(t, tor.trackers, 2)
+
+	tor.info.Private = true
+	tor.handleTEXMessage(pe, peerprotocol.ExtensionTEXMessage{Added: []string{"http://d.rain/announce"}})
+	assert.Len(t, tor.trackers, 2)
+}
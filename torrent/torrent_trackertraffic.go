@@ -0,0 +1,86 @@
+package torrent
+
+import (
+	"github.com/cenkalti/rain/internal/announcer"
+	"github.com/cenkalti/rain/internal/peer"
+	"github.com/cenkalti/rain/internal/peersource"
+	"github.com/cenkalti/rain/internal/tracker"
+)
+
+// trackerTraffic contains the counters for the peers that are received from a tracker.
+type trackerTraffic struct {
+	peersReceived   int
+	peersConnected  int
+	bytesDownloaded int64
+	bytesUploaded   int64
+}
+
+func (t *torrent) getTrackerTraffic(url string) *trackerTraffic {
+	tt, ok := t.trackerTraffic[url]
+	if !ok {
+		tt = new(trackerTraffic)
+		t.trackerTraffic[url] = tt
+	}
+	return tt
+}
+
+func (t *torrent) handleTrackerPeers(tp announcer.TrackerPeers) {
+	if _, ok := t.trackerTraffic[tp.URL]; !ok {
+		t.texAddTracker(tp.URL)
+	}
+	t.getTrackerTraffic(tp.URL).peersReceived += len(tp.Addrs)
+	t.addNewPeers(tp.Addrs, peersource.Tracker, tp.URL)
+}
+
+func (t *torrent) addTrackerPeer(pe *peer.Peer, url string) {
+	t.peerTrackers[pe] = url
+	t.getTrackerTraffic(url).peersConnected++
+}
+
+// removeTrackerPeer adds the bytes transferred with the disconnected peer to the counters of its tracker.
+func (t *torrent) removeTrackerPeer(pe *peer.Peer) {
+	url, ok := t.peerTrackers[pe]
+	if !ok {
+		return
+	}
+	delete(t.peerTrackers, pe)
+	tt := t.getTrackerTraffic(url)
+	tt.bytesDownloaded += pe.BytesDownloaded()
+	tt.bytesUploaded += pe.BytesUploaded()
+}
+
+// trackerTrafficOf returns the sum of counters of the tracker, including the bytes of the peers that are still connected.
+// Counters of all trackers in a tier are added together.
+func (t *torrent) trackerTrafficOf(tr tracker.Tracker) trackerTraffic {
+	urls := make(map[string]struct{})
+	switch tr := tr.(type) {
+	case *tracker.TierList:
+		for _, tier := range tr.Tiers {
+			for _, trk := range tier.List() {
+				urls[trk.URL()] = struct{}{}
+			}
+		}
+	case *tracker.Tier:
+		for _, trk := range tr.List() {
+			urls[trk.URL()] = struct{}{}
+		}
+	default:
+		urls[tr.URL()] = struct{}{}
+	}
+	var sum trackerTraffic
+	for url := range urls {
+		if tt, ok := t.trackerTraffic[url]; ok {
+			sum.peersReceived += tt.peersReceived
+			sum.peersConnected += tt.peersConnected
+			sum.bytesDownloaded += tt.bytesDownloaded
+			sum.bytesUploaded += tt.bytesUploaded
+		}
+	}
+	for pe, url := range t.peerTrackers {
+		if _, ok := urls[url]; ok {
+			sum.bytesDownloaded += pe.BytesDownloaded()
+			sum.bytesUploaded += pe.BytesUploaded()
+		}
+	}
+	return sum
+}
@@ -0,0 +1,39 @@
+package torrent
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cenkalti/rain/internal/peer"
+	"github.com/cenkalti/rain/internal/tracker"
+	"github.com/stretchr/testify/assert"
+)
+
+type testTracker string
+
+func (t testTracker) Announce(ctx context.Context, req tracker.AnnounceRequest) (*tracker.AnnounceResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (t testTracker) URL() string {
+	return string(t)
+}
+
+func TestTrackerTraffic(t *testing.T) {
+	tor := &torrent{
+		trackerTraffic: make(map[string]*trackerTraffic),
+		peerTrackers:   make(map[*peer.Peer]string),
+	}
+	tor.getTrackerTraffic("http://a").peersReceived = 3
+	tor.getTrackerTraffic("http://b").peersReceived = 2
+	tor.getTrackerTraffic("http://b").peersConnected = 1
+	tor.getTrackerTraffic("http://b").bytesDownloaded = 100
+	tor.getTrackerTraffic("http://c").peersReceived = 10
+
+	assert.Equal(t, trackerTraffic{peersReceived: 3}, tor.trackerTrafficOf(testTracker("http://a")))
+	assert.Equal(t, trackerTraffic{}, tor.trackerTrafficOf(testTracker("http://d")))
+
+	tier := &tracker.Tier{Trackers: []tracker.Tracker{testTracker("http://a"), testTracker("http://b")}}
+	assert.Equal(t, trackerTraffic{peersReceived: 5, peersConnected: 1, bytesDownloaded: 100}, tor.trackerTrafficOf(tier))
+}
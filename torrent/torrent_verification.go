@@ -1,17 +1,21 @@
 package torrent
 
 import (
-	"fmt"
+	"errors"
 
 	"github.com/cenkalti/rain/internal/peerprotocol"
 	"github.com/cenkalti/rain/internal/verifier"
 )
 
+var errMerkleIncomplete = errors.New("downloading Merkle torrents is not supported, data must be complete to seed")
+
 func (t *torrent) handleVerifyCommand() {
 	t.log.Info("verifying")
 	t.doVerify = true
 	if t.status() == Stopped {
+		t.mBitfield.Lock()
 		t.bitfield = nil
+		t.mBitfield.Unlock()
 		t.start()
 	} else {
 		t.stop(nil)
@@ -25,7 +29,7 @@ func (t *torrent) handleVerificationDone(ve *verifier.Verifier) {
 	t.verifier = nil
 
 	if ve.Error != nil {
-		t.stop(fmt.Errorf("file verification error: %s", ve.Error))
+		t.stop(&StorageError{Op: "file verification error", Err: ve.Error})
 		return
 	}
 
@@ -51,6 +55,12 @@ func (t *torrent) handleVerificationDone(ve *verifier.Verifier) {
 		}
 	}
 
+	err = t.notifyCompletedFiles()
+	if err != nil {
+		t.stop(err)
+		return
+	}
+
 	// We may detect missing pieces after verification. Then, status must be set from Seeding to Downloading.
 	if !t.bitfield.All() {
 		t.completed = false
@@ -59,6 +69,13 @@ func (t *torrent) handleVerificationDone(ve *verifier.Verifier) {
 		}
 	}
 
+	// Hashes of individual pieces are not received from peers, so only complete Merkle torrents can be seeded.
+	if t.info.RootHash != nil && !t.bitfield.All() {
+		t.doVerify = false
+		t.stop(errMerkleIncomplete)
+		return
+	}
+
 	if t.doVerify {
 		// Stop after manual verification command.
 		t.doVerify = false
@@ -42,9 +42,11 @@ func (t *torrent) handleWebseedPieceResult(msg *urldownloader.PieceResult) {
 	// Prevent receiving piece messages to avoid more than 1 write per torrent.
 	t.pieceMessagesC.Suspend()
 	t.webseedPieceResultC.Suspend()
+	t.dedupResultC.Suspend()
 
 	pw := piecewriter.New(piece, msg.Downloader, msg.Buffer)
-	go pw.Run(t.pieceWriterResultC, t.doneC, t.session.metrics.WritesPerSecond, t.session.metrics.SpeedWrite, t.session.semWrite)
+	pw.HashBlocks = t.smartBan.Has(piece.Index)
+	t.startPieceWriter(pw)
 
 	if msg.Done {
 		for _, src := range t.webseedSources {
@@ -67,6 +69,9 @@ func (t *torrent) disableSource(srcurl string, err error, retry bool) {
 		src.Disabled = true
 		src.DisabledAt = time.Now()
 		src.LastError = err
+		if err != nil {
+			t.addRecentError(err)
+		}
 		t.closeWebseedDownloader(src)
 		if retry {
 			go t.notifyWebseedRetry(src)
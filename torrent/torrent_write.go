@@ -10,35 +10,97 @@ import (
 	"github.com/cenkalti/rain/internal/urldownloader"
 )
 
+// Peers are banned after sending this many corrupt pieces,
+// even if the corrupt block cannot be identified by downloading the piece again.
+const maxCorruptPieces = 3
+
+func (t *torrent) startPieceWriter(pw *piecewriter.PieceWriter) {
+	t.pieceWriters.Add(1)
+	go func() {
+		defer t.pieceWriters.Done()
+		pw.Run(t.pieceWriterResultC, t.closeC, t.session.metrics.WritesPerSecond, t.session.metrics.SpeedWrite, t.session.semWrite)
+	}()
+}
+
+// waitPieceWriters waits until running piece writers exit, so files are not closed in the middle of a write.
+// Writers block on sending their results to the run loop, so the results are received here.
+// Pieces that are written successfully are marked in the bitfield before it is saved.
+func (t *torrent) waitPieceWriters() {
+	doneC := make(chan struct{})
+	go func() {
+		t.pieceWriters.Wait()
+		close(doneC)
+	}()
+	for {
+		select {
+		case pw := <-t.pieceWriterResultC:
+			pw.Piece.Writing = false
+			pw.Buffer.Release()
+			if pw.HashOK && pw.Error == nil && t.bitfield != nil && !t.bitfield.Test(pw.Piece.Index) {
+				pw.Piece.Done = true
+				t.mBitfield.Lock()
+				t.bitfield.Set(pw.Piece.Index)
+				t.mBitfield.Unlock()
+			}
+		case <-doneC:
+			return
+		}
+	}
+}
+
 func (t *torrent) handlePieceWriteDone(pw *piecewriter.PieceWriter) {
 	pw.Piece.Writing = false
 
 	t.pieceMessagesC.Resume()
 	t.webseedPieceResultC.Resume()
+	t.dedupResultC.Resume()
 
 	pw.Buffer.Release()
 
 	if !pw.HashOK {
 		t.bytesWasted.Inc(int64(len(pw.Buffer.Data)))
+		t.publishEvent(Event{Type: EventPieceFailed, Piece: pw.Piece.Index})
 		switch src := pw.Source.(type) {
 		case *peer.Peer:
 			t.log.Debugln("received corrupt piece from peer", src.String())
-			t.closePeer(src)
-			t.bannedPeerIPs[src.IP()] = struct{}{}
+			// The peer is banned after we find out which block is corrupt by downloading the piece again.
+			// If the same peer sends a corrupt copy of the same piece again
+			// or sends too many corrupt pieces, it is banned immediately.
+			again := t.smartBan.Add(pw.Piece.Index, src.IP(), pw.BlockHashes)
+			if again {
+				t.banPeerIP(src.IP())
+			} else {
+				t.closePeer(src)
+			}
 		case *urldownloader.URLDownloader:
 			t.log.Debugln("received corrupt piece from webseed", src.URL)
 			t.disableSource(src.URL, errors.New("corrupt piece"), false)
+		case *dedupSource:
+			// Data is changed on disk after it is read, the piece will be downloaded from the network.
+			t.log.Debugln("piece copied from torrent is corrupt", src.TorrentID)
 		default:
 			panic("unhandled piece source")
 		}
+		t.hashFailures++
+		if limit := t.session.config.MaxPieceHashFailures; limit > 0 && t.hashFailures >= limit {
+			t.stop(ErrTooManyHashFailures)
+			return
+		}
 		t.startPieceDownloaders()
 		return
 	}
 	if pw.Error != nil {
-		t.stop(pw.Error)
+		t.stop(&StorageError{Op: "cannot write piece", Err: pw.Error})
 		return
 	}
 
+	if pw.BlockHashes != nil {
+		for _, ip := range t.smartBan.Check(pw.Piece.Index, pw.BlockHashes) {
+			t.log.Debugf("peer %s has sent corrupt block in piece #%d", ip, pw.Piece.Index)
+			t.banPeerIP(ip)
+		}
+	}
+
 	pw.Piece.Done = true
 	if t.bitfield.Test(pw.Piece.Index) {
 		panic(fmt.Sprintf("already have the piece #%d", pw.Piece.Index))
@@ -46,6 +108,17 @@ func (t *torrent) handlePieceWriteDone(pw *piecewriter.PieceWriter) {
 	t.mBitfield.Lock()
 	t.bitfield.Set(pw.Piece.Index)
 	t.mBitfield.Unlock()
+	t.publishEvent(Event{Type: EventPieceCompleted, Piece: pw.Piece.Index})
+
+	if t.session.config.VerifyMD5 {
+		t.startMD5Verifiers(pw.Piece.Index)
+	}
+
+	err := t.notifyFileCompleted(pw.Piece.Index)
+	if err != nil {
+		t.stop(err)
+		return
+	}
 
 	if t.piecePicker != nil {
 		_, ok := pw.Source.(*urldownloader.URLDownloader)
@@ -0,0 +1,9 @@
+//go:build !windows
+
+package torrent
+
+import "syscall"
+
+func setUmask(value int) {
+	syscall.Umask(value)
+}
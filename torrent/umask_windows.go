@@ -0,0 +1,5 @@
+//go:build windows
+
+package torrent
+
+func setUmask(value int) {}
@@ -0,0 +1,99 @@
+// Package torrenttest provides an in-process tracker, scripted fake peers and deterministic torrent content
+// for running end-to-end swarm tests without network access.
+package torrenttest
+
+import (
+	"crypto/sha1" // nolint: gosec
+	"errors"
+	"math/rand"
+	"os"
+	"path/filepath"
+
+	"github.com/cenkalti/rain/metainfo"
+	"github.com/zeebo/bencode"
+)
+
+// Content is a single file torrent with pseudo-random data generated from a seed.
+// Same arguments always produce the same data and info hash.
+type Content struct {
+	// Name of the file in the torrent.
+	Name string
+	// Data of the file.
+	Data []byte
+	// PieceLength is the number of bytes in each piece except the last one.
+	PieceLength uint32
+	// InfoHash of the torrent.
+	InfoHash [20]byte
+
+	info []byte
+}
+
+type contentInfo struct {
+	Name        string `bencode:"name"`
+	Length      int64  `bencode:"length"`
+	PieceLength uint32 `bencode:"piece length"`
+	Pieces      []byte `bencode:"pieces"`
+}
+
+// NewContent generates size bytes of data from seed and returns the torrent that contains it as a single file.
+func NewContent(name string, size int64, pieceLength uint32, seed int64) (*Content, error) {
+	if size <= 0 {
+		return nil, errors.New("size must be positive")
+	}
+	if pieceLength == 0 {
+		return nil, errors.New("piece length must be positive")
+	}
+	data := make([]byte, size)
+	rand.New(rand.NewSource(seed)).Read(data) // nolint: gosec
+	c := &Content{
+		Name:        name,
+		Data:        data,
+		PieceLength: pieceLength,
+	}
+	pieces := make([]byte, 0, c.NumPieces()*sha1.Size)
+	for i := uint32(0); i < c.NumPieces(); i++ {
+		sum := sha1.Sum(c.Piece(i))
+		pieces = append(pieces, sum[:]...)
+	}
+	info, err := bencode.EncodeBytes(contentInfo{
+		Name:        name,
+		Length:      size,
+		PieceLength: pieceLength,
+		Pieces:      pieces,
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.info = info
+	c.InfoHash = sha1.Sum(info)
+	return c, nil
+}
+
+// NumPieces returns the number of pieces in the torrent.
+func (c *Content) NumPieces() uint32 {
+	return uint32((int64(len(c.Data)) + int64(c.PieceLength) - 1) / int64(c.PieceLength))
+}
+
+// Piece returns the data of the piece at index.
+func (c *Content) Piece(index uint32) []byte {
+	begin := int64(index) * int64(c.PieceLength)
+	end := begin + int64(c.PieceLength)
+	if end > int64(len(c.Data)) {
+		end = int64(len(c.Data))
+	}
+	return c.Data[begin:end]
+}
+
+// Torrent returns the contents of a .torrent file. Each tracker is put in a separate tier.
+func (c *Content) Torrent(trackers ...string) ([]byte, error) {
+	tiers := make([][]string, len(trackers))
+	for i, tr := range trackers {
+		tiers[i] = []string{tr}
+	}
+	return metainfo.NewBytes(c.info, tiers, nil, "")
+}
+
+// WriteFile writes the data into a file with the name of the torrent under dir.
+func (c *Content) WriteFile(dir string) error {
+	return os.WriteFile(filepath.Join(dir, c.Name), c.Data, 0o640)
+}
@@ -0,0 +1,63 @@
+package torrenttest_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/rain/torrent"
+	"github.com/cenkalti/rain/torrenttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionDownload(t *testing.T) {
+	content, err := torrenttest.NewContent("file", 1<<20, 32<<10, 1)
+	require.NoError(t, err)
+
+	trk := torrenttest.NewTracker()
+	defer trk.Close()
+	announceURL, err := trk.StartHTTP()
+	require.NoError(t, err)
+
+	// One peer sends corrupt data for the first piece, the other has the correct data for all pieces.
+	bad, err := torrenttest.NewPeer(content, torrenttest.PeerBehavior{Pieces: []uint32{0}, CorruptPieces: []uint32{0}})
+	require.NoError(t, err)
+	defer bad.Close()
+	good, err := torrenttest.NewPeer(content, torrenttest.PeerBehavior{})
+	require.NoError(t, err)
+	defer good.Close()
+	trk.AddPeer(content.InfoHash, bad.Addr(), false)
+	trk.AddPeer(content.InfoHash, good.Addr(), true)
+
+	dir := t.TempDir()
+	cfg := torrent.DefaultConfig
+	cfg.Database = filepath.Join(dir, "session.db")
+	cfg.DataDir = filepath.Join(dir, "data")
+	cfg.DataDirIncludesTorrentID = false
+	cfg.DHTEnabled = false
+	cfg.PEXEnabled = false
+	cfg.RPCEnabled = false
+	cfg.Host = "127.0.0.1"
+	ses, err := torrent.NewSession(cfg)
+	require.NoError(t, err)
+	defer ses.Close()
+
+	mi, err := content.Torrent(announceURL)
+	require.NoError(t, err)
+	tor, err := ses.AddTorrent(bytes.NewReader(mi), nil)
+	require.NoError(t, err)
+	select {
+	case <-tor.NotifyComplete():
+	case err = <-tor.NotifyStop():
+		t.Fatal(err)
+	case <-time.After(30 * time.Second):
+		t.Fatal("download did not finish")
+	}
+	b, err := os.ReadFile(filepath.Join(cfg.DataDir, content.Name))
+	require.NoError(t, err)
+	assert.Equal(t, content.Data, b)
+	assert.Positive(t, trk.Announces())
+}
@@ -0,0 +1,274 @@
+package torrenttest
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/rain/internal/bitfield"
+	"github.com/cenkalti/rain/internal/btconn"
+	"github.com/cenkalti/rain/internal/mse"
+	"github.com/cenkalti/rain/internal/peerprotocol"
+)
+
+const peerHandshakeTimeout = 10 * time.Second
+
+// PeerBehavior is the script that a Peer follows on each connection.
+// Zero value is a seeder that unchokes immediately and serves all requests.
+type PeerBehavior struct {
+	// Indexes of the pieces that the peer has. The peer has all pieces if nil.
+	Pieces []uint32
+	// Never unchoke the remote peer, so no blocks are sent.
+	Choke bool
+	// Data of these pieces is corrupted before sending, so they fail the hash check of the remote peer.
+	CorruptPieces []uint32
+	// Wait before sending each block.
+	Delay time.Duration
+	// Close the connection after sending this many blocks. Zero means no limit.
+	MaxBlocks int
+}
+
+// Peer is a fake BitTorrent peer that accepts connections for a single torrent and serves the blocks of Content.
+// Encrypted and plain text connections are accepted. Extensions are not supported.
+type Peer struct {
+	content  *Content
+	behavior PeerBehavior
+	bitfield *bitfield.Bitfield
+	corrupt  map[uint32]struct{}
+	id       [20]byte
+	listener net.Listener
+
+	m        sync.Mutex
+	conns    map[net.Conn]struct{}
+	requests int
+	uploaded int64
+
+	closeC    chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// NewPeer returns a new Peer listening on a random port of the loopback interface.
+func NewPeer(c *Content, b PeerBehavior) (*Peer, error) {
+	bf := bitfield.New(c.NumPieces())
+	if b.Pieces == nil {
+		for i := uint32(0); i < c.NumPieces(); i++ {
+			bf.Set(i)
+		}
+	} else {
+		for _, i := range b.Pieces {
+			bf.Set(i)
+		}
+	}
+	corrupt := make(map[uint32]struct{}, len(b.CorruptPieces))
+	for _, i := range b.CorruptPieces {
+		corrupt[i] = struct{}{}
+	}
+	l, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	p := &Peer{
+		content:  c,
+		behavior: b,
+		bitfield: bf,
+		corrupt:  corrupt,
+		listener: l,
+		conns:    make(map[net.Conn]struct{}),
+		closeC:   make(chan struct{}),
+	}
+	copy(p.id[:], "-RT0000-")
+	_, err = rand.Read(p.id[8:])
+	if err != nil {
+		l.Close()
+		return nil, err
+	}
+	p.wg.Add(1)
+	go p.acceptor()
+	return p, nil
+}
+
+// Addr returns the address that the peer is listening on.
+func (p *Peer) Addr() *net.TCPAddr {
+	return p.listener.Addr().(*net.TCPAddr)
+}
+
+// Requests returns the number of block requests received from all connections.
+func (p *Peer) Requests() int {
+	p.m.Lock()
+	defer p.m.Unlock()
+	return p.requests
+}
+
+// Uploaded returns the number of block bytes sent to all connections.
+func (p *Peer) Uploaded() int64 {
+	p.m.Lock()
+	defer p.m.Unlock()
+	return p.uploaded
+}
+
+// Close the listener and all connections.
+func (p *Peer) Close() error {
+	p.closeOnce.Do(func() {
+		close(p.closeC)
+		p.listener.Close()
+		p.m.Lock()
+		for conn := range p.conns {
+			conn.Close()
+		}
+		p.m.Unlock()
+	})
+	p.wg.Wait()
+	return nil
+}
+
+func (p *Peer) acceptor() {
+	defer p.wg.Done()
+	for {
+		conn, err := p.listener.Accept()
+		if err != nil {
+			return
+		}
+		p.m.Lock()
+		select {
+		case <-p.closeC:
+			p.m.Unlock()
+			conn.Close()
+			return
+		default:
+		}
+		p.conns[conn] = struct{}{}
+		p.m.Unlock()
+		p.wg.Add(1)
+		go p.serve(conn)
+	}
+}
+
+func (p *Peer) serve(conn net.Conn) {
+	defer p.wg.Done()
+	defer func() {
+		conn.Close()
+		p.m.Lock()
+		delete(p.conns, conn)
+		p.m.Unlock()
+	}()
+	sKeyHash := mse.HashSKey(p.content.InfoHash[:])
+	getSKey := func(h [20]byte) []byte {
+		if h == sKeyHash {
+			return p.content.InfoHash[:]
+		}
+		return nil
+	}
+	hasInfoHash := func(ih [20]byte) bool { return ih == p.content.InfoHash }
+	encConn, _, _, _, _, err := btconn.Accept(conn, peerHandshakeTimeout, getSKey, false, hasInfoHash, [8]byte{}, p.id)
+	if err != nil {
+		return
+	}
+	err = conn.SetDeadline(time.Time{})
+	if err != nil {
+		return
+	}
+	err = writePeerMessage(encConn, &peerprotocol.BitfieldMessage{Data: p.bitfield.Bytes()}, nil)
+	if err != nil {
+		return
+	}
+	if !p.behavior.Choke {
+		err = writePeerMessage(encConn, peerprotocol.UnchokeMessage{}, nil)
+		if err != nil {
+			return
+		}
+	}
+	var sent int
+	var header [4]byte
+	for {
+		_, err = io.ReadFull(encConn, header[:])
+		if err != nil {
+			return
+		}
+		length := binary.BigEndian.Uint32(header[:])
+		if length == 0 {
+			// keep-alive
+			continue
+		}
+		if length > 1<<20 {
+			return
+		}
+		msg := make([]byte, length)
+		_, err = io.ReadFull(encConn, msg)
+		if err != nil {
+			return
+		}
+		if peerprotocol.MessageID(msg[0]) != peerprotocol.Request || len(msg) != 13 || p.behavior.Choke {
+			continue
+		}
+		req := peerprotocol.RequestMessage{
+			Index:  binary.BigEndian.Uint32(msg[1:5]),
+			Begin:  binary.BigEndian.Uint32(msg[5:9]),
+			Length: binary.BigEndian.Uint32(msg[9:13]),
+		}
+		p.m.Lock()
+		p.requests++
+		p.m.Unlock()
+		data := p.block(req)
+		if data == nil {
+			continue
+		}
+		if p.behavior.Delay > 0 {
+			select {
+			case <-time.After(p.behavior.Delay):
+			case <-p.closeC:
+				return
+			}
+		}
+		err = writePeerMessage(encConn, peerprotocol.PieceMessage{Index: req.Index, Begin: req.Begin}, data)
+		if err != nil {
+			return
+		}
+		p.m.Lock()
+		p.uploaded += int64(len(data))
+		p.m.Unlock()
+		sent++
+		if p.behavior.MaxBlocks > 0 && sent >= p.behavior.MaxBlocks {
+			return
+		}
+	}
+}
+
+// block returns the data for the request. Nil is returned if the peer does not have the piece or the request is invalid.
+func (p *Peer) block(req peerprotocol.RequestMessage) []byte {
+	if req.Index >= p.content.NumPieces() || !p.bitfield.Test(req.Index) {
+		return nil
+	}
+	piece := p.content.Piece(req.Index)
+	end := uint64(req.Begin) + uint64(req.Length)
+	if end > uint64(len(piece)) {
+		return nil
+	}
+	data := piece[req.Begin:end]
+	if _, ok := p.corrupt[req.Index]; ok {
+		data = append([]byte(nil), data...)
+		for i := range data {
+			data[i] = ^data[i]
+		}
+	}
+	return data
+}
+
+func writePeerMessage(w io.Writer, msg peerprotocol.Message, data []byte) error {
+	var payload bytes.Buffer
+	_, err := payload.ReadFrom(msg)
+	if err != nil {
+		return err
+	}
+	b := make([]byte, 5, 5+payload.Len()+len(data))
+	binary.BigEndian.PutUint32(b, uint32(1+payload.Len()+len(data)))
+	b[4] = byte(msg.ID())
+	b = append(b, payload.Bytes()...)
+	b = append(b, data...)
+	_, err = w.Write(b)
+	return err
+}
@@ -0,0 +1,119 @@
+package torrenttest
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/rain/internal/btconn"
+	"github.com/cenkalti/rain/internal/peerprotocol"
+	"github.com/cenkalti/rain/internal/tracker"
+	"github.com/cenkalti/rain/internal/tracker/httptracker"
+	"github.com/cenkalti/rain/internal/tracker/udptracker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const timeout = 10 * time.Second
+
+func TestContent(t *testing.T) {
+	c1, err := NewContent("file", 100, 32, 1)
+	require.NoError(t, err)
+	c2, err := NewContent("file", 100, 32, 1)
+	require.NoError(t, err)
+	assert.Equal(t, c1.InfoHash, c2.InfoHash)
+	assert.Equal(t, uint32(4), c1.NumPieces())
+	assert.Len(t, c1.Piece(3), 4)
+
+	c3, err := NewContent("file", 100, 32, 2)
+	require.NoError(t, err)
+	assert.NotEqual(t, c1.InfoHash, c3.InfoHash)
+}
+
+func TestTracker(t *testing.T) {
+	trk := NewTracker()
+	defer trk.Close()
+	httpURL, err := trk.StartHTTP()
+	require.NoError(t, err)
+	udpURL, err := trk.StartUDP()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	infoHash := [20]byte{1}
+
+	u, err := url.Parse(udpURL)
+	require.NoError(t, err)
+	tr := udptracker.NewTransport(nil, timeout)
+	go tr.Run()
+	defer tr.Close()
+	resp, err := udptracker.New(udpURL, u, tr).Announce(ctx, tracker.AnnounceRequest{
+		Torrent: tracker.Torrent{InfoHash: infoHash, PeerID: [20]byte{1}, Port: 1111},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, resp.Peers)
+	assert.Equal(t, int32(1), resp.Seeders)
+
+	u, err = url.Parse(httpURL)
+	require.NoError(t, err)
+	ht := httptracker.New(httpURL, u, timeout, new(http.Transport), "", 1<<20, true, false)
+	resp, err = ht.Announce(ctx, tracker.AnnounceRequest{
+		Torrent: tracker.Torrent{InfoHash: infoHash, PeerID: [20]byte{2}, Port: 2222, BytesLeft: 10},
+		NumWant: 10,
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Peers, 1)
+	assert.Equal(t, 1111, resp.Peers[0].Port)
+	assert.Equal(t, int32(1), resp.Leechers)
+
+	sr, err := ht.Scrape(ctx, infoHash)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), sr.Seeders)
+	assert.Equal(t, int32(1), sr.Leechers)
+	assert.Equal(t, 2, trk.Announces())
+	assert.Len(t, trk.Peers(infoHash), 2)
+}
+
+func TestPeer(t *testing.T) {
+	c, err := NewContent("file", 100, 32, 1)
+	require.NoError(t, err)
+	p, err := NewPeer(c, PeerBehavior{Pieces: []uint32{0, 1}, CorruptPieces: []uint32{1}})
+	require.NoError(t, err)
+	defer p.Close()
+
+	conn, _, _, _, err := btconn.Dial(p.Addr(), timeout, timeout, 0, true, false, [8]byte{}, c.InfoHash, [20]byte{1}, nil, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NoError(t, conn.SetDeadline(time.Now().Add(timeout)))
+
+	id, payload := readPeerMessage(t, conn)
+	assert.Equal(t, peerprotocol.Bitfield, id)
+	assert.Equal(t, []byte{0xc0}, payload)
+	id, _ = readPeerMessage(t, conn)
+	assert.Equal(t, peerprotocol.Unchoke, id)
+
+	for _, index := range []uint32{0, 1} {
+		require.NoError(t, writePeerMessage(conn, peerprotocol.RequestMessage{Index: index, Begin: 8, Length: 16}, nil))
+		id, payload = readPeerMessage(t, conn)
+		assert.Equal(t, peerprotocol.Piece, id)
+		assert.Equal(t, index, binary.BigEndian.Uint32(payload[0:4]))
+		assert.Equal(t, index == 0, bytes.Equal(c.Piece(index)[8:24], payload[8:]))
+	}
+	assert.Equal(t, 2, p.Requests())
+	assert.Equal(t, int64(32), p.Uploaded())
+}
+
+func readPeerMessage(t *testing.T, r io.Reader) (peerprotocol.MessageID, []byte) {
+	var header [4]byte
+	_, err := io.ReadFull(r, header[:])
+	require.NoError(t, err)
+	b := make([]byte, binary.BigEndian.Uint32(header[:]))
+	_, err = io.ReadFull(r, b)
+	require.NoError(t, err)
+	return peerprotocol.MessageID(b[0]), b[1:]
+}
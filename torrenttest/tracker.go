@@ -0,0 +1,375 @@
+package torrenttest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/zeebo/bencode"
+)
+
+// DefaultAnnounceInterval is the interval returned to the clients in announce responses.
+const DefaultAnnounceInterval = time.Minute
+
+// Tracker keeps the peers of torrents in memory and serves them over HTTP and UDP.
+// Both servers share the same swarms, so peers announced to one of them are returned from the other.
+type Tracker struct {
+	// Interval returned to the clients in announce responses.
+	Interval time.Duration
+
+	m         sync.Mutex
+	swarms    map[[20]byte]map[string]*trackerPeer
+	announces int
+
+	listenMutex sync.Mutex
+	httpServer  *http.Server
+	httpAddr    string
+	udpConn     net.PacketConn
+	udpConnID   int64
+
+	closeC    chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+type trackerPeer struct {
+	addr *net.TCPAddr
+	seed bool
+}
+
+// NewTracker returns a new Tracker. Call StartHTTP or StartUDP to serve requests.
+func NewTracker() *Tracker {
+	return &Tracker{
+		Interval:  DefaultAnnounceInterval,
+		swarms:    make(map[[20]byte]map[string]*trackerPeer),
+		udpConnID: 0x5261696e, // "Rain"
+		closeC:    make(chan struct{}),
+	}
+}
+
+// StartHTTP starts the HTTP server on a random port of the loopback interface and returns the announce URL.
+func (t *Tracker) StartHTTP() (string, error) {
+	t.listenMutex.Lock()
+	defer t.listenMutex.Unlock()
+	if t.httpServer != nil {
+		return "http://" + t.httpAddr + "/announce", nil
+	}
+	l, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/announce", t.handleHTTPAnnounce)
+	mux.HandleFunc("/scrape", t.handleHTTPScrape)
+	t.httpServer = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	t.httpAddr = l.Addr().String()
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		_ = t.httpServer.Serve(l)
+	}()
+	return "http://" + t.httpAddr + "/announce", nil
+}
+
+// StartUDP starts the UDP server on a random port of the loopback interface and returns the announce URL.
+func (t *Tracker) StartUDP() (string, error) {
+	t.listenMutex.Lock()
+	defer t.listenMutex.Unlock()
+	if t.udpConn != nil {
+		return "udp://" + t.udpConn.LocalAddr().String() + "/announce", nil
+	}
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	t.udpConn = conn
+	t.wg.Add(1)
+	go t.serveUDP(conn)
+	return "udp://" + conn.LocalAddr().String() + "/announce", nil
+}
+
+// Close stops the servers.
+func (t *Tracker) Close() error {
+	t.closeOnce.Do(func() {
+		close(t.closeC)
+		t.listenMutex.Lock()
+		if t.httpServer != nil {
+			_ = t.httpServer.Close()
+		}
+		if t.udpConn != nil {
+			_ = t.udpConn.Close()
+		}
+		t.listenMutex.Unlock()
+	})
+	t.wg.Wait()
+	return nil
+}
+
+// AddPeer adds a peer to the swarm of the torrent without an announce.
+// Use it for peers that do not announce themselves, such as the fake peers created with NewPeer.
+func (t *Tracker) AddPeer(infoHash [20]byte, addr *net.TCPAddr, seed bool) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	t.addPeer(infoHash, addr, seed)
+}
+
+// Peers returns the addresses of the peers in the swarm of the torrent.
+func (t *Tracker) Peers(infoHash [20]byte) []*net.TCPAddr {
+	t.m.Lock()
+	defer t.m.Unlock()
+	var ret []*net.TCPAddr
+	for _, p := range t.swarms[infoHash] {
+		ret = append(ret, p.addr)
+	}
+	return ret
+}
+
+// Announces returns the number of announce requests received from both servers.
+func (t *Tracker) Announces() int {
+	t.m.Lock()
+	defer t.m.Unlock()
+	return t.announces
+}
+
+func (t *Tracker) addPeer(infoHash [20]byte, addr *net.TCPAddr, seed bool) {
+	swarm, ok := t.swarms[infoHash]
+	if !ok {
+		swarm = make(map[string]*trackerPeer)
+		t.swarms[infoHash] = swarm
+	}
+	swarm[addr.String()] = &trackerPeer{addr: addr, seed: seed}
+}
+
+// announce updates the swarm and returns the other peers in the swarm in compact format.
+func (t *Tracker) announce(infoHash [20]byte, addr *net.TCPAddr, left int64, event string, numWant int) (peers []byte, seeders, leechers int32) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	t.announces++
+	if event == "stopped" {
+		delete(t.swarms[infoHash], addr.String())
+	} else {
+		t.addPeer(infoHash, addr, left == 0)
+	}
+	if numWant < 0 {
+		numWant = 50
+	}
+	for key, p := range t.swarms[infoHash] {
+		if p.seed {
+			seeders++
+		} else {
+			leechers++
+		}
+		if key == addr.String() || len(peers)/6 >= numWant {
+			continue
+		}
+		ip := p.addr.IP.To4()
+		if ip == nil {
+			continue
+		}
+		peers = append(peers, ip...)
+		peers = append(peers, byte(p.addr.Port>>8), byte(p.addr.Port))
+	}
+	return
+}
+
+func (t *Tracker) scrape(infoHash [20]byte) (seeders, leechers int32) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	for _, p := range t.swarms[infoHash] {
+		if p.seed {
+			seeders++
+		} else {
+			leechers++
+		}
+	}
+	return
+}
+
+type httpAnnounceResponse struct {
+	FailureReason string `bencode:"failure reason,omitempty"`
+	Interval      int32  `bencode:"interval"`
+	Complete      int32  `bencode:"complete"`
+	Incomplete    int32  `bencode:"incomplete"`
+	Peers         []byte `bencode:"peers"`
+}
+
+type httpScrapeFile struct {
+	Complete   int32 `bencode:"complete"`
+	Downloaded int32 `bencode:"downloaded"`
+	Incomplete int32 `bencode:"incomplete"`
+}
+
+func (t *Tracker) handleHTTPAnnounce(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var infoHash [20]byte
+	if len(q.Get("info_hash")) != len(infoHash) {
+		writeBencode(w, httpAnnounceResponse{FailureReason: "invalid info hash"})
+		return
+	}
+	copy(infoHash[:], q.Get("info_hash"))
+	port, err := strconv.ParseUint(q.Get("port"), 10, 16)
+	if err != nil {
+		writeBencode(w, httpAnnounceResponse{FailureReason: "invalid port"})
+		return
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		writeBencode(w, httpAnnounceResponse{FailureReason: err.Error()})
+		return
+	}
+	left, _ := strconv.ParseInt(q.Get("left"), 10, 64)
+	numWant := -1
+	if s := q.Get("numwant"); s != "" {
+		numWant, _ = strconv.Atoi(s)
+	}
+	addr := &net.TCPAddr{IP: net.ParseIP(host), Port: int(port)}
+	peers, seeders, leechers := t.announce(infoHash, addr, left, q.Get("event"), numWant)
+	writeBencode(w, httpAnnounceResponse{
+		Interval:   int32(t.Interval / time.Second),
+		Complete:   seeders,
+		Incomplete: leechers,
+		Peers:      peers,
+	})
+}
+
+func (t *Tracker) handleHTTPScrape(w http.ResponseWriter, r *http.Request) {
+	files := make(map[string]httpScrapeFile)
+	for _, s := range r.URL.Query()["info_hash"] {
+		var infoHash [20]byte
+		if len(s) != len(infoHash) {
+			continue
+		}
+		copy(infoHash[:], s)
+		seeders, leechers := t.scrape(infoHash)
+		files[s] = httpScrapeFile{Complete: seeders, Incomplete: leechers}
+	}
+	writeBencode(w, struct {
+		Files map[string]httpScrapeFile `bencode:"files"`
+	}{files})
+}
+
+func writeBencode(w http.ResponseWriter, v interface{}) {
+	b, err := bencode.EncodeBytes(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	_, _ = w.Write(b)
+}
+
+// UDP tracker protocol (BEP 15)
+const (
+	udpActionConnect  int32 = 0
+	udpActionAnnounce int32 = 1
+	udpActionScrape   int32 = 2
+	udpActionError    int32 = 3
+
+	udpConnectMagic int64 = 0x41727101980
+)
+
+var udpEvents = [...]string{"", "completed", "started", "stopped"}
+
+type udpRequestHeader struct {
+	ConnectionID  int64
+	Action        int32
+	TransactionID int32
+}
+
+type udpAnnounceRequest struct {
+	InfoHash   [20]byte
+	PeerID     [20]byte
+	Downloaded int64
+	Left       int64
+	Uploaded   int64
+	Event      int32
+	IP         uint32
+	Key        uint32
+	NumWant    int32
+	Port       uint16
+}
+
+func (t *Tracker) serveUDP(conn net.PacketConn) {
+	defer t.wg.Done()
+	buf := make([]byte, 2048)
+	for {
+		n, from, err := conn.ReadFrom(buf)
+		if err != nil {
+			select {
+			case <-t.closeC:
+				return
+			default:
+			}
+			continue
+		}
+		resp, err := t.handleUDPRequest(buf[:n], from)
+		if err != nil {
+			continue
+		}
+		_, _ = conn.WriteTo(resp, from)
+	}
+}
+
+func (t *Tracker) handleUDPRequest(b []byte, from net.Addr) ([]byte, error) {
+	var h udpRequestHeader
+	r := bytes.NewReader(b)
+	err := binary.Read(r, binary.BigEndian, &h)
+	if err != nil {
+		return nil, err
+	}
+	var resp bytes.Buffer
+	writeHeader := func(action int32) {
+		_ = binary.Write(&resp, binary.BigEndian, [2]int32{action, h.TransactionID})
+	}
+	if h.Action == udpActionConnect {
+		if h.ConnectionID != udpConnectMagic {
+			return nil, errors.New("invalid connect request")
+		}
+		writeHeader(udpActionConnect)
+		_ = binary.Write(&resp, binary.BigEndian, t.udpConnID)
+		return resp.Bytes(), nil
+	}
+	if h.ConnectionID != t.udpConnID {
+		writeHeader(udpActionError)
+		resp.WriteString("invalid connection id")
+		return resp.Bytes(), nil
+	}
+	switch h.Action {
+	case udpActionAnnounce:
+		var req udpAnnounceRequest
+		err = binary.Read(r, binary.BigEndian, &req)
+		if err != nil {
+			return nil, err
+		}
+		if req.Event < 0 || int(req.Event) >= len(udpEvents) {
+			req.Event = 0
+		}
+		ip := from.(*net.UDPAddr).IP
+		if req.IP != 0 {
+			ip = make(net.IP, net.IPv4len)
+			binary.BigEndian.PutUint32(ip, req.IP)
+		}
+		addr := &net.TCPAddr{IP: ip, Port: int(req.Port)}
+		peers, seeders, leechers := t.announce(req.InfoHash, addr, req.Left, udpEvents[req.Event], int(req.NumWant))
+		writeHeader(udpActionAnnounce)
+		_ = binary.Write(&resp, binary.BigEndian, [3]int32{int32(t.Interval / time.Second), leechers, seeders})
+		resp.Write(peers)
+	case udpActionScrape:
+		writeHeader(udpActionScrape)
+		var infoHash [20]byte
+		for r.Len() >= len(infoHash) {
+			_, _ = r.Read(infoHash[:])
+			seeders, leechers := t.scrape(infoHash)
+			_ = binary.Write(&resp, binary.BigEndian, [3]int32{seeders, 0, leechers})
+		}
+	default:
+		writeHeader(udpActionError)
+		resp.WriteString("unknown action")
+	}
+	return resp.Bytes(), nil
+}